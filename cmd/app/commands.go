@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/database"
+	"github.com/rs/zerolog"
+)
+
+const usage = `usage: mangrove [-config file] [command]
+
+Without a command the server is started.
+
+commands:
+  config check    validate the configuration and print it with secrets redacted
+  reaper run      purge expired data once and print what was removed, e.g. from cron
+`
+
+// runCommand runs a one-shot command and returns the process exit code
+func runCommand(args []string, configFile string, logger zerolog.Logger) int {
+	switch strings.Join(args, " ") {
+	case "config check":
+		return configCheck(configFile, logger)
+	case "reaper run":
+		return reaperRun(configFile, logger)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+}
+
+func configCheck(configFile string, logger zerolog.Logger) int {
+	variables, err := configs.NewConf(logger).Load(configFile)
+
+	out, marshalErr := variables.RedactedYAML()
+	if marshalErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to print configuration: %s\n", marshalErr)
+		return 1
+	}
+	fmt.Print(string(out))
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nconfiguration is invalid:\n")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "\nconfiguration is valid")
+	return 0
+}
+
+// reaperRun purges expired data once; the run is recorded like the background ones. It can run while
+// servers are up.
+func reaperRun(configFile string, logger zerolog.Logger) int {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	variables, err := configs.NewConf(logger).Load(configFile)
+	if err != nil {
+		logger.Err(err).Msg("invalid configuration")
+		return 1
+	}
+	dbpool, err := database.NewPool(ctx, variables, logger)
+	if err != nil {
+		logger.Err(err).Msg("could not connect to the database")
+		return 1
+	}
+	defer dbpool.Close()
+
+	expiredDataReaper, err := newReaper(variables, logger, dal.NewDAL(logger, dbpool))
+	if err != nil {
+		return 1
+	}
+	run, err := expiredDataReaper.Run(ctx)
+
+	names := make([]string, 0, len(run.Removed))
+	for name := range run.Removed {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATA\tREMOVED")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%d\n", name, run.Removed[name])
+	}
+	w.Flush()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nreaper run failed:\n")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+		return 1
+	}
+	return 0
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,29 +15,44 @@ import (
 	"github.com/asatraitis/mangrove/configs"
 	"github.com/asatraitis/mangrove/internal/bll"
 	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/database"
 	"github.com/asatraitis/mangrove/internal/handler"
 	"github.com/asatraitis/mangrove/internal/migrations"
+	"github.com/asatraitis/mangrove/internal/service/certs"
 	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/asatraitis/mangrove/internal/service/jwks"
+	"github.com/asatraitis/mangrove/internal/service/reaper"
 	"github.com/asatraitis/mangrove/internal/service/router"
+	"github.com/asatraitis/mangrove/internal/service/signer"
 	"github.com/asatraitis/mangrove/internal/service/webauthn"
 	wa "github.com/go-webauthn/webauthn/webauthn"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 )
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
+	configFile := flag.String("config", os.Getenv("MANGROVE_CONFIG_FILE"), "path to a YAML or JSON config file; env variables take precedence")
+	flag.Parse()
+
 	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), *configFile, logger))
+	}
 
-	variables := configs.NewConf(logger).GetEnvironmentVars()
+	ctx, cancel := context.WithCancel(context.Background())
+	variables, err := configs.NewConf(logger).Load(*configFile)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid configuration")
+	}
 	logger.Info().Msgf("MangroveEnv: %s", variables.MangroveEnv)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	if variables.MangroveEnv == configs.DEV {
+	switch variables.MangroveEnv {
+	case configs.DEV:
 		go startDev(ctx, variables, logger, &wg)
-	} else {
-		// TODO: Add prod start
+	case configs.PROD:
+		go startProd(ctx, variables, logger, &wg)
 	}
 
 	signalCh := make(chan os.Signal, 1)
@@ -49,26 +66,59 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 	defer wg.Done()
 	logger = logger.Level(zerolog.DebugLevel).Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
+	start(ctx, variables, logger, nil)
+}
+
+// startProd logs JSON at the configured level and serves TLS when a certificate is configured
+func startProd(ctx context.Context, variables *configs.EnvVariables, logger zerolog.Logger, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	// the level was validated with the rest of the configuration
+	level, _ := zerolog.ParseLevel(variables.MangroveLogLevel)
+	logger = logger.Level(level)
+
+	var err error
+	var certReloader certs.Reloader
+	if variables.MangroveTLSCertFile != "" {
+		certReloader, err = certs.NewReloader(logger, variables.MangroveTLSCertFile, variables.MangroveTLSKeyFile)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("could not load TLS certificate")
+			return
+		}
+		certReloader.Start(ctx)
+	} else {
+		logger.Warn().Msg("TLS is not configured; it has to be terminated in front of Mangrove")
+	}
+
+	start(ctx, variables, logger, certReloader)
+}
+
+// start runs the server until ctx is done; it serves TLS when certReloader is set
+func start(ctx context.Context, variables *configs.EnvVariables, logger zerolog.Logger, certReloader certs.Reloader) {
 	// Run migrations
 	migrator, err := migrations.NewMigrator(variables, logger)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("could not create migrator")
 		return
 	}
-	if err := migrator.Run(); err != nil {
+	err = migrator.Run()
+	migrator.Close()
+	if err != nil {
 		logger.Fatal().Err(err).Msg("could not run migrator")
 	}
 
 	// init db connection pool
-	dbpool, err := initDbPool(ctx, variables)
+	dbpool, err := database.NewPool(ctx, variables, logger)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("could not connect to the database")
 		return
 	}
 	defer dbpool.Close()
 
+	DAL := dal.NewDAL(logger, dbpool)
+
 	// init webauthn
-	wauthn, err := webauthn.NewWebAuthN(&wa.Config{
+	wauthnConf := &wa.Config{
 		RPDisplayName: variables.MangroveWebauthnRPDisplayName,
 		RPID:          variables.MangroveWebauthnRPID,
 		RPOrigins:     variables.MangroveWebauthnRPOrigins,
@@ -84,7 +134,18 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 				TimeoutUVD: time.Second * 60, // Timeout for login sessions which have user verification set to discouraged.
 			},
 		},
-	}, logger)
+	}
+	var sessionStore webauthn.SessionStore
+	switch webauthn.SessionStoreType(variables.MangroveSessionStore) {
+	case "", webauthn.SESSION_STORE_MEMORY:
+		sessionStore = webauthn.NewMemorySessionStore(ctx, webauthn.SessionTTL(wauthnConf))
+	case webauthn.SESSION_STORE_POSTGRES:
+		sessionStore = webauthn.NewPostgresSessionStore(ctx, logger, DAL, webauthn.SessionTTL(wauthnConf))
+	default:
+		logger.Fatal().Msgf("unknown session store %s", variables.MangroveSessionStore)
+		return
+	}
+	wauthn, err := webauthn.NewWebAuthN(ctx, wauthnConf, sessionStore, logger)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("could not init webAuthn")
 		return
@@ -92,8 +153,30 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 
 	appConfig := config.NewConfig(ctx, logger)
 
-	DAL := dal.NewDAL(logger, dbpool)
-	BLL := bll.NewBLL(logger, variables, appConfig, wauthn, DAL)
+	tokenSigner, err := signer.NewSigner(ctx, logger, DAL, signer.Config{
+		Algorithm:        models.SigningKeyAlgorithm(variables.MangroveSigningKeyAlgorithm),
+		RotationPeriod:   variables.MangroveSigningKeyRotation,
+		GracePeriod:      variables.MangroveSigningKeyGracePeriod,
+		EncryptionSecret: []byte(variables.MangroveSalt),
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("could not init token signer")
+		return
+	}
+	tokenSigner.Start(ctx)
+
+	if variables.MangroveReaperInterval > 0 {
+		expiredDataReaper, err := newReaper(variables, logger, DAL)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("could not init reaper")
+			return
+		}
+		expiredDataReaper.Start(ctx)
+	} else {
+		logger.Info().Msg("background reaper is off; expired data has to be purged with `mangrove reaper run`")
+	}
+
+	BLL := bll.NewBLL(logger, variables, appConfig, wauthn, tokenSigner, jwks.NewFetcher(logger, nil), DAL)
 
 	initCode, err := BLL.Config(ctx).InitRegistrationCode()
 	if err != nil {
@@ -101,6 +184,8 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 		return
 	}
 
+	go listenForConfigChanges(ctx, BLL, logger)
+
 	ro := router.NewRouter(
 		logger,
 		appConfig,
@@ -108,15 +193,32 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 	)
 
 	httpServer := &http.Server{
-		Addr:    fmt.Sprintf("%s:%s", variables.MangroveHost, variables.MangrovePort),
-		Handler: ro,
+		Addr:              fmt.Sprintf("%s:%s", variables.MangroveHost, variables.MangrovePort),
+		Handler:           ro,
+		ReadHeaderTimeout: variables.MangroveHttpReadHeaderTimeout,
+		ReadTimeout:       variables.MangroveHttpReadTimeout,
+		WriteTimeout:      variables.MangroveHttpWriteTimeout,
+		IdleTimeout:       variables.MangroveHttpIdleTimeout,
+	}
+	if certReloader != nil {
+		httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certReloader.GetCertificate,
+		}
 	}
 	if initCode != "" {
 		fmt.Printf("============================================ [REGISTRATION CODE: %s] ============================================\n", initCode)
 	}
 	go func() {
-		logger.Info().Msgf("Starting http server on %s", httpServer.Addr)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Info().Bool("tls", certReloader != nil).Msgf("Starting http server on %s", httpServer.Addr)
+		var err error
+		if certReloader != nil {
+			// the certificate comes from TLSConfig.GetCertificate
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error().Err(err).Msg("Failed to start http server")
 		}
 	}()
@@ -124,7 +226,7 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 	<-ctx.Done()
 	// Shutdown the server gracefully
 	logger.Info().Msgf("Shutting down server on %s", httpServer.Addr)
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), variables.MangroveShutdownTimeout)
 	defer cancelShutdown()
 
 	err = httpServer.Shutdown(shutdownCtx)
@@ -136,14 +238,39 @@ func startDev(ctx context.Context, variables *configs.EnvVariables, logger zerol
 
 }
 
-// TODO: consolidate w/ getConnection() in migrator.go
-func initDbPool(ctx context.Context, vars *configs.EnvVariables) (*pgxpool.Pool, error) {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
-		vars.MangrovePostgresUser,
-		vars.MangrovePostgresPassword,
-		vars.MangrovePostgresAddress,
-		vars.MangrovePostgresPort,
-		vars.MangrovePostgresDBName,
-	)
-	return pgxpool.New(ctx, connStr)
+// listenForConfigChanges keeps the config cache in sync with other instances until ctx is done,
+// reconnecting with backoff when the listener connection fails
+func listenForConfigChanges(ctx context.Context, BLL bll.BLL, logger zerolog.Logger) {
+	const maxBackoff = time.Minute
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := BLL.Config(ctx).ListenForChanges()
+		if ctx.Err() != nil {
+			return
+		}
+		// a listener that ran for a while failed on its own; start over with a short backoff
+		if time.Since(started) > maxBackoff {
+			backoff = time.Second
+		}
+		logger.Err(err).Msgf("config listener stopped; retrying in %s", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		// changes made while disconnected were missed
+		if _, err := BLL.Config(ctx).GetAll(); err != nil {
+			logger.Err(err).Msg("failed to reload configs")
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func newReaper(variables *configs.EnvVariables, logger zerolog.Logger, DAL dal.DAL) (reaper.Reaper, error) {
+	return reaper.NewReaper(logger, DAL, reaper.Config{
+		Interval:  variables.MangroveReaperInterval,
+		BatchSize: variables.MangroveReaperBatchSize,
+		Retention: variables.MangroveReaperRetention,
+	})
 }
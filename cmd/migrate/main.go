@@ -1,26 +1,146 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/asatraitis/mangrove/configs"
 	"github.com/asatraitis/mangrove/internal/migrations"
 	"github.com/rs/zerolog"
 )
 
+const usage = `usage: migrate [-config file] [-dry-run] [command]
+
+commands:
+  up              apply all pending migrations (default)
+  status          list applied and pending migrations
+  down [n]        revert the last n applied migrations (default 1)
+  to <version>    apply or revert migrations until version is the latest applied; 0 reverts all
+
+-dry-run prints the migrations and their SQL instead of running them
+`
+
+var errUsage = errors.New("invalid command")
+
 func main() {
+	configFile := flag.String("config", os.Getenv("MANGROVE_CONFIG_FILE"), "path to a YAML or JSON config file; env variables take precedence")
+	dryRun := flag.Bool("dry-run", false, "print the migrations that would run instead of running them")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
 	logger := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel).Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	variables := configs.NewConf(logger).GetEnvironmentVars()
+	variables, err := configs.NewConf(logger).Load(*configFile)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid configuration")
+		return
+	}
 
 	migrator, err := migrations.NewMigrator(variables, logger)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("could not create migrator")
 		return
 	}
-	if err := migrator.Run(); err != nil {
-		logger.Fatal().Err(err).Msg("could not run migrator")
+	err = run(migrator, flag.Args(), *dryRun)
+	migrator.Close()
+	if errors.Is(err, errUsage) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Fatal().Err(err).Msg("migration failed")
 		return
 	}
 
 	logger.Info().Msg("Done!")
 }
+
+func run(migrator *migrations.Migrator, args []string, dryRun bool) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	var steps []migrations.Step
+	var err error
+	switch {
+	case command == "up" && len(args) <= 1:
+		steps, err = migrator.PlanUp(0)
+	case command == "status" && len(args) == 1:
+		return printStatus(migrator)
+	case command == "down" && len(args) <= 2:
+		n := 1
+		if len(args) == 2 {
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+		steps, err = migrator.PlanDown(n)
+	case command == "to" && len(args) == 2:
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		steps, err = migrator.PlanTo(version)
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		printSteps(steps)
+		return nil
+	}
+	return migrator.Apply(steps)
+}
+
+func printStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT\tDURATION")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Unknown:
+			state = "applied (unknown)"
+		case s.Modified:
+			state = "applied (modified)"
+		case s.Applied:
+			state = "applied"
+		}
+		appliedAt, duration := "-", "-"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Duration != nil {
+			duration = s.Duration.String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt, duration)
+	}
+	return w.Flush()
+}
+
+func printSteps(steps []migrations.Step) {
+	if len(steps) == 0 {
+		fmt.Println("nothing to migrate")
+		return
+	}
+	for _, step := range steps {
+		fmt.Printf("-- %s %d %s\n", step.Direction, step.Version, step.Name)
+		for _, statement := range step.SQL {
+			fmt.Println(strings.TrimSpace(statement))
+		}
+		fmt.Println()
+	}
+}
@@ -1,8 +1,7 @@
 package configs
 
 import (
-	"os"
-	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -14,40 +13,131 @@ const (
 	PROD MangroveEnvType = "prod"
 )
 
+// Every setting is read from the env variable in its env tag, which overrides the config file key of
+// the same name without the MANGROVE_ prefix, lowercased (MANGROVE_POSTGRES_PORT is postgres_port).
+// Settings tagged secret are redacted when the configuration is printed.
+
+type PostgresConf struct {
+	// MangrovePostgresDSN is a full connection string (URL or key=value); when set, the address, port, user,
+	// password and database name below are ignored
+	MangrovePostgresDSN string `env:"MANGROVE_POSTGRES_DSN" secret:"true"`
+	// MangrovePostgresAddress is the address of the postgres database
+	MangrovePostgresAddress string `env:"MANGROVE_POSTGRES_ADDRESS"`
+	// MangrovePostgresPort is the port of the postgres database
+	MangrovePostgresPort string `env:"MANGROVE_POSTGRES_PORT"`
+	// MangrovePostgresUser is the user of the postgres database
+	MangrovePostgresUser string `env:"MANGROVE_POSTGRES_USER"`
+	// MangrovePostgresPassword is the password of the postgres database
+	MangrovePostgresPassword string `env:"MANGROVE_POSTGRES_PASSWORD" secret:"true"`
+	// MangrovePostgresDBName is the name of the postgres database
+	MangrovePostgresDBName string `env:"MANGROVE_POSTGRES_DB_NAME"`
+	// MangrovePostgresSSLMode is the libpq sslmode; disable, allow, prefer (default), require, verify-ca or verify-full
+	MangrovePostgresSSLMode string `env:"MANGROVE_POSTGRES_SSL_MODE"`
+	// MangrovePostgresSSLRootCert, MangrovePostgresSSLCert and MangrovePostgresSSLKey are the CA and client
+	// certificate files
+	MangrovePostgresSSLRootCert string `env:"MANGROVE_POSTGRES_SSL_ROOT_CERT"`
+	MangrovePostgresSSLCert     string `env:"MANGROVE_POSTGRES_SSL_CERT"`
+	MangrovePostgresSSLKey      string `env:"MANGROVE_POSTGRES_SSL_KEY"`
+	// MangrovePostgresPoolMinConns and MangrovePostgresPoolMaxConns size the connection pool;
+	// 0 keeps the pgx default
+	MangrovePostgresPoolMinConns int `env:"MANGROVE_POSTGRES_POOL_MIN_CONNS" default:"0"`
+	MangrovePostgresPoolMaxConns int `env:"MANGROVE_POSTGRES_POOL_MAX_CONNS" default:"0"`
+	// MangrovePostgresPoolMaxConnLifetime and MangrovePostgresPoolMaxConnIdleTime recycle pooled connections;
+	// 0 keeps the pgx default
+	MangrovePostgresPoolMaxConnLifetime time.Duration `env:"MANGROVE_POSTGRES_POOL_MAX_CONN_LIFETIME" default:"0s"`
+	MangrovePostgresPoolMaxConnIdleTime time.Duration `env:"MANGROVE_POSTGRES_POOL_MAX_CONN_IDLE_TIME" default:"0s"`
+	// MangrovePostgresStatementTimeout aborts app queries running longer; 0 disables it. Migrations are not limited
+	MangrovePostgresStatementTimeout time.Duration `env:"MANGROVE_POSTGRES_STATEMENT_TIMEOUT" default:"30s"`
+	// MangrovePostgresConnectTimeout is how long connecting is retried at startup, while postgres may still be booting
+	MangrovePostgresConnectTimeout time.Duration `env:"MANGROVE_POSTGRES_CONNECT_TIMEOUT" default:"60s"`
+}
+
 type HttpConf struct {
-	MangroveHost string
-	MangrovePort string
+	MangroveHost string `env:"MANGROVE_HOST"`
+	MangrovePort string `env:"MANGROVE_PORT"`
+	// MangroveTLSCertFile and MangroveTLSKeyFile enable TLS; both files are reloaded when they change on disk.
+	// Leave both empty when TLS is terminated in front of Mangrove
+	MangroveTLSCertFile string `env:"MANGROVE_TLS_CERT_FILE"`
+	MangroveTLSKeyFile  string `env:"MANGROVE_TLS_KEY_FILE"`
+	// MangroveHttpReadHeaderTimeout, MangroveHttpReadTimeout, MangroveHttpWriteTimeout and MangroveHttpIdleTimeout
+	// are the http.Server timeouts
+	MangroveHttpReadHeaderTimeout time.Duration `env:"MANGROVE_HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	MangroveHttpReadTimeout       time.Duration `env:"MANGROVE_HTTP_READ_TIMEOUT" default:"15s"`
+	MangroveHttpWriteTimeout      time.Duration `env:"MANGROVE_HTTP_WRITE_TIMEOUT" default:"30s"`
+	MangroveHttpIdleTimeout       time.Duration `env:"MANGROVE_HTTP_IDLE_TIMEOUT" default:"120s"`
+	// MangroveShutdownTimeout is how long in-flight requests get to finish on shutdown
+	MangroveShutdownTimeout time.Duration `env:"MANGROVE_SHUTDOWN_TIMEOUT" default:"30s"`
 }
 
 type WebauthnConf struct {
-	MangroveWebauthnRPDisplayName string
-	MangroveWebauthnRPID          string
-	MangroveWebauthnRPOrigins     []string
+	MangroveWebauthnRPDisplayName string `env:"MANGROVE_WEBAUTHN_RPDISPLAY_NAME" default:"Mangrove"`
+	MangroveWebauthnRPID          string `env:"MANGROVE_WEBAUTHN_RPID"`
+	// MangroveWebauthnRPOrigins is a comma separated list of the origins the UI is served from
+	MangroveWebauthnRPOrigins []string `env:"MANGROVE_WEBAUTHN_RP_ORIGINS"`
+}
+
+type OIDCConf struct {
+	// MangroveOIDCIssuer is the public base URL used as the token issuer; defaults to the first RP origin
+	MangroveOIDCIssuer string `env:"MANGROVE_OIDC_ISSUER"`
+}
+
+type SigningKeyConf struct {
+	// MangroveSigningKeyAlgorithm is the algorithm for newly generated signing keys; EdDSA (default) or ES256
+	MangroveSigningKeyAlgorithm string `env:"MANGROVE_SIGNING_KEY_ALGORITHM" default:"EdDSA"`
+	// MangroveSigningKeyRotation is how long a key signs before it is replaced
+	MangroveSigningKeyRotation time.Duration `env:"MANGROVE_SIGNING_KEY_ROTATION" default:"720h"`
+	// MangroveSigningKeyGracePeriod is how long a retired key is still published and accepted for verification;
+	// it has to outlive the longest lived token signed with it
+	MangroveSigningKeyGracePeriod time.Duration `env:"MANGROVE_SIGNING_KEY_GRACE_PERIOD" default:"168h"`
+}
+
+type SessionConf struct {
+	// MangroveSessionStore is where unfinished WebAuthn ceremonies are kept; memory (default) or postgres.
+	// Running more than one instance requires postgres
+	MangroveSessionStore string `env:"MANGROVE_SESSION_STORE" default:"memory"`
+}
+
+type ReaperConf struct {
+	// MangroveReaperInterval is how often expired data is purged in the background; 0 turns the background
+	// reaper off, e.g. when `mangrove reaper run` is scheduled with cron instead
+	MangroveReaperInterval time.Duration `env:"MANGROVE_REAPER_INTERVAL" default:"1h"`
+	// MangroveReaperBatchSize is how many rows a single delete removes, which keeps locks short
+	MangroveReaperBatchSize int `env:"MANGROVE_REAPER_BATCH_SIZE" default:"1000"`
+	// MangroveReaperRetention is how long expired invitations, expired or revoked client keys and the
+	// reaper's own run records are kept
+	MangroveReaperRetention time.Duration `env:"MANGROVE_REAPER_RETENTION" default:"720h"`
 }
 
 type EnvVariables struct {
 	// MangroveEnv is the environment variable that specifies the environment in which the application is running
 	// It can be either "dev" or "production"
-	MangroveEnv MangroveEnvType
-	// MangrovePostgresAddress is the address of the postgres database
-	MangrovePostgresAddress string
-	// MangrovePostgresPort is the port of the postgres database
-	MangrovePostgresPort string
-	// MangrovePostgresUser is the user of the postgres database
-	MangrovePostgresUser string
-	// MangrovePostgresPassword is the password of the postgres database
-	MangrovePostgresPassword string
-	// MangrovePostgresDBName is the name of the postgres database
-	MangrovePostgresDBName string
+	MangroveEnv MangroveEnvType `env:"MANGROVE_ENV"`
+	// postgres conf
+	PostgresConf
 	// MangroveSal is salt used for hashing emails and init codes
-	MangroveSalt string
+	MangroveSalt string `env:"MANGROVE_SALT" secret:"true"`
+	// MangroveLogLevel is the minimum level logged in prod; debug, info (default), warn or error
+	MangroveLogLevel string `env:"MANGROVE_LOG_LEVEL" default:"info"`
 	// http conf
 	HttpConf
 
 	WebauthnConf
+
+	OIDCConf
+
+	SigningKeyConf
+
+	SessionConf
+
+	ReaperConf
 }
 
 type Conf interface {
+	// Load reads the configuration from the optional file and the environment and validates it;
+	// every problem found is reported in the returned error
+	Load(file string) (*EnvVariables, error)
+	// GetEnvironmentVars reads the environment without validating it, for tools and tests that only need
+	// part of the configuration
 	GetEnvironmentVars() *EnvVariables
 }
 type conf struct {
@@ -61,40 +151,18 @@ func NewConf(logger zerolog.Logger) Conf {
 	}
 }
 
-func (c *conf) GetEnvironmentVars() *EnvVariables {
-	return &EnvVariables{
-		MangroveEnv:              MangroveEnvType(c.getEnvByName("MANGROVE_ENV")),
-		MangrovePostgresAddress:  c.getEnvByName("MANGROVE_POSTGRES_ADDRESS"),
-		MangrovePostgresPort:     c.getEnvByName("MANGROVE_POSTGRES_PORT"),
-		MangrovePostgresUser:     c.getEnvByName("MANGROVE_POSTGRES_USER"),
-		MangrovePostgresPassword: c.getEnvByName("MANGROVE_POSTGRES_PASSWORD"),
-		MangrovePostgresDBName:   c.getEnvByName("MANGROVE_POSTGRES_DB_NAME"),
-		MangroveSalt:             c.getEnvByName("MANGROVE_SALT"),
-		HttpConf: HttpConf{
-			MangroveHost: c.getEnvByName("MANGROVE_HOST"),
-			MangrovePort: c.getEnvByName("MANGROVE_PORT"),
-		},
-		WebauthnConf: WebauthnConf{
-			MangroveWebauthnRPDisplayName: c.getEnvByName("MANGROVE_WEBAUTHN_RPDISPLAY_NAME"),
-			MangroveWebauthnRPID:          c.getEnvByName("MANGROVE_WEBAUTHN_RPID"),
-			MangroveWebauthnRPOrigins:     c.parseEnvListByName("MANGROVE_WEBAUTHN_RP_ORIGINS"),
-		},
+func (c *conf) Load(file string) (*EnvVariables, error) {
+	vars, err := load(file)
+	if err != nil {
+		return vars, err
 	}
+	return vars, vars.Validate()
 }
 
-func (c *conf) getEnvByName(envName string) string {
-	envValue, ok := os.LookupEnv(envName)
-	if ok {
-		return envValue
-	}
-	c.logger.Warn().Msgf("Environment variable %s was not set", envName)
-	return ""
-}
-func (c *conf) parseEnvListByName(envName string) []string {
-	envValue, ok := os.LookupEnv(envName)
-	if ok {
-		return strings.Split(envValue, ",")
+func (c *conf) GetEnvironmentVars() *EnvVariables {
+	vars, err := load("")
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("invalid environment variables")
 	}
-	c.logger.Warn().Msgf("Environment variable %s was not set", envName)
-	return []string{}
+	return vars
 }
@@ -0,0 +1,160 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+const testSalt = "JC41eWlvgPP/+eDcqZA3kxf+k24KP5EyZUOMqV8E1KapmjKSa35SUW3OY5OGX5xP6k0iKlPyGLv2r3LCzcBZcA=="
+
+type ConfigsTestSuite struct {
+	suite.Suite
+}
+
+func TestConfigsTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigsTestSuite))
+}
+
+func (suite *ConfigsTestSuite) SetupTest() {
+	// start every test from an empty environment
+	for _, s := range settings(&EnvVariables{}) {
+		suite.T().Setenv(s.env, "")
+		os.Unsetenv(s.env)
+	}
+}
+
+func (suite *ConfigsTestSuite) setValidEnv() {
+	for env, value := range map[string]string{
+		"MANGROVE_ENV":                 "prod",
+		"MANGROVE_PORT":                "3030",
+		"MANGROVE_POSTGRES_ADDRESS":    "localhost",
+		"MANGROVE_POSTGRES_PORT":       "5432",
+		"MANGROVE_POSTGRES_USER":       "mangrove",
+		"MANGROVE_POSTGRES_PASSWORD":   "password",
+		"MANGROVE_POSTGRES_DB_NAME":    "mangrove",
+		"MANGROVE_SALT":                testSalt,
+		"MANGROVE_WEBAUTHN_RPID":       "mangrove.example.com",
+		"MANGROVE_WEBAUTHN_RP_ORIGINS": "https://mangrove.example.com, https://admin.example.com",
+	} {
+		suite.T().Setenv(env, value)
+	}
+}
+
+func (suite *ConfigsTestSuite) writeFile(name, content string) string {
+	file := filepath.Join(suite.T().TempDir(), name)
+	suite.Require().NoError(os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func (suite *ConfigsTestSuite) TestLoad_OK_Env() {
+	suite.setValidEnv()
+
+	vars, err := NewConf(zerolog.Nop()).Load("")
+	suite.NoError(err)
+	suite.Equal(PROD, vars.MangroveEnv)
+	suite.Equal([]string{"https://mangrove.example.com", "https://admin.example.com"}, vars.MangroveWebauthnRPOrigins)
+	// defaults
+	suite.Equal("info", vars.MangroveLogLevel)
+	suite.Equal("memory", vars.MangroveSessionStore)
+	suite.Equal(30*time.Second, vars.MangroveShutdownTimeout)
+	suite.Equal(30*24*time.Hour, vars.MangroveSigningKeyRotation)
+	suite.Equal(time.Hour, vars.MangroveReaperInterval)
+	suite.Equal(1000, vars.MangroveReaperBatchSize)
+}
+
+func (suite *ConfigsTestSuite) TestLoad_OK_FileWithEnvOverride() {
+	suite.setValidEnv()
+	suite.T().Setenv("MANGROVE_LOG_LEVEL", "warn")
+	file := suite.writeFile("mangrove.yaml", `
+log_level: debug
+session_store: postgres
+shutdown_timeout: 10s
+webauthn_rp_origins:
+  - https://file.example.com
+`)
+	os.Unsetenv("MANGROVE_WEBAUTHN_RP_ORIGINS")
+
+	vars, err := NewConf(zerolog.Nop()).Load(file)
+	suite.NoError(err)
+	suite.Equal("warn", vars.MangroveLogLevel)
+	suite.Equal("postgres", vars.MangroveSessionStore)
+	suite.Equal(10*time.Second, vars.MangroveShutdownTimeout)
+	suite.Equal([]string{"https://file.example.com"}, vars.MangroveWebauthnRPOrigins)
+}
+
+func (suite *ConfigsTestSuite) TestLoad_FailFile() {
+	suite.setValidEnv()
+
+	_, err := NewConf(zerolog.Nop()).Load(suite.writeFile("mangrove.toml", ""))
+	suite.ErrorContains(err, "unsupported config file")
+
+	_, err = NewConf(zerolog.Nop()).Load(suite.writeFile("mangrove.yaml", "salt: [unclosed"))
+	suite.ErrorContains(err, "failed to parse config file")
+
+	_, err = NewConf(zerolog.Nop()).Load(suite.writeFile("mangrove.yaml", "sallt: typo\nshutdown_timeout: soon\n"))
+	suite.ErrorContains(err, "sallt: unknown setting")
+	suite.ErrorContains(err, `shutdown_timeout: invalid duration "soon"`)
+}
+
+func (suite *ConfigsTestSuite) TestLoad_FailReportsAllErrors() {
+	suite.T().Setenv("MANGROVE_ENV", "staging")
+	suite.T().Setenv("MANGROVE_PORT", "80000")
+	suite.T().Setenv("MANGROVE_SALT", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	suite.T().Setenv("MANGROVE_WEBAUTHN_RP_ORIGINS", "not a url")
+	suite.T().Setenv("MANGROVE_TLS_CERT_FILE", "/etc/mangrove/tls.crt")
+	suite.T().Setenv("MANGROVE_REAPER_BATCH_SIZE", "0")
+
+	_, err := NewConf(zerolog.Nop()).Load("")
+	suite.Error(err)
+	for _, expected := range []string{
+		`MANGROVE_ENV: expected dev or prod, got "staging"`,
+		"MANGROVE_POSTGRES_PASSWORD is required",
+		`MANGROVE_PORT: invalid port "80000"`,
+		"MANGROVE_SALT: too weak",
+		"MANGROVE_WEBAUTHN_RPID is required",
+		`MANGROVE_WEBAUTHN_RP_ORIGINS: invalid URL "not a url"`,
+		"have to be set together",
+		"MANGROVE_REAPER_BATCH_SIZE: has to be a positive number",
+	} {
+		suite.ErrorContains(err, expected)
+	}
+}
+
+func (suite *ConfigsTestSuite) TestValidate_FailProdRequiresHttps() {
+	suite.setValidEnv()
+	suite.T().Setenv("MANGROVE_WEBAUTHN_RP_ORIGINS", "http://mangrove.example.com")
+
+	_, err := NewConf(zerolog.Nop()).Load("")
+	suite.ErrorContains(err, "has to use https in prod")
+
+	suite.T().Setenv("MANGROVE_ENV", "dev")
+	_, err = NewConf(zerolog.Nop()).Load("")
+	suite.NoError(err)
+}
+
+func (suite *ConfigsTestSuite) TestSaltEntropyBits() {
+	suite.GreaterOrEqual(saltEntropyBits(testSalt), float64(minSaltEntropyBits))
+	suite.Less(saltEntropyBits("password"), float64(minSaltEntropyBits))
+	suite.Less(saltEntropyBits(strings.Repeat("ab", 32)), float64(minSaltEntropyBits))
+}
+
+func (suite *ConfigsTestSuite) TestRedactedYAML() {
+	suite.setValidEnv()
+	vars, err := NewConf(zerolog.Nop()).Load("")
+	suite.Require().NoError(err)
+
+	out, err := vars.RedactedYAML()
+	suite.NoError(err)
+	suite.NotContains(string(out), testSalt)
+	suite.NotContains(string(out), "password\n")
+	suite.Contains(string(out), "salt: '[REDACTED]'")
+	suite.Contains(string(out), "postgres_password: '[REDACTED]'")
+	suite.Contains(string(out), "shutdown_timeout: 30s")
+	suite.Contains(string(out), "- https://admin.example.com")
+}
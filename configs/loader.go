@@ -0,0 +1,193 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix = "MANGROVE_"
+	redacted  = "[REDACTED]"
+)
+
+// setting is a single tagged field of EnvVariables
+type setting struct {
+	env          string
+	defaultValue string
+	hasDefault   bool
+	secret       bool
+	value        reflect.Value
+}
+
+// fileKey is the config file key of the setting
+func (s setting) fileKey() string {
+	return strings.ToLower(strings.TrimPrefix(s.env, envPrefix))
+}
+
+// settings lists the tagged fields of vars in declaration order, including those of embedded structs
+func settings(vars *EnvVariables) []setting {
+	var all []setting
+	var walk func(reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+			env, ok := field.Tag.Lookup("env")
+			if !ok {
+				continue
+			}
+			defaultValue, hasDefault := field.Tag.Lookup("default")
+			all = append(all, setting{
+				env:          env,
+				defaultValue: defaultValue,
+				hasDefault:   hasDefault,
+				secret:       field.Tag.Get("secret") == "true",
+				value:        v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(vars).Elem())
+	return all
+}
+
+// load applies the defaults, then the file, then the environment; it only reports values that cannot
+// be parsed, Validate checks the result
+func load(file string) (*EnvVariables, error) {
+	vars := &EnvVariables{}
+	var errs []error
+
+	fileValues := map[string]any{}
+	if file != "" {
+		var err error
+		fileValues, err = readFile(file)
+		if err != nil {
+			return vars, err
+		}
+	}
+
+	known := map[string]bool{}
+	for _, s := range settings(vars) {
+		known[s.fileKey()] = true
+
+		if s.hasDefault {
+			if err := setValue(s.value, s.defaultValue); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid default: %w", s.env, err))
+			}
+		}
+		if raw, ok := fileValues[s.fileKey()]; ok {
+			if err := setValue(s.value, fileValueToString(raw)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.fileKey(), err))
+			}
+		}
+		if raw, ok := os.LookupEnv(s.env); ok {
+			if err := setValue(s.value, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	for key := range fileValues {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown setting in %s", key, file))
+		}
+	}
+
+	return vars, errors.Join(errs...)
+}
+
+func readFile(file string) (map[string]any, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, fmt.Errorf("unsupported config file %s; use YAML (.yaml, .yml) or JSON (.json)", file)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	values := map[string]any{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+	return values, nil
+}
+
+// fileValueToString turns a file value into the same form as the env variable; lists become comma separated
+func fileValueToString(raw any) string {
+	switch v := raw.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.Int:
+		i, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetInt(int64(i))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// RedactedYAML prints the configuration in the config file format with the secrets redacted
+func (v *EnvVariables) RedactedYAML() ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings(v) {
+		value := &yaml.Node{}
+		switch {
+		case s.secret && !s.value.IsZero():
+			value.SetString(redacted)
+		case s.value.Type() == reflect.TypeOf(time.Duration(0)):
+			value.SetString(time.Duration(s.value.Int()).String())
+		default:
+			if err := value.Encode(s.value.Interface()); err != nil {
+				return nil, err
+			}
+		}
+		key := &yaml.Node{}
+		key.SetString(s.fileKey())
+		doc.Content = append(doc.Content, key, value)
+	}
+	return yaml.Marshal(doc)
+}
@@ -0,0 +1,161 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// minSaltEntropyBits is the least estimated entropy accepted for MANGROVE_SALT;
+// `openssl rand -base64 32` comfortably passes
+const minSaltEntropyBits = 128
+
+// Validate returns every missing or invalid setting at once
+func (v *EnvVariables) Validate() error {
+	var errs []error
+	required := func(env, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", env))
+		}
+	}
+	port := func(env, value string) {
+		if value == "" {
+			return
+		}
+		if p, err := strconv.Atoi(value); err != nil || p < 1 || p > 65535 {
+			errs = append(errs, fmt.Errorf("%s: invalid port %q", env, value))
+		}
+	}
+
+	switch v.MangroveEnv {
+	case DEV, PROD:
+	case "":
+		required("MANGROVE_ENV", "")
+	default:
+		errs = append(errs, fmt.Errorf("MANGROVE_ENV: expected %s or %s, got %q", DEV, PROD, v.MangroveEnv))
+	}
+	if level, err := zerolog.ParseLevel(v.MangroveLogLevel); err != nil || level == zerolog.NoLevel {
+		errs = append(errs, fmt.Errorf("MANGROVE_LOG_LEVEL: invalid level %q", v.MangroveLogLevel))
+	}
+
+	if v.MangrovePostgresDSN == "" {
+		required("MANGROVE_POSTGRES_ADDRESS", v.MangrovePostgresAddress)
+		required("MANGROVE_POSTGRES_PORT", v.MangrovePostgresPort)
+		port("MANGROVE_POSTGRES_PORT", v.MangrovePostgresPort)
+		required("MANGROVE_POSTGRES_USER", v.MangrovePostgresUser)
+		required("MANGROVE_POSTGRES_PASSWORD", v.MangrovePostgresPassword)
+		required("MANGROVE_POSTGRES_DB_NAME", v.MangrovePostgresDBName)
+	}
+	switch v.MangrovePostgresSSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("MANGROVE_POSTGRES_SSL_MODE: invalid sslmode %q", v.MangrovePostgresSSLMode))
+	}
+	if (v.MangrovePostgresSSLCert == "") != (v.MangrovePostgresSSLKey == "") {
+		errs = append(errs, errors.New("MANGROVE_POSTGRES_SSL_CERT and MANGROVE_POSTGRES_SSL_KEY have to be set together"))
+	}
+	if v.MangrovePostgresPoolMinConns < 0 || v.MangrovePostgresPoolMaxConns < 0 {
+		errs = append(errs, errors.New("MANGROVE_POSTGRES_POOL_MIN_CONNS and MANGROVE_POSTGRES_POOL_MAX_CONNS cannot be negative"))
+	} else if v.MangrovePostgresPoolMaxConns > 0 && v.MangrovePostgresPoolMinConns > v.MangrovePostgresPoolMaxConns {
+		errs = append(errs, errors.New("MANGROVE_POSTGRES_POOL_MIN_CONNS cannot exceed MANGROVE_POSTGRES_POOL_MAX_CONNS"))
+	}
+	notNegative := func(env string, d time.Duration) {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s: cannot be negative", env))
+		}
+	}
+	notNegative("MANGROVE_POSTGRES_POOL_MAX_CONN_LIFETIME", v.MangrovePostgresPoolMaxConnLifetime)
+	notNegative("MANGROVE_POSTGRES_POOL_MAX_CONN_IDLE_TIME", v.MangrovePostgresPoolMaxConnIdleTime)
+	notNegative("MANGROVE_POSTGRES_STATEMENT_TIMEOUT", v.MangrovePostgresStatementTimeout)
+	notNegative("MANGROVE_POSTGRES_CONNECT_TIMEOUT", v.MangrovePostgresConnectTimeout)
+
+	required("MANGROVE_SALT", v.MangroveSalt)
+	if v.MangroveSalt != "" && saltEntropyBits(v.MangroveSalt) < minSaltEntropyBits {
+		errs = append(errs, fmt.Errorf("MANGROVE_SALT: too weak; use at least %d random bits, e.g. `openssl rand -base64 32`", minSaltEntropyBits))
+	}
+
+	required("MANGROVE_PORT", v.MangrovePort)
+	port("MANGROVE_PORT", v.MangrovePort)
+	if (v.MangroveTLSCertFile == "") != (v.MangroveTLSKeyFile == "") {
+		errs = append(errs, errors.New("MANGROVE_TLS_CERT_FILE and MANGROVE_TLS_KEY_FILE have to be set together"))
+	}
+	positive := func(env string, d time.Duration) {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s: has to be a positive duration", env))
+		}
+	}
+	positive("MANGROVE_HTTP_READ_HEADER_TIMEOUT", v.MangroveHttpReadHeaderTimeout)
+	positive("MANGROVE_HTTP_READ_TIMEOUT", v.MangroveHttpReadTimeout)
+	positive("MANGROVE_HTTP_WRITE_TIMEOUT", v.MangroveHttpWriteTimeout)
+	positive("MANGROVE_HTTP_IDLE_TIMEOUT", v.MangroveHttpIdleTimeout)
+	positive("MANGROVE_SHUTDOWN_TIMEOUT", v.MangroveShutdownTimeout)
+	positive("MANGROVE_SIGNING_KEY_ROTATION", v.MangroveSigningKeyRotation)
+	positive("MANGROVE_SIGNING_KEY_GRACE_PERIOD", v.MangroveSigningKeyGracePeriod)
+	positive("MANGROVE_REAPER_RETENTION", v.MangroveReaperRetention)
+	notNegative("MANGROVE_REAPER_INTERVAL", v.MangroveReaperInterval)
+	if v.MangroveReaperBatchSize <= 0 {
+		errs = append(errs, errors.New("MANGROVE_REAPER_BATCH_SIZE: has to be a positive number"))
+	}
+
+	required("MANGROVE_WEBAUTHN_RPID", v.MangroveWebauthnRPID)
+	if len(v.MangroveWebauthnRPOrigins) == 0 {
+		required("MANGROVE_WEBAUTHN_RP_ORIGINS", "")
+	}
+	for _, origin := range v.MangroveWebauthnRPOrigins {
+		if err := v.validateOrigin(origin); err != nil {
+			errs = append(errs, fmt.Errorf("MANGROVE_WEBAUTHN_RP_ORIGINS: %w", err))
+		}
+	}
+	if v.MangroveOIDCIssuer != "" {
+		if err := v.validateOrigin(v.MangroveOIDCIssuer); err != nil {
+			errs = append(errs, fmt.Errorf("MANGROVE_OIDC_ISSUER: %w", err))
+		}
+	}
+
+	switch v.MangroveSigningKeyAlgorithm {
+	case "EdDSA", "ES256":
+	default:
+		errs = append(errs, fmt.Errorf("MANGROVE_SIGNING_KEY_ALGORITHM: expected EdDSA or ES256, got %q", v.MangroveSigningKeyAlgorithm))
+	}
+	switch v.MangroveSessionStore {
+	case "memory", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("MANGROVE_SESSION_STORE: expected memory or postgres, got %q", v.MangroveSessionStore))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOrigin accepts an absolute http(s) URL without query or fragment; prod only allows https
+func (v *EnvVariables) validateOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid URL %q", origin)
+	}
+	if v.MangroveEnv == PROD && u.Scheme != "https" {
+		return fmt.Errorf("%q has to use https in %s", origin, PROD)
+	}
+	return nil
+}
+
+// saltEntropyBits estimates the entropy of the salt from its length and character distribution
+func saltEntropyBits(salt string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range salt {
+		counts[r]++
+		total++
+	}
+	var perChar float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(total)
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/asatraitis/mangrove/configs"
 	"github.com/asatraitis/mangrove/internal/dal"
 	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/asatraitis/mangrove/internal/service/jwks"
+	"github.com/asatraitis/mangrove/internal/service/signer"
 	"github.com/asatraitis/mangrove/internal/service/webauthn"
 	"github.com/rs/zerolog"
 )
@@ -15,19 +17,27 @@ type BLL interface {
 	Config(context.Context) ConfigBLL
 	User(context.Context) UserBLL
 	Client(context.Context) ClientBLL
+	OAuth(context.Context) OAuthBLL
+	OIDC(context.Context) OIDCBLL
+	Invitation(context.Context) InvitationBLL
+	Passkey(context.Context) PasskeyBLL
+	Role(context.Context) RoleBLL
+	Session(context.Context) SessionBLL
 }
 type BaseBLL struct {
 	logger    zerolog.Logger
 	vars      *configs.EnvVariables
 	appConfig config.Configs
 	webauthn  webauthn.WebAuthN
+	signer    signer.Signer
+	jwks      jwks.Fetcher
 	dal       dal.DAL
 }
 type bll struct {
 	*BaseBLL
 }
 
-func NewBLL(logger zerolog.Logger, vars *configs.EnvVariables, appConfig config.Configs, webauthn webauthn.WebAuthN, dal dal.DAL) BLL {
+func NewBLL(logger zerolog.Logger, vars *configs.EnvVariables, appConfig config.Configs, webauthn webauthn.WebAuthN, signer signer.Signer, jwks jwks.Fetcher, dal dal.DAL) BLL {
 	logger = logger.With().Str("component", "BLL").Logger()
 	return &bll{
 		BaseBLL: &BaseBLL{
@@ -35,6 +45,8 @@ func NewBLL(logger zerolog.Logger, vars *configs.EnvVariables, appConfig config.
 			vars:      vars,
 			appConfig: appConfig,
 			webauthn:  webauthn,
+			signer:    signer,
+			jwks:      jwks,
 			dal:       dal,
 		},
 	}
@@ -49,3 +61,21 @@ func (b *bll) User(ctx context.Context) UserBLL {
 func (b *bll) Client(ctx context.Context) ClientBLL {
 	return NewClientBLL(ctx, b.BaseBLL)
 }
+func (b *bll) OAuth(ctx context.Context) OAuthBLL {
+	return NewOAuthBLL(ctx, b.BaseBLL)
+}
+func (b *bll) OIDC(ctx context.Context) OIDCBLL {
+	return NewOIDCBLL(ctx, b.BaseBLL)
+}
+func (b *bll) Invitation(ctx context.Context) InvitationBLL {
+	return NewInvitationBLL(ctx, b.BaseBLL)
+}
+func (b *bll) Passkey(ctx context.Context) PasskeyBLL {
+	return NewPasskeyBLL(ctx, b.BaseBLL)
+}
+func (b *bll) Role(ctx context.Context) RoleBLL {
+	return NewRoleBLL(ctx, b.BaseBLL)
+}
+func (b *bll) Session(ctx context.Context) SessionBLL {
+	return NewSessionBLL(ctx, b.BaseBLL)
+}
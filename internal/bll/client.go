@@ -2,20 +2,57 @@ package bll
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
+	"net"
+	"net/url"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/asatraitis/mangrove/internal/dto"
 	"github.com/asatraitis/mangrove/internal/typeconv"
 	"github.com/asatraitis/mangrove/internal/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	clientKeyDefaultTTL = time.Hour * 24 * 30
+	clientKeyMaxTTL     = time.Hour * 24 * 90
+)
+
+// clientStatusTransitions lists the statuses a client may move to from its current status;
+// a suspended client has to be reinstated before it can be paused
+var clientStatusTransitions = map[models.ClientStatus][]models.ClientStatus{
+	models.CLIENT_STATUS_ACTIVE:    {models.CLIENT_STATUS_PAUSED, models.CLIENT_STATUS_SUSPENDED},
+	models.CLIENT_STATUS_PAUSED:    {models.CLIENT_STATUS_ACTIVE, models.CLIENT_STATUS_SUSPENDED},
+	models.CLIENT_STATUS_SUSPENDED: {models.CLIENT_STATUS_ACTIVE},
+}
+
+const clientStatusReasonMaxLen = 512
+
+// token lifetimes of new clients and the bounds owners can configure them within
+const (
+	clientAccessTokenDefaultLifetime        = time.Hour
+	clientRefreshTokenDefaultLifetime       = time.Hour * 24 * 30
+	clientRefreshTokenDefaultMaxLifetime    = time.Hour * 24 * 90
+	clientAccessTokenMinLifetime            = time.Minute * 5
+	clientAccessTokenMaxLifetime            = time.Hour * 24
+	clientRefreshTokenMinLifetime           = time.Minute * 5
+	clientRefreshTokenMaxLifetimeUpperBound = time.Hour * 24 * 365
 )
 
 type ClientBLL interface {
 	GetUserClients() (dto.UserClientsResponse, error)
 	Create(dto.CreateClientRequest) (*dto.CreateClientResponse, error)
+	GetClient(uuid.UUID) (*dto.UserClient, error)
+	Update(uuid.UUID, dto.UpdateClientRequest) (*dto.UpdateClientResponse, error)
+	Delete(uuid.UUID) error
+	GetKeys(uuid.UUID) (dto.ClientKeysResponse, error)
+	AddKey(uuid.UUID, dto.CreateClientKeyRequest) (*dto.CreateClientKeyResponse, error)
+	RevokeKey(clientID uuid.UUID, keyID uuid.UUID) error
 }
 type clientBLL struct {
 	ctx context.Context
@@ -58,6 +95,12 @@ func (b *clientBLL) GetUserClients() (dto.UserClientsResponse, error) {
 
 func (b *clientBLL) Create(newUserClient dto.CreateClientRequest) (*dto.CreateClientResponse, error) {
 	const funcName = "Create"
+	newUserClient.RedirectURIs = normalizeURIs(newUserClient.RedirectURIs)
+	newUserClient.PostLogoutRedirectURIs = normalizeURIs(newUserClient.PostLogoutRedirectURIs)
+	newUserClient.WebOrigins = normalizeURIs(newUserClient.WebOrigins)
+	newUserClient.AccessTokenLifetime = lifetimeOrDefault(newUserClient.AccessTokenLifetime, clientAccessTokenDefaultLifetime)
+	newUserClient.RefreshTokenLifetime = lifetimeOrDefault(newUserClient.RefreshTokenLifetime, clientRefreshTokenDefaultLifetime)
+	newUserClient.RefreshTokenMaxLifetime = lifetimeOrDefault(newUserClient.RefreshTokenMaxLifetime, clientRefreshTokenDefaultMaxLifetime)
 	if err := validateCreateReq(newUserClient); err != nil {
 		b.logger.Err(err).Str("func", funcName).Msg("failed to validate CreateClientRequest")
 		return nil, err
@@ -84,14 +127,48 @@ func (b *clientBLL) Create(newUserClient dto.CreateClientRequest) (*dto.CreateCl
 	client.ID = ID
 	client.UserID = userID
 
-	// TODO: add config to allow max 3 months for key expiration
-	client.KeyExpiresAt = time.Now().Add(time.Hour * 24 * 30)
+	var key *models.ClientKey
+	if newUserClient.PublicKey != nil {
+		key, err = newClientKey(client.ID, dto.CreateClientKeyRequest{
+			KID:       newUserClient.KeyID,
+			Algorithm: newUserClient.KeyAlgo,
+			PublicKey: newUserClient.PublicKey,
+		})
+		if err != nil {
+			b.logger.Err(err).Str("func", funcName).Msg("failed to create client key")
+			return nil, err
+		}
+	}
+
+	tx, err := b.dal.BeginTx(b.ctx)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to start DB transaction")
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(b.ctx)
+		}
+	}()
 
-	err = b.dal.Client(b.ctx).Create(nil, client)
+	err = b.dal.Client(b.ctx).Create(tx, client)
 	if err != nil {
 		b.logger.Err(err).Str("func", funcName).Msg("failed to create clien in db")
 		return nil, err
 	}
+	if key != nil {
+		err = b.dal.ClientKeys(b.ctx).Create(tx, key)
+		if err != nil {
+			b.logger.Err(err).Str("func", funcName).Msg("failed to create client key in db")
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(b.ctx)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to commit DB transaction")
+		return nil, err
+	}
 
 	clientRes, err := typeconv.ConvertClientToCreateClientResponse(client)
 	if err != nil {
@@ -107,18 +184,444 @@ func validateCreateReq(req dto.CreateClientRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
 		err = errors.Join(err, errors.New("missing name"))
 	}
-	if strings.TrimSpace(req.RedirectURI) == "" {
-		err = errors.Join(err, errors.New("missing redirectUri"))
+	if len(req.RedirectURIs) == 0 {
+		err = errors.Join(err, errors.New("missing redirectURIs"))
 	}
+	err = errors.Join(err, validateRedirectURIs("redirectURI", req.RedirectURIs))
+	err = errors.Join(err, validateRedirectURIs("postLogoutRedirectURI", req.PostLogoutRedirectURIs))
+	err = errors.Join(err, validateWebOrigins(req.WebOrigins))
 	if !slices.Contains([]dto.UserClientStatus{dto.CLIENT_STATUS_ACTIVE, dto.CLIENT_STATUS_PAUSED, dto.CLIENT_STATUS_SUSPENDED}, req.Status) {
 		err = errors.Join(err, errors.New("missing or wrong status"))
 	}
-	if req.PublicKey == nil {
-		err = errors.Join(err, errors.New("missing publicKey"))
+	// clients that rotate through their jwks_uri do not need a registered key
+	if req.PublicKey == nil && req.JWKSURI == "" {
+		err = errors.Join(err, errors.New("missing publicKey or jwksURI"))
 	}
-	if !slices.Contains([]dto.UserClientKeyAlgo{dto.CLIENT_KEY_ALGO_EDDSA}, req.KeyAlgo) {
+	if req.PublicKey != nil && !slices.Contains([]dto.UserClientKeyAlgo{dto.CLIENT_KEY_ALGO_EDDSA, dto.CLIENT_KEY_ALGO_ES256}, req.KeyAlgo) {
 		err = errors.Join(err, errors.New("missing or wrong keyAlgo"))
 	}
+	if req.JWKSURI != "" {
+		err = errors.Join(err, validateJWKSURI(req.JWKSURI))
+	}
+	err = errors.Join(err, validateTokenLifetimes(
+		*lifetimeOrDefault(req.AccessTokenLifetime, clientAccessTokenDefaultLifetime),
+		*lifetimeOrDefault(req.RefreshTokenLifetime, clientRefreshTokenDefaultLifetime),
+		*lifetimeOrDefault(req.RefreshTokenMaxLifetime, clientRefreshTokenDefaultMaxLifetime),
+	))
+
+	return err
+}
+
+// lifetimeOrDefault returns the requested lifetime in seconds, or the default when none was requested
+func lifetimeOrDefault(seconds *int, defaultLifetime time.Duration) *int {
+	if seconds != nil {
+		return seconds
+	}
+	d := int(defaultLifetime.Seconds())
+	return &d
+}
+
+// validateTokenLifetimes checks the lifetimes in seconds; a refresh token lifetime of 0 disables refresh tokens
+func validateTokenLifetimes(access, refresh, refreshMax int) error {
+	inBounds := func(seconds int, min, max time.Duration) bool {
+		return seconds >= int(min.Seconds()) && seconds <= int(max.Seconds())
+	}
+	var err error
+	if !inBounds(access, clientAccessTokenMinLifetime, clientAccessTokenMaxLifetime) {
+		err = errors.Join(err, errors.New("accessTokenLifetime must be between 5 minutes and 24 hours"))
+	}
+	if refresh != 0 && !inBounds(refresh, clientRefreshTokenMinLifetime, clientRefreshTokenMaxLifetimeUpperBound) {
+		err = errors.Join(err, errors.New("refreshTokenLifetime must be 0 or between 5 minutes and 365 days"))
+	}
+	if !inBounds(refreshMax, time.Duration(refresh)*time.Second, clientRefreshTokenMaxLifetimeUpperBound) {
+		err = errors.Join(err, errors.New("refreshTokenMaxLifetime must be at least refreshTokenLifetime and at most 365 days"))
+	}
+	return err
+}
+
+func (b *clientBLL) GetClient(clientID uuid.UUID) (*dto.UserClient, error) {
+	const funcName = "GetClient"
+
+	client, err := b.getOwnClient(clientID)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client")
+		return nil, err
+	}
+	return typeconv.ConvertClientToUserClient(client)
+}
+
+// Update applies a partial update. Paused and suspended clients are both refused at the authorize,
+// token, introspection and revocation endpoints; suspending also revokes every token and authorization
+// code issued to the client, while pausing keeps them so the client picks up where it left off once
+// it is active again.
+func (b *clientBLL) Update(clientID uuid.UUID, req dto.UpdateClientRequest) (*dto.UpdateClientResponse, error) {
+	const funcName = "Update"
+
+	client, err := b.getOwnClient(clientID)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client")
+		return nil, err
+	}
+	for _, uris := range []*[]string{req.RedirectURIs, req.PostLogoutRedirectURIs, req.WebOrigins} {
+		if uris != nil {
+			*uris = normalizeURIs(*uris)
+		}
+	}
+	if err = validateUpdateReq(client, req); err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to validate UpdateClientRequest")
+		return nil, err
+	}
+
+	if req.Name != nil {
+		client.Name = *req.Name
+	}
+	if req.Description != nil {
+		client.Description = *req.Description
+	}
+	if req.RedirectURIs != nil {
+		client.RedirectURIs = *req.RedirectURIs
+	}
+	if req.PostLogoutRedirectURIs != nil {
+		client.PostLogoutRedirectURIs = *req.PostLogoutRedirectURIs
+	}
+	if req.WebOrigins != nil {
+		client.WebOrigins = *req.WebOrigins
+	}
+	if req.JWKSURI != nil {
+		client.JWKSURI = *req.JWKSURI
+	}
+	if req.AccessTokenLifetime != nil {
+		client.AccessTokenLifetime = *req.AccessTokenLifetime
+	}
+	if req.RefreshTokenLifetime != nil {
+		client.RefreshTokenLifetime = *req.RefreshTokenLifetime
+	}
+	if req.RefreshTokenMaxLifetime != nil {
+		client.RefreshTokenMaxLifetime = *req.RefreshTokenMaxLifetime
+	}
+	suspended := false
+	if req.Status != nil && models.ClientStatus(*req.Status) != client.Status {
+		now := time.Now()
+		client.Status = models.ClientStatus(*req.Status)
+		client.StatusReason = strings.TrimSpace(req.StatusReason)
+		client.StatusChangedAt = &now
+		suspended = client.Status == models.CLIENT_STATUS_SUSPENDED
+	}
+
+	tx, err := b.dal.BeginTx(b.ctx)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to start DB transaction")
+		return nil, errors.New("failed to update client")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(b.ctx)
+		}
+	}()
+
+	err = b.dal.Client(b.ctx).Update(tx, client)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to update client in db")
+		return nil, errors.New("failed to update client")
+	}
+	if suspended {
+		if err = b.revokeClientGrants(tx, clientID); err != nil {
+			b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to revoke client tokens")
+			return nil, errors.New("failed to update client")
+		}
+	}
+
+	err = tx.Commit(b.ctx)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to commit DB transaction")
+		return nil, errors.New("failed to update client")
+	}
+	if suspended {
+		b.logger.Info().Str("func", funcName).Str("clientID", clientID.String()).Str("reason", client.StatusReason).Msg("client suspended")
+	}
+
+	res, err := typeconv.ConvertClientToUserClient(client)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to typeconv model client to dto")
+		return nil, err
+	}
+	updated := dto.UpdateClientResponse(*res)
+	return &updated, nil
+}
+
+// Delete removes the client after revoking every token issued to it
+func (b *clientBLL) Delete(clientID uuid.UUID) error {
+	const funcName = "Delete"
+
+	if _, err := b.getOwnClient(clientID); err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client")
+		return err
+	}
+
+	tx, err := b.dal.BeginTx(b.ctx)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to start DB transaction")
+		return errors.New("failed to delete client")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(b.ctx)
+		}
+	}()
+
+	if err = b.revokeClientGrants(tx, clientID); err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to revoke client tokens")
+		return errors.New("failed to delete client")
+	}
+	err = b.dal.Client(b.ctx).Delete(tx, clientID)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to delete client in db")
+		return errors.New("failed to delete client")
+	}
+
+	err = tx.Commit(b.ctx)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Msg("failed to commit DB transaction")
+		return errors.New("failed to delete client")
+	}
+	b.logger.Info().Str("func", funcName).Str("clientID", clientID.String()).Msg("client deleted")
+	return nil
+}
+
+// revokeClientGrants deletes the access and refresh tokens and pending authorization codes issued to the client
+func (b *clientBLL) revokeClientGrants(tx pgx.Tx, clientID uuid.UUID) error {
+	tokens, err := b.dal.UserTokens(b.ctx).DeleteByClientID(tx, clientID)
+	if err != nil {
+		return err
+	}
+	refreshTokens, err := b.dal.RefreshTokens(b.ctx).DeleteByClientID(tx, clientID)
+	if err != nil {
+		return err
+	}
+	codes, err := b.dal.AuthorizationCodes(b.ctx).DeleteByClientID(tx, clientID)
+	if err != nil {
+		return err
+	}
+	b.logger.Info().Str("clientID", clientID.String()).Int64("tokens", tokens).Int64("refreshTokens", refreshTokens).Int64("codes", codes).Msg("revoked client grants")
+	return nil
+}
+
+// validateUpdateReq mirrors validateCreateReq for the fields present in the request and checks the status transition
+func validateUpdateReq(client *models.Client, req dto.UpdateClientRequest) error {
+	var err error
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		err = errors.Join(err, errors.New("missing name"))
+	}
+	if req.RedirectURIs != nil {
+		if len(*req.RedirectURIs) == 0 {
+			err = errors.Join(err, errors.New("missing redirectURIs"))
+		}
+		err = errors.Join(err, validateRedirectURIs("redirectURI", *req.RedirectURIs))
+	}
+	if req.PostLogoutRedirectURIs != nil {
+		err = errors.Join(err, validateRedirectURIs("postLogoutRedirectURI", *req.PostLogoutRedirectURIs))
+	}
+	if req.WebOrigins != nil {
+		err = errors.Join(err, validateWebOrigins(*req.WebOrigins))
+	}
+	if req.JWKSURI != nil && *req.JWKSURI != "" {
+		err = errors.Join(err, validateJWKSURI(*req.JWKSURI))
+	}
+	if req.AccessTokenLifetime != nil || req.RefreshTokenLifetime != nil || req.RefreshTokenMaxLifetime != nil {
+		access, refresh, refreshMax := client.AccessTokenLifetime, client.RefreshTokenLifetime, client.RefreshTokenMaxLifetime
+		if req.AccessTokenLifetime != nil {
+			access = *req.AccessTokenLifetime
+		}
+		if req.RefreshTokenLifetime != nil {
+			refresh = *req.RefreshTokenLifetime
+		}
+		if req.RefreshTokenMaxLifetime != nil {
+			refreshMax = *req.RefreshTokenMaxLifetime
+		}
+		err = errors.Join(err, validateTokenLifetimes(access, refresh, refreshMax))
+	}
+	if len(req.StatusReason) > clientStatusReasonMaxLen {
+		err = errors.Join(err, errors.New("statusReason too long"))
+	}
+	if req.Status != nil && models.ClientStatus(*req.Status) != client.Status {
+		status := models.ClientStatus(*req.Status)
+		switch {
+		case !slices.Contains([]dto.UserClientStatus{dto.CLIENT_STATUS_ACTIVE, dto.CLIENT_STATUS_PAUSED, dto.CLIENT_STATUS_SUSPENDED}, *req.Status):
+			err = errors.Join(err, errors.New("missing or wrong status"))
+		case !slices.Contains(clientStatusTransitions[client.Status], status):
+			err = errors.Join(err, errors.New("status transition from "+string(client.Status)+" to "+string(status)+" is not allowed"))
+		case status != models.CLIENT_STATUS_ACTIVE && strings.TrimSpace(req.StatusReason) == "":
+			err = errors.Join(err, errors.New("missing statusReason"))
+		}
+	}
 
 	return err
 }
+
+func (b *clientBLL) GetKeys(clientID uuid.UUID) (dto.ClientKeysResponse, error) {
+	const funcName = "GetKeys"
+
+	if _, err := b.getOwnClient(clientID); err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client")
+		return nil, err
+	}
+
+	keys, err := b.dal.ClientKeys(b.ctx).GetByClientID(clientID)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client keys from db")
+		return nil, errors.New("failed to get client keys")
+	}
+	if keys == nil {
+		return dto.ClientKeysResponse{}, nil
+	}
+	return typeconv.ConvertClientKeysToClientKeysResponse(keys)
+}
+
+// AddKey registers another key for the client. Keys overlap, so a client can add its next key
+// (optionally with a future notBefore) before it stops signing with the current one.
+func (b *clientBLL) AddKey(clientID uuid.UUID, req dto.CreateClientKeyRequest) (*dto.CreateClientKeyResponse, error) {
+	const funcName = "AddKey"
+
+	if _, err := b.getOwnClient(clientID); err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client")
+		return nil, err
+	}
+
+	key, err := newClientKey(clientID, req)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to create client key")
+		return nil, err
+	}
+
+	err = b.dal.ClientKeys(b.ctx).Create(nil, key)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to create client key in db")
+		return nil, errors.New("failed to create client key")
+	}
+
+	return typeconv.ConvertClientKeyToCreateClientKeyResponse(key)
+}
+
+func (b *clientBLL) RevokeKey(clientID uuid.UUID, keyID uuid.UUID) error {
+	const funcName = "RevokeKey"
+
+	if _, err := b.getOwnClient(clientID); err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to get client")
+		return err
+	}
+
+	revoked, err := b.dal.ClientKeys(b.ctx).Revoke(clientID, keyID)
+	if err != nil {
+		b.logger.Err(err).Str("func", funcName).Str("clientID", clientID.String()).Msg("failed to revoke client key in db")
+		return errors.New("failed to revoke client key")
+	}
+	if !revoked {
+		b.logger.Error().Str("func", funcName).Str("clientID", clientID.String()).Str("keyID", keyID.String()).Msg("client key not found")
+		return errors.New("client key not found")
+	}
+	return nil
+}
+
+// getOwnClient returns the client if it belongs to the user making the request. Users whose role grants
+// users:manage administer the instance and may act on any client, e.g. to suspend one with a leaked key.
+func (b *clientBLL) getOwnClient(clientID uuid.UUID) (*models.Client, error) {
+	const funcName = "getOwnClient"
+
+	userID, err := utils.GetUserIdFromCtx(b.ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := b.dal.Client(b.ctx).GetByID(clientID)
+	if err != nil || client == nil {
+		return nil, errors.New("client not found")
+	}
+	if client.UserID == userID {
+		return client, nil
+	}
+
+	role, err := utils.GetUserRoleFromCtx(b.ctx)
+	if err != nil {
+		return nil, errors.New("client not found")
+	}
+	permissions, err := NewRoleBLL(b.ctx, b.BaseBLL).Permissions(role)
+	if err != nil || !slices.Contains(permissions, models.PERMISSION_USERS_MANAGE) {
+		return nil, errors.New("client not found")
+	}
+	b.logger.Info().Str("func", funcName).Str("clientID", clientID.String()).Str("userID", userID.String()).Msg("acting on another user's client")
+	return client, nil
+}
+
+// newClientKey validates the submitted public key and fills in the kid and validity defaults
+func newClientKey(clientID uuid.UUID, req dto.CreateClientKeyRequest) (*models.ClientKey, error) {
+	if !slices.Contains([]dto.UserClientKeyAlgo{dto.CLIENT_KEY_ALGO_EDDSA, dto.CLIENT_KEY_ALGO_ES256}, req.Algorithm) {
+		return nil, errors.New("missing or wrong algorithm")
+	}
+	publicKey, err := utils.ParsePublicKey(string(req.Algorithm), req.PublicKey)
+	if err != nil {
+		return nil, errors.New("invalid publicKey")
+	}
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, errors.New("invalid publicKey")
+	}
+
+	kid := strings.TrimSpace(req.KID)
+	if kid == "" {
+		jwk, err := utils.PublicKeyToJWK("", string(req.Algorithm), publicKey)
+		if err != nil {
+			return nil, errors.New("invalid publicKey")
+		}
+		kid = utils.JWKThumbprint(jwk)
+	}
+
+	now := time.Now()
+	notBefore := now
+	if req.NotBefore != nil {
+		notBefore = *req.NotBefore
+	}
+	expiresAt := notBefore.Add(clientKeyDefaultTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(notBefore) || !expiresAt.After(now) {
+		return nil, errors.New("expiresAt must be after notBefore and in the future")
+	}
+	if expiresAt.After(now.Add(clientKeyMaxTTL)) {
+		expiresAt = now.Add(clientKeyMaxTTL)
+		if !expiresAt.After(notBefore) {
+			return nil, errors.New("notBefore is too far in the future")
+		}
+	}
+
+	ID, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	return &models.ClientKey{
+		ID:        ID,
+		ClientID:  clientID,
+		KID:       kid,
+		Algorithm: models.ClientKeyAlgo(req.Algorithm),
+		PublicKey: der,
+		NotBefore: notBefore,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}, nil
+}
+
+// validateJWKSURI requires an absolute https URL naming a host; the server fetches it, so IP literals and
+// localhost are refused here, and the fetcher refuses internal addresses a hostname resolves to
+func validateJWKSURI(jwksURI string) error {
+	u, err := url.Parse(jwksURI)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return errors.New("invalid jwksURI")
+	}
+	if u.Scheme != "https" {
+		return errors.New("jwksURI must use https")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if net.ParseIP(host) != nil || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("jwksURI must not name an IP address or localhost")
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package bll
+
+import (
+	"slices"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	OAUTH_CLIENT_AUTH_METHOD_PRIVATE_KEY_JWT = "private_key_jwt"
+	OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER   = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var clientAssertionSigningAlgs = []string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodES256.Alg()}
+
+const (
+	// clientAssertionMaxTTL bounds how far in the future an assertion may expire, which also bounds
+	// how long its jti has to be remembered
+	clientAssertionMaxTTL = 5 * time.Minute
+	clientAssertionLeeway = 30 * time.Second
+)
+
+// authenticateClient resolves and authenticates the client of a token, introspection or revocation request.
+// Every client registers a key or a jwks_uri, so there are no public clients: the caller always proves it
+// holds the client's key with a private_key_jwt assertion (RFC 7523), and a client_id alone is never enough.
+func (o *oauthBLL) authenticateClient(clientID, assertionType, assertion string) (*models.Client, error) {
+	const funcName = "authenticateClient"
+
+	if assertionType == "" && assertion == "" {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Msg("missing client assertion")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "client authentication required")
+	}
+	if assertionType != OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER || assertion == "" {
+		o.logger.Error().Str("func", funcName).Str("assertionType", assertionType).Msg("unsupported client assertion")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "unsupported client_assertion_type")
+	}
+
+	// the assertion names the client; the signature is checked against that client's keys below
+	var unverified jwt.RegisteredClaims
+	token, _, err := jwt.NewParser().ParseUnverified(assertion, &unverified)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to parse client assertion")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "invalid client_assertion")
+	}
+	if clientID == "" {
+		clientID = unverified.Subject
+	}
+	if unverified.Subject != clientID {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Msg("client assertion subject does not match client_id")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "invalid client_assertion")
+	}
+
+	client, err := o.getClient(clientID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", clientID).Msg("failed to get client")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "unknown client")
+	}
+	if client.Status != models.CLIENT_STATUS_ACTIVE {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Str("status", string(client.Status)).Msg("client is not active")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "client is not active")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	keys, err := o.clientVerificationKeys(client, kid, token.Method.Alg())
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", clientID).Msg("failed to get client keys")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to authenticate client")
+	}
+	if len(keys.Keys) == 0 {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Str("kid", kid).Str("alg", token.Method.Alg()).Msg("no usable client key")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "no usable client key")
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(assertion, &claims, func(*jwt.Token) (interface{}, error) {
+		return keys, nil
+	},
+		jwt.WithValidMethods(clientAssertionSigningAlgs),
+		jwt.WithIssuer(clientID),
+		jwt.WithSubject(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clientAssertionLeeway),
+	)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", clientID).Msg("failed to verify client assertion")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "invalid client_assertion")
+	}
+
+	// RFC 7523 section 3: the audience identifies the authorization server; the URL of the endpoint the
+	// assertion is sent to is accepted too
+	issuer := o.issuer()
+	audiences := []string{issuer, issuer + "/oauth2/token", issuer + "/oauth2/introspect", issuer + "/oauth2/revoke"}
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(audiences, aud)
+	}) {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Strs("aud", claims.Audience).Msg("client assertion audience mismatch")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "invalid client_assertion audience")
+	}
+	if claims.ExpiresAt.After(time.Now().Add(clientAssertionMaxTTL + clientAssertionLeeway)) {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Msg("client assertion lifetime too long")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "client_assertion expires too far in the future")
+	}
+	if claims.ID == "" {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Msg("client assertion missing jti")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "client_assertion jti required")
+	}
+
+	recorded, err := o.dal.ClientAssertions(o.ctx).Record(&models.ClientAssertion{
+		ClientID: client.ID,
+		JTI:      claims.ID,
+		Expires:  claims.ExpiresAt.Add(clientAssertionLeeway),
+	})
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", clientID).Msg("failed to record client assertion")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to authenticate client")
+	}
+	if !recorded {
+		o.logger.Error().Str("func", funcName).Str("clientID", clientID).Msg("client assertion replayed")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_CLIENT, "client_assertion already used")
+	}
+
+	return client, nil
+}
+
+// clientVerificationKeys collects the client's keys that may have signed an assertion with the given kid
+// and alg: the usable keys registered in client_keys plus the keys published at the client's jwks_uri.
+// An empty kid matches every key. A kid missing from a cached jwks_uri key set triggers one refetch, so
+// clients can start signing with a freshly published key right away.
+func (o *oauthBLL) clientVerificationKeys(client *models.Client, kid, alg string) (jwt.VerificationKeySet, error) {
+	const funcName = "clientVerificationKeys"
+	var keySet jwt.VerificationKeySet
+
+	stored, err := o.dal.ClientKeys(o.ctx).GetUsableByClientID(client.ID)
+	if err != nil {
+		return keySet, err
+	}
+	for _, key := range stored {
+		if string(key.Algorithm) != alg || (kid != "" && key.KID != kid) {
+			continue
+		}
+		publicKey, err := utils.ParsePublicKey(string(key.Algorithm), key.PublicKey)
+		if err != nil {
+			o.logger.Err(err).Str("func", funcName).Str("clientID", client.ID.String()).Str("kid", key.KID).Msg("failed to parse client key")
+			continue
+		}
+		keySet.Keys = append(keySet.Keys, publicKey)
+	}
+
+	if client.JWKSURI == "" || (kid != "" && len(keySet.Keys) > 0) {
+		return keySet, nil
+	}
+
+	published, err := o.jwks.Get(o.ctx, client.JWKSURI)
+	if err != nil {
+		// the registered keys may still verify the assertion
+		o.logger.Err(err).Str("func", funcName).Str("clientID", client.ID.String()).Msg("failed to fetch client jwks")
+		return keySet, nil
+	}
+	keys := matchingJWKs(published, kid, alg)
+	if kid != "" && len(keys) == 0 {
+		published, err = o.jwks.Refresh(o.ctx, client.JWKSURI)
+		if err != nil {
+			o.logger.Err(err).Str("func", funcName).Str("clientID", client.ID.String()).Msg("failed to refresh client jwks")
+			return keySet, nil
+		}
+		keys = matchingJWKs(published, kid, alg)
+	}
+	keySet.Keys = append(keySet.Keys, keys...)
+	return keySet, nil
+}
+
+func matchingJWKs(jwks dto.JWKSResponse, kid, alg string) []jwt.VerificationKey {
+	var keys []jwt.VerificationKey
+	for _, jwk := range jwks.Keys {
+		if (kid != "" && jwk.Kid != kid) || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != alg) {
+			continue
+		}
+		publicKey, keyAlg, err := utils.JWKToPublicKey(jwk)
+		if err != nil || keyAlg != alg {
+			continue
+		}
+		keys = append(keys, publicKey)
+	}
+	return keys
+}
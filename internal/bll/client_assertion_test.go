@@ -0,0 +1,286 @@
+package bll
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func (suite *OAuthBllTestSuite) clientAssertion(key ed25519.PrivateKey, modify func(*jwt.RegisteredClaims)) string {
+	return suite.signedClientAssertion(jwt.SigningMethodEdDSA, key, "", modify)
+}
+
+func (suite *OAuthBllTestSuite) signedClientAssertion(method jwt.SigningMethod, key crypto.Signer, kid string, modify func(*jwt.RegisteredClaims)) string {
+	claims := &jwt.RegisteredClaims{
+		Issuer:    suite.client.ID.String(),
+		Subject:   suite.client.ID.String(),
+		Audience:  jwt.ClaimStrings{"http://localhost:3030/oauth2/token"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ID:        uuid.NewString(),
+	}
+	if modify != nil {
+		modify(claims)
+	}
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	assertion, err := token.SignedString(key)
+	suite.NoError(err)
+	return assertion
+}
+
+func (suite *OAuthBllTestSuite) storedClientKey(kid string, key crypto.Signer) *models.ClientKey {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	suite.NoError(err)
+	alg := models.CLIENT_KEY_ALGO_EDDSA
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = models.CLIENT_KEY_ALGO_ES256
+	}
+	return &models.ClientKey{
+		ID:        uuid.New(),
+		ClientID:  suite.client.ID,
+		KID:       kid,
+		Algorithm: alg,
+		PublicKey: der,
+		NotBefore: time.Now().Add(-time.Minute),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func (suite *OAuthBllTestSuite) expectClientKeys(keys ...*models.ClientKey) {
+	suite.dal.EXPECT().ClientKeys(gomock.Any()).Times(1).Return(suite.clientKeyDal)
+	suite.clientKeyDal.EXPECT().GetUsableByClientID(suite.client.ID).Times(1).Return(keys, nil)
+}
+
+// expectClientAuthenticated expects an assertion signed with the client's registered key to be verified
+func (suite *OAuthBllTestSuite) expectClientAuthenticated() {
+	suite.expectClient()
+	suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey))
+	suite.dal.EXPECT().ClientAssertions(gomock.Any()).Times(1).Return(suite.assertionDal)
+	suite.assertionDal.EXPECT().Record(gomock.Any()).Times(1).Return(true, nil)
+}
+
+func (suite *OAuthBllTestSuite) expectTokenIssued() {
+	suite.dal.EXPECT().ClientAssertions(gomock.Any()).Times(1).Return(suite.assertionDal)
+	suite.assertionDal.EXPECT().Record(gomock.Any()).Times(1).Return(true, nil)
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(&models.AuthorizationCode{
+		ClientID:            suite.client.ID,
+		UserID:              uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+		RedirectURI:         suite.client.RedirectURIs[0],
+		CodeChallenge:       utils.GeneratePKCEChallengeS256(suite.verifier),
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+	}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+}
+
+func (suite *OAuthBllTestSuite) assertionTokenRequest(assertion string) *dto.TokenRequest {
+	return &dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		CodeVerifier:        suite.verifier,
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     assertion,
+	}
+}
+
+func (suite *OAuthBllTestSuite) expectInvalidClient(req *dto.TokenRequest) {
+	_, err := suite.bll.OAuth(suite.ctx).Token(req)
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_CLIENT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK_PrivateKeyJWT() {
+	userID := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	suite.expectClient()
+	suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey))
+	suite.dal.EXPECT().ClientAssertions(gomock.Any()).Times(1).Return(suite.assertionDal)
+	suite.assertionDal.EXPECT().Record(gomock.Any()).Times(1).DoAndReturn(func(assertion *models.ClientAssertion) (bool, error) {
+		suite.Equal(suite.client.ID, assertion.ClientID)
+		suite.NotEmpty(assertion.JTI)
+		return true, nil
+	})
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(&models.AuthorizationCode{
+		ClientID:            suite.client.ID,
+		UserID:              userID,
+		RedirectURI:         suite.client.RedirectURIs[0],
+		CodeChallenge:       utils.GeneratePKCEChallengeS256(suite.verifier),
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+	}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+	assertion := suite.signedClientAssertion(jwt.SigningMethodEdDSA, suite.clientKey, "key-1", nil)
+	res, err := suite.bll.OAuth(suite.ctx).Token(suite.assertionTokenRequest(assertion))
+	suite.NoError(err)
+	suite.NotEmpty(res.AccessToken)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK_OverlappingKeys() {
+	_, nextKey, err := ed25519.GenerateKey(rand.Reader)
+	suite.NoError(err)
+
+	// both keys verify while the client rolls out the next one; assertions without a kid try every key
+	for _, key := range []ed25519.PrivateKey{suite.clientKey, nextKey} {
+		suite.expectClient()
+		suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey), suite.storedClientKey("key-2", nextKey))
+		suite.expectTokenIssued()
+
+		_, err = suite.bll.OAuth(suite.ctx).Token(suite.assertionTokenRequest(suite.clientAssertion(key, nil)))
+		suite.NoError(err)
+	}
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK_ES256() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.NoError(err)
+	suite.expectClient()
+	suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey), suite.storedClientKey("key-ec", ecKey))
+	suite.expectTokenIssued()
+
+	assertion := suite.signedClientAssertion(jwt.SigningMethodES256, ecKey, "key-ec", nil)
+	_, err = suite.bll.OAuth(suite.ctx).Token(suite.assertionTokenRequest(assertion))
+	suite.NoError(err)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK_JWKSURIRefetchedForUnknownKid() {
+	_, nextKey, err := ed25519.GenerateKey(rand.Reader)
+	suite.NoError(err)
+	currentJWK, err := utils.PublicKeyToJWK("key-1", "EdDSA", suite.clientKey.Public())
+	suite.NoError(err)
+	nextJWK, err := utils.PublicKeyToJWK("key-2", "EdDSA", nextKey.Public())
+	suite.NoError(err)
+
+	suite.client.JWKSURI = "https://app.example.com/jwks.json"
+	suite.expectClient()
+	suite.expectClientKeys()
+	suite.jwks.EXPECT().Get(gomock.Any(), suite.client.JWKSURI).Times(1).Return(dto.JWKSResponse{Keys: []dto.JWK{currentJWK}}, nil)
+	suite.jwks.EXPECT().Refresh(gomock.Any(), suite.client.JWKSURI).Times(1).Return(dto.JWKSResponse{Keys: []dto.JWK{currentJWK, nextJWK}}, nil)
+	suite.expectTokenIssued()
+
+	assertion := suite.signedClientAssertion(jwt.SigningMethodEdDSA, nextKey, "key-2", nil)
+	_, err = suite.bll.OAuth(suite.ctx).Token(suite.assertionTokenRequest(assertion))
+	suite.NoError(err)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_UnknownKid() {
+	suite.expectClient()
+	suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey))
+
+	assertion := suite.signedClientAssertion(jwt.SigningMethodEdDSA, suite.clientKey, "key-9", nil)
+	suite.expectInvalidClient(suite.assertionTokenRequest(assertion))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_JWKSURIUnavailable() {
+	suite.client.JWKSURI = "https://app.example.com/jwks.json"
+	suite.expectClient()
+	suite.expectClientKeys()
+	suite.jwks.EXPECT().Get(gomock.Any(), suite.client.JWKSURI).Times(1).Return(dto.JWKSResponse{}, errors.New("test"))
+
+	suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil)))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_AssertionReplayed() {
+	suite.expectClient()
+	suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey))
+	suite.dal.EXPECT().ClientAssertions(gomock.Any()).Times(1).Return(suite.assertionDal)
+	suite.assertionDal.EXPECT().Record(gomock.Any()).Times(1).Return(false, nil)
+
+	suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil)))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_AssertionWrongKey() {
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	suite.NoError(err)
+	suite.expectClient()
+	suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey))
+
+	suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(otherKey, nil)))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_AssertionClaims() {
+	for _, modify := range []func(*jwt.RegisteredClaims){
+		func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"https://other.example.com"} },
+		func(c *jwt.RegisteredClaims) { c.Issuer = uuid.NewString() },
+		func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) },
+		func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour)) },
+		func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil },
+		func(c *jwt.RegisteredClaims) { c.ID = "" },
+	} {
+		suite.expectClient()
+		suite.expectClientKeys(suite.storedClientKey("key-1", suite.clientKey))
+		suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, modify)))
+	}
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_NoUsableKey() {
+	// expired, revoked and not yet valid keys are filtered out by the DAL
+	suite.expectClient()
+	suite.expectClientKeys()
+
+	suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil)))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_AssertionClientSuspended() {
+	suite.client.Status = models.CLIENT_STATUS_SUSPENDED
+	suite.expectClient()
+
+	suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil)))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_AssertionClientIDMismatch() {
+	req := suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil))
+	req.ClientID = uuid.NewString()
+
+	suite.expectInvalidClient(req)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_NoAssertion() {
+	// knowing the client_id is not enough; the client is not even looked up
+	req := suite.assertionTokenRequest("")
+	req.ClientAssertionType = ""
+	req.ClientID = suite.client.ID.String()
+
+	suite.expectInvalidClient(req)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_AssertionType() {
+	req := suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil))
+	req.ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:saml2-bearer"
+
+	suite.expectInvalidClient(req)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_UnknownAssertionClient() {
+	suite.dal.EXPECT().Client(gomock.Any()).Times(1).Return(suite.clientsDal)
+	suite.clientsDal.EXPECT().GetByID(gomock.Any()).Times(1).Return(nil, pgx.ErrNoRows)
+
+	suite.expectInvalidClient(suite.assertionTokenRequest(suite.clientAssertion(suite.clientKey, nil)))
+}
@@ -0,0 +1,116 @@
+package bll
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const clientURIsMaxLen = 32
+
+// normalizeURIs trims the submitted URIs; the result is never nil since the columns are NOT NULL arrays
+func normalizeURIs(uris []string) []string {
+	normalized := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		normalized = append(normalized, strings.TrimSpace(uri))
+	}
+	return normalized
+}
+
+// validateRedirectURIs checks every URI of a redirect list; field names the list in the returned errors
+func validateRedirectURIs(field string, uris []string) error {
+	if len(uris) > clientURIsMaxLen {
+		return fmt.Errorf("too many %s", field)
+	}
+	var err error
+	for i, uri := range uris {
+		if slices.Contains(uris[:i], uri) {
+			err = errors.Join(err, fmt.Errorf("duplicate %s: %s", field, uri))
+			continue
+		}
+		if verr := validateRedirectURI(uri); verr != nil {
+			err = errors.Join(err, fmt.Errorf("invalid %s %q: %w", field, uri, verr))
+		}
+	}
+	return err
+}
+
+// validateRedirectURI requires an absolute URI without a fragment (RFC 6749 3.1.2);
+// plain http is only accepted for loopback redirects of native apps (RFC 8252 7.3)
+func validateRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return errors.New("must be an absolute URI")
+	}
+	if strings.Contains(uri, "#") {
+		return errors.New("must not contain a fragment")
+	}
+	if u.User != nil {
+		return errors.New("must not contain user info")
+	}
+	if u.Scheme == "https" || (u.Scheme == "http" && isLoopbackHost(u.Hostname())) {
+		return nil
+	}
+	return errors.New("must use https")
+}
+
+// validateWebOrigins checks the allowed browser origins; an origin is scheme://host[:port] and nothing else
+func validateWebOrigins(origins []string) error {
+	if len(origins) > clientURIsMaxLen {
+		return errors.New("too many webOrigins")
+	}
+	var err error
+	for i, origin := range origins {
+		if slices.Contains(origins[:i], origin) {
+			err = errors.Join(err, fmt.Errorf("duplicate webOrigin: %s", origin))
+			continue
+		}
+		u, perr := url.Parse(origin)
+		switch {
+		case perr != nil || !u.IsAbs() || u.Host == "" || origin != u.Scheme+"://"+u.Host:
+			err = errors.Join(err, fmt.Errorf("invalid webOrigin %q: must be scheme://host[:port]", origin))
+		case u.Scheme != "https" && !(u.Scheme == "http" && isLoopbackHost(u.Hostname())):
+			err = errors.Join(err, fmt.Errorf("invalid webOrigin %q: must use https", origin))
+		}
+	}
+	return err
+}
+
+// matchRedirectURI reports whether the requested redirect URI is registered. URIs are compared as
+// exact strings; the only exception is the port of http loopback IP literals, which native apps
+// pick at runtime (RFC 8252 7.3).
+func matchRedirectURI(registered []string, requested string) bool {
+	if requested == "" {
+		return false
+	}
+	if slices.Contains(registered, requested) {
+		return true
+	}
+
+	req, err := url.Parse(requested)
+	if err != nil || req.Scheme != "http" || !isLoopbackIP(req.Hostname()) || req.User != nil || strings.Contains(requested, "#") {
+		return false
+	}
+	for _, uri := range registered {
+		reg, err := url.Parse(uri)
+		if err != nil || reg.Scheme != "http" || !isLoopbackIP(reg.Hostname()) {
+			continue
+		}
+		if reg.Hostname() == req.Hostname() && reg.EscapedPath() == req.EscapedPath() && reg.RawQuery == req.RawQuery {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopbackHost(host string) bool {
+	return host == "localhost" || isLoopbackIP(host)
+}
+
+func isLoopbackIP(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
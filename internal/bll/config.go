@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/asatraitis/mangrove/internal/utils"
 )
 
@@ -14,9 +15,33 @@ import (
 type ConfigBLL interface {
 	GetAll() (dal.Configs, error)
 	Set(dal.ConfigKey, string) error
+	// SetAdminConfig updates one of the configs admins may change at runtime after validating the value
+	SetAdminConfig(dal.ConfigKey, string) error
 	InitRegistrationCode() (string, error)
 	ValidateRegistrationCode(string) error
+	// ListenForChanges reloads the configs whenever any instance updates one; it blocks until ctx is done
+	// or the connection fails
+	ListenForChanges() error
 }
+
+// adminConfigs are the configs settable through the API, each with its value validation
+var adminConfigs = map[dal.ConfigKey]func(string) error{
+	dal.CONFIG_INVITED_USER_STATUS: func(value string) error {
+		if value != string(models.USER_STATUS_ACTIVE) && value != string(models.USER_STATUS_PENDING) {
+			return errors.New("value must be active or pending")
+		}
+		return nil
+	},
+	dal.CONFIG_SESSION_IDLE_TIMEOUT: func(value string) error {
+		_, err := parseSessionDuration(dal.CONFIG_SESSION_IDLE_TIMEOUT, value)
+		return err
+	},
+	dal.CONFIG_SESSION_MAX_LIFETIME: func(value string) error {
+		_, err := parseSessionDuration(dal.CONFIG_SESSION_MAX_LIFETIME, value)
+		return err
+	},
+}
+
 type configBLL struct {
 	ctx    context.Context
 	hasher utils.Crypto
@@ -67,6 +92,38 @@ func (c *configBLL) Set(conf dal.ConfigKey, value string) error {
 	return nil
 }
 
+func (c *configBLL) SetAdminConfig(conf dal.ConfigKey, value string) error {
+	const funcName string = "SetAdminConfig"
+
+	validate, ok := adminConfigs[conf]
+	if !ok {
+		err := errors.New("config cannot be changed")
+		c.logger.Err(err).Str("func", funcName).Str("key", string(conf)).Msg("config is not admin settable")
+		return err
+	}
+	if err := validate(value); err != nil {
+		c.logger.Err(err).Str("func", funcName).Str(string(conf), value).Msg("invalid config value")
+		return err
+	}
+
+	if err := c.Set(conf, value); err != nil {
+		return err
+	}
+	c.logger.Info().Str("func", funcName).Str(string(conf), value).Msg("config updated")
+	return nil
+}
+
+func (c *configBLL) ListenForChanges() error {
+	const funcName string = "ListenForChanges"
+
+	return c.dal.Config(c.ctx).Listen(func(key dal.ConfigKey) {
+		c.logger.Debug().Str("func", funcName).Str("key", string(key)).Msg("config changed; reloading")
+		if _, err := c.GetAll(); err != nil {
+			c.logger.Err(err).Str("func", funcName).Msg("failed to reload configs")
+		}
+	})
+}
+
 func (c *configBLL) InitRegistrationCode() (string, error) {
 	const funcName string = "InitRegistrationCode"
 	var initCode string
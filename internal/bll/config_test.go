@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/asatraitis/mangrove/configs"
 	"github.com/asatraitis/mangrove/internal/dal"
@@ -45,18 +46,20 @@ func (suite *ConfigBLLTestSuite) SetupSuite() {
 	suite.dal.EXPECT().Config(gomock.Any()).Return(suite.configDal).AnyTimes()
 
 	wauthn, err := webauthn.NewWebAuthN(
+		context.Background(),
 		&wa.Config{
 			RPDisplayName: "Mangrove",
 			RPID:          "localhost",
 			RPOrigins:     []string{"http://localhost:3030", "http://localhost:3000"},
 		},
+		nil,
 		suite.logger)
 	if err != nil {
 		suite.T().Fatal(err)
 	}
 
 	suite.appConfig = config.NewConfig(context.Background(), suite.logger)
-	suite.bll = NewBLL(suite.logger, suite.vars, suite.appConfig, wauthn, suite.dal)
+	suite.bll = NewBLL(suite.logger, suite.vars, suite.appConfig, wauthn, nil, nil, suite.dal)
 }
 func (suite *ConfigBLLTestSuite) SetupTest() {
 	suite.ctx = context.Background()
@@ -223,3 +226,81 @@ func (suite *ConfigBLLTestSuite) TestValidateRegistrationCode_FAIL() {
 	suite.Error(err) // expects len of 6
 	suite.ErrorContains(err, "failed to validate code")
 }
+
+func (suite *ConfigBLLTestSuite) TestListenForChanges_OK() {
+	// setup
+	val := "true"
+	suite.configDal.EXPECT().Listen(gomock.Any()).DoAndReturn(func(onChange func(dal.ConfigKey)) error {
+		onChange(dal.CONFIG_INSTANCE_READY)
+		return nil
+	}).Times(1)
+	suite.configDal.EXPECT().GetAll().Return(dal.Configs{dal.CONFIG_INSTANCE_READY: dal.Config{Key: "instanceReady", Value: &val}}, nil).Times(1)
+
+	// run
+	err := suite.bll.Config(suite.ctx).ListenForChanges()
+
+	// test
+	suite.NoError(err)
+	instanceReady, err := suite.appConfig.GetConfig(dal.CONFIG_INSTANCE_READY)
+	suite.NoError(err)
+	suite.Equal("true", instanceReady)
+}
+
+func (suite *ConfigBLLTestSuite) TestListenForChanges_FAIL() {
+	// setup
+	suite.configDal.EXPECT().Listen(gomock.Any()).Return(errors.New("connection lost")).Times(1)
+
+	// run
+	err := suite.bll.Config(suite.ctx).ListenForChanges()
+
+	// test
+	suite.ErrorContains(err, "connection lost")
+}
+
+func (suite *ConfigBLLTestSuite) TestSetAdminConfig_OK() {
+	// setup
+	pending := "pending"
+	suite.appConfig.SetAll(dal.Configs{dal.CONFIG_INVITED_USER_STATUS: dal.Config{Key: "invitedUserStatus", Value: &pending}})
+	suite.configDal.EXPECT().Set(dal.CONFIG_INVITED_USER_STATUS, "active").Return(nil).Times(1)
+
+	// run
+	err := suite.bll.Config(suite.ctx).SetAdminConfig(dal.CONFIG_INVITED_USER_STATUS, "active")
+
+	// test
+	suite.NoError(err)
+	status, err := suite.appConfig.GetConfig(dal.CONFIG_INVITED_USER_STATUS)
+	suite.NoError(err)
+	suite.Equal("active", status)
+}
+
+func (suite *ConfigBLLTestSuite) TestSetAdminConfig_FAIL() {
+	err := suite.bll.Config(suite.ctx).SetAdminConfig(dal.CONFIG_INSTANCE_READY, "false")
+	suite.ErrorContains(err, "config cannot be changed")
+
+	err = suite.bll.Config(suite.ctx).SetAdminConfig(dal.CONFIG_INVITED_USER_STATUS, "suspended")
+	suite.ErrorContains(err, "value must be active or pending")
+}
+
+func (suite *ConfigBLLTestSuite) TestSetAdminConfig_OK_SessionPolicy() {
+	// setup
+	idle := "24h"
+	suite.appConfig.SetAll(dal.Configs{dal.CONFIG_SESSION_IDLE_TIMEOUT: dal.Config{Key: "sessionIdleTimeout", Value: &idle}})
+	suite.configDal.EXPECT().Set(dal.CONFIG_SESSION_IDLE_TIMEOUT, "30m").Return(nil).Times(1)
+
+	// run
+	err := suite.bll.Config(suite.ctx).SetAdminConfig(dal.CONFIG_SESSION_IDLE_TIMEOUT, "30m")
+
+	// test
+	suite.NoError(err)
+	idleTimeout, maxLifetime := suite.bll.(*bll).BaseBLL.sessionPolicy()
+	suite.Equal(30*time.Minute, idleTimeout)
+	suite.Equal(defaultSessionMaxLifetime, maxLifetime)
+}
+
+func (suite *ConfigBLLTestSuite) TestSetAdminConfig_FAIL_SessionPolicy() {
+	err := suite.bll.Config(suite.ctx).SetAdminConfig(dal.CONFIG_SESSION_IDLE_TIMEOUT, "abc")
+	suite.ErrorContains(err, "sessionIdleTimeout must be a duration")
+
+	err = suite.bll.Config(suite.ctx).SetAdminConfig(dal.CONFIG_SESSION_MAX_LIFETIME, "1s")
+	suite.ErrorContains(err, "sessionMaxLifetime must be between 1h0m0s and 8760h0m0s")
+}
@@ -0,0 +1,154 @@
+package bll
+
+import (
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// Introspect reports whether a token issued to the calling client is active (RFC 7662). Unknown, expired and
+// revoked tokens, tokens of inactive users and tokens issued to other clients are all just inactive, so the
+// response never tells a client anything about tokens it does not hold.
+func (o *oauthBLL) Introspect(req *dto.IntrospectRequest) (*dto.IntrospectResponse, error) {
+	const funcName = "Introspect"
+
+	if req == nil || req.Token == "" {
+		return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "token is required")
+	}
+	client, err := o.authenticateClient(req.ClientID, req.ClientAssertionType, req.ClientAssertion)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := o.findToken(req.Token, req.TokenTypeHint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &dto.IntrospectResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if accessToken != nil {
+		if *accessToken.ClientID != client.ID || !now.Before(accessToken.Expires) || accessToken.User.Status != models.USER_STATUS_ACTIVE {
+			return &dto.IntrospectResponse{}, nil
+		}
+		return &dto.IntrospectResponse{
+			Active:   true,
+			Sub:      accessToken.UserID.String(),
+			ClientID: client.ID.String(),
+			Scope:    accessToken.Scope,
+			Exp:      accessToken.Expires.Unix(),
+		}, nil
+	}
+
+	if refreshToken.ClientID != client.ID || refreshToken.UsedAt != nil || refreshToken.RevokedAt != nil || !now.Before(refreshToken.Expires) {
+		return &dto.IntrospectResponse{}, nil
+	}
+	user, err := o.dal.User(o.ctx).GetByID(refreshToken.UserID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("userID", refreshToken.UserID.String()).Msg("failed to get user")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to introspect token")
+	}
+	if user.Status != models.USER_STATUS_ACTIVE {
+		return &dto.IntrospectResponse{}, nil
+	}
+	return &dto.IntrospectResponse{
+		Active:   true,
+		Sub:      refreshToken.UserID.String(),
+		ClientID: client.ID.String(),
+		Scope:    refreshToken.Scope,
+		Exp:      refreshToken.Expires.Unix(),
+	}, nil
+}
+
+// Revoke revokes a token issued to the calling client (RFC 7009). Access tokens are deleted; revoking a refresh
+// token revokes its whole family, while access tokens already issued from it stay valid until they expire.
+// Unknown tokens are not an error since the client's goal, the token being unusable, is already met.
+func (o *oauthBLL) Revoke(req *dto.RevokeRequest) error {
+	const funcName = "Revoke"
+
+	if req == nil || req.Token == "" {
+		return newOAuthError(OAUTH_ERR_INVALID_REQUEST, "token is required")
+	}
+	client, err := o.authenticateClient(req.ClientID, req.ClientAssertionType, req.ClientAssertion)
+	if err != nil {
+		return err
+	}
+
+	accessToken, refreshToken, err := o.findToken(req.Token, req.TokenTypeHint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if accessToken != nil {
+		if *accessToken.ClientID != client.ID {
+			o.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Msg("access token was issued to another client")
+			return newOAuthError(OAUTH_ERR_UNAUTHORIZED_CLIENT, "token was issued to another client")
+		}
+		err = o.dal.UserTokens(o.ctx).Delete(nil, accessToken.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			o.logger.Err(err).Str("func", funcName).Str("clientID", client.ID.String()).Msg("failed to delete access token")
+			return newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to revoke token")
+		}
+		return nil
+	}
+
+	if refreshToken.ClientID != client.ID {
+		o.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Msg("refresh token was issued to another client")
+		return newOAuthError(OAUTH_ERR_UNAUTHORIZED_CLIENT, "token was issued to another client")
+	}
+	_, err = o.dal.RefreshTokens(o.ctx).RevokeFamily(nil, refreshToken.FamilyID, time.Now())
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("familyID", refreshToken.FamilyID.String()).Msg("failed to revoke refresh token family")
+		return newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to revoke token")
+	}
+	return nil
+}
+
+// findToken looks the presented token up as an access token and as a refresh token, in the order suggested
+// by the hint. Exactly one of the returned tokens is set; pgx.ErrNoRows when neither matches. Browser
+// sessions live in the same table as access tokens but are never reported.
+func (o *oauthBLL) findToken(token, hint string) (*models.UserToken, *models.RefreshToken, error) {
+	const funcName = "findToken"
+
+	lookups := []string{OAUTH_TOKEN_TYPE_HINT_ACCESS_TOKEN, OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN}
+	switch hint {
+	case "", OAUTH_TOKEN_TYPE_HINT_ACCESS_TOKEN:
+	case OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN:
+		lookups = []string{OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN, OAUTH_TOKEN_TYPE_HINT_ACCESS_TOKEN}
+	default:
+		return nil, nil, newOAuthError(OAUTH_ERR_UNSUPPORTED_TOKEN_TYPE, "unsupported token_type_hint")
+	}
+
+	hash := utils.HashOpaqueToken(token)
+	for _, lookup := range lookups {
+		if lookup == OAUTH_TOKEN_TYPE_HINT_ACCESS_TOKEN {
+			accessToken, err := o.dal.UserTokens(o.ctx).GetByHashWithUser(hash)
+			if err == nil && accessToken.ClientID != nil {
+				return accessToken, nil, nil
+			}
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				o.logger.Err(err).Str("func", funcName).Msg("failed to get access token")
+				return nil, nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to look up token")
+			}
+			continue
+		}
+		refreshToken, err := o.dal.RefreshTokens(o.ctx).GetByHash(hash)
+		if err == nil {
+			return nil, refreshToken, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			o.logger.Err(err).Str("func", funcName).Msg("failed to get refresh token")
+			return nil, nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to look up token")
+		}
+	}
+	return nil, nil, pgx.ErrNoRows
+}
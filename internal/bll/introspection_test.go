@@ -0,0 +1,186 @@
+package bll
+
+import (
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+// introspectRequest builds a request authenticated with an assertion addressed to the endpoint and expects it to be verified
+func (suite *OAuthBllTestSuite) introspectRequest(token, hint, endpoint string) *dto.IntrospectRequest {
+	suite.expectClientAuthenticated()
+	return &dto.IntrospectRequest{
+		Token:               token,
+		TokenTypeHint:       hint,
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion: suite.clientAssertion(suite.clientKey, func(claims *jwt.RegisteredClaims) {
+			claims.Audience = jwt.ClaimStrings{"http://localhost:3030/oauth2/" + endpoint}
+		}),
+	}
+}
+
+func (suite *OAuthBllTestSuite) accessToken() *models.UserToken {
+	userID := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	return &models.UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ClientID:  &suite.client.ID,
+		Scope:     "openid profile",
+		Expires:   time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+		User:      &models.User{ID: userID, Status: models.USER_STATUS_ACTIVE},
+	}
+}
+
+func (suite *OAuthBllTestSuite) TestIntrospect_OK_AccessToken() {
+	token := suite.accessToken()
+	req := suite.introspectRequest("test-access-token", "", "introspect")
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-access-token")).Times(1).Return(token, nil)
+
+	res, err := suite.bll.OAuth(suite.ctx).Introspect(req)
+	suite.NoError(err)
+	suite.Equal(&dto.IntrospectResponse{
+		Active:   true,
+		Sub:      token.UserID.String(),
+		ClientID: suite.client.ID.String(),
+		Scope:    "openid profile",
+		Exp:      token.Expires.Unix(),
+	}, res)
+}
+
+func (suite *OAuthBllTestSuite) TestIntrospect_OK_RefreshTokenHint() {
+	token := suite.refreshToken("test-refresh-token")
+	req := suite.introspectRequest("test-refresh-token", OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN, "introspect")
+	// the hint means the refresh tokens are searched first
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(token.TokenHash).Times(1).Return(token, nil)
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(token.UserID).Times(1).Return(&models.User{ID: token.UserID, Status: models.USER_STATUS_ACTIVE}, nil)
+
+	res, err := suite.bll.OAuth(suite.ctx).Introspect(req)
+	suite.NoError(err)
+	suite.True(res.Active)
+	suite.Equal(token.UserID.String(), res.Sub)
+	suite.Equal(token.Expires.Unix(), res.Exp)
+}
+
+func (suite *OAuthBllTestSuite) TestIntrospect_OK_Inactive() {
+	expired := suite.accessToken()
+	expired.Expires = time.Now().Add(-time.Minute)
+	otherClient := suite.accessToken()
+	otherClientID := uuid.New()
+	otherClient.ClientID = &otherClientID
+	session := suite.accessToken()
+	session.ClientID = nil
+	used := suite.refreshToken("test-used")
+	usedAt := time.Now()
+	used.UsedAt = &usedAt
+
+	tests := []struct {
+		name   string
+		token  string
+		expect func(hash []byte)
+	}{
+		{"unknown", "test-unknown", func(hash []byte) {
+			suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+			suite.userTokenDal.EXPECT().GetByHashWithUser(hash).Times(1).Return(nil, pgx.ErrNoRows)
+			suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+			suite.refreshDal.EXPECT().GetByHash(hash).Times(1).Return(nil, pgx.ErrNoRows)
+		}},
+		{"expired", "test-expired", func(hash []byte) {
+			suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+			suite.userTokenDal.EXPECT().GetByHashWithUser(hash).Times(1).Return(expired, nil)
+		}},
+		{"other client", "test-other-client", func(hash []byte) {
+			suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+			suite.userTokenDal.EXPECT().GetByHashWithUser(hash).Times(1).Return(otherClient, nil)
+		}},
+		{"browser session", "test-session", func(hash []byte) {
+			suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+			suite.userTokenDal.EXPECT().GetByHashWithUser(hash).Times(1).Return(session, nil)
+			suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+			suite.refreshDal.EXPECT().GetByHash(hash).Times(1).Return(nil, pgx.ErrNoRows)
+		}},
+		{"used refresh token", "test-used", func(hash []byte) {
+			suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+			suite.userTokenDal.EXPECT().GetByHashWithUser(hash).Times(1).Return(nil, pgx.ErrNoRows)
+			suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+			suite.refreshDal.EXPECT().GetByHash(hash).Times(1).Return(used, nil)
+		}},
+	}
+	for _, test := range tests {
+		req := suite.introspectRequest(test.token, "", "introspect")
+		test.expect(utils.HashOpaqueToken(test.token))
+
+		res, err := suite.bll.OAuth(suite.ctx).Introspect(req)
+		suite.NoError(err, test.name)
+		suite.Equal(&dto.IntrospectResponse{}, res, test.name)
+	}
+}
+
+func (suite *OAuthBllTestSuite) TestIntrospect_FAIL_ClientAuthentication() {
+	_, err := suite.bll.OAuth(suite.ctx).Introspect(&dto.IntrospectRequest{Token: "test-token", ClientID: suite.client.ID.String()})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_CLIENT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestIntrospect_FAIL_TokenTypeHint() {
+	req := suite.introspectRequest("test-token", "id_token", "introspect")
+
+	_, err := suite.bll.OAuth(suite.ctx).Introspect(req)
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_UNSUPPORTED_TOKEN_TYPE, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestRevoke_OK_AccessToken() {
+	token := suite.accessToken()
+	req := suite.introspectRequest("test-access-token", "", "revoke")
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(2).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-access-token")).Times(1).Return(token, nil)
+	suite.userTokenDal.EXPECT().Delete(nil, token.ID).Times(1).Return(nil)
+
+	suite.NoError(suite.bll.OAuth(suite.ctx).Revoke((*dto.RevokeRequest)(req)))
+}
+
+func (suite *OAuthBllTestSuite) TestRevoke_OK_RefreshTokenFamily() {
+	token := suite.refreshToken("test-refresh-token")
+	req := suite.introspectRequest("test-refresh-token", OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN, "revoke")
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(2).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(token.TokenHash).Times(1).Return(token, nil)
+	suite.refreshDal.EXPECT().RevokeFamily(nil, token.FamilyID, gomock.Any()).Times(1).Return(int64(2), nil)
+
+	suite.NoError(suite.bll.OAuth(suite.ctx).Revoke((*dto.RevokeRequest)(req)))
+}
+
+func (suite *OAuthBllTestSuite) TestRevoke_OK_UnknownToken() {
+	req := suite.introspectRequest("test-unknown", "", "revoke")
+	hash := utils.HashOpaqueToken("test-unknown")
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(hash).Times(1).Return(nil, pgx.ErrNoRows)
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(hash).Times(1).Return(nil, pgx.ErrNoRows)
+
+	suite.NoError(suite.bll.OAuth(suite.ctx).Revoke((*dto.RevokeRequest)(req)))
+}
+
+func (suite *OAuthBllTestSuite) TestRevoke_FAIL_OtherClient() {
+	token := suite.refreshToken("test-refresh-token")
+	token.ClientID = uuid.New()
+	req := suite.introspectRequest("test-refresh-token", OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN, "revoke")
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(token.TokenHash).Times(1).Return(token, nil)
+
+	err := suite.bll.OAuth(suite.ctx).Revoke((*dto.RevokeRequest)(req))
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_UNAUTHORIZED_CLIENT, oerr.Code)
+}
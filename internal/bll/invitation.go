@@ -0,0 +1,358 @@
+package bll
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/typeconv"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+)
+
+const (
+	invitationDefaultTTL = time.Hour * 72
+	invitationMaxTTL     = time.Hour * 24 * 30
+	// 18 random bytes make a 24 character code
+	invitationCodeBytes = 18
+)
+
+const (
+	usernameMinLen    = 3
+	usernameMaxLen    = 64
+	displayNameMaxLen = 128
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+type InvitationBLL interface {
+	Create(dto.CreateInvitationRequest) (*dto.CreateInvitationResponse, error)
+	GetAll() (dto.InvitationsResponse, error)
+	Revoke(uuid.UUID) error
+	InitRegistration(dto.InitInvitationRegistrationRequest) (*protocol.CredentialCreation, error)
+	FinishRegistration(dto.FinishInvitationRegistrationRequest) (*models.User, error)
+}
+type invitationBLL struct {
+	ctx    context.Context
+	hasher utils.Crypto
+	*BaseBLL
+}
+
+func NewInvitationBLL(ctx context.Context, baseBLL *BaseBLL) InvitationBLL {
+	iBll := &invitationBLL{
+		ctx:     ctx,
+		hasher:  utils.NewStandardCrypto([]byte(baseBLL.vars.MangroveSalt)),
+		BaseBLL: baseBLL,
+	}
+	iBll.logger = baseBLL.logger.With().Str("subcomponent", "InvitationBLL").Logger()
+	return iBll
+}
+
+// Create issues a single-use invitation. The code is returned once; only its hash is stored.
+func (i *invitationBLL) Create(req dto.CreateInvitationRequest) (*dto.CreateInvitationResponse, error) {
+	const funcName = "Create"
+
+	userID, err := utils.GetUserIdFromCtx(i.ctx)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to retrieve userID from context")
+		return nil, err
+	}
+
+	role := models.UserRole(req.Role)
+	if role == "" {
+		role = models.USER_ROLE_USER
+	}
+	// superadmins are promoted from existing users, never invited
+	if role == models.USER_ROLE_SUPERUSER {
+		err = errors.New("wrong role")
+		i.logger.Err(err).Str("func", funcName).Str("role", string(role)).Msg("failed to validate CreateInvitationRequest")
+		return nil, err
+	}
+
+	var email *string
+	if strings.TrimSpace(req.Email) != "" {
+		address, err := parseEmail(req.Email)
+		if err != nil {
+			i.logger.Err(err).Str("func", funcName).Msg("failed to validate CreateInvitationRequest")
+			return nil, err
+		}
+		email = &address
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(invitationDefaultTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) {
+		err = errors.New("expiresAt must be in the future")
+		i.logger.Err(err).Str("func", funcName).Msg("failed to validate CreateInvitationRequest")
+		return nil, err
+	}
+	if expiresAt.After(now.Add(invitationMaxTTL)) {
+		expiresAt = now.Add(invitationMaxTTL)
+	}
+	if err = NewRoleBLL(i.ctx, i.BaseBLL).CheckGrantable(role); err != nil {
+		i.logger.Err(err).Str("func", funcName).Str("role", string(role)).Msg("not allowed to invite with the role")
+		return nil, err
+	}
+
+	code, err := utils.GenerateRandomString(invitationCodeBytes)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to generate invitation code")
+		return nil, errors.New("failed to create invitation")
+	}
+	ID, err := uuid.NewV7()
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to create UUID")
+		return nil, errors.New("failed to create invitation")
+	}
+
+	invitation := &models.UserInvitation{
+		ID:        ID,
+		CodeHash:  i.hasher.GenerateBase64String([]byte(code)),
+		Email:     email,
+		Role:      role,
+		CreatedBy: userID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	err = i.dal.UserInvitations(i.ctx).Create(nil, invitation)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to create invitation in db")
+		return nil, errors.New("failed to create invitation")
+	}
+
+	res, err := typeconv.ConvertUserInvitationToInvitation(invitation)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to typeconv invitation")
+		return nil, err
+	}
+	return &dto.CreateInvitationResponse{Invitation: *res, Code: code}, nil
+}
+
+func (i *invitationBLL) GetAll() (dto.InvitationsResponse, error) {
+	const funcName = "GetAll"
+
+	invitations, err := i.dal.UserInvitations(i.ctx).GetAll()
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to get invitations from db")
+		return nil, errors.New("failed to get invitations")
+	}
+	if invitations == nil {
+		return dto.InvitationsResponse{}, nil
+	}
+	return typeconv.ConvertUserInvitationsToInvitationsResponse(invitations)
+}
+
+// Revoke deletes an invitation that has not been used yet
+func (i *invitationBLL) Revoke(invitationID uuid.UUID) error {
+	const funcName = "Revoke"
+
+	deleted, err := i.dal.UserInvitations(i.ctx).Delete(invitationID)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Str("invitationID", invitationID.String()).Msg("failed to delete invitation in db")
+		return errors.New("failed to revoke invitation")
+	}
+	if !deleted {
+		i.logger.Error().Str("func", funcName).Str("invitationID", invitationID.String()).Msg("unused invitation not found")
+		return errors.New("invitation not found")
+	}
+	return nil
+}
+
+// InitRegistration checks the invitation and the chosen profile and starts the passkey registration
+func (i *invitationBLL) InitRegistration(req dto.InitInvitationRegistrationRequest) (*protocol.CredentialCreation, error) {
+	const funcName = "InitRegistration"
+
+	invitation, err := i.getUsableInvitation(req.InvitationCode)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to validate invitation")
+		return nil, err
+	}
+	if _, err = validateInviteeProfile(invitation, req); err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to validate registration profile")
+		return nil, err
+	}
+
+	username := strings.TrimSpace(req.Username)
+	taken, err := i.dal.User(i.ctx).ExistsByUsername(username)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to check username")
+		return nil, errors.New("failed to start registration")
+	}
+	if taken {
+		return nil, errors.New("username is taken")
+	}
+
+	creds, err := i.webauthn.BeginRegistration(&models.User{
+		Username:    username,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+	})
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to generate user registration credentials")
+		return nil, errors.New("failed to start registration")
+	}
+	return creds, nil
+}
+
+// FinishRegistration creates the invitee with their first passkey and consumes the invitation in one
+// transaction. The user is pending or active depending on the invitedUserStatus config.
+func (i *invitationBLL) FinishRegistration(req dto.FinishInvitationRegistrationRequest) (*models.User, error) {
+	const funcName = "FinishRegistration"
+
+	invitation, err := i.getUsableInvitation(req.InvitationCode)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to validate invitation")
+		return nil, err
+	}
+	email, err := validateInviteeProfile(invitation, req.InitInvitationRegistrationRequest)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to validate registration profile")
+		return nil, err
+	}
+
+	cred, err := i.webauthn.FinishRegistration(req.UserID, &req.Credential)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to validate user credential")
+		return nil, errors.New("failed to validate registration request")
+	}
+	bUserID, err := base64.StdEncoding.DecodeString(req.UserID)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to decode userID")
+		return nil, errors.New("failed to register user")
+	}
+	userID, err := uuid.Parse(string(bUserID))
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to parse userID UUID")
+		return nil, errors.New("failed to register user")
+	}
+
+	user := &models.User{
+		ID:          userID,
+		Username:    strings.TrimSpace(req.Username),
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Email:       email,
+		Status:      i.invitedUserStatus(),
+		Role:        invitation.Role,
+	}
+	ucred, err := typeconv.ConvertWebauthnCredentialToUserCredential(cred, userID)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to typeconv user credential")
+		return nil, errors.New("failed to register user")
+	}
+	ucred.CreatedAt = time.Now()
+
+	tx, err := i.dal.BeginTx(i.ctx)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to start DB transaction")
+		return nil, errors.New("failed to register user")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(i.ctx)
+		}
+	}()
+
+	err = i.dal.User(i.ctx).Create(tx, user)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to create user in db")
+		return nil, errors.New("failed to register user")
+	}
+	err = i.dal.UserCredentials(i.ctx).Create(tx, ucred)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to create user credential in db")
+		return nil, errors.New("failed to register user")
+	}
+	used, err := i.dal.UserInvitations(i.ctx).MarkUsed(tx, invitation.ID, userID, time.Now())
+	if err == nil && !used {
+		// used or expired while the passkey was being created
+		err = errors.New("invalid invitation code")
+	}
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Str("invitationID", invitation.ID.String()).Msg("failed to consume invitation")
+		return nil, errors.New("invalid invitation code")
+	}
+
+	err = tx.Commit(i.ctx)
+	if err != nil {
+		i.logger.Err(err).Str("func", funcName).Msg("failed to commit DB transaction")
+		return nil, errors.New("failed to register user")
+	}
+	i.logger.Info().Str("func", funcName).Str("userID", userID.String()).Str("invitationID", invitation.ID.String()).Str("status", string(user.Status)).Msg("invited user registered")
+	return user, nil
+}
+
+// getUsableInvitation looks the invitation up by the hash of its code; unknown, used and expired
+// invitations all fail the same way
+func (i *invitationBLL) getUsableInvitation(code string) (*models.UserInvitation, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("missing invitation code")
+	}
+	invitation, err := i.dal.UserInvitations(i.ctx).GetByCodeHash(i.hasher.GenerateBase64String([]byte(code)))
+	if err != nil || invitation == nil || invitation.UsedAt != nil || !time.Now().Before(invitation.ExpiresAt) {
+		return nil, errors.New("invalid invitation code")
+	}
+	return invitation, nil
+}
+
+// invitedUserStatus reads the registration policy; anything but an explicit active keeps new users pending
+func (i *invitationBLL) invitedUserStatus() models.UserStatus {
+	status, err := i.appConfig.GetConfig(dal.CONFIG_INVITED_USER_STATUS)
+	if err == nil && models.UserStatus(status) == models.USER_STATUS_ACTIVE {
+		return models.USER_STATUS_ACTIVE
+	}
+	return models.USER_STATUS_PENDING
+}
+
+// validateInviteeProfile checks the profile chosen by the invitee and returns the email to store;
+// an invitation issued for an email address only accepts that address
+func validateInviteeProfile(invitation *models.UserInvitation, req dto.InitInvitationRegistrationRequest) (*string, error) {
+	var err error
+	username := strings.TrimSpace(req.Username)
+	if len(username) < usernameMinLen || len(username) > usernameMaxLen || !usernamePattern.MatchString(username) {
+		err = errors.Join(err, errors.New("username must be 3-64 letters, digits, dots, dashes or underscores"))
+	}
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" || len(displayName) > displayNameMaxLen {
+		err = errors.Join(err, errors.New("missing or too long displayName"))
+	}
+
+	var email *string
+	if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
+		address, perr := parseEmail(*req.Email)
+		if perr != nil {
+			err = errors.Join(err, perr)
+		} else {
+			email = &address
+		}
+	}
+	if invitation.Email != nil {
+		if email == nil {
+			email = invitation.Email
+		} else if !strings.EqualFold(*email, *invitation.Email) {
+			err = errors.Join(err, errors.New("email does not match the invitation"))
+		}
+	}
+
+	return email, err
+}
+
+// parseEmail accepts a bare address only, without a display name
+func parseEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", errors.New("invalid email")
+	}
+	return address.Address, nil
+}
@@ -0,0 +1,269 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/handler/types"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/asatraitis/mangrove/internal/service/webauthn"
+	"github.com/asatraitis/mangrove/internal/utils"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type InvitationBllTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal           *mocks.MockDAL
+	userDal       *mocks.MockUserDAL
+	invitationDal *mocks.MockUserInvitationsDAL
+	appConfig     config.Configs
+	hasher        utils.Crypto
+	bll           BLL
+
+	adminID uuid.UUID
+}
+
+func TestInvitationBllTestSuite(t *testing.T) {
+	suite.Run(t, new(InvitationBllTestSuite))
+}
+
+func (suite *InvitationBllTestSuite) SetupSuite() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.userDal = mocks.NewMockUserDAL(suite.Ctrl)
+	suite.invitationDal = mocks.NewMockUserInvitationsDAL(suite.Ctrl)
+
+	logger := zerolog.Nop()
+	vars := configs.NewConf(logger).GetEnvironmentVars()
+	vars.MangroveSalt = "testsalt"
+	wauthn, err := webauthn.NewWebAuthN(context.Background(), &wa.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, logger)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.appConfig = config.NewConfig(context.Background(), logger)
+	suite.hasher = utils.NewStandardCrypto([]byte(vars.MangroveSalt))
+	suite.bll = NewBLL(logger, vars, suite.appConfig, wauthn, nil, nil, suite.dal)
+	suite.adminID = uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+}
+func (suite *InvitationBllTestSuite) SetupTest() {
+	suite.ctx = context.WithValue(context.Background(), types.REQ_CTX_KEY_USER_ID, suite.adminID.String())
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_ADMIN)
+	suite.appConfig.SetAll(dal.Configs{})
+}
+func (suite *InvitationBllTestSuite) TearDownTest() {}
+
+// expectInvitation serves the invitation for the given code
+func (suite *InvitationBllTestSuite) expectInvitation(code string, invitation *models.UserInvitation) {
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).Times(1).Return(suite.invitationDal)
+	suite.invitationDal.EXPECT().GetByCodeHash(suite.hasher.GenerateBase64String([]byte(code))).Times(1).Return(invitation, nil)
+}
+
+func (suite *InvitationBllTestSuite) newInvitation(email *string) *models.UserInvitation {
+	return &models.UserInvitation{
+		ID:        uuid.New(),
+		Email:     email,
+		Role:      models.USER_ROLE_USER,
+		CreatedBy: suite.adminID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func (suite *InvitationBllTestSuite) TestCreate_OK() {
+	var stored *models.UserInvitation
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).Times(1).Return(suite.invitationDal)
+	suite.invitationDal.EXPECT().Create(nil, gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, invitation *models.UserInvitation) error {
+		stored = invitation
+		return nil
+	})
+
+	res, err := suite.bll.Invitation(suite.ctx).Create(dto.CreateInvitationRequest{
+		Email: " invitee@email.com ",
+		Role:  dto.USER_ROLE_ADMIN,
+	})
+	suite.NoError(err)
+	suite.Len(res.Code, 24)
+	suite.Equal(dto.UserRole("admin"), res.Role)
+	suite.Equal("invitee@email.com", *res.Email)
+	suite.Equal(suite.adminID.String(), res.CreatedBy)
+	suite.WithinDuration(time.Now().Add(invitationDefaultTTL), res.ExpiresAt, time.Minute)
+
+	// only the hash of the code is stored
+	suite.NotContains(stored.CodeHash, res.Code)
+	suite.Equal(suite.hasher.GenerateBase64String([]byte(res.Code)), stored.CodeHash)
+}
+
+func (suite *InvitationBllTestSuite) TestCreate_OK_CapsExpiry() {
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).Times(1).Return(suite.invitationDal)
+	suite.invitationDal.EXPECT().Create(nil, gomock.Any()).Times(1).Return(nil)
+
+	expiresAt := time.Now().Add(invitationMaxTTL * 2)
+	res, err := suite.bll.Invitation(suite.ctx).Create(dto.CreateInvitationRequest{ExpiresAt: &expiresAt})
+	suite.NoError(err)
+	suite.Equal(dto.UserRole("user"), res.Role)
+	suite.Nil(res.Email)
+	suite.WithinDuration(time.Now().Add(invitationMaxTTL), res.ExpiresAt, time.Minute)
+}
+
+func (suite *InvitationBllTestSuite) TestCreate_FAIL() {
+	_, err := suite.bll.Invitation(suite.ctx).Create(dto.CreateInvitationRequest{Role: dto.USER_ROLE_SUPERUSER})
+	suite.ErrorContains(err, "wrong role")
+
+	_, err = suite.bll.Invitation(suite.ctx).Create(dto.CreateInvitationRequest{Email: "Invitee <invitee@email.com>"})
+	suite.ErrorContains(err, "invalid email")
+
+	expired := time.Now().Add(-time.Minute)
+	_, err = suite.bll.Invitation(suite.ctx).Create(dto.CreateInvitationRequest{ExpiresAt: &expired})
+	suite.ErrorContains(err, "expiresAt")
+
+	_, err = suite.bll.Invitation(context.Background()).Create(dto.CreateInvitationRequest{})
+	suite.Error(err)
+
+	// the inviter has to hold every permission of the role
+	ctx := context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_USER)
+	_, err = suite.bll.Invitation(ctx).Create(dto.CreateInvitationRequest{Role: dto.USER_ROLE_ADMIN})
+	suite.ErrorContains(err, "cannot grant permissions you do not hold: clients:read, clients:write, users:manage")
+}
+
+func (suite *InvitationBllTestSuite) TestRevoke_FAIL_NotFound() {
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).Times(1).Return(suite.invitationDal)
+	suite.invitationDal.EXPECT().Delete(gomock.Any()).Times(1).Return(false, nil)
+
+	err := suite.bll.Invitation(suite.ctx).Revoke(uuid.New())
+	suite.ErrorContains(err, "not found")
+}
+
+func (suite *InvitationBllTestSuite) TestInitRegistration_OK() {
+	email := "invitee@email.com"
+	suite.expectInvitation("test-code", suite.newInvitation(&email))
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().ExistsByUsername("invitee").Times(1).Return(false, nil)
+
+	creds, err := suite.bll.Invitation(suite.ctx).InitRegistration(dto.InitInvitationRegistrationRequest{
+		InvitationCode: "test-code",
+		Username:       "invitee",
+		DisplayName:    "Invitee",
+	})
+	suite.NoError(err)
+	suite.Equal("invitee", creds.Response.User.Name)
+	suite.Equal("Invitee", creds.Response.User.DisplayName)
+}
+
+func (suite *InvitationBllTestSuite) TestInitRegistration_FAIL_UsernameTaken() {
+	suite.expectInvitation("test-code", suite.newInvitation(nil))
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().ExistsByUsername("invitee").Times(1).Return(true, nil)
+
+	_, err := suite.bll.Invitation(suite.ctx).InitRegistration(dto.InitInvitationRegistrationRequest{
+		InvitationCode: "test-code",
+		Username:       "invitee",
+		DisplayName:    "Invitee",
+	})
+	suite.ErrorContains(err, "username is taken")
+}
+
+func (suite *InvitationBllTestSuite) TestInitRegistration_FAIL_Invitation() {
+	req := dto.InitInvitationRegistrationRequest{InvitationCode: "test-code", Username: "invitee", DisplayName: "Invitee"}
+
+	used := suite.newInvitation(nil)
+	usedAt := time.Now()
+	used.UsedAt = &usedAt
+	suite.expectInvitation("test-code", used)
+	_, err := suite.bll.Invitation(suite.ctx).InitRegistration(req)
+	suite.ErrorContains(err, "invalid invitation code")
+
+	expired := suite.newInvitation(nil)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	suite.expectInvitation("test-code", expired)
+	_, err = suite.bll.Invitation(suite.ctx).InitRegistration(req)
+	suite.ErrorContains(err, "invalid invitation code")
+
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).Times(1).Return(suite.invitationDal)
+	suite.invitationDal.EXPECT().GetByCodeHash(gomock.Any()).Times(1).Return(nil, pgx.ErrNoRows)
+	_, err = suite.bll.Invitation(suite.ctx).InitRegistration(req)
+	suite.ErrorContains(err, "invalid invitation code")
+
+	req.InvitationCode = ""
+	_, err = suite.bll.Invitation(suite.ctx).InitRegistration(req)
+	suite.ErrorContains(err, "missing invitation code")
+}
+
+func (suite *InvitationBllTestSuite) TestFinishRegistration_FAIL_Credential() {
+	suite.expectInvitation("test-code", suite.newInvitation(nil))
+
+	_, err := suite.bll.Invitation(suite.ctx).FinishRegistration(dto.FinishInvitationRegistrationRequest{
+		InitInvitationRegistrationRequest: dto.InitInvitationRegistrationRequest{
+			InvitationCode: "test-code",
+			Username:       "invitee",
+			DisplayName:    "Invitee",
+		},
+		UserID: "bm90LWEtc2Vzc2lvbg==",
+	})
+	suite.ErrorContains(err, "failed to validate registration request")
+}
+
+func (suite *InvitationBllTestSuite) TestValidateInviteeProfile() {
+	pinned := "invitee@email.com"
+	other := "other@email.com"
+	upper := "INVITEE@email.com"
+
+	email, err := validateInviteeProfile(suite.newInvitation(&pinned), dto.InitInvitationRegistrationRequest{Username: "invitee", DisplayName: "Invitee"})
+	suite.NoError(err)
+	suite.Equal(&pinned, email)
+
+	email, err = validateInviteeProfile(suite.newInvitation(&pinned), dto.InitInvitationRegistrationRequest{Username: "invitee", DisplayName: "Invitee", Email: &upper})
+	suite.NoError(err)
+	suite.Equal(upper, *email)
+
+	email, err = validateInviteeProfile(suite.newInvitation(nil), dto.InitInvitationRegistrationRequest{Username: "invitee", DisplayName: "Invitee"})
+	suite.NoError(err)
+	suite.Nil(email)
+
+	_, err = validateInviteeProfile(suite.newInvitation(&pinned), dto.InitInvitationRegistrationRequest{Username: "in", DisplayName: " ", Email: &other})
+	suite.ErrorContains(err, "username")
+	suite.ErrorContains(err, "displayName")
+	suite.ErrorContains(err, "email does not match")
+
+	_, err = validateInviteeProfile(suite.newInvitation(nil), dto.InitInvitationRegistrationRequest{Username: "in vitee", DisplayName: "Invitee"})
+	suite.ErrorContains(err, "username")
+}
+
+func (suite *InvitationBllTestSuite) TestInvitedUserStatus() {
+	iBll := NewInvitationBLL(suite.ctx, suite.bll.(*bll).BaseBLL).(*invitationBLL)
+	suite.Equal(models.USER_STATUS_PENDING, iBll.invitedUserStatus())
+
+	active := string(models.USER_STATUS_ACTIVE)
+	suite.appConfig.SetAll(dal.Configs{dal.CONFIG_INVITED_USER_STATUS: {Key: string(dal.CONFIG_INVITED_USER_STATUS), Value: &active}})
+	suite.Equal(models.USER_STATUS_ACTIVE, iBll.invitedUserStatus())
+
+	suite.Require().NoError(suite.appConfig.UpdateConfig(dal.CONFIG_INVITED_USER_STATUS, "bogus"))
+	suite.Equal(models.USER_STATUS_PENDING, iBll.invitedUserStatus())
+}
+
+func (suite *InvitationBllTestSuite) TestGetAll_FAIL() {
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).Times(1).Return(suite.invitationDal)
+	suite.invitationDal.EXPECT().GetAll().Times(1).Return(nil, errors.New("test error"))
+
+	_, err := suite.bll.Invitation(suite.ctx).GetAll()
+	suite.Error(err)
+}
@@ -69,6 +69,90 @@ func (mr *MockBLLMockRecorder) Config(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockBLL)(nil).Config), arg0)
 }
 
+// Invitation mocks base method.
+func (m *MockBLL) Invitation(arg0 context.Context) bll.InvitationBLL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invitation", arg0)
+	ret0, _ := ret[0].(bll.InvitationBLL)
+	return ret0
+}
+
+// Invitation indicates an expected call of Invitation.
+func (mr *MockBLLMockRecorder) Invitation(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invitation", reflect.TypeOf((*MockBLL)(nil).Invitation), arg0)
+}
+
+// OAuth mocks base method.
+func (m *MockBLL) OAuth(arg0 context.Context) bll.OAuthBLL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuth", arg0)
+	ret0, _ := ret[0].(bll.OAuthBLL)
+	return ret0
+}
+
+// OAuth indicates an expected call of OAuth.
+func (mr *MockBLLMockRecorder) OAuth(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuth", reflect.TypeOf((*MockBLL)(nil).OAuth), arg0)
+}
+
+// OIDC mocks base method.
+func (m *MockBLL) OIDC(arg0 context.Context) bll.OIDCBLL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDC", arg0)
+	ret0, _ := ret[0].(bll.OIDCBLL)
+	return ret0
+}
+
+// OIDC indicates an expected call of OIDC.
+func (mr *MockBLLMockRecorder) OIDC(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDC", reflect.TypeOf((*MockBLL)(nil).OIDC), arg0)
+}
+
+// Passkey mocks base method.
+func (m *MockBLL) Passkey(arg0 context.Context) bll.PasskeyBLL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Passkey", arg0)
+	ret0, _ := ret[0].(bll.PasskeyBLL)
+	return ret0
+}
+
+// Passkey indicates an expected call of Passkey.
+func (mr *MockBLLMockRecorder) Passkey(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Passkey", reflect.TypeOf((*MockBLL)(nil).Passkey), arg0)
+}
+
+// Role mocks base method.
+func (m *MockBLL) Role(arg0 context.Context) bll.RoleBLL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Role", arg0)
+	ret0, _ := ret[0].(bll.RoleBLL)
+	return ret0
+}
+
+// Role indicates an expected call of Role.
+func (mr *MockBLLMockRecorder) Role(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Role", reflect.TypeOf((*MockBLL)(nil).Role), arg0)
+}
+
+// Session mocks base method.
+func (m *MockBLL) Session(arg0 context.Context) bll.SessionBLL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Session", arg0)
+	ret0, _ := ret[0].(bll.SessionBLL)
+	return ret0
+}
+
+// Session indicates an expected call of Session.
+func (mr *MockBLLMockRecorder) Session(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Session", reflect.TypeOf((*MockBLL)(nil).Session), arg0)
+}
+
 // User mocks base method.
 func (m *MockBLL) User(arg0 context.Context) bll.UserBLL {
 	m.ctrl.T.Helper()
@@ -70,6 +70,20 @@ func (mr *MockConfigBLLMockRecorder) InitRegistrationCode() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitRegistrationCode", reflect.TypeOf((*MockConfigBLL)(nil).InitRegistrationCode))
 }
 
+// ListenForChanges mocks base method.
+func (m *MockConfigBLL) ListenForChanges() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListenForChanges")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListenForChanges indicates an expected call of ListenForChanges.
+func (mr *MockConfigBLLMockRecorder) ListenForChanges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenForChanges", reflect.TypeOf((*MockConfigBLL)(nil).ListenForChanges))
+}
+
 // Set mocks base method.
 func (m *MockConfigBLL) Set(arg0 dal.ConfigKey, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -84,6 +98,20 @@ func (mr *MockConfigBLLMockRecorder) Set(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockConfigBLL)(nil).Set), arg0, arg1)
 }
 
+// SetAdminConfig mocks base method.
+func (m *MockConfigBLL) SetAdminConfig(arg0 dal.ConfigKey, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAdminConfig", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAdminConfig indicates an expected call of SetAdminConfig.
+func (mr *MockConfigBLLMockRecorder) SetAdminConfig(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAdminConfig", reflect.TypeOf((*MockConfigBLL)(nil).SetAdminConfig), arg0, arg1)
+}
+
 // ValidateRegistrationCode mocks base method.
 func (m *MockConfigBLL) ValidateRegistrationCode(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/bll (interfaces: OAuthBLL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_oauth.go -package=mocks github.com/asatraitis/mangrove/internal/bll OAuthBLL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	dto "github.com/asatraitis/mangrove/internal/dto"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOAuthBLL is a mock of OAuthBLL interface.
+type MockOAuthBLL struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthBLLMockRecorder
+	isgomock struct{}
+}
+
+// MockOAuthBLLMockRecorder is the mock recorder for MockOAuthBLL.
+type MockOAuthBLLMockRecorder struct {
+	mock *MockOAuthBLL
+}
+
+// NewMockOAuthBLL creates a new mock instance.
+func NewMockOAuthBLL(ctrl *gomock.Controller) *MockOAuthBLL {
+	mock := &MockOAuthBLL{ctrl: ctrl}
+	mock.recorder = &MockOAuthBLLMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuthBLL) EXPECT() *MockOAuthBLLMockRecorder {
+	return m.recorder
+}
+
+// CreateAuthorizationCode mocks base method.
+func (m *MockOAuthBLL) CreateAuthorizationCode(arg0 *dto.AuthorizeRequest, arg1 uuid.UUID, arg2 []byte) (*dto.FinishAuthorizeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuthorizationCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.FinishAuthorizeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuthorizationCode indicates an expected call of CreateAuthorizationCode.
+func (mr *MockOAuthBLLMockRecorder) CreateAuthorizationCode(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuthorizationCode", reflect.TypeOf((*MockOAuthBLL)(nil).CreateAuthorizationCode), arg0, arg1, arg2)
+}
+
+// Introspect mocks base method.
+func (m *MockOAuthBLL) Introspect(arg0 *dto.IntrospectRequest) (*dto.IntrospectResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", arg0)
+	ret0, _ := ret[0].(*dto.IntrospectResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockOAuthBLLMockRecorder) Introspect(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockOAuthBLL)(nil).Introspect), arg0)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuthBLL) Revoke(arg0 *dto.RevokeRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuthBLLMockRecorder) Revoke(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuthBLL)(nil).Revoke), arg0)
+}
+
+// Token mocks base method.
+func (m *MockOAuthBLL) Token(arg0 *dto.TokenRequest) (*dto.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token", arg0)
+	ret0, _ := ret[0].(*dto.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockOAuthBLLMockRecorder) Token(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockOAuthBLL)(nil).Token), arg0)
+}
+
+// ValidateAuthorizeRequest mocks base method.
+func (m *MockOAuthBLL) ValidateAuthorizeRequest(arg0 *dto.AuthorizeRequest) (*models.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateAuthorizeRequest", arg0)
+	ret0, _ := ret[0].(*models.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateAuthorizeRequest indicates an expected call of ValidateAuthorizeRequest.
+func (mr *MockOAuthBLLMockRecorder) ValidateAuthorizeRequest(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateAuthorizeRequest", reflect.TypeOf((*MockOAuthBLL)(nil).ValidateAuthorizeRequest), arg0)
+}
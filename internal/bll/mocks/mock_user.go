@@ -0,0 +1,195 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/bll (interfaces: UserBLL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_user.go -package=mocks github.com/asatraitis/mangrove/internal/bll UserBLL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	dto "github.com/asatraitis/mangrove/internal/dto"
+	protocol "github.com/go-webauthn/webauthn/protocol"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserBLL is a mock of UserBLL interface.
+type MockUserBLL struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserBLLMockRecorder
+	isgomock struct{}
+}
+
+// MockUserBLLMockRecorder is the mock recorder for MockUserBLL.
+type MockUserBLLMockRecorder struct {
+	mock *MockUserBLL
+}
+
+// NewMockUserBLL creates a new mock instance.
+func NewMockUserBLL(ctrl *gomock.Controller) *MockUserBLL {
+	mock := &MockUserBLL{ctrl: ctrl}
+	mock.recorder = &MockUserBLLMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserBLL) EXPECT() *MockUserBLLMockRecorder {
+	return m.recorder
+}
+
+// CreateToken mocks base method.
+func (m *MockUserBLL) CreateToken(userID uuid.UUID, ip, userAgent string) (*models.UserToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateToken", userID, ip, userAgent)
+	ret0, _ := ret[0].(*models.UserToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateToken indicates an expected call of CreateToken.
+func (mr *MockUserBLLMockRecorder) CreateToken(userID, ip, userAgent any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockUserBLL)(nil).CreateToken), userID, ip, userAgent)
+}
+
+// CreateUserSession mocks base method.
+func (m *MockUserBLL) CreateUserSession() (*protocol.CredentialCreation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserSession")
+	ret0, _ := ret[0].(*protocol.CredentialCreation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserSession indicates an expected call of CreateUserSession.
+func (mr *MockUserBLLMockRecorder) CreateUserSession() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserSession", reflect.TypeOf((*MockUserBLL)(nil).CreateUserSession))
+}
+
+// FinishLogin mocks base method.
+func (m *MockUserBLL) FinishLogin(arg0 *dto.FinishLoginRequest) (*dto.MeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", arg0)
+	ret0, _ := ret[0].(*dto.MeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockUserBLLMockRecorder) FinishLogin(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockUserBLL)(nil).FinishLogin), arg0)
+}
+
+// GetUser mocks base method.
+func (m *MockUserBLL) GetUser(arg0 uuid.UUID) (*dto.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0)
+	ret0, _ := ret[0].(*dto.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserBLLMockRecorder) GetUser(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserBLL)(nil).GetUser), arg0)
+}
+
+// GetUserByID mocks base method.
+func (m *MockUserBLL) GetUserByID(arg0 uuid.UUID) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockUserBLLMockRecorder) GetUserByID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserBLL)(nil).GetUserByID), arg0)
+}
+
+// GetUsers mocks base method.
+func (m *MockUserBLL) GetUsers(arg0 dto.UsersRequest) (*dto.UsersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", arg0)
+	ret0, _ := ret[0].(*dto.UsersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserBLLMockRecorder) GetUsers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserBLL)(nil).GetUsers), arg0)
+}
+
+// InitLogin mocks base method.
+func (m *MockUserBLL) InitLogin(arg0 string) (protocol.PublicKeyCredentialRequestOptions, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitLogin", arg0)
+	ret0, _ := ret[0].(protocol.PublicKeyCredentialRequestOptions)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// InitLogin indicates an expected call of InitLogin.
+func (mr *MockUserBLLMockRecorder) InitLogin(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitLogin", reflect.TypeOf((*MockUserBLL)(nil).InitLogin), arg0)
+}
+
+// RegisterSuperAdmin mocks base method.
+func (m *MockUserBLL) RegisterSuperAdmin(arg0 *dto.FinishRegistrationRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterSuperAdmin", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterSuperAdmin indicates an expected call of RegisterSuperAdmin.
+func (mr *MockUserBLLMockRecorder) RegisterSuperAdmin(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterSuperAdmin", reflect.TypeOf((*MockUserBLL)(nil).RegisterSuperAdmin), arg0)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserBLL) UpdateUser(arg0 uuid.UUID, arg1 dto.UpdateUserRequest) (*dto.UpdateUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
+	ret0, _ := ret[0].(*dto.UpdateUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserBLLMockRecorder) UpdateUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserBLL)(nil).UpdateUser), arg0, arg1)
+}
+
+// ValidateToken mocks base method.
+func (m *MockUserBLL) ValidateToken(arg0 string) (*models.UserToken, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateToken", arg0)
+	ret0, _ := ret[0].(*models.UserToken)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ValidateToken indicates an expected call of ValidateToken.
+func (mr *MockUserBLLMockRecorder) ValidateToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockUserBLL)(nil).ValidateToken), arg0)
+}
@@ -0,0 +1,537 @@
+package bll
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	authorizationCodeTTL   = time.Minute
+	authorizationCodeBytes = 32
+)
+
+const (
+	OAUTH_RESPONSE_TYPE_CODE            = "code"
+	OAUTH_GRANT_TYPE_AUTHORIZATION_CODE = "authorization_code"
+	OAUTH_GRANT_TYPE_REFRESH_TOKEN      = "refresh_token"
+	OAUTH_TOKEN_TYPE_BEARER             = "Bearer"
+	OAUTH_TOKEN_TYPE_HINT_ACCESS_TOKEN  = "access_token"
+	OAUTH_TOKEN_TYPE_HINT_REFRESH_TOKEN = "refresh_token"
+)
+
+type OAuthErrorCode string
+
+const (
+	OAUTH_ERR_INVALID_REQUEST           OAuthErrorCode = "invalid_request"
+	OAUTH_ERR_INVALID_CLIENT            OAuthErrorCode = "invalid_client"
+	OAUTH_ERR_INVALID_GRANT             OAuthErrorCode = "invalid_grant"
+	OAUTH_ERR_UNAUTHORIZED_CLIENT       OAuthErrorCode = "unauthorized_client"
+	OAUTH_ERR_UNSUPPORTED_GRANT_TYPE    OAuthErrorCode = "unsupported_grant_type"
+	OAUTH_ERR_UNSUPPORTED_RESPONSE_TYPE OAuthErrorCode = "unsupported_response_type"
+	OAUTH_ERR_INVALID_SCOPE             OAuthErrorCode = "invalid_scope"
+	OAUTH_ERR_ACCESS_DENIED             OAuthErrorCode = "access_denied"
+	OAUTH_ERR_SERVER_ERROR              OAuthErrorCode = "server_error"
+	OAUTH_ERR_UNSUPPORTED_TOKEN_TYPE    OAuthErrorCode = "unsupported_token_type"
+)
+
+// OAuthError carries an RFC 6749 error code back to the handler.
+// RedirectURI is only set once the client and its redirect URI were verified,
+// meaning the error is safe to send back to the client via redirect.
+type OAuthError struct {
+	Code        OAuthErrorCode
+	Description string
+	RedirectURI string
+}
+
+func (e *OAuthError) Error() string {
+	return string(e.Code) + ": " + e.Description
+}
+
+func newOAuthError(code OAuthErrorCode, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+//go:generate mockgen -destination=./mocks/mock_oauth.go -package=mocks github.com/asatraitis/mangrove/internal/bll OAuthBLL
+type OAuthBLL interface {
+	ValidateAuthorizeRequest(*dto.AuthorizeRequest) (*models.Client, error)
+	CreateAuthorizationCode(*dto.AuthorizeRequest, uuid.UUID, []byte) (*dto.FinishAuthorizeResponse, error)
+	Token(*dto.TokenRequest) (*dto.TokenResponse, error)
+	Introspect(*dto.IntrospectRequest) (*dto.IntrospectResponse, error)
+	Revoke(*dto.RevokeRequest) error
+}
+type oauthBLL struct {
+	ctx context.Context
+	*BaseBLL
+}
+
+func NewOAuthBLL(ctx context.Context, baseBLL *BaseBLL) OAuthBLL {
+	oBll := &oauthBLL{
+		ctx:     ctx,
+		BaseBLL: baseBLL,
+	}
+	oBll.logger = baseBLL.logger.With().Str("subcomponent", "OAuthBLL").Logger()
+	return oBll
+}
+
+func (o *oauthBLL) ValidateAuthorizeRequest(req *dto.AuthorizeRequest) (*models.Client, error) {
+	const funcName = "ValidateAuthorizeRequest"
+
+	if req == nil {
+		return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "missing authorization request")
+	}
+
+	client, err := o.getClient(req.ClientID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", req.ClientID).Msg("failed to get client")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "unknown client")
+	}
+	if !matchRedirectURI(client.RedirectURIs, req.RedirectURI) {
+		o.logger.Error().Str("func", funcName).Str("clientID", req.ClientID).Str("redirectURI", req.RedirectURI).Msg("redirect_uri does not match client")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "invalid redirect_uri")
+	}
+	if client.Status != models.CLIENT_STATUS_ACTIVE {
+		o.logger.Error().Str("func", funcName).Str("clientID", req.ClientID).Str("status", string(client.Status)).Msg("client is not active")
+		return nil, newOAuthError(OAUTH_ERR_UNAUTHORIZED_CLIENT, "client is not active")
+	}
+
+	// client and redirect_uri are trusted from here on; errors can be sent back to the client
+	var oerr *OAuthError
+	switch {
+	case req.ResponseType != OAUTH_RESPONSE_TYPE_CODE:
+		oerr = newOAuthError(OAUTH_ERR_UNSUPPORTED_RESPONSE_TYPE, "response_type must be code")
+	case req.CodeChallenge == "":
+		oerr = newOAuthError(OAUTH_ERR_INVALID_REQUEST, "code_challenge required")
+	case models.CodeChallengeMethod(req.CodeChallengeMethod) != models.CODE_CHALLENGE_METHOD_S256:
+		oerr = newOAuthError(OAUTH_ERR_INVALID_REQUEST, "code_challenge_method must be S256")
+	case !scopesSupported(req.Scope):
+		oerr = newOAuthError(OAUTH_ERR_INVALID_SCOPE, "unsupported scope")
+	}
+	if oerr != nil {
+		o.logger.Err(oerr).Str("func", funcName).Str("clientID", req.ClientID).Msg("invalid authorization request")
+		oerr.RedirectURI = req.RedirectURI
+		return nil, oerr
+	}
+
+	return client, nil
+}
+
+// CreateAuthorizationCode issues a code for the user that just logged in with the given passkey credential;
+// the credential determines the amr/acr values carried into the ID token
+func (o *oauthBLL) CreateAuthorizationCode(req *dto.AuthorizeRequest, userID uuid.UUID, credentialID []byte) (*dto.FinishAuthorizeResponse, error) {
+	const funcName = "CreateAuthorizationCode"
+
+	client, err := o.ValidateAuthorizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := o.dal.User(o.ctx).GetByIdWithCredentials(userID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user")
+		return nil, &OAuthError{Code: OAUTH_ERR_SERVER_ERROR, Description: "failed to get user", RedirectURI: req.RedirectURI}
+	}
+	if user.Status != models.USER_STATUS_ACTIVE {
+		o.logger.Error().Str("func", funcName).Str("userID", userID.String()).Msg("user is not active")
+		return nil, &OAuthError{Code: OAUTH_ERR_ACCESS_DENIED, Description: "user is not active", RedirectURI: req.RedirectURI}
+	}
+
+	var credential *models.UserCredential
+	for _, cred := range user.Credentials {
+		if bytes.Equal(cred.ID, credentialID) {
+			credential = cred
+			break
+		}
+	}
+	if credential == nil {
+		o.logger.Error().Str("func", funcName).Str("userID", userID.String()).Msg("login credential does not belong to user")
+		return nil, &OAuthError{Code: OAUTH_ERR_ACCESS_DENIED, Description: "unknown credential", RedirectURI: req.RedirectURI}
+	}
+	amr, acr := credentialAuthContext(credential)
+
+	code, err := utils.GenerateRandomString(authorizationCodeBytes)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to generate authorization code")
+		return nil, &OAuthError{Code: OAUTH_ERR_SERVER_ERROR, Description: "failed to create authorization code", RedirectURI: req.RedirectURI}
+	}
+
+	err = o.dal.AuthorizationCodes(o.ctx).Create(nil, &models.AuthorizationCode{
+		Code:                o.hashCode(code),
+		ClientID:            client.ID,
+		UserID:              user.ID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               normalizeScope(req.Scope),
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: models.CodeChallengeMethod(req.CodeChallengeMethod),
+		Expires:             time.Now().Add(authorizationCodeTTL),
+		Nonce:               req.Nonce,
+		AuthTime:            time.Now(),
+		AMR:                 amr,
+		ACR:                 acr,
+	})
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to store authorization code")
+		return nil, &OAuthError{Code: OAUTH_ERR_SERVER_ERROR, Description: "failed to create authorization code", RedirectURI: req.RedirectURI}
+	}
+
+	redirectURI, err := buildRedirectURI(req.RedirectURI, map[string]string{
+		"code":  code,
+		"state": req.State,
+	})
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to build redirect uri")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to build redirect uri")
+	}
+
+	return &dto.FinishAuthorizeResponse{RedirectURI: redirectURI}, nil
+}
+
+func (o *oauthBLL) Token(req *dto.TokenRequest) (*dto.TokenResponse, error) {
+	const funcName = "Token"
+
+	if req == nil {
+		return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "missing token request")
+	}
+	switch req.GrantType {
+	case OAUTH_GRANT_TYPE_AUTHORIZATION_CODE:
+		if req.Code == "" || req.CodeVerifier == "" || req.RedirectURI == "" {
+			return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "code, code_verifier and redirect_uri are required")
+		}
+	case OAUTH_GRANT_TYPE_REFRESH_TOKEN:
+		if req.RefreshToken == "" {
+			return nil, newOAuthError(OAUTH_ERR_INVALID_REQUEST, "refresh_token is required")
+		}
+	default:
+		o.logger.Error().Str("func", funcName).Str("grantType", req.GrantType).Msg("unsupported grant type")
+		return nil, newOAuthError(OAUTH_ERR_UNSUPPORTED_GRANT_TYPE, "unsupported grant_type")
+	}
+
+	// paused and suspended clients fail authentication, so they can neither redeem codes nor refresh
+	client, err := o.authenticateClient(req.ClientID, req.ClientAssertionType, req.ClientAssertion)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GrantType == OAUTH_GRANT_TYPE_REFRESH_TOKEN {
+		return o.refresh(client, req)
+	}
+	return o.exchangeCode(client, req)
+}
+
+// exchangeCode redeems an authorization code; clients with a refresh token lifetime also get the first
+// refresh token of a new family
+func (o *oauthBLL) exchangeCode(client *models.Client, req *dto.TokenRequest) (*dto.TokenResponse, error) {
+	const funcName = "exchangeCode"
+
+	// the code is only consumed when issued to this client for this redirect_uri, so presenting someone
+	// else's code cannot burn it
+	authCode, err := o.dal.AuthorizationCodes(o.ctx).Consume(o.hashCode(req.Code), client.ID, req.RedirectURI)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", client.ID.String()).Msg("failed to consume authorization code")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid authorization code")
+	}
+	if !time.Now().Before(authCode.Expires) {
+		o.logger.Error().Str("func", funcName).Msg("authorization code expired")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "authorization code expired")
+	}
+	if err := utils.VerifyPKCEChallengeS256(req.CodeVerifier, authCode.CodeChallenge); err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("clientID", client.ID.String()).Msg("failed PKCE verification")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid code_verifier")
+	}
+
+	now := time.Now()
+	accessToken, err := newAccessToken(client, authCode.UserID, authCode.Scope, now)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to generate access token")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create access token")
+	}
+	var refreshToken *models.RefreshToken
+	if client.RefreshTokenLifetime > 0 {
+		familyID, err := uuid.NewV7()
+		if err != nil {
+			o.logger.Err(err).Str("func", funcName).Msg("failed to generate refresh token family ID")
+			return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create refresh token")
+		}
+		familyExpires := now.Add(time.Duration(client.RefreshTokenMaxLifetime) * time.Second)
+		refreshToken, err = newRefreshToken(client, authCode.UserID, authCode.Scope, familyID, familyExpires, now)
+		if err != nil {
+			o.logger.Err(err).Str("func", funcName).Msg("failed to generate refresh token")
+			return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create refresh token")
+		}
+	}
+	if err := o.storeTokens(accessToken, refreshToken, nil); err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to store tokens")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create access token")
+	}
+
+	res := newTokenResponse(client, accessToken, refreshToken)
+	if slices.Contains(strings.Fields(authCode.Scope), OIDC_SCOPE_OPENID) {
+		res.IDToken, err = NewOIDCBLL(o.ctx, o.BaseBLL).CreateIDToken(authCode)
+		if err != nil {
+			o.logger.Err(err).Str("func", funcName).Msg("failed to create id token")
+			return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create id token")
+		}
+	}
+
+	return res, nil
+}
+
+// refresh rotates the refresh token: the presented token is marked used and replaced by a new one of the same
+// family. Presenting a used token means it was replayed, by the client or by whoever stole it, so the whole
+// family is revoked and the user has to sign in again. The caller has already proven it holds the client's
+// key, so a leaked refresh token alone cannot be redeemed. No ID token is issued on refresh.
+func (o *oauthBLL) refresh(client *models.Client, req *dto.TokenRequest) (*dto.TokenResponse, error) {
+	const funcName = "refresh"
+
+	presented, err := o.dal.RefreshTokens(o.ctx).GetByHash(utils.HashOpaqueToken(req.RefreshToken))
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to get refresh token")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid refresh token")
+	}
+	if presented.ClientID != client.ID {
+		o.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Msg("refresh token was issued to another client")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid refresh token")
+	}
+	if presented.RevokedAt != nil {
+		o.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Msg("refresh token revoked")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid refresh token")
+	}
+	if presented.UsedAt != nil {
+		o.revokeRefreshTokenFamily(presented)
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid refresh token")
+	}
+	now := time.Now()
+	if !now.Before(presented.Expires) {
+		o.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Msg("refresh token expired")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "refresh token expired")
+	}
+
+	// the access token may be narrowed to a subset of the granted scope; the refresh token keeps the full grant
+	scope := presented.Scope
+	if req.Scope != "" {
+		scope = normalizeScope(req.Scope)
+		granted := strings.Fields(presented.Scope)
+		for _, s := range strings.Fields(scope) {
+			if !slices.Contains(granted, s) {
+				o.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Str("scope", req.Scope).Msg("scope exceeds the original grant")
+				return nil, newOAuthError(OAUTH_ERR_INVALID_SCOPE, "scope exceeds the original grant")
+			}
+		}
+	}
+
+	user, err := o.dal.User(o.ctx).GetByID(presented.UserID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("userID", presented.UserID.String()).Msg("failed to get user")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid refresh token")
+	}
+	if user.Status != models.USER_STATUS_ACTIVE {
+		o.logger.Error().Str("func", funcName).Str("userID", user.ID.String()).Msg("user is not active")
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "user is not active")
+	}
+
+	accessToken, err := newAccessToken(client, presented.UserID, scope, now)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to generate access token")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create access token")
+	}
+	var refreshToken *models.RefreshToken
+	if client.RefreshTokenLifetime > 0 {
+		refreshToken, err = newRefreshToken(client, presented.UserID, presented.Scope, presented.FamilyID, presented.FamilyExpires, now)
+		if err != nil {
+			o.logger.Err(err).Str("func", funcName).Msg("failed to generate refresh token")
+			return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create refresh token")
+		}
+	}
+	err = o.storeTokens(accessToken, refreshToken, presented)
+	if errors.Is(err, errRefreshTokenUsed) {
+		// a concurrent request used the token first
+		o.revokeRefreshTokenFamily(presented)
+		return nil, newOAuthError(OAUTH_ERR_INVALID_GRANT, "invalid refresh token")
+	}
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to store tokens")
+		return nil, newOAuthError(OAUTH_ERR_SERVER_ERROR, "failed to create access token")
+	}
+
+	return newTokenResponse(client, accessToken, refreshToken), nil
+}
+
+var errRefreshTokenUsed = errors.New("refresh token already used")
+
+// storeTokens stores the issued tokens in one transaction; the used refresh token, if any, is marked used
+// in the same transaction so it can only be exchanged once
+func (o *oauthBLL) storeTokens(accessToken *models.UserToken, refreshToken *models.RefreshToken, used *models.RefreshToken) error {
+	tx, err := o.dal.BeginTx(o.ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(o.ctx)
+		}
+	}()
+
+	if used != nil {
+		var marked bool
+		marked, err = o.dal.RefreshTokens(o.ctx).MarkUsed(tx, used.ID, accessToken.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			err = errRefreshTokenUsed
+			return err
+		}
+	}
+	if err = o.dal.UserTokens(o.ctx).Create(tx, accessToken); err != nil {
+		return err
+	}
+	if refreshToken != nil {
+		if err = o.dal.RefreshTokens(o.ctx).Create(tx, refreshToken); err != nil {
+			return err
+		}
+	}
+	err = tx.Commit(o.ctx)
+	return err
+}
+
+// revokeRefreshTokenFamily revokes every refresh token of the family after the token was replayed;
+// access tokens already issued from the family stay valid until they expire
+func (o *oauthBLL) revokeRefreshTokenFamily(replayed *models.RefreshToken) {
+	const funcName = "revokeRefreshTokenFamily"
+
+	revoked, err := o.dal.RefreshTokens(o.ctx).RevokeFamily(nil, replayed.FamilyID, time.Now())
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("familyID", replayed.FamilyID.String()).Msg("failed to revoke refresh token family")
+		return
+	}
+	o.logger.Warn().Str("func", funcName).Str("clientID", replayed.ClientID.String()).Str("userID", replayed.UserID.String()).Str("familyID", replayed.FamilyID.String()).Int64("revoked", revoked).Msg("refresh token reuse detected; revoked token family")
+}
+
+func newAccessToken(client *models.Client, userID uuid.UUID, scope string, now time.Time) (*models.UserToken, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	secret, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserToken{
+		ID:        tokenID,
+		TokenHash: hash,
+		Token:     secret,
+		UserID:    userID,
+		Expires:   now.Add(time.Duration(client.AccessTokenLifetime) * time.Second),
+		ClientID:  &client.ID,
+		Scope:     scope,
+		CreatedAt: now,
+	}, nil
+}
+
+// newRefreshToken creates the next token of the family; it never outlives the family
+func newRefreshToken(client *models.Client, userID uuid.UUID, scope string, familyID uuid.UUID, familyExpires time.Time, now time.Time) (*models.RefreshToken, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	secret, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	expires := now.Add(time.Duration(client.RefreshTokenLifetime) * time.Second)
+	if expires.After(familyExpires) {
+		expires = familyExpires
+	}
+	return &models.RefreshToken{
+		ID:            tokenID,
+		TokenHash:     hash,
+		Token:         secret,
+		FamilyID:      familyID,
+		ClientID:      client.ID,
+		UserID:        userID,
+		Scope:         scope,
+		CreatedAt:     now,
+		Expires:       expires,
+		FamilyExpires: familyExpires,
+	}, nil
+}
+
+func newTokenResponse(client *models.Client, accessToken *models.UserToken, refreshToken *models.RefreshToken) *dto.TokenResponse {
+	res := &dto.TokenResponse{
+		AccessToken: accessToken.Token,
+		TokenType:   OAUTH_TOKEN_TYPE_BEARER,
+		ExpiresIn:   client.AccessTokenLifetime,
+		Scope:       accessToken.Scope,
+	}
+	if refreshToken != nil {
+		res.RefreshToken = refreshToken.Token
+	}
+	return res
+}
+
+func (o *oauthBLL) getClient(clientID string) (*models.Client, error) {
+	ID, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := o.dal.Client(o.ctx).GetByID(ID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return client, nil
+}
+
+func (o *oauthBLL) hashCode(code string) string {
+	return utils.SignToken(code, []byte(o.vars.MangroveSalt))
+}
+
+// normalizeScope removes duplicate and empty scope values while keeping the requested order
+func normalizeScope(scope string) string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+func scopesSupported(scope string) bool {
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(oidcScopesSupported, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func buildRedirectURI(redirectURI string, params map[string]string) (string, error) {
+	if redirectURI == "" {
+		return "", errors.New("missing redirect uri")
+	}
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range params {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,650 @@
+package bll
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	jwksMocks "github.com/asatraitis/mangrove/internal/service/jwks/mocks"
+	signerMocks "github.com/asatraitis/mangrove/internal/service/signer/mocks"
+	"github.com/asatraitis/mangrove/internal/service/webauthn"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type OAuthBllTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal          *mocks.MockDAL
+	clientsDal   *mocks.MockClientsDAL
+	userDal      *mocks.MockUserDAL
+	codesDal     *mocks.MockAuthorizationCodesDAL
+	userTokenDal *mocks.MockUserTokensDAL
+	refreshDal   *mocks.MockRefreshTokensDAL
+	assertionDal *mocks.MockClientAssertionsDAL
+	clientKeyDal *mocks.MockClientKeysDAL
+	signer       *signerMocks.MockSigner
+	jwks         *jwksMocks.MockFetcher
+	bll          BLL
+
+	client    *models.Client
+	clientKey ed25519.PrivateKey
+	verifier  string
+}
+
+func TestOAuthBllTestSuite(t *testing.T) {
+	suite.Run(t, new(OAuthBllTestSuite))
+}
+
+func (suite *OAuthBllTestSuite) SetupSuite() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.clientsDal = mocks.NewMockClientsDAL(suite.Ctrl)
+	suite.userDal = mocks.NewMockUserDAL(suite.Ctrl)
+	suite.codesDal = mocks.NewMockAuthorizationCodesDAL(suite.Ctrl)
+	suite.userTokenDal = mocks.NewMockUserTokensDAL(suite.Ctrl)
+	suite.refreshDal = mocks.NewMockRefreshTokensDAL(suite.Ctrl)
+	suite.assertionDal = mocks.NewMockClientAssertionsDAL(suite.Ctrl)
+	suite.clientKeyDal = mocks.NewMockClientKeysDAL(suite.Ctrl)
+	suite.jwks = jwksMocks.NewMockFetcher(suite.Ctrl)
+
+	logger := zerolog.Nop()
+	vars := configs.NewConf(logger).GetEnvironmentVars()
+	vars.MangroveSalt = "testsalt"
+	vars.MangroveOIDCIssuer = ""
+	vars.MangroveWebauthnRPOrigins = []string{"http://localhost:3030"}
+	wauthn, err := webauthn.NewWebAuthN(context.Background(), &wa.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, logger)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.signer = signerMocks.NewMockSigner(suite.Ctrl)
+	appConfig := config.NewConfig(context.Background(), logger)
+	suite.bll = NewBLL(logger, vars, appConfig, wauthn, suite.signer, suite.jwks, suite.dal)
+	suite.verifier = strings.Repeat("v", 43)
+	_, suite.clientKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+func (suite *OAuthBllTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.client = &models.Client{
+		ID:           uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+		UserID:       uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+		Name:         "test-client",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Status:       models.CLIENT_STATUS_ACTIVE,
+
+		AccessTokenLifetime:     3600,
+		RefreshTokenLifetime:    86400,
+		RefreshTokenMaxLifetime: 7 * 86400,
+	}
+}
+func (suite *OAuthBllTestSuite) TearDownTest() {}
+
+func (suite *OAuthBllTestSuite) authorizeRequest() *dto.AuthorizeRequest {
+	return &dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            suite.client.ID.String(),
+		RedirectURI:         suite.client.RedirectURIs[0],
+		Scope:               "openid openid profile",
+		State:               "test-state",
+		CodeChallenge:       utils.GeneratePKCEChallengeS256(suite.verifier),
+		CodeChallengeMethod: "S256",
+		Nonce:               "test-nonce",
+	}
+}
+
+func (suite *OAuthBllTestSuite) expectClient() {
+	suite.dal.EXPECT().Client(gomock.Any()).Times(1).Return(suite.clientsDal)
+	suite.clientsDal.EXPECT().GetByID(suite.client.ID).Times(1).Return(suite.client, nil)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_OK() {
+	suite.expectClient()
+
+	client, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(suite.authorizeRequest())
+	suite.NoError(err)
+	suite.Equal(suite.client.ID, client.ID)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_FAIL_UnknownClient() {
+	suite.dal.EXPECT().Client(gomock.Any()).Times(1).Return(suite.clientsDal)
+	suite.clientsDal.EXPECT().GetByID(gomock.Any()).Times(1).Return(nil, pgx.ErrNoRows)
+
+	_, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(suite.authorizeRequest())
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_REQUEST, oerr.Code)
+	suite.Empty(oerr.RedirectURI)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_FAIL_RedirectMismatch() {
+	suite.expectClient()
+	req := suite.authorizeRequest()
+	req.RedirectURI = "https://evil.example.com/callback"
+
+	_, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(req)
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Empty(oerr.RedirectURI)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_OK_LoopbackPort() {
+	suite.client.RedirectURIs = append(suite.client.RedirectURIs, "http://127.0.0.1/callback")
+	suite.expectClient()
+	req := suite.authorizeRequest()
+	req.RedirectURI = "http://127.0.0.1:53124/callback"
+
+	client, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(req)
+	suite.NoError(err)
+	suite.Equal(suite.client.ID, client.ID)
+
+	// errors after the redirect check go back to the redirect URI the client asked for
+	suite.expectClient()
+	req.CodeChallengeMethod = "plain"
+	_, err = suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(req)
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal("http://127.0.0.1:53124/callback", oerr.RedirectURI)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_FAIL_ClientPaused() {
+	suite.client.Status = models.CLIENT_STATUS_PAUSED
+	suite.expectClient()
+
+	_, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(suite.authorizeRequest())
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_UNAUTHORIZED_CLIENT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_FAIL_NoPKCE() {
+	suite.expectClient()
+	req := suite.authorizeRequest()
+	req.CodeChallengeMethod = "plain"
+
+	_, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(req)
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_REQUEST, oerr.Code)
+	suite.Equal(suite.client.RedirectURIs[0], oerr.RedirectURI)
+}
+
+func (suite *OAuthBllTestSuite) TestValidateAuthorizeRequest_FAIL_UnknownScope() {
+	suite.expectClient()
+	req := suite.authorizeRequest()
+	req.Scope = "openid offline_access"
+
+	_, err := suite.bll.OAuth(suite.ctx).ValidateAuthorizeRequest(req)
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_SCOPE, oerr.Code)
+	suite.Equal(suite.client.RedirectURIs[0], oerr.RedirectURI)
+}
+
+func (suite *OAuthBllTestSuite) TestCreateAuthorizationCode_OK() {
+	userID := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	suite.expectClient()
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByIdWithCredentials(userID).Times(1).Return(&models.User{
+		ID:     userID,
+		Status: models.USER_STATUS_ACTIVE,
+		Credentials: []*models.UserCredential{
+			{ID: []byte("other-cred"), AuthAttachment: protocol.Platform},
+			{ID: []byte("test-cred"), AuthAttachment: protocol.CrossPlatform, FlagVerified: true},
+		},
+	}, nil)
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, code *models.AuthorizationCode) error {
+		suite.Equal(suite.client.ID, code.ClientID)
+		suite.Equal(userID, code.UserID)
+		suite.Equal("openid profile", code.Scope)
+		suite.True(code.Expires.After(time.Now()))
+		suite.Equal("test-nonce", code.Nonce)
+		suite.Equal([]string{"hwk", "user"}, code.AMR)
+		suite.Equal("phrh", code.ACR)
+		return nil
+	})
+
+	res, err := suite.bll.OAuth(suite.ctx).CreateAuthorizationCode(suite.authorizeRequest(), userID, []byte("test-cred"))
+	suite.NoError(err)
+	redirect, err := url.Parse(res.RedirectURI)
+	suite.NoError(err)
+	suite.Equal("app.example.com", redirect.Host)
+	suite.NotEmpty(redirect.Query().Get("code"))
+	suite.Equal("test-state", redirect.Query().Get("state"))
+}
+
+func (suite *OAuthBllTestSuite) TestCreateAuthorizationCode_FAIL_UnknownCredential() {
+	userID := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	suite.expectClient()
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByIdWithCredentials(userID).Times(1).Return(&models.User{
+		ID:          userID,
+		Status:      models.USER_STATUS_ACTIVE,
+		Credentials: []*models.UserCredential{{ID: []byte("other-cred")}},
+	}, nil)
+
+	_, err := suite.bll.OAuth(suite.ctx).CreateAuthorizationCode(suite.authorizeRequest(), userID, []byte("test-cred"))
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_ACCESS_DENIED, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK() {
+	userID := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(utils.SignToken("test-code", []byte("testsalt")), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(&models.AuthorizationCode{
+		ClientID:            suite.client.ID,
+		UserID:              userID,
+		RedirectURI:         suite.client.RedirectURIs[0],
+		Scope:               "openid profile",
+		CodeChallenge:       utils.GeneratePKCEChallengeS256(suite.verifier),
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+		Nonce:               "test-nonce",
+		AuthTime:            time.Now(),
+		AMR:                 []string{"hwk", "user"},
+		ACR:                 "phr",
+	}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	var tokenHash []byte
+	suite.userTokenDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, token *models.UserToken) error {
+		suite.Equal(userID, token.UserID)
+		suite.Equal(suite.client.ID, *token.ClientID)
+		tokenHash = token.TokenHash
+		return nil
+	})
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+	var refreshToken *models.RefreshToken
+	suite.refreshDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, token *models.RefreshToken) error {
+		suite.Equal(userID, token.UserID)
+		suite.Equal(suite.client.ID, token.ClientID)
+		suite.Equal("openid profile", token.Scope)
+		suite.WithinDuration(time.Now().Add(24*time.Hour), token.Expires, time.Minute)
+		suite.WithinDuration(time.Now().Add(7*24*time.Hour), token.FamilyExpires, time.Minute)
+		refreshToken = token
+		return nil
+	})
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(userID).Times(1).Return(&models.User{
+		ID:          userID,
+		Username:    "test-user",
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+	}, nil)
+	suite.signer.EXPECT().Sign(gomock.Any()).Times(1).DoAndReturn(func(c jwt.Claims) (string, error) {
+		claims := c.(*IDTokenClaims)
+		suite.Equal(userID.String(), claims.Subject)
+		suite.Equal(jwt.ClaimStrings{suite.client.ID.String()}, claims.Audience)
+		suite.Equal("http://localhost:3030", claims.Issuer)
+		suite.Equal("test-nonce", claims.Nonce)
+		suite.Equal("test-user", claims.PreferredUsername)
+		suite.Empty(claims.Email)
+		suite.Equal([]string{"hwk", "user"}, claims.AMR)
+		suite.Equal("phr", claims.ACR)
+		return "test-id-token", nil
+	})
+
+	res, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        suite.verifier,
+	})
+	suite.NoError(err)
+	suite.NotEmpty(res.AccessToken)
+	// only the digest of the access token is stored
+	suite.Equal(tokenHash, utils.HashOpaqueToken(res.AccessToken))
+	suite.Equal("Bearer", res.TokenType)
+	suite.Equal(3600, res.ExpiresIn)
+	suite.Equal("openid profile", res.Scope)
+	suite.Equal("test-id-token", res.IDToken)
+	suite.Equal(refreshToken.TokenHash, utils.HashOpaqueToken(res.RefreshToken))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK_NoRefreshToken() {
+	suite.client.AccessTokenLifetime = 600
+	suite.client.RefreshTokenLifetime = 0
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(&models.AuthorizationCode{
+		ClientID:            suite.client.ID,
+		UserID:              uuid.New(),
+		RedirectURI:         suite.client.RedirectURIs[0],
+		Scope:               "profile",
+		CodeChallenge:       utils.GeneratePKCEChallengeS256(suite.verifier),
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+	}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, token *models.UserToken) error {
+		suite.WithinDuration(time.Now().Add(10*time.Minute), token.Expires, time.Minute)
+		return nil
+	})
+
+	res, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        suite.verifier,
+	})
+	suite.NoError(err)
+	suite.Equal(600, res.ExpiresIn)
+	suite.Empty(res.RefreshToken)
+	suite.Empty(res.IDToken)
+}
+
+func (suite *OAuthBllTestSuite) refreshToken(secret string) *models.RefreshToken {
+	now := time.Now()
+	return &models.RefreshToken{
+		ID:            uuid.New(),
+		TokenHash:     utils.HashOpaqueToken(secret),
+		FamilyID:      uuid.New(),
+		ClientID:      suite.client.ID,
+		UserID:        uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+		Scope:         "openid profile",
+		CreatedAt:     now.Add(-time.Hour),
+		Expires:       now.Add(time.Hour),
+		FamilyExpires: now.Add(2 * time.Hour),
+	}
+}
+
+func (suite *OAuthBllTestSuite) TestToken_OK_Refresh() {
+	presented := suite.refreshToken("test-refresh-token")
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(3).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(presented.TokenHash).Times(1).Return(presented, nil)
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(presented.UserID).Times(1).Return(&models.User{ID: presented.UserID, Status: models.USER_STATUS_ACTIVE}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.refreshDal.EXPECT().MarkUsed(gomock.Any(), presented.ID, gomock.Any()).Times(1).Return(true, nil)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, token *models.UserToken) error {
+		suite.Equal(presented.UserID, token.UserID)
+		suite.Equal("profile", token.Scope)
+		return nil
+	})
+	var rotated *models.RefreshToken
+	suite.refreshDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, token *models.RefreshToken) error {
+		suite.Equal(presented.FamilyID, token.FamilyID)
+		suite.Equal("openid profile", token.Scope)
+		// capped by the family
+		suite.Equal(presented.FamilyExpires, token.Expires)
+		rotated = token
+		return nil
+	})
+
+	res, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "refresh_token",
+		RefreshToken:        "test-refresh-token",
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		Scope:               "profile",
+	})
+	suite.NoError(err)
+	suite.NotEmpty(res.AccessToken)
+	suite.Equal("profile", res.Scope)
+	suite.Empty(res.IDToken)
+	suite.Equal(rotated.TokenHash, utils.HashOpaqueToken(res.RefreshToken))
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_RefreshTokenReused() {
+	presented := suite.refreshToken("test-refresh-token")
+	usedAt := time.Now().Add(-time.Minute)
+	presented.UsedAt = &usedAt
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(2).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(presented.TokenHash).Times(1).Return(presented, nil)
+	suite.refreshDal.EXPECT().RevokeFamily(nil, presented.FamilyID, gomock.Any()).Times(1).Return(int64(2), nil)
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "refresh_token",
+		RefreshToken:        "test-refresh-token",
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_GRANT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_RefreshTokenUsedConcurrently() {
+	presented := suite.refreshToken("test-refresh-token")
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(3).Return(suite.refreshDal)
+	suite.refreshDal.EXPECT().GetByHash(presented.TokenHash).Times(1).Return(presented, nil)
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(presented.UserID).Times(1).Return(&models.User{ID: presented.UserID, Status: models.USER_STATUS_ACTIVE}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.refreshDal.EXPECT().MarkUsed(gomock.Any(), presented.ID, gomock.Any()).Times(1).Return(false, nil)
+	suite.refreshDal.EXPECT().RevokeFamily(nil, presented.FamilyID, gomock.Any()).Times(1).Return(int64(2), nil)
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "refresh_token",
+		RefreshToken:        "test-refresh-token",
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_GRANT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_RefreshClientPaused() {
+	suite.client.Status = models.CLIENT_STATUS_PAUSED
+	// inactive clients fail authentication before their keys are looked up
+	suite.expectClient()
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "refresh_token",
+		RefreshToken:        "test-refresh-token",
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_CLIENT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_CodeClientPaused() {
+	suite.client.Status = models.CLIENT_STATUS_PAUSED
+	// the code is not consumed, so it can still be redeemed if the client is reactivated before it expires
+	suite.expectClient()
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        suite.verifier,
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_CLIENT, oerr.Code)
+	suite.Equal("client is not active", oerr.Description)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_RefreshWithoutAssertion() {
+	// a leaked refresh token is useless without the client's key; neither the client nor the token is looked up
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:    "refresh_token",
+		RefreshToken: "test-refresh-token",
+		ClientID:     suite.client.ID.String(),
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_CLIENT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_RefreshInvalid() {
+	for name, modify := range map[string]func(*models.RefreshToken){
+		"expired":      func(t *models.RefreshToken) { t.Expires = time.Now().Add(-time.Minute) },
+		"otherClient":  func(t *models.RefreshToken) { t.ClientID = uuid.New() },
+		"revoked":      func(t *models.RefreshToken) { now := time.Now(); t.RevokedAt = &now },
+		"widenedScope": func(t *models.RefreshToken) { t.Scope = "openid" },
+	} {
+		presented := suite.refreshToken("test-refresh-token")
+		modify(presented)
+		suite.expectClientAuthenticated()
+		suite.dal.EXPECT().RefreshTokens(gomock.Any()).Times(1).Return(suite.refreshDal)
+		suite.refreshDal.EXPECT().GetByHash(presented.TokenHash).Times(1).Return(presented, nil)
+
+		_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+			GrantType:           "refresh_token",
+			RefreshToken:        "test-refresh-token",
+			ClientID:            suite.client.ID.String(),
+			ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+			ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+			Scope:               "profile",
+		})
+		var oerr *OAuthError
+		suite.ErrorAs(err, &oerr, name)
+		suite.Contains([]OAuthErrorCode{OAUTH_ERR_INVALID_GRANT, OAUTH_ERR_INVALID_SCOPE}, oerr.Code, name)
+	}
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_UnsupportedGrant() {
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{GrantType: "password"})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_UNSUPPORTED_GRANT_TYPE, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_CodeReused() {
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(nil, pgx.ErrNoRows)
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        suite.verifier,
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_GRANT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_RedirectURIMismatch() {
+	// the mismatching redirect_uri is part of the consuming query, so the code is left for its real client
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, "http://localhost:3031/other").Times(1).Return(nil, pgx.ErrNoRows)
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         "http://localhost:3031/other",
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        suite.verifier,
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_GRANT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_BadVerifier() {
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(&models.AuthorizationCode{
+		ClientID:            suite.client.ID,
+		RedirectURI:         suite.client.RedirectURIs[0],
+		CodeChallenge:       utils.GeneratePKCEChallengeS256(suite.verifier),
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+	}, nil)
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        strings.Repeat("x", 43),
+	})
+	var oerr *OAuthError
+	suite.ErrorAs(err, &oerr)
+	suite.Equal(OAUTH_ERR_INVALID_GRANT, oerr.Code)
+}
+
+func (suite *OAuthBllTestSuite) TestToken_FAIL_Expired() {
+	suite.expectClientAuthenticated()
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).Times(1).Return(suite.codesDal)
+	suite.codesDal.EXPECT().Consume(gomock.Any(), suite.client.ID, suite.client.RedirectURIs[0]).Times(1).Return(&models.AuthorizationCode{
+		ClientID:    suite.client.ID,
+		RedirectURI: suite.client.RedirectURIs[0],
+		Expires:     time.Now().Add(-time.Minute),
+	}, nil)
+
+	_, err := suite.bll.OAuth(suite.ctx).Token(&dto.TokenRequest{
+		GrantType:           "authorization_code",
+		Code:                "test-code",
+		RedirectURI:         suite.client.RedirectURIs[0],
+		ClientID:            suite.client.ID.String(),
+		ClientAssertionType: OAUTH_CLIENT_ASSERTION_TYPE_JWT_BEARER,
+		ClientAssertion:     suite.clientAssertion(suite.clientKey, nil),
+		CodeVerifier:        suite.verifier,
+	})
+	suite.Error(err)
+	suite.ErrorContains(err, "expired")
+}
+
+func (suite *OAuthBllTestSuite) TestNormalizeScope() {
+	suite.Equal("openid profile", normalizeScope(" openid  profile openid "))
+	suite.Equal("", normalizeScope(""))
+}
+
+func (suite *OAuthBllTestSuite) TestOAuthError() {
+	var err error = newOAuthError(OAUTH_ERR_INVALID_GRANT, "test")
+	var oerr *OAuthError
+	suite.True(errors.As(err, &oerr))
+	suite.Equal("invalid_grant: test", err.Error())
+}
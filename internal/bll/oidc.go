@@ -0,0 +1,208 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/typeconv"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const idTokenTTL = time.Hour
+
+const (
+	OIDC_SCOPE_OPENID  = "openid"
+	OIDC_SCOPE_PROFILE = "profile"
+	OIDC_SCOPE_EMAIL   = "email"
+)
+
+// Authentication method references (RFC 8176); every login is a passkey, i.e. proof of possession of a key
+// held by an authenticator, so hwk is always present
+const (
+	OIDC_AMR_HARDWARE_KEY = "hwk"
+	OIDC_AMR_USER         = "user"
+)
+
+// Authentication context classes; phrh is used when the passkey lives on a roaming hardware authenticator
+const (
+	OIDC_ACR_PHR  = "phr"
+	OIDC_ACR_PHRH = "phrh"
+)
+
+var oidcScopesSupported = []string{OIDC_SCOPE_OPENID, OIDC_SCOPE_PROFILE, OIDC_SCOPE_EMAIL}
+
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string   `json:"nonce,omitempty"`
+	AuthTime          int64    `json:"auth_time,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	AMR               []string `json:"amr,omitempty"`
+	ACR               string   `json:"acr,omitempty"`
+}
+
+type OIDCBLL interface {
+	Discovery() *dto.DiscoveryResponse
+	JWKS() dto.JWKSResponse
+	CreateIDToken(*models.AuthorizationCode) (string, error)
+	UserInfo(string) (*dto.UserInfoResponse, error)
+}
+type oidcBLL struct {
+	ctx context.Context
+	*BaseBLL
+}
+
+func NewOIDCBLL(ctx context.Context, baseBLL *BaseBLL) OIDCBLL {
+	oBll := &oidcBLL{
+		ctx:     ctx,
+		BaseBLL: baseBLL,
+	}
+	oBll.logger = baseBLL.logger.With().Str("subcomponent", "OIDCBLL").Logger()
+	return oBll
+}
+
+func (o *oidcBLL) Discovery() *dto.DiscoveryResponse {
+	issuer := o.issuer()
+	return &dto.DiscoveryResponse{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth2/authorize",
+		TokenEndpoint:                     issuer + "/oauth2/token",
+		IntrospectionEndpoint:             issuer + "/oauth2/introspect",
+		RevocationEndpoint:                issuer + "/oauth2/revoke",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   oidcScopesSupported,
+		ResponseTypesSupported:            []string{OAUTH_RESPONSE_TYPE_CODE},
+		GrantTypesSupported:               []string{OAUTH_GRANT_TYPE_AUTHORIZATION_CODE, OAUTH_GRANT_TYPE_REFRESH_TOKEN},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  o.signer.Algorithms(),
+		TokenEndpointAuthMethodsSupported: []string{OAUTH_CLIENT_AUTH_METHOD_PRIVATE_KEY_JWT},
+		TokenEndpointAuthSigningAlgValuesSupported: clientAssertionSigningAlgs,
+		IntrospectionEndpointAuthMethodsSupported:  []string{OAUTH_CLIENT_AUTH_METHOD_PRIVATE_KEY_JWT},
+		RevocationEndpointAuthMethodsSupported:     []string{OAUTH_CLIENT_AUTH_METHOD_PRIVATE_KEY_JWT},
+		CodeChallengeMethodsSupported:              []string{string(models.CODE_CHALLENGE_METHOD_S256)},
+		ClaimsSupported:                            []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "amr", "acr", "preferred_username", "name", "email"},
+		ACRValuesSupported:                         []string{OIDC_ACR_PHR, OIDC_ACR_PHRH},
+	}
+}
+
+func (o *oidcBLL) JWKS() dto.JWKSResponse {
+	return o.signer.JWKS()
+}
+
+// CreateIDToken builds and signs the ID token for a consumed authorization code
+func (o *oidcBLL) CreateIDToken(authCode *models.AuthorizationCode) (string, error) {
+	const funcName = "CreateIDToken"
+
+	if authCode == nil {
+		return "", errors.New("missing authorization code")
+	}
+
+	user, err := o.dal.User(o.ctx).GetByID(authCode.UserID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("userID", authCode.UserID.String()).Msg("failed to get user")
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    o.issuer(),
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{authCode.ClientID.String()},
+			ExpiresAt: jwt.NewNumericDate(now.Add(idTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Nonce:    authCode.Nonce,
+		AuthTime: authCode.AuthTime.Unix(),
+		AMR:      authCode.AMR,
+		ACR:      authCode.ACR,
+	}
+
+	scopes := strings.Fields(authCode.Scope)
+	if slices.Contains(scopes, OIDC_SCOPE_PROFILE) {
+		claims.PreferredUsername = user.Username
+		claims.Name = user.DisplayName
+	}
+	if slices.Contains(scopes, OIDC_SCOPE_EMAIL) && user.Email != nil {
+		claims.Email = *user.Email
+	}
+
+	return o.signer.Sign(claims)
+}
+
+func (o *oidcBLL) UserInfo(accessToken string) (*dto.UserInfoResponse, error) {
+	const funcName = "UserInfo"
+
+	if accessToken == "" {
+		return nil, errors.New("invalid access token")
+	}
+	token, err := o.dal.UserTokens(o.ctx).GetByHashWithUser(utils.HashOpaqueToken(accessToken))
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to get access token")
+		return nil, errors.New("invalid access token")
+	}
+	if token.ClientID == nil {
+		o.logger.Error().Str("func", funcName).Msg("browser sessions cannot be used as access tokens")
+		return nil, errors.New("invalid access token")
+	}
+	if !time.Now().Before(token.Expires) {
+		o.logger.Error().Str("func", funcName).Msg("access token expired")
+		return nil, errors.New("access token expired")
+	}
+	scopes := strings.Fields(token.Scope)
+	if !slices.Contains(scopes, OIDC_SCOPE_OPENID) {
+		o.logger.Error().Str("func", funcName).Msg("access token missing openid scope")
+		return nil, errors.New("insufficient scope")
+	}
+
+	user, err := o.dal.User(o.ctx).GetByID(token.UserID)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Str("userID", token.UserID.String()).Msg("failed to get user")
+		return nil, errors.New("failed to get user")
+	}
+	if user.Status != models.USER_STATUS_ACTIVE {
+		o.logger.Error().Str("func", funcName).Str("userID", token.UserID.String()).Msg("user is not active")
+		return nil, errors.New("user is not active")
+	}
+
+	me, err := typeconv.ConvertUserToMeResponse(user)
+	if err != nil {
+		o.logger.Err(err).Str("func", funcName).Msg("failed to convert user")
+		return nil, errors.New("failed to get user")
+	}
+
+	return typeconv.ConvertMeResponseToUserInfoResponse(me, scopes)
+}
+
+// issuer is the configured OIDC issuer, falling back to the first RP origin
+func (b *BaseBLL) issuer() string {
+	issuer := b.vars.MangroveOIDCIssuer
+	if issuer == "" && len(b.vars.MangroveWebauthnRPOrigins) > 0 {
+		issuer = b.vars.MangroveWebauthnRPOrigins[0]
+	}
+	return strings.TrimSuffix(issuer, "/")
+}
+
+// credentialAuthContext derives the amr and acr values from the passkey used to log in: amr is hwk, plus
+// user when the authenticator verified the user (FlagVerified); acr is phrh for roaming authenticators
+// (AuthAttachment cross-platform) and phr otherwise. The mapping is documented in the README.
+func credentialAuthContext(cred *models.UserCredential) ([]string, string) {
+	amr := []string{OIDC_AMR_HARDWARE_KEY}
+	acr := OIDC_ACR_PHR
+	if cred.AuthAttachment == protocol.CrossPlatform {
+		acr = OIDC_ACR_PHRH
+	}
+	if cred.FlagVerified {
+		amr = append(amr, OIDC_AMR_USER)
+	}
+	return amr, acr
+}
@@ -0,0 +1,195 @@
+package bll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	signerMocks "github.com/asatraitis/mangrove/internal/service/signer/mocks"
+	"github.com/asatraitis/mangrove/internal/service/webauthn"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type OIDCBllTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal          *mocks.MockDAL
+	userDal      *mocks.MockUserDAL
+	userTokenDal *mocks.MockUserTokensDAL
+	signer       *signerMocks.MockSigner
+	bll          BLL
+
+	userID   uuid.UUID
+	clientID uuid.UUID
+	email    string
+}
+
+func TestOIDCBllTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCBllTestSuite))
+}
+
+func (suite *OIDCBllTestSuite) SetupSuite() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.userDal = mocks.NewMockUserDAL(suite.Ctrl)
+	suite.userTokenDal = mocks.NewMockUserTokensDAL(suite.Ctrl)
+	suite.signer = signerMocks.NewMockSigner(suite.Ctrl)
+
+	logger := zerolog.Nop()
+	vars := configs.NewConf(logger).GetEnvironmentVars()
+	vars.MangroveSalt = "testsalt"
+	vars.MangroveOIDCIssuer = "https://id.example.com/"
+	wauthn, err := webauthn.NewWebAuthN(context.Background(), &wa.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, logger)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	appConfig := config.NewConfig(context.Background(), logger)
+	suite.bll = NewBLL(logger, vars, appConfig, wauthn, suite.signer, nil, suite.dal)
+}
+func (suite *OIDCBllTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.userID = uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	suite.clientID = uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508")
+	suite.email = "test@email.com"
+}
+func (suite *OIDCBllTestSuite) TearDownTest() {}
+
+const testAccessToken = "test-access-token"
+
+func (suite *OIDCBllTestSuite) expectToken(token *models.UserToken) {
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken(testAccessToken)).Times(1).Return(token, nil)
+}
+
+func (suite *OIDCBllTestSuite) TestDiscovery() {
+	suite.signer.EXPECT().Algorithms().Times(1).Return([]string{"EdDSA", "ES256"})
+
+	res := suite.bll.OIDC(suite.ctx).Discovery()
+	suite.Equal("https://id.example.com", res.Issuer)
+	suite.Equal("https://id.example.com/oauth2/token", res.TokenEndpoint)
+	suite.Equal("https://id.example.com/oauth2/introspect", res.IntrospectionEndpoint)
+	suite.Equal("https://id.example.com/oauth2/revoke", res.RevocationEndpoint)
+	suite.Equal([]string{"private_key_jwt"}, res.TokenEndpointAuthMethodsSupported)
+	suite.Equal([]string{"private_key_jwt"}, res.IntrospectionEndpointAuthMethodsSupported)
+	suite.Equal("https://id.example.com/.well-known/jwks.json", res.JWKSURI)
+	suite.Equal([]string{"EdDSA", "ES256"}, res.IDTokenSigningAlgValuesSupported)
+	suite.Contains(res.ScopesSupported, "openid")
+}
+
+func (suite *OIDCBllTestSuite) TestJWKS() {
+	suite.signer.EXPECT().JWKS().Times(1).Return(dto.JWKSResponse{Keys: []dto.JWK{{Kty: "OKP", Kid: "test-kid"}}})
+
+	res := suite.bll.OIDC(suite.ctx).JWKS()
+	suite.Len(res.Keys, 1)
+	suite.Equal("test-kid", res.Keys[0].Kid)
+}
+
+func (suite *OIDCBllTestSuite) TestUserInfo_OK() {
+	token := &models.UserToken{
+		ID:       uuid.New(),
+		UserID:   suite.userID,
+		Expires:  time.Now().Add(time.Hour),
+		ClientID: &suite.clientID,
+		Scope:    "openid email",
+	}
+	suite.expectToken(token)
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(suite.userID).Times(1).Return(&models.User{
+		ID:          suite.userID,
+		Username:    "test-user",
+		DisplayName: "Test User",
+		Email:       &suite.email,
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	}, nil)
+
+	res, err := suite.bll.OIDC(suite.ctx).UserInfo(testAccessToken)
+	suite.NoError(err)
+	suite.Equal(suite.userID.String(), res.Sub)
+	suite.Equal(&suite.email, res.Email)
+	suite.Empty(res.PreferredUsername)
+}
+
+func (suite *OIDCBllTestSuite) TestUserInfo_FAIL_BrowserSession() {
+	token := &models.UserToken{
+		ID:      uuid.New(),
+		UserID:  suite.userID,
+		Expires: time.Now().Add(time.Hour),
+	}
+	suite.expectToken(token)
+
+	_, err := suite.bll.OIDC(suite.ctx).UserInfo(testAccessToken)
+	suite.Error(err)
+}
+
+func (suite *OIDCBllTestSuite) TestUserInfo_FAIL_NoOpenIDScope() {
+	token := &models.UserToken{
+		ID:       uuid.New(),
+		UserID:   suite.userID,
+		Expires:  time.Now().Add(time.Hour),
+		ClientID: &suite.clientID,
+		Scope:    "profile",
+	}
+	suite.expectToken(token)
+
+	_, err := suite.bll.OIDC(suite.ctx).UserInfo(testAccessToken)
+	suite.ErrorContains(err, "scope")
+}
+
+func (suite *OIDCBllTestSuite) TestUserInfo_FAIL_Expired() {
+	token := &models.UserToken{
+		ID:       uuid.New(),
+		UserID:   suite.userID,
+		Expires:  time.Now().Add(-time.Minute),
+		ClientID: &suite.clientID,
+		Scope:    "openid",
+	}
+	suite.expectToken(token)
+
+	_, err := suite.bll.OIDC(suite.ctx).UserInfo(testAccessToken)
+	suite.ErrorContains(err, "expired")
+}
+
+func (suite *OIDCBllTestSuite) TestUserInfo_FAIL_UnknownToken() {
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(gomock.Any()).Times(1).Return(nil, pgx.ErrNoRows)
+
+	_, err := suite.bll.OIDC(suite.ctx).UserInfo(uuid.NewString())
+	suite.Error(err)
+
+	_, err = suite.bll.OIDC(suite.ctx).UserInfo("")
+	suite.Error(err)
+}
+
+func (suite *OIDCBllTestSuite) TestCredentialAuthContext() {
+	amr, acr := credentialAuthContext(&models.UserCredential{AuthAttachment: protocol.Platform, FlagVerified: true})
+	suite.Equal([]string{"hwk", "user"}, amr)
+	suite.Equal("phr", acr)
+
+	amr, acr = credentialAuthContext(&models.UserCredential{AuthAttachment: protocol.CrossPlatform, FlagVerified: true})
+	suite.Equal([]string{"hwk", "user"}, amr)
+	suite.Equal("phrh", acr)
+
+	amr, acr = credentialAuthContext(&models.UserCredential{AuthAttachment: protocol.CrossPlatform})
+	suite.Equal([]string{"hwk"}, amr)
+	suite.Equal("phrh", acr)
+}
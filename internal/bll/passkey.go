@@ -0,0 +1,222 @@
+package bll
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/typeconv"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+const passkeyNameMaxLen = 64
+
+// PasskeyBLL manages the passkeys of the signed in user
+type PasskeyBLL interface {
+	GetAll() (dto.PasskeysResponse, error)
+	BeginEnrollment() (*protocol.CredentialCreation, error)
+	FinishEnrollment(dto.FinishPasskeyEnrollmentRequest) (*dto.Passkey, error)
+	Rename(string, dto.RenamePasskeyRequest) error
+	Delete(string) error
+}
+type passkeyBLL struct {
+	ctx context.Context
+	*BaseBLL
+}
+
+func NewPasskeyBLL(ctx context.Context, baseBLL *BaseBLL) PasskeyBLL {
+	pBll := &passkeyBLL{
+		ctx:     ctx,
+		BaseBLL: baseBLL,
+	}
+	pBll.logger = baseBLL.logger.With().Str("subcomponent", "PasskeyBLL").Logger()
+	return pBll
+}
+
+func (p *passkeyBLL) GetAll() (dto.PasskeysResponse, error) {
+	const funcName = "GetAll"
+
+	userID, err := utils.GetUserIdFromCtx(p.ctx)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to retrieve userID from context")
+		return nil, err
+	}
+
+	credentials, err := p.dal.UserCredentials(p.ctx).GetByUserID(userID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user credentials from db")
+		return nil, errors.New("failed to get passkeys")
+	}
+	if credentials == nil {
+		credentials = []*models.UserCredential{}
+	}
+
+	res, err := typeconv.ConvertUserCredentialsToPasskeysResponse(credentials)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to typeconv user credentials")
+		return nil, errors.New("failed to get passkeys")
+	}
+	return res, nil
+}
+
+// BeginEnrollment starts registering an additional passkey; the user's current passkeys are excluded
+func (p *passkeyBLL) BeginEnrollment() (*protocol.CredentialCreation, error) {
+	const funcName = "BeginEnrollment"
+
+	userID, err := utils.GetUserIdFromCtx(p.ctx)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to retrieve userID from context")
+		return nil, err
+	}
+
+	user, err := p.dal.User(p.ctx).GetByIdWithCredentials(userID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user from db")
+		return nil, errors.New("failed to begin passkey enrollment")
+	}
+
+	creds, err := p.webauthn.BeginRegistration(user)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to generate registration options")
+		return nil, errors.New("failed to begin passkey enrollment")
+	}
+	return creds, nil
+}
+
+// FinishEnrollment stores the new passkey for the signed in user. The webauthn session is looked up
+// by the user's own ID, so a credential can only be enrolled for the user that began the enrollment.
+func (p *passkeyBLL) FinishEnrollment(req dto.FinishPasskeyEnrollmentRequest) (*dto.Passkey, error) {
+	const funcName = "FinishEnrollment"
+
+	userID, err := utils.GetUserIdFromCtx(p.ctx)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to retrieve userID from context")
+		return nil, err
+	}
+
+	name := models.USER_CREDENTIAL_DEFAULT_NAME
+	if strings.TrimSpace(req.Name) != "" {
+		name, err = validatePasskeyName(req.Name)
+		if err != nil {
+			p.logger.Err(err).Str("func", funcName).Msg("failed to validate FinishPasskeyEnrollmentRequest")
+			return nil, err
+		}
+	}
+
+	cred, err := p.webauthn.FinishRegistration(base64.StdEncoding.EncodeToString([]byte(userID.String())), &req.Credential)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to validate passkey")
+		return nil, errors.New("failed to validate passkey")
+	}
+
+	ucred, err := typeconv.ConvertWebauthnCredentialToUserCredential(cred, userID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to typeconv user credential")
+		return nil, errors.New("failed to enroll passkey")
+	}
+	ucred.Name = name
+	ucred.CreatedAt = time.Now()
+
+	err = p.dal.UserCredentials(p.ctx).Create(nil, ucred)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to create user credential in db")
+		return nil, errors.New("failed to enroll passkey")
+	}
+	p.logger.Info().Str("func", funcName).Str("userID", userID.String()).Msg("passkey enrolled")
+
+	return typeconv.ConvertUserCredentialToPasskey(ucred)
+}
+
+func (p *passkeyBLL) Rename(ID string, req dto.RenamePasskeyRequest) error {
+	const funcName = "Rename"
+
+	userID, err := utils.GetUserIdFromCtx(p.ctx)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to retrieve userID from context")
+		return err
+	}
+	credentialID, err := decodePasskeyID(ID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to decode passkey ID")
+		return err
+	}
+	name, err := validatePasskeyName(req.Name)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to validate RenamePasskeyRequest")
+		return err
+	}
+
+	renamed, err := p.dal.UserCredentials(p.ctx).Rename(userID, credentialID, name)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to rename user credential in db")
+		return errors.New("failed to rename passkey")
+	}
+	if !renamed {
+		return errors.New("passkey not found")
+	}
+	return nil
+}
+
+// Delete removes one of the user's passkeys; the last one cannot be removed, it is the only way back in
+func (p *passkeyBLL) Delete(ID string) error {
+	const funcName = "Delete"
+
+	userID, err := utils.GetUserIdFromCtx(p.ctx)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to retrieve userID from context")
+		return err
+	}
+	credentialID, err := decodePasskeyID(ID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Msg("failed to decode passkey ID")
+		return err
+	}
+
+	credentials, err := p.dal.UserCredentials(p.ctx).GetByUserID(userID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user credentials from db")
+		return errors.New("failed to delete passkey")
+	}
+	if !slices.ContainsFunc(credentials, func(c *models.UserCredential) bool { return bytes.Equal(c.ID, credentialID) }) {
+		return errors.New("passkey not found")
+	}
+
+	// the DAL re-checks under lock; another passkey may have been deleted since the list was read
+	deleted, err := p.dal.UserCredentials(p.ctx).DeleteUnlessLast(userID, credentialID)
+	if err != nil {
+		p.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to delete user credential in db")
+		return errors.New("failed to delete passkey")
+	}
+	if !deleted {
+		return errors.New("cannot delete the last passkey")
+	}
+	p.logger.Info().Str("func", funcName).Str("userID", userID.String()).Msg("passkey deleted")
+	return nil
+}
+
+func decodePasskeyID(ID string) ([]byte, error) {
+	credentialID, err := base64.RawURLEncoding.DecodeString(ID)
+	if err != nil || len(credentialID) == 0 {
+		return nil, errors.New("invalid passkey id")
+	}
+	return credentialID, nil
+}
+
+func validatePasskeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("missing name")
+	}
+	if utf8.RuneCountInString(name) > passkeyNameMaxLen {
+		return "", errors.New("name is too long")
+	}
+	return name, nil
+}
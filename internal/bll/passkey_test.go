@@ -0,0 +1,194 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/handler/types"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/asatraitis/mangrove/internal/service/webauthn"
+	"github.com/go-webauthn/webauthn/protocol"
+	wa "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type PasskeyBllTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal           *mocks.MockDAL
+	userDal       *mocks.MockUserDAL
+	credentialDal *mocks.MockUserCredentialsDAL
+	bll           BLL
+
+	userID uuid.UUID
+}
+
+func TestPasskeyBllTestSuite(t *testing.T) {
+	suite.Run(t, new(PasskeyBllTestSuite))
+}
+
+func (suite *PasskeyBllTestSuite) SetupSuite() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.userDal = mocks.NewMockUserDAL(suite.Ctrl)
+	suite.credentialDal = mocks.NewMockUserCredentialsDAL(suite.Ctrl)
+
+	logger := zerolog.Nop()
+	vars := configs.NewConf(logger).GetEnvironmentVars()
+	wauthn, err := webauthn.NewWebAuthN(context.Background(), &wa.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, logger)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.bll = NewBLL(logger, vars, config.NewConfig(context.Background(), logger), wauthn, nil, nil, suite.dal)
+	suite.userID = uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+}
+func (suite *PasskeyBllTestSuite) SetupTest() {
+	suite.ctx = context.WithValue(context.Background(), types.REQ_CTX_KEY_USER_ID, suite.userID.String())
+}
+func (suite *PasskeyBllTestSuite) TearDownTest() {}
+
+func (suite *PasskeyBllTestSuite) newCredential(ID string) *models.UserCredential {
+	return &models.UserCredential{
+		ID:             []byte(ID),
+		UserID:         suite.userID,
+		Transport:      []protocol.AuthenticatorTransport{protocol.USB},
+		AuthAttachment: protocol.CrossPlatform,
+		Name:           models.USER_CREDENTIAL_DEFAULT_NAME,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func (suite *PasskeyBllTestSuite) TestGetAll_OK() {
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByUserID(suite.userID).Times(1).Return([]*models.UserCredential{
+		suite.newCredential("first"),
+		suite.newCredential("second"),
+	}, nil)
+
+	res, err := suite.bll.Passkey(suite.ctx).GetAll()
+	suite.NoError(err)
+	suite.Len(res, 2)
+	suite.Equal("Zmlyc3Q", res[0].ID)
+	suite.Equal([]string{"usb"}, res[0].Transport)
+}
+
+func (suite *PasskeyBllTestSuite) TestGetAll_FailNoUser() {
+	_, err := suite.bll.Passkey(context.Background()).GetAll()
+	suite.Error(err)
+}
+
+func (suite *PasskeyBllTestSuite) TestBeginEnrollment_OK_ExcludesExisting() {
+	user := &models.User{
+		ID:          suite.userID,
+		Username:    "tester",
+		DisplayName: "Tester",
+		Credentials: []*models.UserCredential{suite.newCredential("first"), suite.newCredential("second")},
+	}
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByIdWithCredentials(suite.userID).Times(1).Return(user, nil)
+
+	res, err := suite.bll.Passkey(suite.ctx).BeginEnrollment()
+	suite.NoError(err)
+	suite.Equal("tester", res.Response.User.Name)
+	suite.Equal(protocol.URLEncodedBase64(suite.userID.String()), res.Response.User.ID)
+	suite.Len(res.Response.CredentialExcludeList, 2)
+	suite.Equal(protocol.URLEncodedBase64("first"), res.Response.CredentialExcludeList[0].CredentialID)
+	suite.Equal(protocol.URLEncodedBase64("second"), res.Response.CredentialExcludeList[1].CredentialID)
+}
+
+func (suite *PasskeyBllTestSuite) TestBeginEnrollment_FailUser() {
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByIdWithCredentials(suite.userID).Times(1).Return(nil, errors.New("db error"))
+
+	_, err := suite.bll.Passkey(suite.ctx).BeginEnrollment()
+	suite.ErrorContains(err, "failed to begin passkey enrollment")
+}
+
+func (suite *PasskeyBllTestSuite) TestFinishEnrollment_FailNoSession() {
+	_, err := suite.bll.Passkey(suite.ctx).FinishEnrollment(dto.FinishPasskeyEnrollmentRequest{Name: "YubiKey"})
+	suite.ErrorContains(err, "failed to validate passkey")
+}
+
+func (suite *PasskeyBllTestSuite) TestFinishEnrollment_FailName() {
+	_, err := suite.bll.Passkey(suite.ctx).FinishEnrollment(dto.FinishPasskeyEnrollmentRequest{Name: strings.Repeat("a", passkeyNameMaxLen+1)})
+	suite.ErrorContains(err, "name is too long")
+}
+
+func (suite *PasskeyBllTestSuite) TestRename_OK() {
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().Rename(suite.userID, []byte("first"), "Backup key").Times(1).Return(true, nil)
+
+	err := suite.bll.Passkey(suite.ctx).Rename("Zmlyc3Q", dto.RenamePasskeyRequest{Name: " Backup key "})
+	suite.NoError(err)
+}
+
+func (suite *PasskeyBllTestSuite) TestRename_FailNotFound() {
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().Rename(suite.userID, []byte("first"), "Backup key").Times(1).Return(false, nil)
+
+	err := suite.bll.Passkey(suite.ctx).Rename("Zmlyc3Q", dto.RenamePasskeyRequest{Name: "Backup key"})
+	suite.ErrorContains(err, "passkey not found")
+}
+
+func (suite *PasskeyBllTestSuite) TestRename_FailValidation() {
+	err := suite.bll.Passkey(suite.ctx).Rename("not base64url!", dto.RenamePasskeyRequest{Name: "Backup key"})
+	suite.ErrorContains(err, "invalid passkey id")
+
+	err = suite.bll.Passkey(suite.ctx).Rename("Zmlyc3Q", dto.RenamePasskeyRequest{Name: "  "})
+	suite.ErrorContains(err, "missing name")
+}
+
+func (suite *PasskeyBllTestSuite) TestDelete_OK() {
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(2).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByUserID(suite.userID).Times(1).Return([]*models.UserCredential{
+		suite.newCredential("first"),
+		suite.newCredential("second"),
+	}, nil)
+	suite.credentialDal.EXPECT().DeleteUnlessLast(suite.userID, []byte("first")).Times(1).Return(true, nil)
+
+	err := suite.bll.Passkey(suite.ctx).Delete("Zmlyc3Q")
+	suite.NoError(err)
+}
+
+func (suite *PasskeyBllTestSuite) TestDelete_FailNotFound() {
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByUserID(suite.userID).Times(1).Return([]*models.UserCredential{
+		suite.newCredential("second"),
+	}, nil)
+
+	err := suite.bll.Passkey(suite.ctx).Delete("Zmlyc3Q")
+	suite.ErrorContains(err, "passkey not found")
+}
+
+func (suite *PasskeyBllTestSuite) TestDelete_FailLastPasskey() {
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(2).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByUserID(suite.userID).Times(1).Return([]*models.UserCredential{
+		suite.newCredential("first"),
+	}, nil)
+	suite.credentialDal.EXPECT().DeleteUnlessLast(suite.userID, []byte("first")).Times(1).Return(false, nil)
+
+	err := suite.bll.Passkey(suite.ctx).Delete("Zmlyc3Q")
+	suite.ErrorContains(err, "cannot delete the last passkey")
+}
+
+func (suite *PasskeyBllTestSuite) TestDelete_FailInvalidID() {
+	err := suite.bll.Passkey(suite.ctx).Delete("")
+	suite.ErrorContains(err, "invalid passkey id")
+}
@@ -0,0 +1,314 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/typeconv"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+const roleDescriptionMaxLen = 256
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{2,64}$`)
+
+// builtInRoles are the roles every instance has; they are not stored and cannot be changed
+var builtInRoles = []models.Role{
+	{
+		Name:        models.USER_ROLE_USER,
+		Description: "Signs in to applications",
+		Permissions: []models.Permission{},
+	},
+	{
+		Name:        models.USER_ROLE_ADMIN,
+		Description: "Manages applications and users",
+		Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ, models.PERMISSION_CLIENTS_WRITE, models.PERMISSION_USERS_MANAGE},
+	},
+	{
+		Name:        models.USER_ROLE_SUPERUSER,
+		Description: "Manages the instance",
+		Permissions: models.Permissions,
+	},
+}
+
+type RoleBLL interface {
+	GetAll() (dto.RolesResponse, error)
+	Create(dto.CreateRoleRequest) (*dto.Role, error)
+	Update(string, dto.UpdateRoleRequest) (*dto.Role, error)
+	Delete(string) error
+	// Permissions resolves the permissions of a built-in or custom role
+	Permissions(models.UserRole) ([]models.Permission, error)
+	// CheckGrantable fails unless the user in ctx may assign the role, i.e. holds all its permissions
+	CheckGrantable(models.UserRole) error
+}
+type roleBLL struct {
+	ctx context.Context
+	*BaseBLL
+}
+
+func NewRoleBLL(ctx context.Context, baseBLL *BaseBLL) RoleBLL {
+	rBll := &roleBLL{
+		ctx:     ctx,
+		BaseBLL: baseBLL,
+	}
+	rBll.logger = baseBLL.logger.With().Str("subcomponent", "RoleBLL").Logger()
+	return rBll
+}
+
+// GetAll lists the built-in roles followed by the custom ones
+func (r *roleBLL) GetAll() (dto.RolesResponse, error) {
+	const funcName = "GetAll"
+
+	roles, err := r.dal.Roles(r.ctx).GetAll()
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to get roles from db")
+		return nil, errors.New("failed to get roles")
+	}
+
+	res := dto.RolesResponse{}
+	for _, role := range builtInRoles {
+		converted, err := typeconv.ConvertRoleToRole(&role)
+		if err != nil {
+			r.logger.Err(err).Str("func", funcName).Msg("failed to typeconv role")
+			return nil, errors.New("failed to get roles")
+		}
+		converted.BuiltIn = true
+		res = append(res, *converted)
+	}
+	for _, role := range roles {
+		converted, err := typeconv.ConvertRoleToRole(role)
+		if err != nil {
+			r.logger.Err(err).Str("func", funcName).Msg("failed to typeconv role")
+			return nil, errors.New("failed to get roles")
+		}
+		res = append(res, *converted)
+	}
+	return res, nil
+}
+
+func (r *roleBLL) Create(req dto.CreateRoleRequest) (*dto.Role, error) {
+	const funcName = "Create"
+
+	name := models.UserRole(strings.TrimSpace(string(req.Name)))
+	var err error
+	if !roleNamePattern.MatchString(string(name)) {
+		err = errors.Join(err, errors.New("name must be 2-64 lowercase letters, digits, dashes or underscores"))
+	}
+	if builtInRole(name) != nil {
+		err = errors.Join(err, errors.New("name is taken by a built-in role"))
+	}
+	if len(req.Description) > roleDescriptionMaxLen {
+		err = errors.Join(err, errors.New("description too long"))
+	}
+	permissions, perr := parsePermissions(req.Permissions)
+	err = errors.Join(err, perr)
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to validate CreateRoleRequest")
+		return nil, err
+	}
+	if err = r.checkPermissionsGrantable(permissions); err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to create role")
+		return nil, err
+	}
+
+	if _, err = r.dal.Roles(r.ctx).GetByName(name); err == nil {
+		return nil, errors.New("role already exists")
+	}
+	role := &models.Role{
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissions,
+		CreatedAt:   time.Now(),
+	}
+	err = r.dal.Roles(r.ctx).Create(nil, role)
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", string(name)).Msg("failed to create role in db")
+		return nil, errors.New("failed to create role")
+	}
+	r.logger.Info().Str("func", funcName).Str("role", string(name)).Strs("permissions", permissionStrings(permissions)).Msg("role created")
+
+	return typeconv.ConvertRoleToRole(role)
+}
+
+// Update applies a partial update; the new permissions apply to every user holding the role
+func (r *roleBLL) Update(name string, req dto.UpdateRoleRequest) (*dto.Role, error) {
+	const funcName = "Update"
+
+	role, err := r.getCustomRole(models.UserRole(name))
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to get role")
+		return nil, err
+	}
+	// whoever edits a role must be able to hold it, before and after the change
+	if err = r.checkPermissionsGrantable(role.Permissions); err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to update role")
+		return nil, err
+	}
+
+	if req.Description != nil {
+		if len(*req.Description) > roleDescriptionMaxLen {
+			return nil, errors.New("description too long")
+		}
+		role.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Permissions != nil {
+		permissions, err := parsePermissions(*req.Permissions)
+		if err != nil {
+			r.logger.Err(err).Str("func", funcName).Msg("failed to validate UpdateRoleRequest")
+			return nil, err
+		}
+		if err = r.checkPermissionsGrantable(permissions); err != nil {
+			r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to update role")
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+
+	err = r.dal.Roles(r.ctx).Update(nil, role)
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to update role in db")
+		return nil, errors.New("failed to update role")
+	}
+	r.logger.Info().Str("func", funcName).Str("role", name).Strs("permissions", permissionStrings(role.Permissions)).Msg("role updated")
+
+	return typeconv.ConvertRoleToRole(role)
+}
+
+// Delete removes a custom role that no user or pending invitation holds
+func (r *roleBLL) Delete(name string) error {
+	const funcName = "Delete"
+
+	role, err := r.getCustomRole(models.UserRole(name))
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to get role")
+		return err
+	}
+	if err = r.checkPermissionsGrantable(role.Permissions); err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to delete role")
+		return err
+	}
+
+	deleted, err := r.dal.Roles(r.ctx).Delete(nil, role.Name)
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", name).Msg("failed to delete role in db")
+		return errors.New("failed to delete role")
+	}
+	if !deleted {
+		return errors.New("role is assigned to users or pending invitations")
+	}
+	r.logger.Info().Str("func", funcName).Str("role", name).Msg("role deleted")
+	return nil
+}
+
+func (r *roleBLL) Permissions(name models.UserRole) ([]models.Permission, error) {
+	const funcName = "Permissions"
+
+	if role := builtInRole(name); role != nil {
+		return role.Permissions, nil
+	}
+	role, err := r.dal.Roles(r.ctx).GetByName(name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("unknown role")
+	}
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", string(name)).Msg("failed to get role from db")
+		return nil, errors.New("failed to get role")
+	}
+	return role.Permissions, nil
+}
+
+func (r *roleBLL) CheckGrantable(name models.UserRole) error {
+	permissions, err := r.Permissions(name)
+	if err != nil {
+		return err
+	}
+	if name == models.USER_ROLE_SUPERUSER {
+		role, err := utils.GetUserRoleFromCtx(r.ctx)
+		if err != nil || role != models.USER_ROLE_SUPERUSER {
+			return errors.New("only superadmins can grant the superadmin role")
+		}
+	}
+	return r.checkPermissionsGrantable(permissions)
+}
+
+// checkPermissionsGrantable fails unless the user in ctx holds every one of the permissions, so nobody
+// can hand out more access than they have
+func (r *roleBLL) checkPermissionsGrantable(permissions []models.Permission) error {
+	role, err := utils.GetUserRoleFromCtx(r.ctx)
+	if err != nil {
+		r.logger.Err(err).Msg("failed to get user role from context")
+		return errors.New("failed to validate permissions")
+	}
+	held, err := r.Permissions(role)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, permission := range permissions {
+		if !slices.Contains(held, permission) {
+			missing = append(missing, string(permission))
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("cannot grant permissions you do not hold: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (r *roleBLL) getCustomRole(name models.UserRole) (*models.Role, error) {
+	if builtInRole(name) != nil {
+		return nil, errors.New("built-in roles cannot be changed")
+	}
+	role, err := r.dal.Roles(r.ctx).GetByName(name)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+func builtInRole(name models.UserRole) *models.Role {
+	for i := range builtInRoles {
+		if builtInRoles[i].Name == name {
+			return &builtInRoles[i]
+		}
+	}
+	return nil
+}
+
+// parsePermissions validates the permissions and returns them without duplicates, in the order of
+// models.Permissions
+func parsePermissions(permissions []dto.Permission) ([]models.Permission, error) {
+	var err error
+	for _, permission := range permissions {
+		if !slices.Contains(models.Permissions, models.Permission(permission)) {
+			err = errors.Join(err, errors.New("unknown permission "+string(permission)))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := []models.Permission{}
+	for _, permission := range models.Permissions {
+		if slices.Contains(permissions, dto.Permission(permission)) {
+			parsed = append(parsed, permission)
+		}
+	}
+	return parsed, nil
+}
+
+func permissionStrings(permissions []models.Permission) []string {
+	var s []string
+	for _, permission := range permissions {
+		s = append(s, string(permission))
+	}
+	return s
+}
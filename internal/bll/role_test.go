@@ -0,0 +1,173 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/handler/types"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type RoleBllTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal      *mocks.MockDAL
+	rolesDal *mocks.MockRolesDAL
+	bll      BLL
+}
+
+func TestRoleBllTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleBllTestSuite))
+}
+
+func (suite *RoleBllTestSuite) SetupSuite() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.rolesDal = mocks.NewMockRolesDAL(suite.Ctrl)
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+
+	logger := zerolog.Nop()
+	vars := configs.NewConf(logger).GetEnvironmentVars()
+	appConfig := config.NewConfig(context.Background(), logger)
+	suite.bll = NewBLL(logger, vars, appConfig, nil, nil, nil, suite.dal)
+}
+func (suite *RoleBllTestSuite) SetupTest() {
+	suite.ctx = context.WithValue(context.Background(), types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_ADMIN)
+}
+func (suite *RoleBllTestSuite) TearDownTest() {}
+
+func (suite *RoleBllTestSuite) TestGetAll_OK() {
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(1).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetAll().Times(1).Return([]*models.Role{
+		{Name: "auditor", Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ}},
+	}, nil)
+
+	roles, err := suite.bll.Role(suite.ctx).GetAll()
+	suite.NoError(err)
+	suite.Len(roles, 4)
+	suite.Equal(dto.USER_ROLE_USER, roles[0].Name)
+	suite.True(roles[0].BuiltIn)
+	suite.Empty(roles[0].Permissions)
+	suite.Equal(dto.USER_ROLE_SUPERUSER, roles[2].Name)
+	suite.Len(roles[2].Permissions, len(models.Permissions))
+	suite.Equal(dto.UserRole("auditor"), roles[3].Name)
+	suite.False(roles[3].BuiltIn)
+}
+
+func (suite *RoleBllTestSuite) TestPermissions() {
+	permissions, err := suite.bll.Role(suite.ctx).Permissions(models.USER_ROLE_ADMIN)
+	suite.NoError(err)
+	suite.Equal([]models.Permission{models.PERMISSION_CLIENTS_READ, models.PERMISSION_CLIENTS_WRITE, models.PERMISSION_USERS_MANAGE}, permissions)
+
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(1).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(&models.Role{Name: "auditor", Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ}}, nil)
+	permissions, err = suite.bll.Role(suite.ctx).Permissions("auditor")
+	suite.NoError(err)
+	suite.Equal([]models.Permission{models.PERMISSION_CLIENTS_READ}, permissions)
+
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(1).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("removed")).Times(1).Return(nil, pgx.ErrNoRows)
+	_, err = suite.bll.Role(suite.ctx).Permissions("removed")
+	suite.ErrorContains(err, "unknown role")
+}
+
+func (suite *RoleBllTestSuite) TestCheckGrantable() {
+	suite.NoError(suite.bll.Role(suite.ctx).CheckGrantable(models.USER_ROLE_ADMIN))
+	suite.ErrorContains(suite.bll.Role(suite.ctx).CheckGrantable(models.USER_ROLE_SUPERUSER), "only superadmins")
+
+	superadmin := context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_SUPERUSER)
+	suite.NoError(suite.bll.Role(superadmin).CheckGrantable(models.USER_ROLE_SUPERUSER))
+
+	suite.ErrorContains(suite.bll.Role(context.Background()).CheckGrantable(models.USER_ROLE_USER), "failed to validate permissions")
+}
+
+func (suite *RoleBllTestSuite) TestCreate_OK() {
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(2).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(nil, pgx.ErrNoRows)
+	suite.rolesDal.EXPECT().Create(nil, gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, role *models.Role) error {
+		// permissions are deduplicated and kept in a stable order
+		suite.Equal([]models.Permission{models.PERMISSION_CLIENTS_READ, models.PERMISSION_USERS_MANAGE}, role.Permissions)
+		suite.NotZero(role.CreatedAt)
+		return nil
+	})
+
+	role, err := suite.bll.Role(suite.ctx).Create(dto.CreateRoleRequest{
+		Name:        "auditor",
+		Description: " Audits ",
+		Permissions: []dto.Permission{dto.PERMISSION_USERS_MANAGE, dto.PERMISSION_CLIENTS_READ, dto.PERMISSION_CLIENTS_READ},
+	})
+	suite.NoError(err)
+	suite.Equal(dto.UserRole("auditor"), role.Name)
+	suite.Equal("Audits", role.Description)
+}
+
+func (suite *RoleBllTestSuite) TestCreate_FAIL() {
+	_, err := suite.bll.Role(suite.ctx).Create(dto.CreateRoleRequest{Name: "Admin!", Permissions: []dto.Permission{"clients:delete"}})
+	suite.ErrorContains(err, "name must be")
+	suite.ErrorContains(err, "unknown permission clients:delete")
+
+	_, err = suite.bll.Role(suite.ctx).Create(dto.CreateRoleRequest{Name: "admin"})
+	suite.ErrorContains(err, "built-in role")
+
+	_, err = suite.bll.Role(suite.ctx).Create(dto.CreateRoleRequest{Name: "operator", Permissions: []dto.Permission{dto.PERMISSION_CONFIG_WRITE}})
+	suite.ErrorContains(err, "cannot grant permissions you do not hold: config:write")
+
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(1).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(&models.Role{Name: "auditor"}, nil)
+	_, err = suite.bll.Role(suite.ctx).Create(dto.CreateRoleRequest{Name: "auditor"})
+	suite.ErrorContains(err, "role already exists")
+}
+
+func (suite *RoleBllTestSuite) TestUpdate_OK() {
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(2).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(&models.Role{Name: "auditor", Description: "Audits", Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ}}, nil)
+	suite.rolesDal.EXPECT().Update(nil, gomock.Any()).Times(1).Return(nil)
+
+	permissions := []dto.Permission{dto.PERMISSION_CLIENTS_READ, dto.PERMISSION_CLIENTS_WRITE}
+	role, err := suite.bll.Role(suite.ctx).Update("auditor", dto.UpdateRoleRequest{Permissions: &permissions})
+	suite.NoError(err)
+	suite.Equal("Audits", role.Description)
+	suite.Equal(permissions, role.Permissions)
+}
+
+func (suite *RoleBllTestSuite) TestUpdate_FAIL() {
+	_, err := suite.bll.Role(suite.ctx).Update("superadmin", dto.UpdateRoleRequest{})
+	suite.ErrorContains(err, "built-in roles cannot be changed")
+
+	// a role holding more than the editor cannot be changed by them
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(1).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("operator")).Times(1).Return(&models.Role{Name: "operator", Permissions: []models.Permission{models.PERMISSION_CONFIG_WRITE}}, nil)
+	description := "Operates"
+	_, err = suite.bll.Role(suite.ctx).Update("operator", dto.UpdateRoleRequest{Description: &description})
+	suite.ErrorContains(err, "cannot grant permissions you do not hold")
+}
+
+func (suite *RoleBllTestSuite) TestDelete() {
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(2).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(&models.Role{Name: "auditor"}, nil)
+	suite.rolesDal.EXPECT().Delete(nil, models.UserRole("auditor")).Times(1).Return(true, nil)
+	suite.NoError(suite.bll.Role(suite.ctx).Delete("auditor"))
+
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(2).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(&models.Role{Name: "auditor"}, nil)
+	suite.rolesDal.EXPECT().Delete(nil, models.UserRole("auditor")).Times(1).Return(false, nil)
+	suite.ErrorContains(suite.bll.Role(suite.ctx).Delete("auditor"), "assigned to users")
+
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(2).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("auditor")).Times(1).Return(&models.Role{Name: "auditor"}, nil)
+	suite.rolesDal.EXPECT().Delete(nil, models.UserRole("auditor")).Times(1).Return(false, errors.New("test"))
+	suite.ErrorContains(suite.bll.Role(suite.ctx).Delete("auditor"), "failed to delete role")
+
+	suite.ErrorContains(suite.bll.Role(suite.ctx).Delete("user"), "built-in roles cannot be changed")
+}
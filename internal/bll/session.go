@@ -0,0 +1,188 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/typeconv"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultSessionIdleTimeout = 24 * time.Hour
+	defaultSessionMaxLifetime = 7 * 24 * time.Hour
+)
+
+// sessionPolicyBounds keeps the session configs within sane limits
+var sessionPolicyBounds = map[dal.ConfigKey][2]time.Duration{
+	dal.CONFIG_SESSION_IDLE_TIMEOUT: {5 * time.Minute, 30 * 24 * time.Hour},
+	dal.CONFIG_SESSION_MAX_LIFETIME: {time.Hour, 365 * 24 * time.Hour},
+}
+
+type SessionBLL interface {
+	// GetAll lists the browser sessions of the user in ctx
+	GetAll() (dto.SessionsResponse, error)
+	// Revoke signs one of the sessions of the user in ctx out
+	Revoke(uuid.UUID) error
+	// Logout ends the session the request was made with
+	Logout() error
+	// RevokeAllForUser signs a user out everywhere; the caller must be able to grant the user's role
+	RevokeAllForUser(uuid.UUID) (*dto.RevokeSessionsResponse, error)
+}
+type sessionBLL struct {
+	ctx context.Context
+	*BaseBLL
+}
+
+func NewSessionBLL(ctx context.Context, baseBLL *BaseBLL) SessionBLL {
+	sBll := &sessionBLL{
+		ctx:     ctx,
+		BaseBLL: baseBLL,
+	}
+	sBll.logger = baseBLL.logger.With().Str("subcomponent", "SessionBLL").Logger()
+	return sBll
+}
+
+func (s *sessionBLL) GetAll() (dto.SessionsResponse, error) {
+	const funcName = "GetAll"
+
+	userID, err := utils.GetUserIdFromCtx(s.ctx)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to get user id from context")
+		return nil, errors.New("failed to get sessions")
+	}
+	current, _ := utils.GetSessionIdFromCtx(s.ctx)
+
+	tokens, err := s.dal.UserTokens(s.ctx).GetSessionsByUserID(userID)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get sessions from db")
+		return nil, errors.New("failed to get sessions")
+	}
+
+	res := dto.SessionsResponse{}
+	for _, token := range tokens {
+		session, err := typeconv.ConvertUserTokenToSession(token)
+		if err != nil {
+			s.logger.Err(err).Str("func", funcName).Msg("failed to typeconv session")
+			return nil, errors.New("failed to get sessions")
+		}
+		session.Current = token.ID == current
+		res = append(res, *session)
+	}
+	return res, nil
+}
+
+func (s *sessionBLL) Revoke(sessionID uuid.UUID) error {
+	const funcName = "Revoke"
+
+	userID, err := utils.GetUserIdFromCtx(s.ctx)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to get user id from context")
+		return errors.New("failed to revoke session")
+	}
+
+	err = s.dal.UserTokens(s.ctx).DeleteSession(nil, userID, sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("session not found")
+	}
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to delete session from db")
+		return errors.New("failed to revoke session")
+	}
+	s.logger.Info().Str("func", funcName).Str("userID", userID.String()).Str("sessionID", sessionID.String()).Msg("session revoked")
+	return nil
+}
+
+func (s *sessionBLL) Logout() error {
+	const funcName = "Logout"
+
+	userID, err := utils.GetUserIdFromCtx(s.ctx)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to get user id from context")
+		return errors.New("failed to logout")
+	}
+	sessionID, err := utils.GetSessionIdFromCtx(s.ctx)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to get session id from context")
+		return errors.New("failed to logout")
+	}
+
+	// a session that is already gone is as good as logged out
+	err = s.dal.UserTokens(s.ctx).DeleteSession(nil, userID, sessionID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		s.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to delete session from db")
+		return errors.New("failed to logout")
+	}
+	return nil
+}
+
+func (s *sessionBLL) RevokeAllForUser(userID uuid.UUID) (*dto.RevokeSessionsResponse, error) {
+	const funcName = "RevokeAllForUser"
+
+	user, err := s.dal.User(s.ctx).GetByID(userID)
+	if err != nil || user == nil {
+		s.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user from db")
+		return nil, errors.New("user not found")
+	}
+	if err = NewRoleBLL(s.ctx, s.BaseBLL).CheckGrantable(user.Role); err != nil {
+		s.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("not allowed to manage the user")
+		return nil, err
+	}
+
+	revoked, err := s.dal.UserTokens(s.ctx).DeleteSessionsByUserID(nil, userID)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to delete sessions from db")
+		return nil, errors.New("failed to revoke sessions")
+	}
+	s.logger.Info().Str("func", funcName).Str("userID", userID.String()).Int64("revoked", revoked).Msg("user sessions revoked")
+	return &dto.RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+// sessionPolicy returns the idle timeout and max lifetime of browser sessions; unset or invalid configs
+// fall back to the defaults
+func (b *BaseBLL) sessionPolicy() (time.Duration, time.Duration) {
+	idle, err := parseSessionDuration(dal.CONFIG_SESSION_IDLE_TIMEOUT, b.configValue(dal.CONFIG_SESSION_IDLE_TIMEOUT))
+	if err != nil {
+		b.logger.Warn().Err(err).Msg("using the default session idle timeout")
+		idle = defaultSessionIdleTimeout
+	}
+	lifetime, err := parseSessionDuration(dal.CONFIG_SESSION_MAX_LIFETIME, b.configValue(dal.CONFIG_SESSION_MAX_LIFETIME))
+	if err != nil {
+		b.logger.Warn().Err(err).Msg("using the default session max lifetime")
+		lifetime = defaultSessionMaxLifetime
+	}
+	return idle, lifetime
+}
+
+func (b *BaseBLL) configValue(key dal.ConfigKey) string {
+	value, _ := b.appConfig.GetConfig(key)
+	return value
+}
+
+// sessionExpiry is when a session used at seenAt expires: after the idle timeout, but never past
+// its max lifetime
+func sessionExpiry(createdAt, seenAt time.Time, idle, lifetime time.Duration) time.Time {
+	expires := seenAt.Add(idle)
+	if deadline := createdAt.Add(lifetime); expires.After(deadline) {
+		return deadline
+	}
+	return expires
+}
+
+func parseSessionDuration(key dal.ConfigKey, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration like 30m or 24h", key)
+	}
+	bounds := sessionPolicyBounds[key]
+	if d < bounds[0] || d > bounds[1] {
+		return 0, fmt.Errorf("%s must be between %s and %s", key, bounds[0], bounds[1])
+	}
+	return d, nil
+}
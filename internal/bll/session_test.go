@@ -0,0 +1,139 @@
+package bll
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/handler/types"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type SessionBllTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal          *mocks.MockDAL
+	userDal      *mocks.MockUserDAL
+	userTokenDal *mocks.MockUserTokensDAL
+	bll          BLL
+
+	userID    uuid.UUID
+	sessionID uuid.UUID
+}
+
+func TestSessionBllTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionBllTestSuite))
+}
+
+func (suite *SessionBllTestSuite) SetupSuite() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.userDal = mocks.NewMockUserDAL(suite.Ctrl)
+	suite.userTokenDal = mocks.NewMockUserTokensDAL(suite.Ctrl)
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+
+	logger := zerolog.Nop()
+	vars := configs.NewConf(logger).GetEnvironmentVars()
+	appConfig := config.NewConfig(context.Background(), logger)
+	suite.bll = NewBLL(logger, vars, appConfig, nil, nil, nil, suite.dal)
+}
+func (suite *SessionBllTestSuite) SetupTest() {
+	suite.userID = uuid.New()
+	suite.sessionID = uuid.New()
+	suite.ctx = context.WithValue(context.Background(), types.REQ_CTX_KEY_USER_ID, suite.userID.String())
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_SESSION_ID, suite.sessionID.String())
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_ADMIN)
+}
+func (suite *SessionBllTestSuite) TearDownTest() {}
+
+func (suite *SessionBllTestSuite) TestGetAll_OK() {
+	now := time.Now()
+	other := uuid.New()
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetSessionsByUserID(suite.userID).Times(1).Return([]*models.UserToken{
+		{ID: suite.sessionID, UserID: suite.userID, Expires: now.Add(time.Hour), CreatedAt: now, IP: "127.0.0.1", UserAgent: "test-agent"},
+		{ID: other, UserID: suite.userID, Expires: now.Add(time.Hour), CreatedAt: now.Add(-time.Hour)},
+	}, nil)
+
+	sessions, err := suite.bll.Session(suite.ctx).GetAll()
+	suite.NoError(err)
+	suite.Len(sessions, 2)
+	suite.True(sessions[0].Current)
+	suite.Equal("127.0.0.1", sessions[0].IP)
+	suite.Equal(other.String(), sessions[1].ID)
+	suite.False(sessions[1].Current)
+}
+
+func (suite *SessionBllTestSuite) TestGetAll_FAIL() {
+	_, err := suite.bll.Session(context.Background()).GetAll()
+	suite.ErrorContains(err, "failed to get sessions")
+
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetSessionsByUserID(suite.userID).Times(1).Return(nil, errors.New("test"))
+	_, err = suite.bll.Session(suite.ctx).GetAll()
+	suite.ErrorContains(err, "failed to get sessions")
+}
+
+func (suite *SessionBllTestSuite) TestRevoke() {
+	sessionID := uuid.New()
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().DeleteSession(nil, suite.userID, sessionID).Times(1).Return(nil)
+	suite.NoError(suite.bll.Session(suite.ctx).Revoke(sessionID))
+
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().DeleteSession(nil, suite.userID, sessionID).Times(1).Return(pgx.ErrNoRows)
+	suite.ErrorContains(suite.bll.Session(suite.ctx).Revoke(sessionID), "session not found")
+}
+
+func (suite *SessionBllTestSuite) TestLogout() {
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().DeleteSession(nil, suite.userID, suite.sessionID).Times(1).Return(nil)
+	suite.NoError(suite.bll.Session(suite.ctx).Logout())
+
+	// already removed, e.g. revoked from another device
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().DeleteSession(nil, suite.userID, suite.sessionID).Times(1).Return(pgx.ErrNoRows)
+	suite.NoError(suite.bll.Session(suite.ctx).Logout())
+
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().DeleteSession(nil, suite.userID, suite.sessionID).Times(1).Return(errors.New("test"))
+	suite.ErrorContains(suite.bll.Session(suite.ctx).Logout(), "failed to logout")
+}
+
+func (suite *SessionBllTestSuite) TestRevokeAllForUser_OK() {
+	target := uuid.New()
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(target).Times(1).Return(&models.User{ID: target, Role: models.USER_ROLE_USER}, nil)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().DeleteSessionsByUserID(nil, target).Times(1).Return(int64(3), nil)
+
+	res, err := suite.bll.Session(suite.ctx).RevokeAllForUser(target)
+	suite.NoError(err)
+	suite.Equal(int64(3), res.Revoked)
+}
+
+func (suite *SessionBllTestSuite) TestRevokeAllForUser_FAIL_NotGrantable() {
+	target := uuid.New()
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(target).Times(1).Return(&models.User{ID: target, Role: models.USER_ROLE_SUPERUSER}, nil)
+
+	_, err := suite.bll.Session(suite.ctx).RevokeAllForUser(target)
+	suite.ErrorContains(err, "only superadmins")
+}
+
+func (suite *SessionBllTestSuite) TestSessionExpiry() {
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.Equal(createdAt.Add(26*time.Hour), sessionExpiry(createdAt, createdAt.Add(2*time.Hour), 24*time.Hour, 7*24*time.Hour))
+	suite.Equal(createdAt.Add(7*24*time.Hour), sessionExpiry(createdAt, createdAt.Add(7*24*time.Hour-time.Hour), 24*time.Hour, 7*24*time.Hour))
+}
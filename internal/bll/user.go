@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/asatraitis/mangrove/internal/dal"
 	"github.com/asatraitis/mangrove/internal/dal/models"
@@ -14,16 +17,36 @@ import (
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+const (
+	usersDefaultLimit = 50
+	usersMaxLimit     = 200
+
+	// sessionLastSeenInterval throttles the last seen updates of a session in use
+	sessionLastSeenInterval = time.Minute
+	sessionUserAgentMaxLen  = 512
+)
+
+var userStatuses = []dto.UserStatus{dto.USER_STATUS_ACTIVE, dto.USER_STATUS_INACTIVE, dto.USER_STATUS_SUSPENDED, dto.USER_STATUS_PENDING}
+
+//go:generate mockgen -destination=./mocks/mock_user.go -package=mocks github.com/asatraitis/mangrove/internal/bll UserBLL
 type UserBLL interface {
 	CreateUserSession() (*protocol.CredentialCreation, error)
 	RegisterSuperAdmin(*dto.FinishRegistrationRequest) error
-	CreateToken(uuid.UUID) (*models.UserToken, error)
+	// CreateToken starts a browser session for the user; ip and userAgent are kept to list the session.
+	// The returned token's Token is the opaque session token for the auth cookie.
+	CreateToken(userID uuid.UUID, ip string, userAgent string) (*models.UserToken, error)
 	GetUserByID(uuid.UUID) (*models.User, error)
-	ValidateTokenAndGetUser(uuid.UUID) (*models.User, error)
+	// ValidateToken returns the browser session, with its user, of an opaque session token. Using a
+	// session extends it; renewed reports that its Expires moved and the cookie needs to follow.
+	ValidateToken(string) (session *models.UserToken, renewed bool, err error)
 	InitLogin(string) (protocol.PublicKeyCredentialRequestOptions, string, error)
 	FinishLogin(*dto.FinishLoginRequest) (*dto.MeResponse, error)
+	GetUsers(dto.UsersRequest) (*dto.UsersResponse, error)
+	GetUser(uuid.UUID) (*dto.User, error)
+	UpdateUser(uuid.UUID, dto.UpdateUserRequest) (*dto.UpdateUserResponse, error)
 }
 type userBLL struct {
 	ctx    context.Context
@@ -44,7 +67,7 @@ func NewUserBLL(ctx context.Context, baseBLL *BaseBLL) UserBLL {
 
 func (u *userBLL) CreateUserSession() (*protocol.CredentialCreation, error) {
 	const funcName string = "CreateUserSession"
-	creds, err := u.webauthn.BeginRegistration()
+	creds, err := u.webauthn.BeginRegistration(nil)
 	if err != nil {
 		u.logger.Err(err).Str("func", funcName).Msg("failed to generate user registration credentials")
 		return nil, err
@@ -98,30 +121,12 @@ func (u *userBLL) RegisterSuperAdmin(req *dto.FinishRegistrationRequest) error {
 		return errors.New("failed to create superadmin")
 	}
 
-	// TODO: add typeconv
-	ucred := &models.UserCredential{
-		ID:                            cred.ID,
-		UserID:                        userUUID,
-		PublicKey:                     cred.PublicKey,
-		AttestationType:               cred.AttestationType,
-		Transport:                     cred.Transport,
-		FlagUserPresent:               cred.Flags.UserPresent,
-		FlagVerified:                  cred.Flags.UserVerified,
-		FlagBackupEligible:            cred.Flags.BackupEligible,
-		FlagBackupState:               cred.Flags.BackupState,
-		AuthAaguid:                    cred.Authenticator.AAGUID,
-		AuthSignCount:                 cred.Authenticator.SignCount,
-		AuthCloneWarning:              cred.Authenticator.CloneWarning,
-		AuthAttachment:                cred.Authenticator.Attachment,
-		AttestationClientDataJson:     cred.Attestation.ClientDataJSON,
-		AttestationDataHash:           cred.Attestation.ClientDataHash,
-		AttestationAuthenticatorData:  cred.Attestation.AuthenticatorData,
-		AttestationPublicKeyAlgorithm: cred.Attestation.PublicKeyAlgorithm,
-		AttestationObject:             cred.Attestation.Object,
-	}
-	if len(cred.Transport) == 0 {
-		ucred.Transport = []protocol.AuthenticatorTransport{}
+	ucred, err := typeconv.ConvertWebauthnCredentialToUserCredential(cred, userUUID)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed to typeconv superadmin credential")
+		return errors.New("failed to create superadmin")
 	}
+	ucred.CreatedAt = time.Now()
 
 	err = u.dal.UserCredentials(u.ctx).Create(tx, ucred)
 	if err != nil {
@@ -149,7 +154,21 @@ func (u *userBLL) RegisterSuperAdmin(req *dto.FinishRegistrationRequest) error {
 	return nil
 }
 
-func (u *userBLL) CreateToken(userID uuid.UUID) (*models.UserToken, error) {
+// truncateUserAgent cuts the header to sessionUserAgentMaxLen bytes on a rune boundary and drops invalid
+// UTF-8, which Postgres would reject in a text column
+func truncateUserAgent(userAgent string) string {
+	userAgent = strings.ToValidUTF8(userAgent, "")
+	if len(userAgent) <= sessionUserAgentMaxLen {
+		return userAgent
+	}
+	end := sessionUserAgentMaxLen
+	for end > 0 && !utf8.RuneStart(userAgent[end]) {
+		end--
+	}
+	return userAgent[:end]
+}
+
+func (u *userBLL) CreateToken(userID uuid.UUID, ip string, userAgent string) (*models.UserToken, error) {
 	const funcName = "CreateToken"
 
 	id, err := uuid.NewV7()
@@ -157,14 +176,26 @@ func (u *userBLL) CreateToken(userID uuid.UUID) (*models.UserToken, error) {
 		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to generate token ID")
 		return nil, errors.New("failed to create user token")
 	}
+	secret, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to generate token")
+		return nil, errors.New("failed to create user token")
+	}
 
+	userAgent = truncateUserAgent(userAgent)
+	idle, lifetime := u.sessionPolicy()
+	now := time.Now()
 	token := &models.UserToken{
-		ID:      id,
-		UserID:  userID,
-		Expires: time.Now().Add(time.Hour * 24),
+		ID:        id,
+		TokenHash: hash,
+		Token:     secret,
+		UserID:    userID,
+		Expires:   sessionExpiry(now, now, idle, lifetime),
+		CreatedAt: now,
+		IP:        ip,
+		UserAgent: userAgent,
 	}
 
-	// TODO: maybe use first 12bits of the v7 uuid to extract date time?
 	err = u.dal.UserTokens(u.ctx).Create(nil, token)
 	if err != nil {
 		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to create token in db")
@@ -190,32 +221,70 @@ func (u *userBLL) GetUserByID(userID uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
-func (u *userBLL) ValidateTokenAndGetUser(tokenID uuid.UUID) (*models.User, error) {
-	const funcName = "ValidateTokenAndGetUser"
+func (u *userBLL) ValidateToken(token string) (*models.UserToken, bool, error) {
+	const funcName = "ValidateToken"
 
-	userToken, err := u.dal.UserTokens(u.ctx).GetByIdWithUser(tokenID)
+	if token == "" {
+		return nil, false, errors.New("failed to validate token")
+	}
+	userToken, err := u.dal.UserTokens(u.ctx).GetByHashWithUser(utils.HashOpaqueToken(token))
 	if err != nil {
-		u.logger.Err(err).Str("func", funcName).Str("tokenID", tokenID.String()).Msg("failed to get user token from db")
-		return nil, errors.New("failed to validate token")
+		u.logger.Err(err).Str("func", funcName).Msg("failed to get user token from db")
+		return nil, false, errors.New("failed to validate token")
 	}
 	// make sure not nil
 	if userToken == nil || userToken.User == nil {
-		u.logger.Err(err).Str("func", funcName).Str("tokenID", tokenID.String()).Msg("returned nil token/user")
-		return nil, errors.New("failed to retrieve user data")
+		u.logger.Err(err).Str("func", funcName).Msg("returned nil token/user")
+		return nil, false, errors.New("failed to retrieve user data")
+	}
+	sessionID := userToken.ID.String()
+
+	// access tokens issued to OAuth clients are not browser sessions
+	if userToken.ClientID != nil {
+		u.logger.Error().Str("func", funcName).Str("sessionID", sessionID).Msg("token belongs to an OAuth client")
+		return nil, false, errors.New("failed to validate token")
 	}
 
-	// check if expired
-	if !time.Now().Before(userToken.Expires) {
-		u.logger.Err(err).Str("func", funcName).Str("tokenID", tokenID.String()).Msg("user token expired")
-		return nil, errors.New("expired token")
+	// check if expired; the max lifetime is checked as well in case it was lowered since the last renewal
+	idle, lifetime := u.sessionPolicy()
+	now := time.Now()
+	if !now.Before(userToken.Expires) || !now.Before(userToken.CreatedAt.Add(lifetime)) {
+		u.logger.Error().Str("func", funcName).Str("sessionID", sessionID).Msg("user token expired")
+		return nil, false, errors.New("expired token")
 	}
 
-	return userToken.User, nil
+	// renewals are throttled so a busy session doesn't write on every request
+	if userToken.LastSeenAt != nil && now.Sub(*userToken.LastSeenAt) < sessionLastSeenInterval {
+		return userToken, false, nil
+	}
+	expires := sessionExpiry(userToken.CreatedAt, now, idle, lifetime)
+	err = u.dal.UserTokens(u.ctx).Touch(userToken.ID, now, expires)
+	if err != nil {
+		// the session is still valid until its current expiry
+		u.logger.Err(err).Str("func", funcName).Str("sessionID", sessionID).Msg("failed to renew session")
+		return userToken, false, nil
+	}
+	renewed := !expires.Equal(userToken.Expires)
+	userToken.LastSeenAt = &now
+	userToken.Expires = expires
+
+	return userToken, renewed, nil
 }
 
+// InitLogin starts a login for the username; without one it starts a usernameless login where the
+// browser offers the user's discoverable passkeys
 func (u *userBLL) InitLogin(username string) (protocol.PublicKeyCredentialRequestOptions, string, error) {
 	const funcName = "InitLogin"
 
+	if strings.TrimSpace(username) == "" {
+		creds, sessionKey, err := u.webauthn.BeginDiscoverableLogin()
+		if err != nil {
+			u.logger.Err(err).Str("func", funcName).Msg("failed to init discoverable login credentials")
+			return protocol.PublicKeyCredentialRequestOptions{}, "", err
+		}
+		return creds.Response, sessionKey, nil
+	}
+
 	user, err := u.dal.User(u.ctx).GetByUsernameWithCredentials(username)
 	if err != nil {
 		u.logger.Err(err).Str("func", funcName).Msg("failed to init login credentials")
@@ -262,35 +331,259 @@ func (u *userBLL) FinishLogin(login *dto.FinishLoginRequest) (*dto.MeResponse, e
 		return nil, err
 	}
 
-	userID, err := uuid.Parse(string(session.UserID))
+	var user *models.User
+	var waCredential *webauthn.Credential
+	var err error
+	// a session without a user ID was started by a usernameless login
+	if len(session.UserID) == 0 {
+		waCredential, user, err = u.webauthn.FinishDiscoverableLogin(login, u.getDiscoverableUser)
+		if err != nil {
+			u.logger.Err(err).Str("func", funcName).Msg("failed webauthn discoverable validation")
+			return nil, err
+		}
+	} else {
+		var userID uuid.UUID
+		userID, err = uuid.Parse(string(session.UserID))
+		if err != nil {
+			u.logger.Err(err).Str("func", funcName).Msg("failed to get user")
+			return nil, err
+		}
+
+		user, err = u.dal.User(u.ctx).GetByIdWithCredentials(userID)
+		if err != nil {
+			u.logger.Err(err).Str("func", funcName).Msg("failed get user from db")
+			return nil, err
+		}
+
+		waCredential, err = u.webauthn.FinishLogin(login, user)
+		if err != nil {
+			u.logger.Err(err).Str("func", funcName).Msg("failed webauthn validation")
+			return nil, err
+		}
+	}
+
+	err = u.dal.UserCredentials(u.ctx).MarkUsed(nil, waCredential.ID, waCredential.Authenticator.SignCount, time.Now())
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed get update credential")
+		return nil, err
+	}
+
+	meResponse, err := typeconv.ConvertUserToMeResponse(user)
 	if err != nil {
-		u.logger.Err(err).Str("func", funcName).Msg("failed to get user")
+		u.logger.Err(err).Str("func", funcName).Msg("failed to typeconv user to me")
 		return nil, err
 	}
 
+	return meResponse, nil
+}
+
+// getDiscoverableUser resolves the user of a usernameless login. The userHandle is the user's UUID
+// (see WebAuthNUser.WebAuthnID) and has to match the owner of the credential the authenticator used.
+func (u *userBLL) getDiscoverableUser(credentialID []byte, userHandle []byte) (*models.User, error) {
+	const funcName = "getDiscoverableUser"
+
+	userID, err := uuid.Parse(string(userHandle))
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed to parse userHandle")
+		return nil, errors.New("invalid user handle")
+	}
+
+	credential, err := u.dal.UserCredentials(u.ctx).GetByID(credentialID)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed to get user credential from db")
+		return nil, errors.New("unknown credential")
+	}
+	if credential.UserID != userID {
+		u.logger.Error().Str("func", funcName).Str("userID", userID.String()).Msg("credential does not belong to the userHandle")
+		return nil, errors.New("unknown credential")
+	}
+
 	user, err := u.dal.User(u.ctx).GetByIdWithCredentials(userID)
 	if err != nil {
-		u.logger.Err(err).Str("func", funcName).Msg("failed get user from db")
-		return nil, err
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed get user from db")
+		return nil, errors.New("unknown user")
 	}
+	return user, nil
+}
+
+// GetUsers returns a page of users matching the request filters
+func (u *userBLL) GetUsers(req dto.UsersRequest) (*dto.UsersResponse, error) {
+	const funcName = "GetUsers"
 
-	waCredential, err := u.webauthn.FinishLogin(login, user)
+	var err error
+	if req.Status != "" && !slices.Contains(userStatuses, req.Status) {
+		err = errors.Join(err, errors.New("wrong status"))
+	}
+	if req.Limit < 0 || req.Offset < 0 {
+		err = errors.Join(err, errors.New("limit and offset cannot be negative"))
+	}
 	if err != nil {
-		u.logger.Err(err).Str("func", funcName).Msg("failed webauthn validation")
+		u.logger.Err(err).Str("func", funcName).Msg("failed to validate UsersRequest")
 		return nil, err
 	}
+	if req.Limit == 0 {
+		req.Limit = usersDefaultLimit
+	}
+	req.Limit = min(req.Limit, usersMaxLimit)
 
-	err = u.dal.UserCredentials(u.ctx).UpdateSignCount(nil, waCredential.ID, waCredential.Authenticator.SignCount)
+	users, total, err := u.dal.User(u.ctx).List(dal.UserFilter{
+		Search: strings.TrimSpace(req.Search),
+		Status: models.UserStatus(req.Status),
+		Role:   models.UserRole(req.Role),
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
 	if err != nil {
-		u.logger.Err(err).Str("func", funcName).Msg("failed get update credential")
+		u.logger.Err(err).Str("func", funcName).Msg("failed to get users from db")
+		return nil, errors.New("failed to get users")
+	}
+	if users == nil {
+		users = []*models.User{}
+	}
+
+	res, err := typeconv.ConvertUsersToUsers(users)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed to typeconv users")
+		return nil, errors.New("failed to get users")
+	}
+	return &dto.UsersResponse{
+		Users:  res,
+		Total:  total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+func (u *userBLL) GetUser(userID uuid.UUID) (*dto.User, error) {
+	const funcName = "GetUser"
+
+	user, err := u.dal.User(u.ctx).GetByID(userID)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user from db")
+		return nil, errors.New("user not found")
+	}
+	return typeconv.ConvertUserToUser(user)
+}
+
+// UpdateUser applies a partial update. Admins can only change users whose role they could grant, and
+// only grant such roles. The last active superadmin cannot be demoted or lose the active status, or
+// nobody would be left to administer the instance.
+func (u *userBLL) UpdateUser(userID uuid.UUID, req dto.UpdateUserRequest) (*dto.UpdateUserResponse, error) {
+	const funcName = "UpdateUser"
+
+	if err := validateUpdateUserReq(req); err != nil {
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to validate UpdateUserRequest")
+		return nil, err
+	}
+	user, err := u.dal.User(u.ctx).GetByID(userID)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to get user from db")
+		return nil, errors.New("user not found")
+	}
+	wasSuperadmin := isActiveSuperadmin(user)
+
+	roles := NewRoleBLL(u.ctx, u.BaseBLL)
+	if err = roles.CheckGrantable(user.Role); err != nil {
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("not allowed to update user")
 		return nil, err
 	}
+	if req.Role != nil {
+		if err = roles.CheckGrantable(models.UserRole(*req.Role)); err != nil {
+			u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("not allowed to grant role")
+			return nil, err
+		}
+	}
 
-	meResponse, err := typeconv.ConvertUserToMeResponse(user)
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Email != nil {
+		user.Email = nil
+		if strings.TrimSpace(*req.Email) != "" {
+			// validated above
+			address, _ := parseEmail(*req.Email)
+			user.Email = &address
+		}
+	}
+	if req.Role != nil {
+		user.Role = models.UserRole(*req.Role)
+	}
+	if req.Status != nil {
+		user.Status = models.UserStatus(*req.Status)
+	}
+
+	tx, err := u.dal.BeginTx(u.ctx)
 	if err != nil {
-		u.logger.Err(err).Str("func", funcName).Msg("failed to typeconv user to me")
+		u.logger.Err(err).Str("func", funcName).Msg("failed to start DB transaction")
+		return nil, errors.New("failed to update user")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(u.ctx)
+		}
+	}()
+
+	if wasSuperadmin && !isActiveSuperadmin(user) {
+		var superadmins int
+		superadmins, err = u.dal.User(u.ctx).CountActiveSuperadmins(tx)
+		if err != nil {
+			u.logger.Err(err).Str("func", funcName).Msg("failed to count superadmins")
+			return nil, errors.New("failed to update user")
+		}
+		if superadmins <= 1 {
+			err = errors.New("cannot demote or deactivate the last active superadmin")
+			u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to update user")
+			return nil, err
+		}
+	}
+
+	err = u.dal.User(u.ctx).Update(tx, user)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("user not found")
+	}
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Str("userID", userID.String()).Msg("failed to update user in db")
+		return nil, errors.New("failed to update user")
+	}
+
+	err = tx.Commit(u.ctx)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed to commit DB transaction")
+		return nil, errors.New("failed to update user")
+	}
+	if req.Role != nil || req.Status != nil {
+		adminID, _ := utils.GetUserIdFromCtx(u.ctx)
+		u.logger.Info().Str("func", funcName).Str("userID", userID.String()).Str("adminID", adminID.String()).Str("role", string(user.Role)).Str("status", string(user.Status)).Msg("user access changed")
+	}
+
+	res, err := typeconv.ConvertUserToUser(user)
+	if err != nil {
+		u.logger.Err(err).Str("func", funcName).Msg("failed to typeconv user")
 		return nil, err
 	}
+	updated := dto.UpdateUserResponse(*res)
+	return &updated, nil
+}
+
+func validateUpdateUserReq(req dto.UpdateUserRequest) error {
+	var err error
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if displayName == "" || len(displayName) > displayNameMaxLen {
+			err = errors.Join(err, errors.New("missing or too long displayName"))
+		}
+	}
+	if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
+		if _, perr := parseEmail(*req.Email); perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+	if req.Status != nil && !slices.Contains(userStatuses, *req.Status) {
+		err = errors.Join(err, errors.New("wrong status"))
+	}
+	return err
+}
 
-	return meResponse, nil
+func isActiveSuperadmin(user *models.User) bool {
+	return user.Role == models.USER_ROLE_SUPERUSER && user.Status == models.USER_STATUS_ACTIVE
 }
@@ -3,16 +3,23 @@ package bll
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal"
 	"github.com/asatraitis/mangrove/internal/dal/mocks"
 	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/handler/types"
 	"github.com/asatraitis/mangrove/internal/service/config"
 	"github.com/asatraitis/mangrove/internal/service/webauthn"
+	"github.com/asatraitis/mangrove/internal/utils"
 	wa "github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
@@ -24,10 +31,12 @@ type UserBllTestSuite struct {
 	Ctrl *gomock.Controller
 	ctx  context.Context
 
-	dal          *mocks.MockDAL
-	userDal      *mocks.MockUserDAL
-	userTokenDal *mocks.MockUserTokensDAL
-	bll          BLL
+	dal           *mocks.MockDAL
+	userDal       *mocks.MockUserDAL
+	userTokenDal  *mocks.MockUserTokensDAL
+	credentialDal *mocks.MockUserCredentialsDAL
+	rolesDal      *mocks.MockRolesDAL
+	bll           BLL
 }
 
 func TestUserBllTestSuite(t *testing.T) {
@@ -38,21 +47,23 @@ func (suite *UserBllTestSuite) SetupSuite() {
 	suite.Ctrl = gomock.NewController(suite.T())
 	suite.userDal = mocks.NewMockUserDAL(suite.Ctrl)
 	suite.userTokenDal = mocks.NewMockUserTokensDAL(suite.Ctrl)
+	suite.credentialDal = mocks.NewMockUserCredentialsDAL(suite.Ctrl)
+	suite.rolesDal = mocks.NewMockRolesDAL(suite.Ctrl)
 	suite.dal = mocks.NewMockDAL(suite.Ctrl)
 
 	logger := zerolog.Nop()
 	vars := configs.NewConf(logger).GetEnvironmentVars()
 	vars.MangroveEnv = "testsalt"
-	wauthn, err := webauthn.NewWebAuthN(&wa.Config{
+	wauthn, err := webauthn.NewWebAuthN(context.Background(), &wa.Config{
 		RPDisplayName: "Mangrove",
 		RPID:          "localhost",
 		RPOrigins:     []string{"http://localhost:3030", "http://localhost:3000"},
-	}, logger)
+	}, nil, logger)
 	if err != nil {
 		suite.T().Fatal(err)
 	}
 	appConfig := config.NewConfig(context.Background(), logger)
-	suite.bll = NewBLL(logger, vars, appConfig, wauthn, suite.dal)
+	suite.bll = NewBLL(logger, vars, appConfig, wauthn, nil, nil, suite.dal)
 }
 func (suite *UserBllTestSuite) SetupTest() {
 	suite.ctx = context.Background()
@@ -94,12 +105,14 @@ func (suite *UserBllTestSuite) TestGetUserByID_FAIL() {
 	suite.Nil(user)
 }
 
-func (suite *UserBllTestSuite) TestValidateTokenAndGetUser_OK() {
-	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
-	suite.userTokenDal.EXPECT().GetByIdWithUser(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a")).Times(1).Return(&models.UserToken{
-		ID:      uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
-		UserID:  uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"),
-		Expires: time.Now().Add(time.Hour * 24),
+func (suite *UserBllTestSuite) TestValidateToken_OK() {
+	createdAt := time.Now().Add(-time.Hour)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(2).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-session-token")).Times(1).Return(&models.UserToken{
+		ID:        uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
+		UserID:    uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"),
+		Expires:   createdAt.Add(time.Hour * 24),
+		CreatedAt: createdAt,
 		User: &models.User{
 			ID:          uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"),
 			Username:    "test-user",
@@ -108,10 +121,22 @@ func (suite *UserBllTestSuite) TestValidateTokenAndGetUser_OK() {
 			Role:        models.UserRole("user"),
 		},
 	}, nil)
+	var expires time.Time
+	suite.userTokenDal.EXPECT().Touch(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ uuid.UUID, seenAt time.Time, e time.Time) error {
+		// slides by the default idle timeout
+		suite.Equal(seenAt.Add(defaultSessionIdleTimeout), e)
+		expires = e
+		return nil
+	})
 
-	user, err := suite.bll.User(suite.ctx).ValidateTokenAndGetUser(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"))
+	session, renewed, err := suite.bll.User(suite.ctx).ValidateToken("test-session-token")
 	suite.NoError(err)
-	suite.NotNil(user)
+	suite.NotNil(session)
+	suite.True(renewed)
+	suite.Equal(expires, session.Expires)
+	suite.NotNil(session.LastSeenAt)
+	suite.Equal("561fe1de-21dd-45a7-91f6-b2d831fe117a", session.ID.String())
+	user := session.User
 	suite.Equal("561fe1de-21dd-45a7-91f6-b2d831fe117b", user.ID.String())
 	suite.Equal("test-user", user.Username)
 	suite.Equal("test display name", user.DisplayName)
@@ -119,9 +144,101 @@ func (suite *UserBllTestSuite) TestValidateTokenAndGetUser_OK() {
 	suite.Equal(models.UserRole("user"), user.Role)
 }
 
-func (suite *UserBllTestSuite) TestValidateTokenAndGetUser_FAIL_ExpiredToken() {
+func (suite *UserBllTestSuite) TestValidateToken_OK_CappedByMaxLifetime() {
+	// an hour of the max lifetime left, less than the idle timeout
+	createdAt := time.Now().Add(-defaultSessionMaxLifetime + time.Hour)
+	lastSeen := time.Now().Add(-time.Hour)
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(2).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-session-token")).Times(1).Return(&models.UserToken{
+		ID:         uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
+		Expires:    time.Now().Add(time.Hour * 2),
+		CreatedAt:  createdAt,
+		LastSeenAt: &lastSeen,
+		User:       &models.User{},
+	}, nil)
+	suite.userTokenDal.EXPECT().Touch(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"), gomock.Any(), createdAt.Add(defaultSessionMaxLifetime)).Times(1).Return(nil)
+
+	session, renewed, err := suite.bll.User(suite.ctx).ValidateToken("test-session-token")
+	suite.NoError(err)
+	suite.True(renewed)
+	suite.Equal(createdAt.Add(defaultSessionMaxLifetime), session.Expires)
+}
+
+func (suite *UserBllTestSuite) TestValidateToken_OK_RecentlySeen() {
+	// renewals are throttled, so no Touch is expected
+	lastSeen := time.Now().Add(-time.Second * 10)
 	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
-	suite.userTokenDal.EXPECT().GetByIdWithUser(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a")).Times(1).Return(&models.UserToken{
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-session-token")).Times(1).Return(&models.UserToken{
+		ID:         uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
+		UserID:     uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"),
+		Expires:    time.Now().Add(time.Hour * 24),
+		CreatedAt:  time.Now().Add(-time.Hour),
+		LastSeenAt: &lastSeen,
+		User: &models.User{
+			ID:     uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"),
+			Status: models.UserStatus("active"),
+			Role:   models.UserRole("user"),
+		},
+	}, nil)
+
+	session, renewed, err := suite.bll.User(suite.ctx).ValidateToken("test-session-token")
+	suite.NoError(err)
+	suite.NotNil(session)
+	suite.False(renewed)
+}
+
+func (suite *UserBllTestSuite) TestValidateToken_OK_TouchFailed() {
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(2).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-session-token")).Times(1).Return(&models.UserToken{
+		ID:        uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
+		Expires:   time.Now().Add(time.Hour),
+		CreatedAt: time.Now().Add(-time.Hour),
+		User:      &models.User{},
+	}, nil)
+	suite.userTokenDal.EXPECT().Touch(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(errors.New("test"))
+
+	session, renewed, err := suite.bll.User(suite.ctx).ValidateToken("test-session-token")
+	suite.NoError(err)
+	suite.NotNil(session)
+	suite.False(renewed)
+}
+
+func (suite *UserBllTestSuite) TestCreateToken_OK() {
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().Create(nil, gomock.Any()).Times(1).Return(nil)
+
+	token, err := suite.bll.User(suite.ctx).CreateToken(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"), "127.0.0.1", "test-agent")
+	suite.NoError(err)
+	suite.Equal("127.0.0.1", token.IP)
+	suite.Equal("test-agent", token.UserAgent)
+	suite.NotZero(token.CreatedAt)
+	// the cookie gets the opaque token, the db only its digest
+	suite.Len(token.Token, 43)
+	suite.Equal(utils.HashOpaqueToken(token.Token), token.TokenHash)
+	suite.Equal(token.CreatedAt.Add(defaultSessionIdleTimeout), token.Expires)
+}
+
+func (suite *UserBllTestSuite) TestTruncateUserAgent() {
+	suite.Equal("test-agent", truncateUserAgent("test-agent"))
+	suite.Equal("test-agent", truncateUserAgent("test-\xffagent"))
+
+	// a two byte rune straddling the limit is dropped instead of split
+	long := strings.Repeat("a", sessionUserAgentMaxLen-1) + "é"
+	truncated := truncateUserAgent(long)
+	suite.Equal(strings.Repeat("a", sessionUserAgentMaxLen-1), truncated)
+	suite.True(utf8.ValidString(truncated))
+	suite.Len(truncateUserAgent(strings.Repeat("a", 2*sessionUserAgentMaxLen)), sessionUserAgentMaxLen)
+}
+
+func (suite *UserBllTestSuite) TestValidateToken_FAIL_Empty() {
+	session, _, err := suite.bll.User(suite.ctx).ValidateToken("")
+	suite.Error(err)
+	suite.Nil(session)
+}
+
+func (suite *UserBllTestSuite) TestValidateToken_FAIL_ExpiredToken() {
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-session-token")).Times(1).Return(&models.UserToken{
 		ID:      uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
 		UserID:  uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117b"),
 		Expires: time.Now().Add(time.Hour * -1),
@@ -134,9 +251,24 @@ func (suite *UserBllTestSuite) TestValidateTokenAndGetUser_FAIL_ExpiredToken() {
 		},
 	}, nil)
 
-	user, err := suite.bll.User(suite.ctx).ValidateTokenAndGetUser(uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"))
+	session, _, err := suite.bll.User(suite.ctx).ValidateToken("test-session-token")
 	suite.Error(err)
-	suite.Nil(user)
+	suite.Nil(session)
+}
+
+func (suite *UserBllTestSuite) TestValidateToken_FAIL_PastMaxLifetime() {
+	// e.g. the max lifetime was lowered after the session was last renewed
+	suite.dal.EXPECT().UserTokens(gomock.Any()).Times(1).Return(suite.userTokenDal)
+	suite.userTokenDal.EXPECT().GetByHashWithUser(utils.HashOpaqueToken("test-session-token")).Times(1).Return(&models.UserToken{
+		ID:        uuid.MustParse("561fe1de-21dd-45a7-91f6-b2d831fe117a"),
+		Expires:   time.Now().Add(time.Hour),
+		CreatedAt: time.Now().Add(-defaultSessionMaxLifetime),
+		User:      &models.User{},
+	}, nil)
+
+	session, _, err := suite.bll.User(suite.ctx).ValidateToken("test-session-token")
+	suite.ErrorContains(err, "expired token")
+	suite.Nil(session)
 }
 
 // TODO: decide on how to unit test webauthn flow
@@ -144,3 +276,197 @@ func (suite *UserBllTestSuite) TestRegisterSuperAdmin_OK() {
 
 }
 func (suite *UserBllTestSuite) TestInitLogin_OK() {}
+
+func (suite *UserBllTestSuite) TestInitLogin_OK_Usernameless() {
+	// no user lookup happens without a username
+	opts, sessionKey, err := suite.bll.User(suite.ctx).InitLogin(" ")
+	suite.NoError(err)
+	suite.NotEmpty(sessionKey)
+	suite.NotEmpty(opts.Challenge)
+	suite.Empty(opts.AllowedCredentials)
+}
+
+func (suite *UserBllTestSuite) TestFinishLogin_FAIL_UsernamelessInvalidCredential() {
+	_, sessionKey, err := suite.bll.User(suite.ctx).InitLogin("")
+	suite.NoError(err)
+
+	_, err = suite.bll.User(suite.ctx).FinishLogin(&dto.FinishLoginRequest{SessionKey: sessionKey})
+	suite.Error(err)
+}
+
+func (suite *UserBllTestSuite) TestGetDiscoverableUser() {
+	userID := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507")
+	credentialID := []byte("test-credential-id")
+	uBll := suite.bll.User(suite.ctx).(*userBLL)
+
+	// OK
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByID(credentialID).Times(1).Return(&models.UserCredential{ID: credentialID, UserID: userID}, nil)
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByIdWithCredentials(userID).Times(1).Return(&models.User{ID: userID}, nil)
+	user, err := uBll.getDiscoverableUser(credentialID, []byte(userID.String()))
+	suite.NoError(err)
+	suite.Equal(userID, user.ID)
+
+	// credential owned by someone else
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByID(credentialID).Times(1).Return(&models.UserCredential{ID: credentialID, UserID: uuid.New()}, nil)
+	_, err = uBll.getDiscoverableUser(credentialID, []byte(userID.String()))
+	suite.ErrorContains(err, "unknown credential")
+
+	// unknown credential
+	suite.dal.EXPECT().UserCredentials(gomock.Any()).Times(1).Return(suite.credentialDal)
+	suite.credentialDal.EXPECT().GetByID(credentialID).Times(1).Return(nil, errors.New("no rows in result set"))
+	_, err = uBll.getDiscoverableUser(credentialID, []byte(userID.String()))
+	suite.ErrorContains(err, "unknown credential")
+
+	// userHandle is not a UUID
+	_, err = uBll.getDiscoverableUser(credentialID, []byte("not-a-uuid"))
+	suite.ErrorContains(err, "invalid user handle")
+}
+
+func (suite *UserBllTestSuite) TestGetUsers_OK() {
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().List(dal.UserFilter{Search: "jane", Status: models.USER_STATUS_SUSPENDED, Limit: usersMaxLimit, Offset: 10}).Times(1).Return([]*models.User{
+		{
+			ID:          uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+			Username:    "jane",
+			DisplayName: "Jane",
+			Status:      models.USER_STATUS_SUSPENDED,
+			Role:        models.USER_ROLE_USER,
+		},
+	}, 11, nil)
+
+	res, err := suite.bll.User(suite.ctx).GetUsers(dto.UsersRequest{Search: " jane ", Status: dto.USER_STATUS_SUSPENDED, Limit: 1000, Offset: 10})
+	suite.NoError(err)
+	suite.Equal(11, res.Total)
+	suite.Equal(usersMaxLimit, res.Limit)
+	suite.Len(res.Users, 1)
+	suite.Equal(dto.USER_STATUS_SUSPENDED, res.Users[0].Status)
+}
+
+func (suite *UserBllTestSuite) TestGetUsers_OK_Empty() {
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().List(dal.UserFilter{Limit: usersDefaultLimit}).Times(1).Return(nil, 0, nil)
+
+	res, err := suite.bll.User(suite.ctx).GetUsers(dto.UsersRequest{})
+	suite.NoError(err)
+	suite.NotNil(res.Users)
+	suite.Empty(res.Users)
+}
+
+func (suite *UserBllTestSuite) TestGetUsers_FAIL_Validation() {
+	_, err := suite.bll.User(suite.ctx).GetUsers(dto.UsersRequest{Status: "deleted", Offset: -1})
+	suite.ErrorContains(err, "wrong status")
+	suite.ErrorContains(err, "cannot be negative")
+}
+
+func (suite *UserBllTestSuite) superadmin(userID uuid.UUID) *models.User {
+	return &models.User{
+		ID:          userID,
+		Username:    "superadmin",
+		DisplayName: "Superadmin",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_SUPERUSER,
+	}
+}
+
+func (suite *UserBllTestSuite) TestUpdateUser_OK_Fields() {
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_ADMIN)
+	userID := uuid.New()
+	displayName := " Jane Doe "
+	email := "jane@email.com"
+	suite.dal.EXPECT().User(gomock.Any()).Times(2).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(userID).Times(1).Return(&models.User{ID: userID, Username: "jane", Status: models.USER_STATUS_PENDING, Role: models.USER_ROLE_USER}, nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.userDal.EXPECT().Update(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, user *models.User) error {
+		suite.Equal("Jane Doe", user.DisplayName)
+		suite.Equal(&email, user.Email)
+		suite.Equal(models.USER_STATUS_ACTIVE, user.Status)
+		return nil
+	})
+
+	status := dto.USER_STATUS_ACTIVE
+	res, err := suite.bll.User(suite.ctx).UpdateUser(userID, dto.UpdateUserRequest{DisplayName: &displayName, Email: &email, Status: &status})
+	suite.NoError(err)
+	suite.Equal("Jane Doe", res.DisplayName)
+	suite.Equal(dto.USER_STATUS_ACTIVE, res.Status)
+}
+
+func (suite *UserBllTestSuite) TestUpdateUser_OK_DemoteWithAnotherSuperadmin() {
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_SUPERUSER)
+	userID := uuid.New()
+	suite.dal.EXPECT().User(gomock.Any()).Times(3).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(userID).Times(1).Return(suite.superadmin(userID), nil)
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.userDal.EXPECT().CountActiveSuperadmins(gomock.Any()).Times(1).Return(2, nil)
+	suite.userDal.EXPECT().Update(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+	role := dto.USER_ROLE_ADMIN
+	res, err := suite.bll.User(suite.ctx).UpdateUser(userID, dto.UpdateUserRequest{Role: &role})
+	suite.NoError(err)
+	suite.Equal(dto.USER_ROLE_ADMIN, res.Role)
+}
+
+func (suite *UserBllTestSuite) TestUpdateUser_FAIL_LastSuperadmin() {
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_SUPERUSER)
+	userID := uuid.New()
+	for _, req := range []dto.UpdateUserRequest{
+		{Role: func() *dto.UserRole { r := dto.USER_ROLE_USER; return &r }()},
+		{Status: func() *dto.UserStatus { s := dto.USER_STATUS_SUSPENDED; return &s }()},
+	} {
+		suite.dal.EXPECT().User(gomock.Any()).Times(2).Return(suite.userDal)
+		suite.userDal.EXPECT().GetByID(userID).Times(1).Return(suite.superadmin(userID), nil)
+		suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+		suite.userDal.EXPECT().CountActiveSuperadmins(gomock.Any()).Times(1).Return(1, nil)
+
+		_, err := suite.bll.User(suite.ctx).UpdateUser(userID, req)
+		suite.ErrorContains(err, "last active superadmin")
+	}
+}
+
+func (suite *UserBllTestSuite) TestUpdateUser_FAIL_NotGrantable() {
+	suite.ctx = context.WithValue(suite.ctx, types.REQ_CTX_KEY_USER_ROLE, models.USER_ROLE_ADMIN)
+	userID := uuid.New()
+
+	// admins cannot change superadmins
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(userID).Times(1).Return(suite.superadmin(userID), nil)
+	displayName := "Renamed"
+	_, err := suite.bll.User(suite.ctx).UpdateUser(userID, dto.UpdateUserRequest{DisplayName: &displayName})
+	suite.ErrorContains(err, "only superadmins can grant the superadmin role")
+
+	// nor grant a custom role with permissions they do not hold
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(userID).Times(1).Return(&models.User{ID: userID, Status: models.USER_STATUS_ACTIVE, Role: models.USER_ROLE_USER}, nil)
+	suite.dal.EXPECT().Roles(gomock.Any()).Times(1).Return(suite.rolesDal)
+	suite.rolesDal.EXPECT().GetByName(models.UserRole("operator")).Times(1).Return(&models.Role{
+		Name:        "operator",
+		Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ, models.PERMISSION_CONFIG_WRITE},
+	}, nil)
+	role := dto.UserRole("operator")
+	_, err = suite.bll.User(suite.ctx).UpdateUser(userID, dto.UpdateUserRequest{Role: &role})
+	suite.ErrorContains(err, "cannot grant permissions you do not hold: config:write")
+}
+
+func (suite *UserBllTestSuite) TestUpdateUser_FAIL_NotFound() {
+	userID := uuid.New()
+	suite.dal.EXPECT().User(gomock.Any()).Times(1).Return(suite.userDal)
+	suite.userDal.EXPECT().GetByID(userID).Times(1).Return(nil, pgx.ErrNoRows)
+
+	_, err := suite.bll.User(suite.ctx).UpdateUser(userID, dto.UpdateUserRequest{})
+	suite.ErrorContains(err, "user not found")
+}
+
+func (suite *UserBllTestSuite) TestValidateUpdateUserReq() {
+	empty := " "
+	badEmail := "Jane <jane@email.com>"
+	status := dto.UserStatus("deleted")
+	err := validateUpdateUserReq(dto.UpdateUserRequest{DisplayName: &empty, Email: &badEmail, Status: &status})
+	suite.ErrorContains(err, "missing or too long displayName")
+	suite.ErrorContains(err, "invalid email")
+	suite.ErrorContains(err, "wrong status")
+
+	// an empty email removes it
+	suite.NoError(validateUpdateUserReq(dto.UpdateUserRequest{Email: &empty}))
+}
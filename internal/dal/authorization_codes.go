@@ -0,0 +1,141 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:generate mockgen -destination=./mocks/mock_authorization_codes.go -package=mocks github.com/asatraitis/mangrove/internal/dal AuthorizationCodesDAL
+type AuthorizationCodesDAL interface {
+	Create(pgx.Tx, *models.AuthorizationCode) error
+	// Consume deletes and returns the code if it was issued to the client for the redirect URI; codes presented
+	// by another client, or with another redirect URI, are left alone and reported as pgx.ErrNoRows
+	Consume(code string, clientID uuid.UUID, redirectURI string) (*models.AuthorizationCode, error)
+	DeleteByClientID(pgx.Tx, uuid.UUID) (int64, error)
+	// DeleteExpired deletes up to limit unredeemed codes that expired before the given time
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type authorizationCodesDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewAuthorizationCodesDAL(ctx context.Context, baseDAL *BaseDAL) AuthorizationCodesDAL {
+	acDAL := &authorizationCodesDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	acDAL.logger = baseDAL.logger.With().Str("subcomponent", "AuthorizationCodesDAL").Logger()
+	return acDAL
+}
+
+func (ac *authorizationCodesDAL) Create(tx pgx.Tx, code *models.AuthorizationCode) error {
+	const funcName = "Create"
+	const query = "INSERT INTO authorization_codes (code, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, expires, nonce, auth_time, amr, acr) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);"
+
+	if code == nil {
+		ac.logger.Error().Str("func", funcName).Msg("nil authorization code")
+		return errors.New("failed to create authorization code; nil code")
+	}
+	args := []interface{}{
+		code.Code,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		code.Scope,
+		code.CodeChallenge,
+		code.CodeChallengeMethod,
+		code.Expires,
+		code.Nonce,
+		code.AuthTime,
+		code.AMR,
+		code.ACR,
+	}
+
+	if tx == nil {
+		_, err := ac.db.Exec(
+			ac.ctx,
+			query,
+			args...,
+		)
+		if err != nil {
+			ac.logger.Err(err).Str("func", funcName).Msg("failed to insert authorization code")
+		}
+		return err
+	}
+
+	_, err := tx.Exec(
+		ac.ctx,
+		query,
+		args...,
+	)
+	if err != nil {
+		ac.logger.Err(err).Str("func", funcName).Msg("failed to insert authorization code")
+	}
+	return err
+}
+
+// Consume deletes the code and returns it; a code can only ever be consumed once, and only by its client
+func (ac *authorizationCodesDAL) Consume(code string, clientID uuid.UUID, redirectURI string) (*models.AuthorizationCode, error) {
+	const funcName = "Consume"
+	const query = "DELETE FROM authorization_codes WHERE code = $1 AND client_id = $2 AND redirect_uri = $3 RETURNING code, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, expires, nonce, auth_time, amr, acr"
+
+	authCode := &models.AuthorizationCode{}
+	err := ac.db.QueryRow(ac.ctx, query, code, clientID, redirectURI).Scan(
+		&authCode.Code,
+		&authCode.ClientID,
+		&authCode.UserID,
+		&authCode.RedirectURI,
+		&authCode.Scope,
+		&authCode.CodeChallenge,
+		&authCode.CodeChallengeMethod,
+		&authCode.Expires,
+		&authCode.Nonce,
+		&authCode.AuthTime,
+		&authCode.AMR,
+		&authCode.ACR,
+	)
+	if err != nil {
+		ac.logger.Err(err).Str("func", funcName).Msg("failed to consume authorization code")
+		return nil, err
+	}
+
+	return authCode, nil
+}
+
+// DeleteByClientID drops the client's unredeemed authorization codes
+func (ac *authorizationCodesDAL) DeleteByClientID(tx pgx.Tx, clientID uuid.UUID) (int64, error) {
+	const funcName = "DeleteByClientID"
+	const query = "DELETE FROM authorization_codes WHERE client_id = $1"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ac.db.Exec(ac.ctx, query, clientID)
+	} else {
+		tag, err = tx.Exec(ac.ctx, query, clientID)
+	}
+	if err != nil {
+		ac.logger.Err(err).Str("func", funcName).Msg("failed to delete client authorization codes")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (ac *authorizationCodesDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM authorization_codes WHERE code IN (SELECT code FROM authorization_codes WHERE expires <= $1 LIMIT $2)"
+
+	tag, err := ac.db.Exec(ac.ctx, query, before, limit)
+	if err != nil {
+		ac.logger.Err(err).Str("func", funcName).Msg("failed to delete expired authorization codes")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,148 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthorizationCodesDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+
+	userID   uuid.UUID
+	clientID uuid.UUID
+}
+
+func TestAuthorizationCodesDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(AuthorizationCodesDALTestSuite))
+}
+
+func (suite *AuthorizationCodesDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+
+func (suite *AuthorizationCodesDALTestSuite) SetupTest() {
+	suite.userID = uuid.New()
+	err := suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          suite.userID,
+		Username:    "test" + suite.userID.String(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	})
+	suite.NoError(err)
+
+	suite.clientID = uuid.New()
+	err = suite.dal.Client(suite.ctx).Create(nil, &models.Client{
+		ID:                     suite.clientID,
+		UserID:                 suite.userID,
+		Name:                   "test-client-name",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
+	})
+	suite.NoError(err)
+}
+func (suite *AuthorizationCodesDALTestSuite) TearDownTest() {}
+
+func (suite *AuthorizationCodesDALTestSuite) TestCreateConsume_OK() {
+	code := &models.AuthorizationCode{
+		Code:                "test-code-" + uuid.NewString(),
+		ClientID:            suite.clientID,
+		UserID:              suite.userID,
+		RedirectURI:         "http://localhost:3030",
+		Scope:               "openid",
+		CodeChallenge:       "test-challenge",
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+	}
+	err := suite.dal.AuthorizationCodes(suite.ctx).Create(nil, code)
+	suite.NoError(err)
+
+	// another client, or another redirect URI, cannot burn the code
+	_, err = suite.dal.AuthorizationCodes(suite.ctx).Consume(code.Code, uuid.New(), "http://localhost:3030")
+	suite.ErrorIs(err, pgx.ErrNoRows)
+	_, err = suite.dal.AuthorizationCodes(suite.ctx).Consume(code.Code, suite.clientID, "http://localhost:3031")
+	suite.ErrorIs(err, pgx.ErrNoRows)
+
+	consumed, err := suite.dal.AuthorizationCodes(suite.ctx).Consume(code.Code, suite.clientID, "http://localhost:3030")
+	suite.NoError(err)
+	suite.Equal(code.Code, consumed.Code)
+	suite.Equal(suite.clientID, consumed.ClientID)
+	suite.Equal(suite.userID, consumed.UserID)
+	suite.Equal("openid", consumed.Scope)
+	suite.Equal("test-challenge", consumed.CodeChallenge)
+
+	// codes are single use
+	_, err = suite.dal.AuthorizationCodes(suite.ctx).Consume(code.Code, suite.clientID, "http://localhost:3030")
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *AuthorizationCodesDALTestSuite) TestCreate_FAIL_NilCode() {
+	err := suite.dal.AuthorizationCodes(suite.ctx).Create(nil, nil)
+	suite.Error(err)
+}
+
+func (suite *AuthorizationCodesDALTestSuite) TestDeleteByClientID_OK() {
+	code := &models.AuthorizationCode{
+		Code:                "test-code-" + uuid.NewString(),
+		ClientID:            suite.clientID,
+		UserID:              suite.userID,
+		RedirectURI:         "http://localhost:3030",
+		CodeChallenge:       "test-challenge",
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(time.Minute),
+	}
+	err := suite.dal.AuthorizationCodes(suite.ctx).Create(nil, code)
+	suite.NoError(err)
+
+	deleted, err := suite.dal.AuthorizationCodes(suite.ctx).DeleteByClientID(nil, suite.clientID)
+	suite.NoError(err)
+	suite.Equal(int64(1), deleted)
+
+	_, err = suite.dal.AuthorizationCodes(suite.ctx).Consume(code.Code, suite.clientID, "http://localhost:3030")
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *AuthorizationCodesDALTestSuite) TestDeleteExpired_OK() {
+	code := &models.AuthorizationCode{
+		Code:                "test-code-" + uuid.NewString(),
+		ClientID:            suite.clientID,
+		UserID:              suite.userID,
+		RedirectURI:         "http://localhost:3030",
+		CodeChallenge:       "test-challenge",
+		CodeChallengeMethod: models.CODE_CHALLENGE_METHOD_S256,
+		Expires:             time.Now().Add(-time.Minute),
+	}
+	err := suite.dal.AuthorizationCodes(suite.ctx).Create(nil, code)
+	suite.NoError(err)
+
+	deleted, err := suite.dal.AuthorizationCodes(suite.ctx).DeleteExpired(time.Now(), 1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(1))
+
+	_, err = suite.dal.AuthorizationCodes(suite.ctx).Consume(code.Code, suite.clientID, "http://localhost:3030")
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
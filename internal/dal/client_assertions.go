@@ -0,0 +1,62 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+)
+
+//go:generate mockgen -destination=./mocks/mock_client_assertions.go -package=mocks github.com/asatraitis/mangrove/internal/dal ClientAssertionsDAL
+type ClientAssertionsDAL interface {
+	Record(*models.ClientAssertion) (bool, error)
+	// DeleteExpired deletes up to limit assertions that expired before the given time; their jti cannot be
+	// replayed anymore since the assertions themselves are rejected
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type clientAssertionsDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewClientAssertionsDAL(ctx context.Context, baseDAL *BaseDAL) ClientAssertionsDAL {
+	caDAL := &clientAssertionsDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	caDAL.logger = baseDAL.logger.With().Str("subcomponent", "ClientAssertionsDAL").Logger()
+	return caDAL
+}
+
+// Record stores the assertion's jti; it returns false when the jti was already used by the client
+func (ca *clientAssertionsDAL) Record(assertion *models.ClientAssertion) (bool, error) {
+	const funcName = "Record"
+	const query = "INSERT INTO client_assertions (client_id, jti, expires) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+
+	if assertion == nil {
+		ca.logger.Error().Str("func", funcName).Msg("nil client assertion")
+		return false, errors.New("failed to record client assertion; nil assertion")
+	}
+
+	tag, err := ca.db.Exec(ca.ctx, query, assertion.ClientID, assertion.JTI, assertion.Expires)
+	if err != nil {
+		ca.logger.Err(err).Str("func", funcName).Msg("failed to record client assertion")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (ca *clientAssertionsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = `DELETE FROM client_assertions WHERE (client_id, jti) IN (
+		SELECT client_id, jti FROM client_assertions WHERE expires <= $1 LIMIT $2
+	)`
+
+	tag, err := ca.db.Exec(ca.ctx, query, before, limit)
+	if err != nil {
+		ca.logger.Err(err).Str("func", funcName).Msg("failed to delete expired client assertions")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
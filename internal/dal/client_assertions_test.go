@@ -0,0 +1,100 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientAssertionsDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+
+	clientID uuid.UUID
+}
+
+func TestClientAssertionsDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(ClientAssertionsDALTestSuite))
+}
+
+func (suite *ClientAssertionsDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+
+func (suite *ClientAssertionsDALTestSuite) SetupTest() {
+	userID := uuid.New()
+	err := suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          userID,
+		Username:    "test" + userID.String(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	})
+	suite.NoError(err)
+
+	suite.clientID = uuid.New()
+	err = suite.dal.Client(suite.ctx).Create(nil, &models.Client{
+		ID:                     suite.clientID,
+		UserID:                 userID,
+		Name:                   "test-client-name",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
+	})
+	suite.NoError(err)
+}
+func (suite *ClientAssertionsDALTestSuite) TearDownTest() {}
+
+func (suite *ClientAssertionsDALTestSuite) TestRecord_OK() {
+	assertion := &models.ClientAssertion{
+		ClientID: suite.clientID,
+		JTI:      uuid.NewString(),
+		Expires:  time.Now().Add(time.Minute),
+	}
+	recorded, err := suite.dal.ClientAssertions(suite.ctx).Record(assertion)
+	suite.NoError(err)
+	suite.True(recorded)
+
+	// replayed jti
+	recorded, err = suite.dal.ClientAssertions(suite.ctx).Record(assertion)
+	suite.NoError(err)
+	suite.False(recorded)
+}
+
+func (suite *ClientAssertionsDALTestSuite) TestDeleteExpired_OK() {
+	_, err := suite.dal.ClientAssertions(suite.ctx).Record(&models.ClientAssertion{
+		ClientID: suite.clientID,
+		JTI:      uuid.NewString(),
+		Expires:  time.Now().Add(-time.Minute),
+	})
+	suite.NoError(err)
+
+	deleted, err := suite.dal.ClientAssertions(suite.ctx).DeleteExpired(time.Now(), 1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(1))
+}
+
+func (suite *ClientAssertionsDALTestSuite) TestRecord_FAIL_Nil() {
+	_, err := suite.dal.ClientAssertions(suite.ctx).Record(nil)
+	suite.Error(err)
+}
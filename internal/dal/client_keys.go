@@ -0,0 +1,121 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+//go:generate mockgen -destination=./mocks/mock_client_keys.go -package=mocks github.com/asatraitis/mangrove/internal/dal ClientKeysDAL
+type ClientKeysDAL interface {
+	Create(pgx.Tx, *models.ClientKey) error
+	GetByClientID(uuid.UUID) ([]*models.ClientKey, error)
+	GetUsableByClientID(uuid.UUID) ([]*models.ClientKey, error)
+	Revoke(clientID uuid.UUID, keyID uuid.UUID) (bool, error)
+	// DeleteExpired deletes up to limit keys that expired or were revoked before the given time
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type clientKeysDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewClientKeysDAL(ctx context.Context, baseDAL *BaseDAL) ClientKeysDAL {
+	ckDAL := &clientKeysDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	ckDAL.logger = baseDAL.logger.With().Str("subcomponent", "ClientKeysDAL").Logger()
+	return ckDAL
+}
+
+func (ck *clientKeysDAL) Create(tx pgx.Tx, key *models.ClientKey) error {
+	const funcName = "Create"
+	const query = "INSERT INTO client_keys (id, client_id, kid, algorithm, public_key, not_before, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
+
+	if key == nil {
+		ck.logger.Error().Str("func", funcName).Msg("nil client key")
+		return errors.New("failed to create client key; nil key")
+	}
+	args := []interface{}{
+		key.ID,
+		key.ClientID,
+		key.KID,
+		key.Algorithm,
+		key.PublicKey,
+		key.NotBefore,
+		key.ExpiresAt,
+		key.CreatedAt,
+	}
+
+	var err error
+	if tx == nil {
+		_, err = ck.db.Exec(ck.ctx, query, args...)
+	} else {
+		_, err = tx.Exec(ck.ctx, query, args...)
+	}
+	if err != nil {
+		ck.logger.Err(err).Str("func", funcName).Msg("failed to insert client key")
+	}
+	return err
+}
+
+// GetByClientID returns all keys of the client, including revoked and expired ones, newest first
+func (ck *clientKeysDAL) GetByClientID(clientID uuid.UUID) ([]*models.ClientKey, error) {
+	const funcName = "GetByClientID"
+	const query = "SELECT id, client_id, kid, algorithm, public_key, not_before, expires_at, revoked, revoked_at, created_at FROM client_keys WHERE client_id = $1 ORDER BY created_at DESC"
+
+	var keys []*models.ClientKey
+	err := pgxscan.Select(ck.ctx, ck.db, &keys, query, clientID)
+	if err != nil {
+		ck.logger.Err(err).Str("func", funcName).Msg("failed to get client keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetUsableByClientID returns the keys that may currently verify client assertions
+func (ck *clientKeysDAL) GetUsableByClientID(clientID uuid.UUID) ([]*models.ClientKey, error) {
+	const funcName = "GetUsableByClientID"
+	const query = "SELECT id, client_id, kid, algorithm, public_key, not_before, expires_at, revoked, revoked_at, created_at FROM client_keys WHERE client_id = $1 AND NOT revoked AND not_before <= $2 AND expires_at > $2 ORDER BY created_at DESC"
+
+	var keys []*models.ClientKey
+	err := pgxscan.Select(ck.ctx, ck.db, &keys, query, clientID, time.Now())
+	if err != nil {
+		ck.logger.Err(err).Str("func", funcName).Msg("failed to get usable client keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke marks the client's key as revoked; it returns false when no such unrevoked key exists
+func (ck *clientKeysDAL) Revoke(clientID uuid.UUID, keyID uuid.UUID) (bool, error) {
+	const funcName = "Revoke"
+	const query = "UPDATE client_keys SET revoked = TRUE, revoked_at = $1 WHERE client_id = $2 AND id = $3 AND NOT revoked"
+
+	tag, err := ck.db.Exec(ck.ctx, query, time.Now(), clientID, keyID)
+	if err != nil {
+		ck.logger.Err(err).Str("func", funcName).Msg("failed to revoke client key")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (ck *clientKeysDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = `DELETE FROM client_keys WHERE id IN (
+		SELECT id FROM client_keys WHERE expires_at <= $1 OR (revoked AND revoked_at <= $1) LIMIT $2
+	)`
+
+	tag, err := ck.db.Exec(ck.ctx, query, before, limit)
+	if err != nil {
+		ck.logger.Err(err).Str("func", funcName).Msg("failed to delete expired client keys")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
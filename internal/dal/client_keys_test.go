@@ -0,0 +1,171 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientKeysDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+
+	clientID uuid.UUID
+}
+
+func TestClientKeysDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(ClientKeysDALTestSuite))
+}
+
+func (suite *ClientKeysDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+
+func (suite *ClientKeysDALTestSuite) SetupTest() {
+	userID := uuid.New()
+	err := suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          userID,
+		Username:    "test" + userID.String(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	})
+	suite.NoError(err)
+
+	suite.clientID = uuid.New()
+	err = suite.dal.Client(suite.ctx).Create(nil, &models.Client{
+		ID:                     suite.clientID,
+		UserID:                 userID,
+		Name:                   "test-client-name",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
+	})
+	suite.NoError(err)
+}
+func (suite *ClientKeysDALTestSuite) TearDownTest() {}
+
+func (suite *ClientKeysDALTestSuite) newKey(kid string, notBefore, expiresAt time.Time) *models.ClientKey {
+	key := &models.ClientKey{
+		ID:        uuid.New(),
+		ClientID:  suite.clientID,
+		KID:       kid,
+		Algorithm: models.CLIENT_KEY_ALGO_EDDSA,
+		PublicKey: []byte("test-public-key"),
+		NotBefore: notBefore,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	err := suite.dal.ClientKeys(suite.ctx).Create(nil, key)
+	suite.NoError(err)
+	return key
+}
+
+func (suite *ClientKeysDALTestSuite) TestCreate_FAIL() {
+	err := suite.dal.ClientKeys(suite.ctx).Create(nil, nil)
+	suite.Error(err)
+
+	// kid is unique per client
+	now := time.Now()
+	suite.newKey("test-kid", now, now.Add(time.Hour))
+	err = suite.dal.ClientKeys(suite.ctx).Create(nil, &models.ClientKey{
+		ID:        uuid.New(),
+		ClientID:  suite.clientID,
+		KID:       "test-kid",
+		Algorithm: models.CLIENT_KEY_ALGO_EDDSA,
+		PublicKey: []byte("test-public-key"),
+		NotBefore: now,
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	})
+	suite.Error(err)
+}
+
+func (suite *ClientKeysDALTestSuite) TestGetUsableByClientID_OK() {
+	now := time.Now()
+	current := suite.newKey("current", now.Add(-time.Minute), now.Add(time.Hour))
+	next := suite.newKey("next", now.Add(-time.Second), now.Add(2*time.Hour))
+	suite.newKey("future", now.Add(time.Hour), now.Add(2*time.Hour))
+	suite.newKey("expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	revoked := suite.newKey("revoked", now.Add(-time.Minute), now.Add(time.Hour))
+
+	ok, err := suite.dal.ClientKeys(suite.ctx).Revoke(suite.clientID, revoked.ID)
+	suite.NoError(err)
+	suite.True(ok)
+
+	keys, err := suite.dal.ClientKeys(suite.ctx).GetUsableByClientID(suite.clientID)
+	suite.NoError(err)
+	suite.Len(keys, 2)
+	kids := []string{keys[0].KID, keys[1].KID}
+	suite.ElementsMatch([]string{current.KID, next.KID}, kids)
+
+	all, err := suite.dal.ClientKeys(suite.ctx).GetByClientID(suite.clientID)
+	suite.NoError(err)
+	suite.Len(all, 5)
+}
+
+func (suite *ClientKeysDALTestSuite) TestRevoke_FAIL() {
+	now := time.Now()
+	key := suite.newKey("test-kid", now, now.Add(time.Hour))
+
+	// another client's key
+	ok, err := suite.dal.ClientKeys(suite.ctx).Revoke(uuid.New(), key.ID)
+	suite.NoError(err)
+	suite.False(ok)
+
+	ok, err = suite.dal.ClientKeys(suite.ctx).Revoke(suite.clientID, key.ID)
+	suite.NoError(err)
+	suite.True(ok)
+
+	// already revoked
+	ok, err = suite.dal.ClientKeys(suite.ctx).Revoke(suite.clientID, key.ID)
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *ClientKeysDALTestSuite) TestDeleteExpired_OK() {
+	now := time.Now()
+	suite.newKey("expired", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	suite.newKey("recently-expired", now.Add(-2*time.Hour), now.Add(-time.Minute))
+	revoked := suite.newKey("revoked", now.Add(-time.Hour), now.Add(time.Hour))
+	suite.newKey("current", now.Add(-time.Hour), now.Add(time.Hour))
+	ok, err := suite.dal.ClientKeys(suite.ctx).Revoke(suite.clientID, revoked.ID)
+	suite.NoError(err)
+	suite.True(ok)
+
+	deleted, err := suite.dal.ClientKeys(suite.ctx).DeleteExpired(now.Add(-time.Hour), 1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(1))
+	keys, err := suite.dal.ClientKeys(suite.ctx).GetByClientID(suite.clientID)
+	suite.NoError(err)
+	suite.Len(keys, 3)
+
+	// revoked keys go once their revocation is older than the cutoff
+	deleted, err = suite.dal.ClientKeys(suite.ctx).DeleteExpired(time.Now(), 1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(2))
+	keys, err = suite.dal.ClientKeys(suite.ctx).GetByClientID(suite.clientID)
+	suite.NoError(err)
+	suite.Len(keys, 1)
+	suite.Equal("current", keys[0].KID)
+}
@@ -8,12 +8,16 @@ import (
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 //go:generate mockgen -destination=./mocks/mock_clientsDAL.go -package=mocks github.com/asatraitis/mangrove/internal/dal ClientsDAL
 type ClientsDAL interface {
 	Create(pgx.Tx, *models.Client) error
 	GetAllByUserID(uuid.UUID) ([]*models.Client, error)
+	GetByID(uuid.UUID) (*models.Client, error)
+	Update(pgx.Tx, *models.Client) error
+	Delete(pgx.Tx, uuid.UUID) error
 }
 type clientsDAL struct {
 	ctx context.Context
@@ -31,7 +35,7 @@ func NewClientsDAL(ctx context.Context, baseDAL *BaseDAL) ClientsDAL {
 
 func (c *clientsDAL) Create(tx pgx.Tx, client *models.Client) error {
 	const funcName = "Create"
-	const query = "INSERT INTO clients VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);"
+	const query = "INSERT INTO clients (id, user_id, name, description, redirect_uris, post_logout_redirect_uris, web_origins, jwks_uri, status, access_token_lifetime, refresh_token_lifetime, refresh_token_max_lifetime) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);"
 
 	if client == nil {
 		c.logger.Error().Str("func", funcName).Msg("nil client")
@@ -43,11 +47,14 @@ func (c *clientsDAL) Create(tx pgx.Tx, client *models.Client) error {
 		client.UserID,
 		client.Name,
 		client.Description,
-		client.RedirectURI,
-		client.PublicKey,
-		client.KeyAlgo,
-		client.KeyExpiresAt,
+		client.RedirectURIs,
+		client.PostLogoutRedirectURIs,
+		client.WebOrigins,
+		client.JWKSURI,
 		client.Status,
+		client.AccessTokenLifetime,
+		client.RefreshTokenLifetime,
+		client.RefreshTokenMaxLifetime,
 	}
 
 	if tx == nil {
@@ -75,7 +82,7 @@ func (c *clientsDAL) Create(tx pgx.Tx, client *models.Client) error {
 
 func (c *clientsDAL) GetAllByUserID(userID uuid.UUID) ([]*models.Client, error) {
 	const funcName = "GetAllByUserID"
-	const query = "SELECT id, user_id, name, description, redirect_uri, status FROM clients WHERE user_id=$1"
+	const query = "SELECT id, user_id, name, description, redirect_uris, post_logout_redirect_uris, web_origins, jwks_uri, status, status_reason, status_changed_at, access_token_lifetime, refresh_token_lifetime, refresh_token_max_lifetime FROM clients WHERE user_id=$1"
 
 	var clients []*models.Client
 	err := pgxscan.Select(c.ctx, c.db, &clients, query, userID)
@@ -86,3 +93,82 @@ func (c *clientsDAL) GetAllByUserID(userID uuid.UUID) ([]*models.Client, error)
 
 	return clients, nil
 }
+
+func (c *clientsDAL) GetByID(ID uuid.UUID) (*models.Client, error) {
+	const funcName = "GetByID"
+	const query = "SELECT id, user_id, name, description, redirect_uris, post_logout_redirect_uris, web_origins, jwks_uri, status, status_reason, status_changed_at, access_token_lifetime, refresh_token_lifetime, refresh_token_max_lifetime FROM clients WHERE id=$1"
+
+	client := &models.Client{}
+	err := pgxscan.Get(c.ctx, c.db, client, query, ID)
+	if err != nil {
+		c.logger.Err(err).Str("func", funcName).Msg("failed to get a client")
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (c *clientsDAL) Update(tx pgx.Tx, client *models.Client) error {
+	const funcName = "Update"
+	const query = "UPDATE clients SET name = $1, description = $2, redirect_uris = $3, post_logout_redirect_uris = $4, web_origins = $5, jwks_uri = $6, status = $7, status_reason = $8, status_changed_at = $9, access_token_lifetime = $10, refresh_token_lifetime = $11, refresh_token_max_lifetime = $12 WHERE id = $13"
+
+	if client == nil {
+		c.logger.Error().Str("func", funcName).Msg("nil client")
+		return errors.New("failed to update a client; nil")
+	}
+	args := []interface{}{
+		client.Name,
+		client.Description,
+		client.RedirectURIs,
+		client.PostLogoutRedirectURIs,
+		client.WebOrigins,
+		client.JWKSURI,
+		client.Status,
+		client.StatusReason,
+		client.StatusChangedAt,
+		client.AccessTokenLifetime,
+		client.RefreshTokenLifetime,
+		client.RefreshTokenMaxLifetime,
+		client.ID,
+	}
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = c.db.Exec(c.ctx, query, args...)
+	} else {
+		tag, err = tx.Exec(c.ctx, query, args...)
+	}
+	if err != nil {
+		c.logger.Err(err).Str("func", funcName).Msg("failed to update client")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		c.logger.Error().Str("func", funcName).Str("clientID", client.ID.String()).Msg("client not found")
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes the client; its keys, authorization codes and tokens are removed by the foreign key cascade
+func (c *clientsDAL) Delete(tx pgx.Tx, ID uuid.UUID) error {
+	const funcName = "Delete"
+	const query = "DELETE FROM clients WHERE id = $1"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = c.db.Exec(c.ctx, query, ID)
+	} else {
+		tag, err = tx.Exec(c.ctx, query, ID)
+	}
+	if err != nil {
+		c.logger.Err(err).Str("func", funcName).Msg("failed to delete client")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		c.logger.Error().Str("func", funcName).Str("clientID", ID.String()).Msg("client not found")
+		return pgx.ErrNoRows
+	}
+	return nil
+}
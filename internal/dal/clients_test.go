@@ -67,18 +67,15 @@ func (suite *ClientsDALTestSuite) TestCreate_OK() {
 	ID, err := uuid.NewV7()
 	suite.NoError(err)
 
-	expiresAt := time.Now().Add(time.Hour)
-
 	client := models.Client{
-		ID:           ID,
-		UserID:       suite.userID,
-		Name:         "test-client-name",
-		Description:  "test-client-description",
-		RedirectURI:  "http://localhost:3030",
-		PublicKey:    []byte("test-public-key"),
-		KeyExpiresAt: expiresAt,
-		KeyAlgo:      models.CLIENT_KEY_ALGO_EDDSA,
-		Status:       models.CLIENT_STATUS_ACTIVE,
+		ID:                     ID,
+		UserID:                 suite.userID,
+		Name:                   "test-client-name",
+		Description:            "test-client-description",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
 	}
 
 	err = suite.dal.Client(suite.ctx).Create(nil, &client)
@@ -104,18 +101,16 @@ func (suite *ClientsDALTestSuite) TestGetAllByUserID_OK() {
 	ID, err := uuid.NewV7()
 	suite.NoError(err)
 
-	expiresAt := time.Now().Add(time.Hour)
-
 	client := models.Client{
-		ID:           ID,
-		UserID:       suite.userID,
-		Name:         "test-client-name",
-		Description:  "test-client-description",
-		RedirectURI:  "http://localhost:3030",
-		PublicKey:    []byte("test-public-key"),
-		KeyExpiresAt: expiresAt,
-		KeyAlgo:      models.CLIENT_KEY_ALGO_EDDSA,
-		Status:       models.CLIENT_STATUS_ACTIVE,
+		ID:                     ID,
+		UserID:                 suite.userID,
+		Name:                   "test-client-name",
+		Description:            "test-client-description",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		JWKSURI:                "https://client.example.com/jwks.json",
+		Status:                 models.CLIENT_STATUS_ACTIVE,
 	}
 
 	err = suite.dal.Client(suite.ctx).Create(nil, &client)
@@ -138,6 +133,82 @@ func (suite *ClientsDALTestSuite) TestGetAllByUserID_OK() {
 	suite.Equal(suite.userID, createdClient.UserID)
 	suite.Equal("test-client-name", createdClient.Name)
 	suite.Equal("test-client-description", createdClient.Description)
-	suite.Equal("http://localhost:3030", createdClient.RedirectURI)
+	suite.Equal([]string{"http://localhost:3030"}, createdClient.RedirectURIs)
+	suite.Equal("https://client.example.com/jwks.json", createdClient.JWKSURI)
 	suite.Equal(models.ClientStatus("active"), createdClient.Status)
 }
+
+func (suite *ClientsDALTestSuite) TestUpdate_OK() {
+	client := &models.Client{
+		ID:                     uuid.New(),
+		UserID:                 suite.userID,
+		Name:                   "test-client-name",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
+	}
+	err := suite.dal.Client(suite.ctx).Create(nil, client)
+	suite.NoError(err)
+
+	changedAt := time.Now().Truncate(time.Microsecond)
+	client.Name = "updated-name"
+	client.Status = models.CLIENT_STATUS_SUSPENDED
+	client.StatusReason = "leaked key"
+	client.StatusChangedAt = &changedAt
+	client.AccessTokenLifetime = 900
+	client.RefreshTokenLifetime = 86400
+	client.RefreshTokenMaxLifetime = 604800
+	err = suite.dal.Client(suite.ctx).Update(nil, client)
+	suite.NoError(err)
+
+	updated, err := suite.dal.Client(suite.ctx).GetByID(client.ID)
+	suite.NoError(err)
+	suite.Equal("updated-name", updated.Name)
+	suite.Equal(models.CLIENT_STATUS_SUSPENDED, updated.Status)
+	suite.Equal("leaked key", updated.StatusReason)
+	suite.True(changedAt.Equal(*updated.StatusChangedAt))
+	suite.Equal(900, updated.AccessTokenLifetime)
+	suite.Equal(86400, updated.RefreshTokenLifetime)
+	suite.Equal(604800, updated.RefreshTokenMaxLifetime)
+
+	client.ID = uuid.New()
+	err = suite.dal.Client(suite.ctx).Update(nil, client)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *ClientsDALTestSuite) TestDelete_OK() {
+	client := &models.Client{
+		ID:                     uuid.New(),
+		UserID:                 suite.userID,
+		Name:                   "test-client-name",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
+	}
+	err := suite.dal.Client(suite.ctx).Create(nil, client)
+	suite.NoError(err)
+
+	for range 2 {
+		err = suite.dal.UserTokens(suite.ctx).Create(nil, &models.UserToken{
+			ID:        uuid.New(),
+			TokenHash: utils.HashOpaqueToken(uuid.NewString()),
+			UserID:    suite.userID,
+			Expires:   time.Now().Add(time.Hour),
+			ClientID:  &client.ID,
+		})
+		suite.NoError(err)
+	}
+	revoked, err := suite.dal.UserTokens(suite.ctx).DeleteByClientID(nil, client.ID)
+	suite.NoError(err)
+	suite.Equal(int64(2), revoked)
+
+	err = suite.dal.Client(suite.ctx).Delete(nil, client.ID)
+	suite.NoError(err)
+	_, err = suite.dal.Client(suite.ctx).GetByID(client.ID)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+
+	err = suite.dal.Client(suite.ctx).Delete(nil, client.ID)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
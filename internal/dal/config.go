@@ -8,6 +8,9 @@ import (
 type ConfigDAL interface {
 	GetAll() (Configs, error)
 	Set(ConfigKey, string) error
+	// Listen blocks until ctx is done or the connection fails, calling onChange for every config
+	// updated by any instance
+	Listen(func(ConfigKey)) error
 }
 type configDAL struct {
 	ctx context.Context
@@ -26,10 +29,19 @@ const (
 	CONFIG_INSTANCE_READY ConfigKey = "instanceReady"
 	CONFIG_INIT_SA_CODE   ConfigKey = "initSACode"
 	CONFIG_INIT_ATTEMPTS  ConfigKey = "initAttempts"
+	// CONFIG_INVITED_USER_STATUS is the status (pending or active) given to users registering with an invitation
+	CONFIG_INVITED_USER_STATUS ConfigKey = "invitedUserStatus"
+	// CONFIG_SESSION_IDLE_TIMEOUT is how long (a Go duration, e.g. 24h) an unused browser session stays valid
+	CONFIG_SESSION_IDLE_TIMEOUT ConfigKey = "sessionIdleTimeout"
+	// CONFIG_SESSION_MAX_LIFETIME is how long after login a browser session ends regardless of use
+	CONFIG_SESSION_MAX_LIFETIME ConfigKey = "sessionMaxLifetime"
 )
 
 type Configs map[ConfigKey]Config
 
+// configChangedChannel is the postgres NOTIFY channel carrying the keys of updated configs
+const configChangedChannel = "config_changed"
+
 func NewConfigDAL(ctx context.Context, baseDAL *BaseDAL) ConfigDAL {
 	cdal := &configDAL{
 		ctx:     ctx,
@@ -62,11 +74,42 @@ func (c *configDAL) GetAll() (Configs, error) {
 	return configs, nil
 }
 
+// Set updates the config and notifies the other instances in the same statement
 func (c *configDAL) Set(key ConfigKey, value string) error {
-	_, err := c.db.Exec(c.ctx, "UPDATE config SET value=$1 WHERE key=$2", value, key)
+	const query = "WITH updated AS (UPDATE config SET value=$1 WHERE key=$2 RETURNING key) SELECT pg_notify('" + configChangedChannel + "', key) FROM updated"
+	_, err := c.db.Exec(c.ctx, query, value, key)
 	if err != nil {
 		c.logger.Err(err).Str("func", "Set")
 		return err
 	}
 	return nil
 }
+
+func (c *configDAL) Listen(onChange func(ConfigKey)) error {
+	const funcName = "Listen"
+
+	conn, err := c.db.Acquire(c.ctx)
+	if err != nil {
+		c.logger.Err(err).Str("func", funcName).Msg("failed to acquire connection")
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(c.ctx, "LISTEN "+configChangedChannel)
+	if err != nil {
+		c.logger.Err(err).Str("func", funcName).Msg("failed to listen for config changes")
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(c.ctx)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return nil
+			}
+			c.logger.Err(err).Str("func", funcName).Msg("failed to wait for config changes")
+			return err
+		}
+		onChange(ConfigKey(notification.Payload))
+	}
+}
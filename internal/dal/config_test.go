@@ -3,6 +3,7 @@ package dal
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/asatraitis/mangrove/internal/utils"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -59,3 +60,32 @@ func (suite *ConfigDALTestSuite) TestSet_OK() {
 	suite.True(ok)
 	suite.Equal("true", *instanceRaedyConf.Value)
 }
+
+func (suite *ConfigDALTestSuite) TestListen_OK() {
+	ctx, cancel := context.WithCancel(suite.ctx)
+	defer cancel()
+	listener := NewConfigDAL(ctx, &BaseDAL{
+		logger: zerolog.Nop(),
+		db:     suite.DB,
+	})
+
+	changed := make(chan ConfigKey, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.Listen(func(key ConfigKey) { changed <- key })
+	}()
+
+	// LISTEN is registered asynchronously; keep updating until a notification arrives
+	suite.Eventually(func() bool {
+		suite.NoError(suite.configDAL.Set(CONFIG_INSTANCE_READY, "true"))
+		select {
+		case key := <-changed:
+			return key == CONFIG_INSTANCE_READY
+		case <-time.After(time.Millisecond * 100):
+			return false
+		}
+	}, time.Second*5, time.Millisecond*10)
+
+	cancel()
+	suite.NoError(<-done)
+}
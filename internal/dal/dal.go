@@ -17,6 +17,15 @@ type DAL interface {
 	UserCredentials(ctx context.Context) UserCredentialsDAL
 	UserTokens(ctx context.Context) UserTokensDAL
 	Client(ctx context.Context) ClientsDAL
+	AuthorizationCodes(ctx context.Context) AuthorizationCodesDAL
+	SigningKeys(ctx context.Context) SigningKeysDAL
+	ClientAssertions(ctx context.Context) ClientAssertionsDAL
+	ClientKeys(ctx context.Context) ClientKeysDAL
+	UserInvitations(ctx context.Context) UserInvitationsDAL
+	WebauthnSessions(ctx context.Context) WebauthnSessionsDAL
+	Roles(ctx context.Context) RolesDAL
+	ReaperRuns(ctx context.Context) ReaperRunsDAL
+	RefreshTokens(ctx context.Context) RefreshTokensDAL
 }
 type BaseDAL struct {
 	logger zerolog.Logger
@@ -61,3 +70,30 @@ func (d *dal) UserTokens(ctx context.Context) UserTokensDAL {
 func (d *dal) Client(ctx context.Context) ClientsDAL {
 	return NewClientsDAL(ctx, d.BaseDAL)
 }
+func (d *dal) AuthorizationCodes(ctx context.Context) AuthorizationCodesDAL {
+	return NewAuthorizationCodesDAL(ctx, d.BaseDAL)
+}
+func (d *dal) SigningKeys(ctx context.Context) SigningKeysDAL {
+	return NewSigningKeysDAL(ctx, d.BaseDAL)
+}
+func (d *dal) ClientAssertions(ctx context.Context) ClientAssertionsDAL {
+	return NewClientAssertionsDAL(ctx, d.BaseDAL)
+}
+func (d *dal) ClientKeys(ctx context.Context) ClientKeysDAL {
+	return NewClientKeysDAL(ctx, d.BaseDAL)
+}
+func (d *dal) UserInvitations(ctx context.Context) UserInvitationsDAL {
+	return NewUserInvitationsDAL(ctx, d.BaseDAL)
+}
+func (d *dal) WebauthnSessions(ctx context.Context) WebauthnSessionsDAL {
+	return NewWebauthnSessionsDAL(ctx, d.BaseDAL)
+}
+func (d *dal) Roles(ctx context.Context) RolesDAL {
+	return NewRolesDAL(ctx, d.BaseDAL)
+}
+func (d *dal) ReaperRuns(ctx context.Context) ReaperRunsDAL {
+	return NewReaperRunsDAL(ctx, d.BaseDAL)
+}
+func (d *dal) RefreshTokens(ctx context.Context) RefreshTokensDAL {
+	return NewRefreshTokensDAL(ctx, d.BaseDAL)
+}
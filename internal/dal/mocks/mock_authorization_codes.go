@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: AuthorizationCodesDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_authorization_codes.go -package=mocks github.com/asatraitis/mangrove/internal/dal AuthorizationCodesDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthorizationCodesDAL is a mock of AuthorizationCodesDAL interface.
+type MockAuthorizationCodesDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizationCodesDALMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthorizationCodesDALMockRecorder is the mock recorder for MockAuthorizationCodesDAL.
+type MockAuthorizationCodesDALMockRecorder struct {
+	mock *MockAuthorizationCodesDAL
+}
+
+// NewMockAuthorizationCodesDAL creates a new mock instance.
+func NewMockAuthorizationCodesDAL(ctrl *gomock.Controller) *MockAuthorizationCodesDAL {
+	mock := &MockAuthorizationCodesDAL{ctrl: ctrl}
+	mock.recorder = &MockAuthorizationCodesDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorizationCodesDAL) EXPECT() *MockAuthorizationCodesDALMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockAuthorizationCodesDAL) Consume(code string, clientID uuid.UUID, redirectURI string) (*models.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", code, clientID, redirectURI)
+	ret0, _ := ret[0].(*models.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockAuthorizationCodesDALMockRecorder) Consume(code, clientID, redirectURI any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockAuthorizationCodesDAL)(nil).Consume), code, clientID, redirectURI)
+}
+
+// Create mocks base method.
+func (m *MockAuthorizationCodesDAL) Create(arg0 pgx.Tx, arg1 *models.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuthorizationCodesDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuthorizationCodesDAL)(nil).Create), arg0, arg1)
+}
+
+// DeleteByClientID mocks base method.
+func (m *MockAuthorizationCodesDAL) DeleteByClientID(arg0 pgx.Tx, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByClientID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByClientID indicates an expected call of DeleteByClientID.
+func (mr *MockAuthorizationCodesDALMockRecorder) DeleteByClientID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByClientID", reflect.TypeOf((*MockAuthorizationCodesDAL)(nil).DeleteByClientID), arg0, arg1)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockAuthorizationCodesDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockAuthorizationCodesDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockAuthorizationCodesDAL)(nil).DeleteExpired), before, limit)
+}
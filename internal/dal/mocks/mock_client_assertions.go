@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: ClientAssertionsDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_client_assertions.go -package=mocks github.com/asatraitis/mangrove/internal/dal ClientAssertionsDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClientAssertionsDAL is a mock of ClientAssertionsDAL interface.
+type MockClientAssertionsDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientAssertionsDALMockRecorder
+	isgomock struct{}
+}
+
+// MockClientAssertionsDALMockRecorder is the mock recorder for MockClientAssertionsDAL.
+type MockClientAssertionsDALMockRecorder struct {
+	mock *MockClientAssertionsDAL
+}
+
+// NewMockClientAssertionsDAL creates a new mock instance.
+func NewMockClientAssertionsDAL(ctrl *gomock.Controller) *MockClientAssertionsDAL {
+	mock := &MockClientAssertionsDAL{ctrl: ctrl}
+	mock.recorder = &MockClientAssertionsDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClientAssertionsDAL) EXPECT() *MockClientAssertionsDALMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpired mocks base method.
+func (m *MockClientAssertionsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockClientAssertionsDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockClientAssertionsDAL)(nil).DeleteExpired), before, limit)
+}
+
+// Record mocks base method.
+func (m *MockClientAssertionsDAL) Record(arg0 *models.ClientAssertion) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockClientAssertionsDALMockRecorder) Record(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockClientAssertionsDAL)(nil).Record), arg0)
+}
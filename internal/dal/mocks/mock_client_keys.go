@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: ClientKeysDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_client_keys.go -package=mocks github.com/asatraitis/mangrove/internal/dal ClientKeysDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClientKeysDAL is a mock of ClientKeysDAL interface.
+type MockClientKeysDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientKeysDALMockRecorder
+	isgomock struct{}
+}
+
+// MockClientKeysDALMockRecorder is the mock recorder for MockClientKeysDAL.
+type MockClientKeysDALMockRecorder struct {
+	mock *MockClientKeysDAL
+}
+
+// NewMockClientKeysDAL creates a new mock instance.
+func NewMockClientKeysDAL(ctrl *gomock.Controller) *MockClientKeysDAL {
+	mock := &MockClientKeysDAL{ctrl: ctrl}
+	mock.recorder = &MockClientKeysDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClientKeysDAL) EXPECT() *MockClientKeysDALMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockClientKeysDAL) Create(arg0 pgx.Tx, arg1 *models.ClientKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockClientKeysDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClientKeysDAL)(nil).Create), arg0, arg1)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockClientKeysDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockClientKeysDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockClientKeysDAL)(nil).DeleteExpired), before, limit)
+}
+
+// GetByClientID mocks base method.
+func (m *MockClientKeysDAL) GetByClientID(arg0 uuid.UUID) ([]*models.ClientKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByClientID", arg0)
+	ret0, _ := ret[0].([]*models.ClientKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByClientID indicates an expected call of GetByClientID.
+func (mr *MockClientKeysDALMockRecorder) GetByClientID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByClientID", reflect.TypeOf((*MockClientKeysDAL)(nil).GetByClientID), arg0)
+}
+
+// GetUsableByClientID mocks base method.
+func (m *MockClientKeysDAL) GetUsableByClientID(arg0 uuid.UUID) ([]*models.ClientKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsableByClientID", arg0)
+	ret0, _ := ret[0].([]*models.ClientKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsableByClientID indicates an expected call of GetUsableByClientID.
+func (mr *MockClientKeysDALMockRecorder) GetUsableByClientID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsableByClientID", reflect.TypeOf((*MockClientKeysDAL)(nil).GetUsableByClientID), arg0)
+}
+
+// Revoke mocks base method.
+func (m *MockClientKeysDAL) Revoke(clientID, keyID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", clientID, keyID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockClientKeysDALMockRecorder) Revoke(clientID, keyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockClientKeysDAL)(nil).Revoke), clientID, keyID)
+}
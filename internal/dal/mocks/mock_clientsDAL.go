@@ -56,6 +56,20 @@ func (mr *MockClientsDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClientsDAL)(nil).Create), arg0, arg1)
 }
 
+// Delete mocks base method.
+func (m *MockClientsDAL) Delete(arg0 pgx.Tx, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientsDALMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClientsDAL)(nil).Delete), arg0, arg1)
+}
+
 // GetAllByUserID mocks base method.
 func (m *MockClientsDAL) GetAllByUserID(arg0 uuid.UUID) ([]*models.Client, error) {
 	m.ctrl.T.Helper()
@@ -70,3 +84,32 @@ func (mr *MockClientsDALMockRecorder) GetAllByUserID(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByUserID", reflect.TypeOf((*MockClientsDAL)(nil).GetAllByUserID), arg0)
 }
+
+// GetByID mocks base method.
+func (m *MockClientsDAL) GetByID(arg0 uuid.UUID) (*models.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0)
+	ret0, _ := ret[0].(*models.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockClientsDALMockRecorder) GetByID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockClientsDAL)(nil).GetByID), arg0)
+}
+
+// Update mocks base method.
+func (m *MockClientsDAL) Update(arg0 pgx.Tx, arg1 *models.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockClientsDALMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClientsDAL)(nil).Update), arg0, arg1)
+}
@@ -55,6 +55,20 @@ func (mr *MockConfigDALMockRecorder) GetAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockConfigDAL)(nil).GetAll))
 }
 
+// Listen mocks base method.
+func (m *MockConfigDAL) Listen(arg0 func(dal.ConfigKey)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Listen", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Listen indicates an expected call of Listen.
+func (mr *MockConfigDALMockRecorder) Listen(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listen", reflect.TypeOf((*MockConfigDAL)(nil).Listen), arg0)
+}
+
 // Set mocks base method.
 func (m *MockConfigDAL) Set(arg0 dal.ConfigKey, arg1 string) error {
 	m.ctrl.T.Helper()
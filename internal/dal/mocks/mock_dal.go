@@ -42,6 +42,20 @@ func (m *MockDAL) EXPECT() *MockDALMockRecorder {
 	return m.recorder
 }
 
+// AuthorizationCodes mocks base method.
+func (m *MockDAL) AuthorizationCodes(ctx context.Context) dal.AuthorizationCodesDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizationCodes", ctx)
+	ret0, _ := ret[0].(dal.AuthorizationCodesDAL)
+	return ret0
+}
+
+// AuthorizationCodes indicates an expected call of AuthorizationCodes.
+func (mr *MockDALMockRecorder) AuthorizationCodes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizationCodes", reflect.TypeOf((*MockDAL)(nil).AuthorizationCodes), ctx)
+}
+
 // BeginTx mocks base method.
 func (m *MockDAL) BeginTx(ctx context.Context) (pgx.Tx, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +85,34 @@ func (mr *MockDALMockRecorder) Client(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Client", reflect.TypeOf((*MockDAL)(nil).Client), ctx)
 }
 
+// ClientAssertions mocks base method.
+func (m *MockDAL) ClientAssertions(ctx context.Context) dal.ClientAssertionsDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientAssertions", ctx)
+	ret0, _ := ret[0].(dal.ClientAssertionsDAL)
+	return ret0
+}
+
+// ClientAssertions indicates an expected call of ClientAssertions.
+func (mr *MockDALMockRecorder) ClientAssertions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientAssertions", reflect.TypeOf((*MockDAL)(nil).ClientAssertions), ctx)
+}
+
+// ClientKeys mocks base method.
+func (m *MockDAL) ClientKeys(ctx context.Context) dal.ClientKeysDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientKeys", ctx)
+	ret0, _ := ret[0].(dal.ClientKeysDAL)
+	return ret0
+}
+
+// ClientKeys indicates an expected call of ClientKeys.
+func (mr *MockDALMockRecorder) ClientKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientKeys", reflect.TypeOf((*MockDAL)(nil).ClientKeys), ctx)
+}
+
 // Config mocks base method.
 func (m *MockDAL) Config(ctx context.Context) dal.ConfigDAL {
 	m.ctrl.T.Helper()
@@ -85,6 +127,62 @@ func (mr *MockDALMockRecorder) Config(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockDAL)(nil).Config), ctx)
 }
 
+// ReaperRuns mocks base method.
+func (m *MockDAL) ReaperRuns(ctx context.Context) dal.ReaperRunsDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReaperRuns", ctx)
+	ret0, _ := ret[0].(dal.ReaperRunsDAL)
+	return ret0
+}
+
+// ReaperRuns indicates an expected call of ReaperRuns.
+func (mr *MockDALMockRecorder) ReaperRuns(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReaperRuns", reflect.TypeOf((*MockDAL)(nil).ReaperRuns), ctx)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockDAL) RefreshTokens(ctx context.Context) dal.RefreshTokensDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx)
+	ret0, _ := ret[0].(dal.RefreshTokensDAL)
+	return ret0
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockDALMockRecorder) RefreshTokens(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockDAL)(nil).RefreshTokens), ctx)
+}
+
+// Roles mocks base method.
+func (m *MockDAL) Roles(ctx context.Context) dal.RolesDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Roles", ctx)
+	ret0, _ := ret[0].(dal.RolesDAL)
+	return ret0
+}
+
+// Roles indicates an expected call of Roles.
+func (mr *MockDALMockRecorder) Roles(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Roles", reflect.TypeOf((*MockDAL)(nil).Roles), ctx)
+}
+
+// SigningKeys mocks base method.
+func (m *MockDAL) SigningKeys(ctx context.Context) dal.SigningKeysDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SigningKeys", ctx)
+	ret0, _ := ret[0].(dal.SigningKeysDAL)
+	return ret0
+}
+
+// SigningKeys indicates an expected call of SigningKeys.
+func (mr *MockDALMockRecorder) SigningKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SigningKeys", reflect.TypeOf((*MockDAL)(nil).SigningKeys), ctx)
+}
+
 // User mocks base method.
 func (m *MockDAL) User(ctx context.Context) dal.UserDAL {
 	m.ctrl.T.Helper()
@@ -113,6 +211,20 @@ func (mr *MockDALMockRecorder) UserCredentials(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserCredentials", reflect.TypeOf((*MockDAL)(nil).UserCredentials), ctx)
 }
 
+// UserInvitations mocks base method.
+func (m *MockDAL) UserInvitations(ctx context.Context) dal.UserInvitationsDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInvitations", ctx)
+	ret0, _ := ret[0].(dal.UserInvitationsDAL)
+	return ret0
+}
+
+// UserInvitations indicates an expected call of UserInvitations.
+func (mr *MockDALMockRecorder) UserInvitations(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInvitations", reflect.TypeOf((*MockDAL)(nil).UserInvitations), ctx)
+}
+
 // UserTokens mocks base method.
 func (m *MockDAL) UserTokens(ctx context.Context) dal.UserTokensDAL {
 	m.ctrl.T.Helper()
@@ -126,3 +238,17 @@ func (mr *MockDALMockRecorder) UserTokens(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserTokens", reflect.TypeOf((*MockDAL)(nil).UserTokens), ctx)
 }
+
+// WebauthnSessions mocks base method.
+func (m *MockDAL) WebauthnSessions(ctx context.Context) dal.WebauthnSessionsDAL {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebauthnSessions", ctx)
+	ret0, _ := ret[0].(dal.WebauthnSessionsDAL)
+	return ret0
+}
+
+// WebauthnSessions indicates an expected call of WebauthnSessions.
+func (mr *MockDALMockRecorder) WebauthnSessions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebauthnSessions", reflect.TypeOf((*MockDAL)(nil).WebauthnSessions), ctx)
+}
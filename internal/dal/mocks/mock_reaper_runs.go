@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: ReaperRunsDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_reaper_runs.go -package=mocks github.com/asatraitis/mangrove/internal/dal ReaperRunsDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReaperRunsDAL is a mock of ReaperRunsDAL interface.
+type MockReaperRunsDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockReaperRunsDALMockRecorder
+	isgomock struct{}
+}
+
+// MockReaperRunsDALMockRecorder is the mock recorder for MockReaperRunsDAL.
+type MockReaperRunsDALMockRecorder struct {
+	mock *MockReaperRunsDAL
+}
+
+// NewMockReaperRunsDAL creates a new mock instance.
+func NewMockReaperRunsDAL(ctrl *gomock.Controller) *MockReaperRunsDAL {
+	mock := &MockReaperRunsDAL{ctrl: ctrl}
+	mock.recorder = &MockReaperRunsDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReaperRunsDAL) EXPECT() *MockReaperRunsDALMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockReaperRunsDAL) Create(arg0 *models.ReaperRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockReaperRunsDALMockRecorder) Create(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockReaperRunsDAL)(nil).Create), arg0)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockReaperRunsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockReaperRunsDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockReaperRunsDAL)(nil).DeleteExpired), before, limit)
+}
+
+// GetLatest mocks base method.
+func (m *MockReaperRunsDAL) GetLatest(limit int) ([]*models.ReaperRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatest", limit)
+	ret0, _ := ret[0].([]*models.ReaperRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatest indicates an expected call of GetLatest.
+func (mr *MockReaperRunsDALMockRecorder) GetLatest(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatest", reflect.TypeOf((*MockReaperRunsDAL)(nil).GetLatest), limit)
+}
@@ -0,0 +1,133 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: RefreshTokensDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_refresh_tokens.go -package=mocks github.com/asatraitis/mangrove/internal/dal RefreshTokensDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRefreshTokensDAL is a mock of RefreshTokensDAL interface.
+type MockRefreshTokensDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefreshTokensDALMockRecorder
+	isgomock struct{}
+}
+
+// MockRefreshTokensDALMockRecorder is the mock recorder for MockRefreshTokensDAL.
+type MockRefreshTokensDALMockRecorder struct {
+	mock *MockRefreshTokensDAL
+}
+
+// NewMockRefreshTokensDAL creates a new mock instance.
+func NewMockRefreshTokensDAL(ctrl *gomock.Controller) *MockRefreshTokensDAL {
+	mock := &MockRefreshTokensDAL{ctrl: ctrl}
+	mock.recorder = &MockRefreshTokensDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefreshTokensDAL) EXPECT() *MockRefreshTokensDALMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRefreshTokensDAL) Create(arg0 pgx.Tx, arg1 *models.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRefreshTokensDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefreshTokensDAL)(nil).Create), arg0, arg1)
+}
+
+// DeleteByClientID mocks base method.
+func (m *MockRefreshTokensDAL) DeleteByClientID(arg0 pgx.Tx, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByClientID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByClientID indicates an expected call of DeleteByClientID.
+func (mr *MockRefreshTokensDALMockRecorder) DeleteByClientID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByClientID", reflect.TypeOf((*MockRefreshTokensDAL)(nil).DeleteByClientID), arg0, arg1)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockRefreshTokensDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockRefreshTokensDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockRefreshTokensDAL)(nil).DeleteExpired), before, limit)
+}
+
+// GetByHash mocks base method.
+func (m *MockRefreshTokensDAL) GetByHash(arg0 []byte) (*models.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", arg0)
+	ret0, _ := ret[0].(*models.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockRefreshTokensDALMockRecorder) GetByHash(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockRefreshTokensDAL)(nil).GetByHash), arg0)
+}
+
+// MarkUsed mocks base method.
+func (m *MockRefreshTokensDAL) MarkUsed(tx pgx.Tx, ID uuid.UUID, usedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUsed", tx, ID, usedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkUsed indicates an expected call of MarkUsed.
+func (mr *MockRefreshTokensDALMockRecorder) MarkUsed(tx, ID, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsed", reflect.TypeOf((*MockRefreshTokensDAL)(nil).MarkUsed), tx, ID, usedAt)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockRefreshTokensDAL) RevokeFamily(tx pgx.Tx, familyID uuid.UUID, revokedAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", tx, familyID, revokedAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockRefreshTokensDALMockRecorder) RevokeFamily(tx, familyID, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockRefreshTokensDAL)(nil).RevokeFamily), tx, familyID, revokedAt)
+}
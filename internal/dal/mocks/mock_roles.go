@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: RolesDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_roles.go -package=mocks github.com/asatraitis/mangrove/internal/dal RolesDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	pgx "github.com/jackc/pgx/v5"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRolesDAL is a mock of RolesDAL interface.
+type MockRolesDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockRolesDALMockRecorder
+	isgomock struct{}
+}
+
+// MockRolesDALMockRecorder is the mock recorder for MockRolesDAL.
+type MockRolesDALMockRecorder struct {
+	mock *MockRolesDAL
+}
+
+// NewMockRolesDAL creates a new mock instance.
+func NewMockRolesDAL(ctrl *gomock.Controller) *MockRolesDAL {
+	mock := &MockRolesDAL{ctrl: ctrl}
+	mock.recorder = &MockRolesDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRolesDAL) EXPECT() *MockRolesDALMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRolesDAL) Create(arg0 pgx.Tx, arg1 *models.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRolesDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRolesDAL)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockRolesDAL) Delete(arg0 pgx.Tx, arg1 models.UserRole) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRolesDALMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRolesDAL)(nil).Delete), arg0, arg1)
+}
+
+// GetAll mocks base method.
+func (m *MockRolesDAL) GetAll() ([]*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll")
+	ret0, _ := ret[0].([]*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockRolesDALMockRecorder) GetAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRolesDAL)(nil).GetAll))
+}
+
+// GetByName mocks base method.
+func (m *MockRolesDAL) GetByName(arg0 models.UserRole) (*models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByName", arg0)
+	ret0, _ := ret[0].(*models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByName indicates an expected call of GetByName.
+func (mr *MockRolesDALMockRecorder) GetByName(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockRolesDAL)(nil).GetByName), arg0)
+}
+
+// Update mocks base method.
+func (m *MockRolesDAL) Update(arg0 pgx.Tx, arg1 *models.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRolesDALMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRolesDAL)(nil).Update), arg0, arg1)
+}
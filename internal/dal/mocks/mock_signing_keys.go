@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: SigningKeysDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_signing_keys.go -package=mocks github.com/asatraitis/mangrove/internal/dal SigningKeysDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	pgx "github.com/jackc/pgx/v5"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSigningKeysDAL is a mock of SigningKeysDAL interface.
+type MockSigningKeysDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockSigningKeysDALMockRecorder
+	isgomock struct{}
+}
+
+// MockSigningKeysDALMockRecorder is the mock recorder for MockSigningKeysDAL.
+type MockSigningKeysDALMockRecorder struct {
+	mock *MockSigningKeysDAL
+}
+
+// NewMockSigningKeysDAL creates a new mock instance.
+func NewMockSigningKeysDAL(ctrl *gomock.Controller) *MockSigningKeysDAL {
+	mock := &MockSigningKeysDAL{ctrl: ctrl}
+	mock.recorder = &MockSigningKeysDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSigningKeysDAL) EXPECT() *MockSigningKeysDALMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSigningKeysDAL) Create(arg0 pgx.Tx, arg1 *models.SigningKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSigningKeysDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSigningKeysDAL)(nil).Create), arg0, arg1)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockSigningKeysDAL) DeleteExpired() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockSigningKeysDALMockRecorder) DeleteExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockSigningKeysDAL)(nil).DeleteExpired))
+}
+
+// GetVerifiable mocks base method.
+func (m *MockSigningKeysDAL) GetVerifiable() ([]*models.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVerifiable")
+	ret0, _ := ret[0].([]*models.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVerifiable indicates an expected call of GetVerifiable.
+func (mr *MockSigningKeysDALMockRecorder) GetVerifiable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVerifiable", reflect.TypeOf((*MockSigningKeysDAL)(nil).GetVerifiable))
+}
+
+// RetireActive mocks base method.
+func (m *MockSigningKeysDAL) RetireActive(arg0 pgx.Tx, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetireActive", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetireActive indicates an expected call of RetireActive.
+func (mr *MockSigningKeysDALMockRecorder) RetireActive(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetireActive", reflect.TypeOf((*MockSigningKeysDAL)(nil).RetireActive), arg0, arg1)
+}
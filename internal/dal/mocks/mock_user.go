@@ -12,6 +12,7 @@ package mocks
 import (
 	reflect "reflect"
 
+	dal "github.com/asatraitis/mangrove/internal/dal"
 	models "github.com/asatraitis/mangrove/internal/dal/models"
 	uuid "github.com/google/uuid"
 	pgx "github.com/jackc/pgx/v5"
@@ -42,6 +43,21 @@ func (m *MockUserDAL) EXPECT() *MockUserDALMockRecorder {
 	return m.recorder
 }
 
+// CountActiveSuperadmins mocks base method.
+func (m *MockUserDAL) CountActiveSuperadmins(arg0 pgx.Tx) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountActiveSuperadmins", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountActiveSuperadmins indicates an expected call of CountActiveSuperadmins.
+func (mr *MockUserDALMockRecorder) CountActiveSuperadmins(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountActiveSuperadmins", reflect.TypeOf((*MockUserDAL)(nil).CountActiveSuperadmins), arg0)
+}
+
 // Create mocks base method.
 func (m *MockUserDAL) Create(arg0 pgx.Tx, arg1 *models.User) error {
 	m.ctrl.T.Helper()
@@ -56,6 +72,21 @@ func (mr *MockUserDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserDAL)(nil).Create), arg0, arg1)
 }
 
+// ExistsByUsername mocks base method.
+func (m *MockUserDAL) ExistsByUsername(arg0 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsByUsername", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsByUsername indicates an expected call of ExistsByUsername.
+func (mr *MockUserDALMockRecorder) ExistsByUsername(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsByUsername", reflect.TypeOf((*MockUserDAL)(nil).ExistsByUsername), arg0)
+}
+
 // GetByID mocks base method.
 func (m *MockUserDAL) GetByID(arg0 uuid.UUID) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -100,3 +131,33 @@ func (mr *MockUserDALMockRecorder) GetByUsernameWithCredentials(arg0 any) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsernameWithCredentials", reflect.TypeOf((*MockUserDAL)(nil).GetByUsernameWithCredentials), arg0)
 }
+
+// List mocks base method.
+func (m *MockUserDAL) List(arg0 dal.UserFilter) ([]*models.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockUserDALMockRecorder) List(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserDAL)(nil).List), arg0)
+}
+
+// Update mocks base method.
+func (m *MockUserDAL) Update(arg0 pgx.Tx, arg1 *models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserDALMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserDAL)(nil).Update), arg0, arg1)
+}
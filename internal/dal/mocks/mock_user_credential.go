@@ -11,6 +11,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	models "github.com/asatraitis/mangrove/internal/dal/models"
 	uuid "github.com/google/uuid"
@@ -56,6 +57,36 @@ func (mr *MockUserCredentialsDALMockRecorder) Create(tx, credential any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserCredentialsDAL)(nil).Create), tx, credential)
 }
 
+// DeleteUnlessLast mocks base method.
+func (m *MockUserCredentialsDAL) DeleteUnlessLast(userID uuid.UUID, ID []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnlessLast", userID, ID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUnlessLast indicates an expected call of DeleteUnlessLast.
+func (mr *MockUserCredentialsDALMockRecorder) DeleteUnlessLast(userID, ID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnlessLast", reflect.TypeOf((*MockUserCredentialsDAL)(nil).DeleteUnlessLast), userID, ID)
+}
+
+// GetByID mocks base method.
+func (m *MockUserCredentialsDAL) GetByID(arg0 []byte) (*models.UserCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0)
+	ret0, _ := ret[0].(*models.UserCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserCredentialsDALMockRecorder) GetByID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserCredentialsDAL)(nil).GetByID), arg0)
+}
+
 // GetByUserID mocks base method.
 func (m *MockUserCredentialsDAL) GetByUserID(arg0 uuid.UUID) ([]*models.UserCredential, error) {
 	m.ctrl.T.Helper()
@@ -71,16 +102,31 @@ func (mr *MockUserCredentialsDALMockRecorder) GetByUserID(arg0 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockUserCredentialsDAL)(nil).GetByUserID), arg0)
 }
 
-// UpdateSignCount mocks base method.
-func (m *MockUserCredentialsDAL) UpdateSignCount(arg0 pgx.Tx, arg1 []byte, arg2 uint32) error {
+// MarkUsed mocks base method.
+func (m *MockUserCredentialsDAL) MarkUsed(tx pgx.Tx, ID []byte, signCount uint32, usedAt time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSignCount", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "MarkUsed", tx, ID, signCount, usedAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateSignCount indicates an expected call of UpdateSignCount.
-func (mr *MockUserCredentialsDALMockRecorder) UpdateSignCount(arg0, arg1, arg2 any) *gomock.Call {
+// MarkUsed indicates an expected call of MarkUsed.
+func (mr *MockUserCredentialsDALMockRecorder) MarkUsed(tx, ID, signCount, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsed", reflect.TypeOf((*MockUserCredentialsDAL)(nil).MarkUsed), tx, ID, signCount, usedAt)
+}
+
+// Rename mocks base method.
+func (m *MockUserCredentialsDAL) Rename(userID uuid.UUID, ID []byte, name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", userID, ID, name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockUserCredentialsDALMockRecorder) Rename(userID, ID, name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSignCount", reflect.TypeOf((*MockUserCredentialsDAL)(nil).UpdateSignCount), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockUserCredentialsDAL)(nil).Rename), userID, ID, name)
 }
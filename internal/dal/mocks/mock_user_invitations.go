@@ -0,0 +1,133 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: UserInvitationsDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_user_invitations.go -package=mocks github.com/asatraitis/mangrove/internal/dal UserInvitationsDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserInvitationsDAL is a mock of UserInvitationsDAL interface.
+type MockUserInvitationsDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserInvitationsDALMockRecorder
+	isgomock struct{}
+}
+
+// MockUserInvitationsDALMockRecorder is the mock recorder for MockUserInvitationsDAL.
+type MockUserInvitationsDALMockRecorder struct {
+	mock *MockUserInvitationsDAL
+}
+
+// NewMockUserInvitationsDAL creates a new mock instance.
+func NewMockUserInvitationsDAL(ctrl *gomock.Controller) *MockUserInvitationsDAL {
+	mock := &MockUserInvitationsDAL{ctrl: ctrl}
+	mock.recorder = &MockUserInvitationsDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserInvitationsDAL) EXPECT() *MockUserInvitationsDALMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUserInvitationsDAL) Create(arg0 pgx.Tx, arg1 *models.UserInvitation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserInvitationsDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserInvitationsDAL)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockUserInvitationsDAL) Delete(arg0 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserInvitationsDALMockRecorder) Delete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserInvitationsDAL)(nil).Delete), arg0)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockUserInvitationsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockUserInvitationsDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockUserInvitationsDAL)(nil).DeleteExpired), before, limit)
+}
+
+// GetAll mocks base method.
+func (m *MockUserInvitationsDAL) GetAll() ([]*models.UserInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll")
+	ret0, _ := ret[0].([]*models.UserInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockUserInvitationsDALMockRecorder) GetAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockUserInvitationsDAL)(nil).GetAll))
+}
+
+// GetByCodeHash mocks base method.
+func (m *MockUserInvitationsDAL) GetByCodeHash(arg0 string) (*models.UserInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCodeHash", arg0)
+	ret0, _ := ret[0].(*models.UserInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCodeHash indicates an expected call of GetByCodeHash.
+func (mr *MockUserInvitationsDALMockRecorder) GetByCodeHash(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCodeHash", reflect.TypeOf((*MockUserInvitationsDAL)(nil).GetByCodeHash), arg0)
+}
+
+// MarkUsed mocks base method.
+func (m *MockUserInvitationsDAL) MarkUsed(tx pgx.Tx, ID, userID uuid.UUID, usedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUsed", tx, ID, userID, usedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkUsed indicates an expected call of MarkUsed.
+func (mr *MockUserInvitationsDALMockRecorder) MarkUsed(tx, ID, userID, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsed", reflect.TypeOf((*MockUserInvitationsDAL)(nil).MarkUsed), tx, ID, userID, usedAt)
+}
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	models "github.com/asatraitis/mangrove/internal/dal/models"
 	uuid "github.com/google/uuid"
@@ -56,6 +57,109 @@ func (mr *MockUserTokensDALMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserTokensDAL)(nil).Create), arg0, arg1)
 }
 
+// Delete mocks base method.
+func (m *MockUserTokensDAL) Delete(tx pgx.Tx, ID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", tx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserTokensDALMockRecorder) Delete(tx, ID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserTokensDAL)(nil).Delete), tx, ID)
+}
+
+// DeleteByClientID mocks base method.
+func (m *MockUserTokensDAL) DeleteByClientID(arg0 pgx.Tx, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByClientID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteByClientID indicates an expected call of DeleteByClientID.
+func (mr *MockUserTokensDALMockRecorder) DeleteByClientID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByClientID", reflect.TypeOf((*MockUserTokensDAL)(nil).DeleteByClientID), arg0, arg1)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockUserTokensDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockUserTokensDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockUserTokensDAL)(nil).DeleteExpired), before, limit)
+}
+
+// DeleteOfDisabledUsers mocks base method.
+func (m *MockUserTokensDAL) DeleteOfDisabledUsers(limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOfDisabledUsers", limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOfDisabledUsers indicates an expected call of DeleteOfDisabledUsers.
+func (mr *MockUserTokensDALMockRecorder) DeleteOfDisabledUsers(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOfDisabledUsers", reflect.TypeOf((*MockUserTokensDAL)(nil).DeleteOfDisabledUsers), limit)
+}
+
+// DeleteSession mocks base method.
+func (m *MockUserTokensDAL) DeleteSession(tx pgx.Tx, userID, ID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", tx, userID, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockUserTokensDALMockRecorder) DeleteSession(tx, userID, ID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockUserTokensDAL)(nil).DeleteSession), tx, userID, ID)
+}
+
+// DeleteSessionsByUserID mocks base method.
+func (m *MockUserTokensDAL) DeleteSessionsByUserID(arg0 pgx.Tx, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionsByUserID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSessionsByUserID indicates an expected call of DeleteSessionsByUserID.
+func (mr *MockUserTokensDALMockRecorder) DeleteSessionsByUserID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionsByUserID", reflect.TypeOf((*MockUserTokensDAL)(nil).DeleteSessionsByUserID), arg0, arg1)
+}
+
+// GetByHashWithUser mocks base method.
+func (m *MockUserTokensDAL) GetByHashWithUser(arg0 []byte) (*models.UserToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHashWithUser", arg0)
+	ret0, _ := ret[0].(*models.UserToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHashWithUser indicates an expected call of GetByHashWithUser.
+func (mr *MockUserTokensDALMockRecorder) GetByHashWithUser(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHashWithUser", reflect.TypeOf((*MockUserTokensDAL)(nil).GetByHashWithUser), arg0)
+}
+
 // GetByID mocks base method.
 func (m *MockUserTokensDAL) GetByID(arg0 uuid.UUID) (*models.UserToken, error) {
 	m.ctrl.T.Helper()
@@ -71,17 +175,31 @@ func (mr *MockUserTokensDALMockRecorder) GetByID(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserTokensDAL)(nil).GetByID), arg0)
 }
 
-// GetByIdWithUser mocks base method.
-func (m *MockUserTokensDAL) GetByIdWithUser(arg0 uuid.UUID) (*models.UserToken, error) {
+// GetSessionsByUserID mocks base method.
+func (m *MockUserTokensDAL) GetSessionsByUserID(arg0 uuid.UUID) ([]*models.UserToken, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByIdWithUser", arg0)
-	ret0, _ := ret[0].(*models.UserToken)
+	ret := m.ctrl.Call(m, "GetSessionsByUserID", arg0)
+	ret0, _ := ret[0].([]*models.UserToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByIdWithUser indicates an expected call of GetByIdWithUser.
-func (mr *MockUserTokensDALMockRecorder) GetByIdWithUser(arg0 any) *gomock.Call {
+// GetSessionsByUserID indicates an expected call of GetSessionsByUserID.
+func (mr *MockUserTokensDALMockRecorder) GetSessionsByUserID(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionsByUserID", reflect.TypeOf((*MockUserTokensDAL)(nil).GetSessionsByUserID), arg0)
+}
+
+// Touch mocks base method.
+func (m *MockUserTokensDAL) Touch(ID uuid.UUID, seenAt, expires time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ID, seenAt, expires)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockUserTokensDALMockRecorder) Touch(ID, seenAt, expires any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdWithUser", reflect.TypeOf((*MockUserTokensDAL)(nil).GetByIdWithUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockUserTokensDAL)(nil).Touch), ID, seenAt, expires)
 }
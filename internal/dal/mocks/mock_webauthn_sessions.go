@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/asatraitis/mangrove/internal/dal (interfaces: WebauthnSessionsDAL)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_webauthn_sessions.go -package=mocks github.com/asatraitis/mangrove/internal/dal WebauthnSessionsDAL
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/asatraitis/mangrove/internal/dal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWebauthnSessionsDAL is a mock of WebauthnSessionsDAL interface.
+type MockWebauthnSessionsDAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebauthnSessionsDALMockRecorder
+	isgomock struct{}
+}
+
+// MockWebauthnSessionsDALMockRecorder is the mock recorder for MockWebauthnSessionsDAL.
+type MockWebauthnSessionsDALMockRecorder struct {
+	mock *MockWebauthnSessionsDAL
+}
+
+// NewMockWebauthnSessionsDAL creates a new mock instance.
+func NewMockWebauthnSessionsDAL(ctrl *gomock.Controller) *MockWebauthnSessionsDAL {
+	mock := &MockWebauthnSessionsDAL{ctrl: ctrl}
+	mock.recorder = &MockWebauthnSessionsDALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebauthnSessionsDAL) EXPECT() *MockWebauthnSessionsDALMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpired mocks base method.
+func (m *MockWebauthnSessionsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockWebauthnSessionsDALMockRecorder) DeleteExpired(before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockWebauthnSessionsDAL)(nil).DeleteExpired), before, limit)
+}
+
+// Get mocks base method.
+func (m *MockWebauthnSessionsDAL) Get(arg0 string) (*models.WebauthnSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0)
+	ret0, _ := ret[0].(*models.WebauthnSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockWebauthnSessionsDALMockRecorder) Get(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWebauthnSessionsDAL)(nil).Get), arg0)
+}
+
+// GetAndDelete mocks base method.
+func (m *MockWebauthnSessionsDAL) GetAndDelete(arg0 string) (*models.WebauthnSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAndDelete", arg0)
+	ret0, _ := ret[0].(*models.WebauthnSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAndDelete indicates an expected call of GetAndDelete.
+func (mr *MockWebauthnSessionsDALMockRecorder) GetAndDelete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAndDelete", reflect.TypeOf((*MockWebauthnSessionsDAL)(nil).GetAndDelete), arg0)
+}
+
+// Set mocks base method.
+func (m *MockWebauthnSessionsDAL) Set(arg0 *models.WebauthnSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockWebauthnSessionsDALMockRecorder) Set(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockWebauthnSessionsDAL)(nil).Set), arg0)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CodeChallengeMethod string
+
+const (
+	CODE_CHALLENGE_METHOD_S256 CodeChallengeMethod = "S256"
+)
+
+type AuthorizationCode struct {
+	// Code is the HMAC of the code handed out to the client; raw codes are never stored
+	Code                string              `json:"code"`
+	ClientID            uuid.UUID           `json:"clientId"`
+	UserID              uuid.UUID           `json:"userId"`
+	RedirectURI         string              `json:"redirectURI"`
+	Scope               string              `json:"scope"`
+	CodeChallenge       string              `json:"codeChallenge"`
+	CodeChallengeMethod CodeChallengeMethod `json:"codeChallengeMethod"`
+	Expires             time.Time           `json:"expires"`
+	Nonce               string              `json:"nonce"`
+	AuthTime            time.Time           `json:"authTime"`
+	AMR                 []string            `json:"amr"`
+	ACR                 string              `json:"acr"`
+}
@@ -18,16 +18,30 @@ type ClientKeyAlgo string
 
 const (
 	CLIENT_KEY_ALGO_EDDSA ClientKeyAlgo = "EdDSA"
+	CLIENT_KEY_ALGO_ES256 ClientKeyAlgo = "ES256"
 )
 
 type Client struct {
-	ID           uuid.UUID     `json:"id"`
-	UserID       uuid.UUID     `json:"userId"`
-	Name         string        `json:"name"`
-	Description  string        `json:"description,omitempty"`
-	RedirectURI  string        `json:"redirectURI"`
-	PublicKey    []byte        `json:"publicKey"`
-	KeyExpiresAt time.Time     `json:"keyExpiresAt"`
-	KeyAlgo      ClientKeyAlgo `json:"keyAlgo"`
-	Status       ClientStatus  `json:"status"`
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"userId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	// RedirectURIs are matched exactly, except for the port of loopback IP redirects (RFC 8252)
+	RedirectURIs           []string `json:"redirectURIs"`
+	PostLogoutRedirectURIs []string `json:"postLogoutRedirectURIs"`
+	// WebOrigins are the browser origins the client's front end is served from
+	WebOrigins []string `json:"webOrigins"`
+	// JWKSURI is an optional client hosted JWK set used alongside the keys registered in client_keys
+	JWKSURI string       `json:"jwksURI,omitempty"`
+	Status  ClientStatus `json:"status"`
+	// StatusReason explains why the client was paused or suspended
+	StatusReason    string     `json:"statusReason,omitempty"`
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty"`
+	// AccessTokenLifetime is how many seconds the access tokens issued to the client are valid
+	AccessTokenLifetime int `json:"accessTokenLifetime"`
+	// RefreshTokenLifetime is how many seconds a refresh token can be used; every refresh rotates it.
+	// 0 means the client gets no refresh tokens
+	RefreshTokenLifetime int `json:"refreshTokenLifetime"`
+	// RefreshTokenMaxLifetime is how many seconds a chain of rotated refresh tokens lives before the user has to sign in again
+	RefreshTokenMaxLifetime int `json:"refreshTokenMaxLifetime"`
 }
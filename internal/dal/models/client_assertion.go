@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientAssertion records a used private_key_jwt assertion so its jti cannot be replayed before it expires
+type ClientAssertion struct {
+	ClientID uuid.UUID `json:"clientId"`
+	JTI      string    `json:"jti"`
+	Expires  time.Time `json:"expires"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ClientKey struct {
+	ID       uuid.UUID `json:"id"`
+	ClientID uuid.UUID `json:"clientId"`
+	// KID is matched against the kid header of client assertions; unique per client
+	KID       string        `json:"kid"`
+	Algorithm ClientKeyAlgo `json:"algorithm"`
+	// PublicKey is PKIX DER encoded (EdDSA keys may also be the raw 32 bytes)
+	PublicKey []byte     `json:"publicKey"`
+	NotBefore time.Time  `json:"notBefore"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	Revoked   bool       `json:"revoked"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
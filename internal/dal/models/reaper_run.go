@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReaperRun records one pass of the expired-data reaper
+type ReaperRun struct {
+	ID         uuid.UUID `json:"id"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// Removed is the number of rows deleted per kind of data, e.g. "user_tokens"
+	Removed map[string]int64 `json:"removed"`
+	// Error lists the purges that failed; the others still ran
+	Error *string `json:"error,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshToken struct {
+	ID uuid.UUID `json:"id"`
+	// TokenHash is the SHA-256 digest of the opaque token handed to the client
+	TokenHash []byte `json:"-"`
+	// Token is the opaque token itself; it is only set when the token is created and never stored
+	Token string `json:"-" db:"-"`
+	// FamilyID is shared by all tokens rotated out of the same authorization; replaying a used token revokes the family
+	FamilyID  uuid.UUID `json:"familyId"`
+	ClientID  uuid.UUID `json:"clientId"`
+	UserID    uuid.UUID `json:"userId"`
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"createdAt"`
+	Expires   time.Time `json:"expires"`
+	// FamilyExpires caps Expires of every token in the family
+	FamilyExpires time.Time  `json:"familyExpires"`
+	UsedAt        *time.Time `json:"usedAt,omitempty"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty"`
+}
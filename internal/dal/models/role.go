@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type Permission string
+
+const (
+	PERMISSION_CLIENTS_READ  Permission = "clients:read"
+	PERMISSION_CLIENTS_WRITE Permission = "clients:write"
+	PERMISSION_USERS_MANAGE  Permission = "users:manage"
+	PERMISSION_CONFIG_WRITE  Permission = "config:write"
+)
+
+// Permissions lists every permission a role can hold
+var Permissions = []Permission{
+	PERMISSION_CLIENTS_READ,
+	PERMISSION_CLIENTS_WRITE,
+	PERMISSION_USERS_MANAGE,
+	PERMISSION_CONFIG_WRITE,
+}
+
+// Role is a custom role; the built-in user, admin and superadmin roles are not stored
+type Role struct {
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
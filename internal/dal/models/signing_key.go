@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type SigningKeyAlgorithm string
+
+const (
+	SIGNING_KEY_ALGORITHM_EDDSA SigningKeyAlgorithm = "EdDSA"
+	SIGNING_KEY_ALGORITHM_ES256 SigningKeyAlgorithm = "ES256"
+)
+
+type SigningKeyStatus string
+
+const (
+	SIGNING_KEY_STATUS_ACTIVE  SigningKeyStatus = "active"
+	SIGNING_KEY_STATUS_RETIRED SigningKeyStatus = "retired"
+)
+
+type SigningKey struct {
+	// ID is the kid published in the JWKS
+	ID        string              `json:"id"`
+	Algorithm SigningKeyAlgorithm `json:"algorithm"`
+	// PublicKey is PKIX DER encoded
+	PublicKey []byte `json:"publicKey"`
+	// PrivateKey is the PKCS #8 DER encoded key, encrypted with the key encryption key
+	PrivateKey []byte           `json:"-"`
+	Status     SigningKeyStatus `json:"status"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	RetiredAt  *time.Time       `json:"retiredAt,omitempty"`
+	// ExpiresAt is the end of the grace period during which a retired key still verifies
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
@@ -1,10 +1,15 @@
 package models
 
 import (
+	"time"
+
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/google/uuid"
 )
 
+// USER_CREDENTIAL_DEFAULT_NAME names passkeys the user did not name at enrollment
+const USER_CREDENTIAL_DEFAULT_NAME = "Passkey"
+
 type UserCredential struct {
 	ID                            []byte                            `json:"id"`
 	UserID                        uuid.UUID                         `json:"userId"`
@@ -24,4 +29,8 @@ type UserCredential struct {
 	AttestationAuthenticatorData  []byte                            `json:"attestationAuthenticatorData"`
 	AttestationPublicKeyAlgorithm int64                             `json:"attestationPublicKeyAlgorithm"`
 	AttestationObject             []byte                            `json:"attestationObject"`
+	// Name is the user's label for the passkey, e.g. "Backup YubiKey"
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
 }
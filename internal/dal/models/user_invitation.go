@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserInvitation is a single-use invitation to register; only the hash of the code is stored
+type UserInvitation struct {
+	ID        uuid.UUID  `json:"id"`
+	CodeHash  string     `json:"-"`
+	Email     *string    `json:"email,omitempty"`
+	Role      UserRole   `json:"role"`
+	CreatedBy uuid.UUID  `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	UsedBy    *uuid.UUID `json:"usedBy,omitempty"`
+}
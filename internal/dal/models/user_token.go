@@ -7,8 +7,22 @@ import (
 )
 
 type UserToken struct {
-	ID      uuid.UUID `json:"id"`
+	// ID identifies the token, e.g. to list and revoke sessions; it does not authenticate anyone
+	ID uuid.UUID `json:"id"`
+	// TokenHash is the SHA-256 digest of the opaque token handed to the holder
+	TokenHash []byte `json:"-"`
+	// Token is the opaque token itself; it is only set when the token is created and never stored
+	Token   string    `json:"-" db:"-"`
 	UserID  uuid.UUID `json:"userId"`
 	Expires time.Time `json:"expires"`
-	User    *User     `json:"user,omitempty"`
+	// ClientID is set for access tokens issued to OAuth clients; nil for browser sessions
+	ClientID  *uuid.UUID `json:"clientId,omitempty"`
+	Scope     string     `json:"scope,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	// LastSeenAt is refreshed at most once a minute while the session is in use, which also slides Expires
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+	// IP and UserAgent describe the browser the session was created from
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	User      *User  `json:"user,omitempty"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// WebauthnSession is an unfinished WebAuthn ceremony shared between instances
+type WebauthnSession struct {
+	Key string `json:"key"`
+	// Data is the JSON encoded webauthn.SessionData
+	Data      []byte    `json:"data"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,73 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+//go:generate mockgen -destination=./mocks/mock_reaper_runs.go -package=mocks github.com/asatraitis/mangrove/internal/dal ReaperRunsDAL
+type ReaperRunsDAL interface {
+	Create(*models.ReaperRun) error
+	// GetLatest lists up to limit runs, newest first
+	GetLatest(limit int) ([]*models.ReaperRun, error)
+	// DeleteExpired deletes up to limit runs that started before the given time
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type reaperRunsDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewReaperRunsDAL(ctx context.Context, baseDAL *BaseDAL) ReaperRunsDAL {
+	rrDAL := &reaperRunsDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	rrDAL.logger = baseDAL.logger.With().Str("subcomponent", "ReaperRunsDAL").Logger()
+	return rrDAL
+}
+
+func (rr *reaperRunsDAL) Create(run *models.ReaperRun) error {
+	const funcName = "Create"
+	const query = "INSERT INTO reaper_runs (id, started_at, finished_at, removed, error) VALUES ($1, $2, $3, $4, $5)"
+
+	if run == nil {
+		rr.logger.Error().Str("func", funcName).Msg("nil reaper run")
+		return errors.New("failed to create reaper run; nil run")
+	}
+
+	_, err := rr.db.Exec(rr.ctx, query, run.ID, run.StartedAt, run.FinishedAt, run.Removed, run.Error)
+	if err != nil {
+		rr.logger.Err(err).Str("func", funcName).Msg("failed to insert reaper run")
+	}
+	return err
+}
+
+func (rr *reaperRunsDAL) GetLatest(limit int) ([]*models.ReaperRun, error) {
+	const funcName = "GetLatest"
+	const query = "SELECT id, started_at, finished_at, removed, error FROM reaper_runs ORDER BY started_at DESC LIMIT $1"
+
+	var runs []*models.ReaperRun
+	err := pgxscan.Select(rr.ctx, rr.db, &runs, query, limit)
+	if err != nil {
+		rr.logger.Err(err).Str("func", funcName).Msg("failed to get reaper runs")
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (rr *reaperRunsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM reaper_runs WHERE id IN (SELECT id FROM reaper_runs WHERE started_at <= $1 LIMIT $2)"
+
+	tag, err := rr.db.Exec(rr.ctx, query, before, limit)
+	if err != nil {
+		rr.logger.Err(err).Str("func", funcName).Msg("failed to delete old reaper runs")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,73 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReaperRunsDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+}
+
+func TestReaperRunsDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(ReaperRunsDALTestSuite))
+}
+
+func (suite *ReaperRunsDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+func (suite *ReaperRunsDALTestSuite) SetupTest()    {}
+func (suite *ReaperRunsDALTestSuite) TearDownTest() {}
+
+func (suite *ReaperRunsDALTestSuite) TestCreateGetLatest_OK() {
+	failure := "user_tokens: test"
+	run := &models.ReaperRun{
+		ID:         uuid.New(),
+		StartedAt:  time.Now().Add(time.Hour),
+		FinishedAt: time.Now().Add(time.Hour + time.Second),
+		Removed:    map[string]int64{"user_tokens": 3, "authorization_codes": 0},
+		Error:      &failure,
+	}
+	suite.NoError(suite.dal.ReaperRuns(suite.ctx).Create(run))
+
+	runs, err := suite.dal.ReaperRuns(suite.ctx).GetLatest(1)
+	suite.NoError(err)
+	suite.Len(runs, 1)
+	suite.Equal(run.ID, runs[0].ID)
+	suite.Equal(run.Removed, runs[0].Removed)
+	suite.Equal(&failure, runs[0].Error)
+}
+
+func (suite *ReaperRunsDALTestSuite) TestCreate_FAIL_Nil() {
+	suite.Error(suite.dal.ReaperRuns(suite.ctx).Create(nil))
+}
+
+func (suite *ReaperRunsDALTestSuite) TestDeleteExpired_OK() {
+	old := &models.ReaperRun{ID: uuid.New(), StartedAt: time.Now().Add(-48 * time.Hour), FinishedAt: time.Now().Add(-48 * time.Hour), Removed: map[string]int64{}}
+	suite.NoError(suite.dal.ReaperRuns(suite.ctx).Create(old))
+
+	deleted, err := suite.dal.ReaperRuns(suite.ctx).DeleteExpired(time.Now().Add(-24*time.Hour), 1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(1))
+}
@@ -0,0 +1,157 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:generate mockgen -destination=./mocks/mock_refresh_tokens.go -package=mocks github.com/asatraitis/mangrove/internal/dal RefreshTokensDAL
+type RefreshTokensDAL interface {
+	Create(pgx.Tx, *models.RefreshToken) error
+	// GetByHash looks a token up by the digest of the opaque token presented by the client
+	GetByHash([]byte) (*models.RefreshToken, error)
+	// MarkUsed marks an unused, unrevoked token as used; false when it was already used or revoked
+	MarkUsed(tx pgx.Tx, ID uuid.UUID, usedAt time.Time) (bool, error)
+	// RevokeFamily revokes every token rotated out of the same authorization
+	RevokeFamily(tx pgx.Tx, familyID uuid.UUID, revokedAt time.Time) (int64, error)
+	DeleteByClientID(pgx.Tx, uuid.UUID) (int64, error)
+	// DeleteExpired deletes up to limit tokens whose family expired before the given time; used tokens are
+	// kept until then so replaying them is still detected
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type refreshTokensDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewRefreshTokensDAL(ctx context.Context, baseDAL *BaseDAL) RefreshTokensDAL {
+	rtDAL := &refreshTokensDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	rtDAL.logger = baseDAL.logger.With().Str("subcomponent", "RefreshTokensDAL").Logger()
+	return rtDAL
+}
+
+func (rt *refreshTokensDAL) Create(tx pgx.Tx, token *models.RefreshToken) error {
+	const funcName = "Create"
+	const query = "INSERT INTO refresh_tokens (id, token_hash, family_id, client_id, user_id, scope, created_at, expires, family_expires) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);"
+
+	if token == nil {
+		rt.logger.Error().Str("func", funcName).Msg("nil refresh token")
+		return errors.New("failed to create refresh token; nil token")
+	}
+	if len(token.TokenHash) == 0 {
+		rt.logger.Error().Str("func", funcName).Msg("refresh token without hash")
+		return errors.New("failed to create refresh token; no token hash")
+	}
+	args := []interface{}{
+		token.ID,
+		token.TokenHash,
+		token.FamilyID,
+		token.ClientID,
+		token.UserID,
+		token.Scope,
+		token.CreatedAt,
+		token.Expires,
+		token.FamilyExpires,
+	}
+
+	var err error
+	if tx == nil {
+		_, err = rt.db.Exec(rt.ctx, query, args...)
+	} else {
+		_, err = tx.Exec(rt.ctx, query, args...)
+	}
+	if err != nil {
+		rt.logger.Err(err).Str("func", funcName).Msg("failed to insert refresh token")
+	}
+	return err
+}
+
+func (rt *refreshTokensDAL) GetByHash(tokenHash []byte) (*models.RefreshToken, error) {
+	const funcName = "GetByHash"
+	const query = "SELECT id, token_hash, family_id, client_id, user_id, scope, created_at, expires, family_expires, used_at, revoked_at FROM refresh_tokens WHERE token_hash = $1"
+
+	token := &models.RefreshToken{}
+	err := pgxscan.Get(rt.ctx, rt.db, token, query, tokenHash)
+	if err != nil {
+		rt.logger.Err(err).Str("func", funcName).Msg("failed to get refresh token")
+		return nil, err
+	}
+	return token, nil
+}
+
+func (rt *refreshTokensDAL) MarkUsed(tx pgx.Tx, ID uuid.UUID, usedAt time.Time) (bool, error) {
+	const funcName = "MarkUsed"
+	const query = "UPDATE refresh_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = rt.db.Exec(rt.ctx, query, ID, usedAt)
+	} else {
+		tag, err = tx.Exec(rt.ctx, query, ID, usedAt)
+	}
+	if err != nil {
+		rt.logger.Err(err).Str("func", funcName).Msg("failed to mark refresh token used")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (rt *refreshTokensDAL) RevokeFamily(tx pgx.Tx, familyID uuid.UUID, revokedAt time.Time) (int64, error) {
+	const funcName = "RevokeFamily"
+	const query = "UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = rt.db.Exec(rt.ctx, query, familyID, revokedAt)
+	} else {
+		tag, err = tx.Exec(rt.ctx, query, familyID, revokedAt)
+	}
+	if err != nil {
+		rt.logger.Err(err).Str("func", funcName).Msg("failed to revoke refresh token family")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteByClientID drops every refresh token issued to the client
+func (rt *refreshTokensDAL) DeleteByClientID(tx pgx.Tx, clientID uuid.UUID) (int64, error) {
+	const funcName = "DeleteByClientID"
+	const query = "DELETE FROM refresh_tokens WHERE client_id = $1"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = rt.db.Exec(rt.ctx, query, clientID)
+	} else {
+		tag, err = tx.Exec(rt.ctx, query, clientID)
+	}
+	if err != nil {
+		rt.logger.Err(err).Str("func", funcName).Msg("failed to delete client refresh tokens")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (rt *refreshTokensDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM refresh_tokens WHERE id IN (SELECT id FROM refresh_tokens WHERE family_expires <= $1 LIMIT $2)"
+
+	tag, err := rt.db.Exec(rt.ctx, query, before, limit)
+	if err != nil {
+		rt.logger.Err(err).Str("func", funcName).Msg("failed to delete expired refresh tokens")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
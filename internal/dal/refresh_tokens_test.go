@@ -0,0 +1,159 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type RefreshTokensDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+
+	userID   uuid.UUID
+	clientID uuid.UUID
+}
+
+func TestRefreshTokensDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(RefreshTokensDALTestSuite))
+}
+
+func (suite *RefreshTokensDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+
+func (suite *RefreshTokensDALTestSuite) SetupTest() {
+	suite.userID = uuid.New()
+	suite.NoError(suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          suite.userID,
+		Username:    uuid.NewString(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	}))
+	suite.clientID = uuid.New()
+	suite.NoError(suite.dal.Client(suite.ctx).Create(nil, &models.Client{
+		ID:                     suite.clientID,
+		UserID:                 suite.userID,
+		Name:                   "test-client-name",
+		RedirectURIs:           []string{"http://localhost:3030"},
+		PostLogoutRedirectURIs: []string{},
+		WebOrigins:             []string{},
+		Status:                 models.CLIENT_STATUS_ACTIVE,
+	}))
+}
+func (suite *RefreshTokensDALTestSuite) TearDownTest() {}
+
+func (suite *RefreshTokensDALTestSuite) newToken(familyID uuid.UUID, familyExpires time.Time) *models.RefreshToken {
+	now := time.Now()
+	return &models.RefreshToken{
+		ID:            uuid.New(),
+		TokenHash:     utils.HashOpaqueToken(uuid.NewString()),
+		FamilyID:      familyID,
+		ClientID:      suite.clientID,
+		UserID:        suite.userID,
+		Scope:         "openid",
+		CreatedAt:     now,
+		Expires:       now.Add(time.Hour),
+		FamilyExpires: familyExpires,
+	}
+}
+
+func (suite *RefreshTokensDALTestSuite) TestCreateGetMarkUsed_OK() {
+	token := suite.newToken(uuid.New(), time.Now().Add(time.Hour*24))
+	suite.NoError(suite.dal.RefreshTokens(suite.ctx).Create(nil, token))
+
+	created, err := suite.dal.RefreshTokens(suite.ctx).GetByHash(token.TokenHash)
+	suite.NoError(err)
+	suite.Equal(token.ID, created.ID)
+	suite.Equal(token.FamilyID, created.FamilyID)
+	suite.Equal(suite.clientID, created.ClientID)
+	suite.Equal("openid", created.Scope)
+	suite.Nil(created.UsedAt)
+
+	used, err := suite.dal.RefreshTokens(suite.ctx).MarkUsed(nil, token.ID, time.Now())
+	suite.NoError(err)
+	suite.True(used)
+	used, err = suite.dal.RefreshTokens(suite.ctx).MarkUsed(nil, token.ID, time.Now())
+	suite.NoError(err)
+	suite.False(used)
+
+	created, err = suite.dal.RefreshTokens(suite.ctx).GetByHash(token.TokenHash)
+	suite.NoError(err)
+	suite.NotNil(created.UsedAt)
+
+	_, err = suite.dal.RefreshTokens(suite.ctx).GetByHash(utils.HashOpaqueToken(uuid.NewString()))
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *RefreshTokensDALTestSuite) TestCreate_FAIL_NoHash() {
+	token := suite.newToken(uuid.New(), time.Now().Add(time.Hour))
+	token.TokenHash = nil
+	suite.ErrorContains(suite.dal.RefreshTokens(suite.ctx).Create(nil, token), "no token hash")
+}
+
+func (suite *RefreshTokensDALTestSuite) TestRevokeFamily_OK() {
+	familyID := uuid.New()
+	first := suite.newToken(familyID, time.Now().Add(time.Hour*24))
+	second := suite.newToken(familyID, first.FamilyExpires)
+	other := suite.newToken(uuid.New(), first.FamilyExpires)
+	for _, token := range []*models.RefreshToken{first, second, other} {
+		suite.NoError(suite.dal.RefreshTokens(suite.ctx).Create(nil, token))
+	}
+
+	revoked, err := suite.dal.RefreshTokens(suite.ctx).RevokeFamily(nil, familyID, time.Now())
+	suite.NoError(err)
+	suite.Equal(int64(2), revoked)
+
+	used, err := suite.dal.RefreshTokens(suite.ctx).MarkUsed(nil, second.ID, time.Now())
+	suite.NoError(err)
+	suite.False(used)
+	token, err := suite.dal.RefreshTokens(suite.ctx).GetByHash(other.TokenHash)
+	suite.NoError(err)
+	suite.Nil(token.RevokedAt)
+
+	deleted, err := suite.dal.RefreshTokens(suite.ctx).DeleteByClientID(nil, suite.clientID)
+	suite.NoError(err)
+	suite.Equal(int64(3), deleted)
+}
+
+func (suite *RefreshTokensDALTestSuite) TestDeleteExpired_OK() {
+	now := time.Now()
+	expired := suite.newToken(uuid.New(), now.Add(-time.Hour))
+	valid := suite.newToken(uuid.New(), now.Add(time.Hour))
+	suite.NoError(suite.dal.RefreshTokens(suite.ctx).Create(nil, expired))
+	suite.NoError(suite.dal.RefreshTokens(suite.ctx).Create(nil, valid))
+
+	for {
+		deleted, err := suite.dal.RefreshTokens(suite.ctx).DeleteExpired(now, 100)
+		suite.Require().NoError(err)
+		if deleted < 100 {
+			break
+		}
+	}
+
+	_, err := suite.dal.RefreshTokens(suite.ctx).GetByHash(expired.TokenHash)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+	_, err = suite.dal.RefreshTokens(suite.ctx).GetByHash(valid.TokenHash)
+	suite.NoError(err)
+}
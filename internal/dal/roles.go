@@ -0,0 +1,136 @@
+package dal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:generate mockgen -destination=./mocks/mock_roles.go -package=mocks github.com/asatraitis/mangrove/internal/dal RolesDAL
+type RolesDAL interface {
+	Create(pgx.Tx, *models.Role) error
+	GetAll() ([]*models.Role, error)
+	GetByName(models.UserRole) (*models.Role, error)
+	Update(pgx.Tx, *models.Role) error
+	Delete(pgx.Tx, models.UserRole) (bool, error)
+}
+type rolesDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewRolesDAL(ctx context.Context, baseDAL *BaseDAL) RolesDAL {
+	rDAL := &rolesDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	rDAL.logger = baseDAL.logger.With().Str("subcomponent", "RolesDAL").Logger()
+	return rDAL
+}
+
+func (r *rolesDAL) Create(tx pgx.Tx, role *models.Role) error {
+	const funcName = "Create"
+	const query = "INSERT INTO roles (name, description, permissions, created_at) VALUES ($1, $2, $3, $4)"
+
+	if role == nil {
+		r.logger.Error().Str("func", funcName).Msg("nil role")
+		return errors.New("failed to create role; nil role")
+	}
+	args := []interface{}{
+		role.Name,
+		role.Description,
+		role.Permissions,
+		role.CreatedAt,
+	}
+
+	var err error
+	if tx == nil {
+		_, err = r.db.Exec(r.ctx, query, args...)
+	} else {
+		_, err = tx.Exec(r.ctx, query, args...)
+	}
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to insert role")
+	}
+	return err
+}
+
+func (r *rolesDAL) GetAll() ([]*models.Role, error) {
+	const funcName = "GetAll"
+	const query = "SELECT name, description, permissions, created_at FROM roles ORDER BY name"
+
+	var roles []*models.Role
+	err := pgxscan.Select(r.ctx, r.db, &roles, query)
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to get roles")
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *rolesDAL) GetByName(name models.UserRole) (*models.Role, error) {
+	const funcName = "GetByName"
+	const query = "SELECT name, description, permissions, created_at FROM roles WHERE name = $1"
+
+	role := &models.Role{}
+	err := pgxscan.Get(r.ctx, r.db, role, query, name)
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Str("role", string(name)).Msg("failed to get role")
+		return nil, err
+	}
+	return role, nil
+}
+
+// Update saves the description and permissions of the role; users holding it get the new permissions
+// on their next request
+func (r *rolesDAL) Update(tx pgx.Tx, role *models.Role) error {
+	const funcName = "Update"
+	const query = "UPDATE roles SET description = $1, permissions = $2 WHERE name = $3"
+
+	if role == nil {
+		r.logger.Error().Str("func", funcName).Msg("nil role")
+		return errors.New("failed to update role; nil role")
+	}
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = r.db.Exec(r.ctx, query, role.Description, role.Permissions, role.Name)
+	} else {
+		tag, err = tx.Exec(r.ctx, query, role.Description, role.Permissions, role.Name)
+	}
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to update role")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		r.logger.Error().Str("func", funcName).Str("role", string(role.Name)).Msg("role not found")
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes the role unless a user or a pending invitation still has it; false means nothing was deleted
+func (r *rolesDAL) Delete(tx pgx.Tx, name models.UserRole) (bool, error) {
+	const funcName = "Delete"
+	const query = `DELETE FROM roles WHERE name = $1
+		AND NOT EXISTS (SELECT 1 FROM users WHERE role = $1)
+		AND NOT EXISTS (SELECT 1 FROM user_invitations WHERE role = $1 AND used_at IS NULL AND expires_at > now())`
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = r.db.Exec(r.ctx, query, name)
+	} else {
+		tag, err = tx.Exec(r.ctx, query, name)
+	}
+	if err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to delete role")
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,104 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type RolesDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+}
+
+func TestRolesDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(RolesDALTestSuite))
+}
+
+func (suite *RolesDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+func (suite *RolesDALTestSuite) SetupTest()    {}
+func (suite *RolesDALTestSuite) TearDownTest() {}
+
+func (suite *RolesDALTestSuite) newRole() *models.Role {
+	return &models.Role{
+		Name:        models.UserRole("role-" + uuid.NewString()),
+		Description: "Test role",
+		Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ},
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func (suite *RolesDALTestSuite) TestCreateGetUpdate_OK() {
+	role := suite.newRole()
+	err := suite.dal.Roles(suite.ctx).Create(nil, role)
+	suite.NoError(err)
+
+	stored, err := suite.dal.Roles(suite.ctx).GetByName(role.Name)
+	suite.NoError(err)
+	suite.Equal(role, stored)
+
+	role.Description = "Updated role"
+	role.Permissions = []models.Permission{models.PERMISSION_CLIENTS_READ, models.PERMISSION_CLIENTS_WRITE}
+	err = suite.dal.Roles(suite.ctx).Update(nil, role)
+	suite.NoError(err)
+
+	roles, err := suite.dal.Roles(suite.ctx).GetAll()
+	suite.NoError(err)
+	suite.Contains(roles, role)
+
+	err = suite.dal.Roles(suite.ctx).Update(nil, suite.newRole())
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *RolesDALTestSuite) TestDelete_OK() {
+	role := suite.newRole()
+	err := suite.dal.Roles(suite.ctx).Create(nil, role)
+	suite.NoError(err)
+
+	deleted, err := suite.dal.Roles(suite.ctx).Delete(nil, role.Name)
+	suite.NoError(err)
+	suite.True(deleted)
+
+	_, err = suite.dal.Roles(suite.ctx).GetByName(role.Name)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *RolesDALTestSuite) TestDelete_FAIL_Assigned() {
+	role := suite.newRole()
+	err := suite.dal.Roles(suite.ctx).Create(nil, role)
+	suite.NoError(err)
+	err = suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          uuid.New(),
+		Username:    "role" + uuid.NewString(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        role.Name,
+	})
+	suite.NoError(err)
+
+	deleted, err := suite.dal.Roles(suite.ctx).Delete(nil, role.Name)
+	suite.NoError(err)
+	suite.False(deleted)
+}
@@ -0,0 +1,121 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+//go:generate mockgen -destination=./mocks/mock_signing_keys.go -package=mocks github.com/asatraitis/mangrove/internal/dal SigningKeysDAL
+type SigningKeysDAL interface {
+	Create(pgx.Tx, *models.SigningKey) error
+	GetVerifiable() ([]*models.SigningKey, error)
+	RetireActive(pgx.Tx, time.Time) error
+	DeleteExpired() (int64, error)
+}
+type signingKeysDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewSigningKeysDAL(ctx context.Context, baseDAL *BaseDAL) SigningKeysDAL {
+	skDAL := &signingKeysDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	skDAL.logger = baseDAL.logger.With().Str("subcomponent", "SigningKeysDAL").Logger()
+	return skDAL
+}
+
+func (sk *signingKeysDAL) Create(tx pgx.Tx, key *models.SigningKey) error {
+	const funcName = "Create"
+	const query = "INSERT INTO signing_keys (id, algorithm, public_key, private_key, status, created_at) VALUES ($1, $2, $3, $4, $5, $6);"
+
+	if key == nil {
+		sk.logger.Error().Str("func", funcName).Msg("nil signing key")
+		return errors.New("failed to create signing key; nil key")
+	}
+	args := []interface{}{
+		key.ID,
+		key.Algorithm,
+		key.PublicKey,
+		key.PrivateKey,
+		key.Status,
+		key.CreatedAt,
+	}
+
+	if tx == nil {
+		_, err := sk.db.Exec(
+			sk.ctx,
+			query,
+			args...,
+		)
+		if err != nil {
+			sk.logger.Err(err).Str("func", funcName).Msg("failed to insert signing key")
+		}
+		return err
+	}
+
+	_, err := tx.Exec(
+		sk.ctx,
+		query,
+		args...,
+	)
+	if err != nil {
+		sk.logger.Err(err).Str("func", funcName).Msg("failed to insert signing key")
+	}
+	return err
+}
+
+// GetVerifiable returns the active key and retired keys still within their grace period, newest first
+func (sk *signingKeysDAL) GetVerifiable() ([]*models.SigningKey, error) {
+	const funcName = "GetVerifiable"
+	const query = "SELECT id, algorithm, public_key, private_key, status, created_at, retired_at, expires_at FROM signing_keys WHERE status = $1 OR expires_at > $2 ORDER BY created_at DESC"
+
+	var keys []*models.SigningKey
+	err := pgxscan.Select(sk.ctx, sk.db, &keys, query, models.SIGNING_KEY_STATUS_ACTIVE, time.Now())
+	if err != nil {
+		sk.logger.Err(err).Str("func", funcName).Msg("failed to get signing keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RetireActive retires the current active key; it keeps verifying until expiresAt
+func (sk *signingKeysDAL) RetireActive(tx pgx.Tx, expiresAt time.Time) error {
+	const funcName = "RetireActive"
+	const query = "UPDATE signing_keys SET status = $1, retired_at = $2, expires_at = $3 WHERE status = $4"
+	args := []interface{}{
+		models.SIGNING_KEY_STATUS_RETIRED,
+		time.Now(),
+		expiresAt,
+		models.SIGNING_KEY_STATUS_ACTIVE,
+	}
+
+	var err error
+	if tx == nil {
+		_, err = sk.db.Exec(sk.ctx, query, args...)
+	} else {
+		_, err = tx.Exec(sk.ctx, query, args...)
+	}
+	if err != nil {
+		sk.logger.Err(err).Str("func", funcName).Msg("failed to retire signing key")
+	}
+	return err
+}
+
+func (sk *signingKeysDAL) DeleteExpired() (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM signing_keys WHERE status = $1 AND expires_at <= $2"
+
+	tag, err := sk.db.Exec(sk.ctx, query, models.SIGNING_KEY_STATUS_RETIRED, time.Now())
+	if err != nil {
+		sk.logger.Err(err).Str("func", funcName).Msg("failed to delete expired signing keys")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
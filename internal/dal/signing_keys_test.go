@@ -0,0 +1,103 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type SigningKeysDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+}
+
+func TestSigningKeysDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(SigningKeysDALTestSuite))
+}
+
+func (suite *SigningKeysDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+func (suite *SigningKeysDALTestSuite) SetupTest() {
+	_, err := suite.DB.Exec(suite.ctx, "DELETE FROM signing_keys")
+	suite.NoError(err)
+}
+func (suite *SigningKeysDALTestSuite) TearDownTest() {}
+
+func (suite *SigningKeysDALTestSuite) newKey() *models.SigningKey {
+	return &models.SigningKey{
+		ID:         uuid.NewString(),
+		Algorithm:  models.SIGNING_KEY_ALGORITHM_EDDSA,
+		PublicKey:  []byte("test-public-key"),
+		PrivateKey: []byte("test-private-key"),
+		Status:     models.SIGNING_KEY_STATUS_ACTIVE,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func (suite *SigningKeysDALTestSuite) TestRotate_OK() {
+	first := suite.newKey()
+	err := suite.dal.SigningKeys(suite.ctx).Create(nil, first)
+	suite.NoError(err)
+
+	tx, err := suite.DB.BeginTx(suite.ctx, pgx.TxOptions{})
+	suite.NoError(err)
+	err = suite.dal.SigningKeys(suite.ctx).RetireActive(tx, time.Now().Add(time.Hour))
+	suite.NoError(err)
+	second := suite.newKey()
+	err = suite.dal.SigningKeys(suite.ctx).Create(tx, second)
+	suite.NoError(err)
+	suite.NoError(tx.Commit(suite.ctx))
+
+	keys, err := suite.dal.SigningKeys(suite.ctx).GetVerifiable()
+	suite.NoError(err)
+	suite.Len(keys, 2)
+	suite.Equal(second.ID, keys[0].ID)
+	suite.Equal(models.SIGNING_KEY_STATUS_ACTIVE, keys[0].Status)
+	suite.Equal(first.ID, keys[1].ID)
+	suite.Equal(models.SIGNING_KEY_STATUS_RETIRED, keys[1].Status)
+	suite.NotNil(keys[1].ExpiresAt)
+}
+
+func (suite *SigningKeysDALTestSuite) TestCreate_FAIL_SecondActive() {
+	err := suite.dal.SigningKeys(suite.ctx).Create(nil, suite.newKey())
+	suite.NoError(err)
+
+	err = suite.dal.SigningKeys(suite.ctx).Create(nil, suite.newKey())
+	suite.Error(err)
+}
+
+func (suite *SigningKeysDALTestSuite) TestDeleteExpired_OK() {
+	err := suite.dal.SigningKeys(suite.ctx).Create(nil, suite.newKey())
+	suite.NoError(err)
+	err = suite.dal.SigningKeys(suite.ctx).RetireActive(nil, time.Now().Add(-time.Minute))
+	suite.NoError(err)
+
+	keys, err := suite.dal.SigningKeys(suite.ctx).GetVerifiable()
+	suite.NoError(err)
+	suite.Empty(keys)
+
+	deleted, err := suite.dal.SigningKeys(suite.ctx).DeleteExpired()
+	suite.NoError(err)
+	suite.Equal(int64(1), deleted)
+}
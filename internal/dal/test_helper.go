@@ -1,6 +1,8 @@
 package dal
 
 import (
+	"time"
+
 	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/google/uuid"
@@ -26,5 +28,7 @@ func getUserCredential(userID uuid.UUID) *models.UserCredential {
 		AttestationAuthenticatorData:  []byte("test-authenticator-data"),
 		AttestationPublicKeyAlgorithm: int64(1),
 		AttestationObject:             []byte("test-attestation-object"),
+		Name:                          "Test Passkey",
+		CreatedAt:                     time.Now().UTC().Truncate(time.Millisecond),
 	}
 }
@@ -3,18 +3,36 @@ package dal
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// UserFilter narrows List; zero values match everything
+type UserFilter struct {
+	// Search matches a part of the username or email, case insensitive
+	Search string
+	Status models.UserStatus
+	Role   models.UserRole
+	Limit  int
+	Offset int
+}
+
 //go:generate mockgen -destination=./mocks/mock_user.go -package=mocks github.com/asatraitis/mangrove/internal/dal UserDAL
 type UserDAL interface {
 	Create(pgx.Tx, *models.User) error
 	GetByID(uuid.UUID) (*models.User, error)
 	GetByUsernameWithCredentials(string) (*models.User, error)
 	GetByIdWithCredentials(uuid.UUID) (*models.User, error)
+	ExistsByUsername(string) (bool, error)
+	List(UserFilter) ([]*models.User, int, error)
+	Update(pgx.Tx, *models.User) error
+	CountActiveSuperadmins(pgx.Tx) (int, error)
 }
 
 type userDAL struct {
@@ -131,3 +149,112 @@ func (ud *userDAL) GetByIdWithCredentials(ID uuid.UUID) (*models.User, error) {
 	user.Credentials = credentials
 	return &user, err
 }
+
+func (ud *userDAL) ExistsByUsername(username string) (bool, error) {
+	const funcName = "ExistsByUsername"
+
+	var exists bool
+	err := ud.db.QueryRow(ud.ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)", username).Scan(&exists)
+	if err != nil {
+		ud.logger.Err(err).Str("func", funcName).Msg("failed to check username")
+		return false, err
+	}
+	return exists, nil
+}
+
+// List returns a page of users ordered by username, and the number of users matching the filter
+func (ud *userDAL) List(filter UserFilter) ([]*models.User, int, error) {
+	const funcName = "List"
+
+	var conditions []string
+	var args []interface{}
+	if filter.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf("(username ILIKE $%[1]d OR email ILIKE $%[1]d)", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := ud.db.QueryRow(ud.ctx, "SELECT count(*) FROM users"+where, args...).Scan(&total)
+	if err != nil {
+		ud.logger.Err(err).Str("func", funcName).Msg("failed to count users")
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf("SELECT id, username, display_name, email, status, role FROM users%s ORDER BY username LIMIT $%d OFFSET $%d", where, len(args)-1, len(args))
+	var users []*models.User
+	err = pgxscan.Select(ud.ctx, ud.db, &users, query, args...)
+	if err != nil {
+		ud.logger.Err(err).Str("func", funcName).Msg("failed to get users")
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Update saves the display name, email, status and role of the user
+func (ud *userDAL) Update(tx pgx.Tx, user *models.User) error {
+	const funcName = "Update"
+	const query = "UPDATE users SET display_name = $1, email = $2, status = $3, role = $4 WHERE id = $5"
+
+	if user == nil {
+		ud.logger.Error().Str("func", funcName).Msg("user is nil")
+		return errors.New("failed to update user; nil user")
+	}
+	args := []interface{}{
+		user.DisplayName,
+		user.Email,
+		user.Status,
+		user.Role,
+		user.ID,
+	}
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ud.db.Exec(ud.ctx, query, args...)
+	} else {
+		tag, err = tx.Exec(ud.ctx, query, args...)
+	}
+	if err != nil {
+		ud.logger.Err(err).Str("func", funcName).Msg("failed to update user")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		ud.logger.Error().Str("func", funcName).Str("userID", user.ID.String()).Msg("user not found")
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// CountActiveSuperadmins locks the active superadmins until tx ends, so concurrent demotions cannot
+// both see another superadmin left
+func (ud *userDAL) CountActiveSuperadmins(tx pgx.Tx) (int, error) {
+	const funcName = "CountActiveSuperadmins"
+
+	rows, err := tx.Query(ud.ctx, "SELECT id FROM users WHERE role = $1 AND status = $2 FOR UPDATE", models.USER_ROLE_SUPERUSER, models.USER_STATUS_ACTIVE)
+	if err != nil {
+		ud.logger.Err(err).Str("func", funcName).Msg("failed to lock superadmins")
+		return 0, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		ud.logger.Err(err).Str("func", funcName).Msg("failed to lock superadmins")
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// likeEscaper makes search input match literally in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -3,6 +3,7 @@ package dal
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/georgysavva/scany/v2/pgxscan"
@@ -14,7 +15,10 @@ import (
 type UserCredentialsDAL interface {
 	Create(tx pgx.Tx, credential *models.UserCredential) error
 	GetByUserID(uuid.UUID) ([]*models.UserCredential, error)
-	UpdateSignCount(pgx.Tx, []byte, uint32) error
+	GetByID([]byte) (*models.UserCredential, error)
+	MarkUsed(tx pgx.Tx, ID []byte, signCount uint32, usedAt time.Time) error
+	Rename(userID uuid.UUID, ID []byte, name string) (bool, error)
+	DeleteUnlessLast(userID uuid.UUID, ID []byte) (bool, error)
 }
 type userCredentialsDAL struct {
 	ctx context.Context
@@ -32,7 +36,7 @@ func NewUserCredentialsDAL(ctx context.Context, baseDAL *BaseDAL) UserCredential
 
 func (uc *userCredentialsDAL) Create(tx pgx.Tx, credential *models.UserCredential) error {
 	const funcName string = "Create"
-	const query string = "INSERT INTO user_credentials VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18);"
+	const query string = "INSERT INTO user_credentials (id, user_id, public_key, attestation_type, transport, flag_user_present, flag_verified, flag_backup_eligible, flag_backup_state, auth_aaguid, auth_sign_count, auth_clone_warning, auth_attachment, attestation_client_data_json, attestation_data_hash, attestation_authenticator_data, attestation_public_key_algorithm, attestation_object, name, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20);"
 
 	if credential == nil {
 		uc.logger.Error().Str("func", funcName).Msg("nil credential")
@@ -58,6 +62,8 @@ func (uc *userCredentialsDAL) Create(tx pgx.Tx, credential *models.UserCredentia
 		credential.AttestationAuthenticatorData,
 		credential.AttestationPublicKeyAlgorithm,
 		credential.AttestationObject,
+		credential.Name,
+		credential.CreatedAt,
 	}
 
 	if tx == nil {
@@ -87,7 +93,7 @@ func (uc *userCredentialsDAL) Create(tx pgx.Tx, credential *models.UserCredentia
 func (uc *userCredentialsDAL) GetByUserID(userID uuid.UUID) ([]*models.UserCredential, error) {
 	const funcName = "GetByUserID"
 
-	const userCredentialsQuery = "SELECT id, user_id, public_key, attestation_type, transport, flag_user_present, flag_verified, flag_backup_eligible, flag_backup_state, auth_aaguid, auth_sign_count, auth_clone_warning, auth_attachment, attestation_client_data_json, attestation_data_hash, attestation_authenticator_data, attestation_public_key_algorithm, attestation_object FROM user_credentials WHERE user_id = $1"
+	const userCredentialsQuery = "SELECT id, user_id, public_key, attestation_type, transport, flag_user_present, flag_verified, flag_backup_eligible, flag_backup_state, auth_aaguid, auth_sign_count, auth_clone_warning, auth_attachment, attestation_client_data_json, attestation_data_hash, attestation_authenticator_data, attestation_public_key_algorithm, attestation_object, name, created_at, last_used_at FROM user_credentials WHERE user_id = $1 ORDER BY created_at"
 	var credentials []*models.UserCredential
 	err := pgxscan.Select(uc.ctx, uc.db, &credentials, userCredentialsQuery, userID)
 	if err != nil {
@@ -98,20 +104,62 @@ func (uc *userCredentialsDAL) GetByUserID(userID uuid.UUID) ([]*models.UserCrede
 	return credentials, nil
 }
 
-func (uc *userCredentialsDAL) UpdateSignCount(tx pgx.Tx, ID []byte, signCount uint32) error {
-	const funcName = "UpdateSignCount"
-	const query = "UPDATE user_credentials SET auth_sign_count=$1 WHERE id=$2"
+// GetByID looks a credential up by the ID the authenticator returned, e.g. for usernameless login
+func (uc *userCredentialsDAL) GetByID(ID []byte) (*models.UserCredential, error) {
+	const funcName = "GetByID"
+	const query = "SELECT id, user_id, public_key, attestation_type, transport, flag_user_present, flag_verified, flag_backup_eligible, flag_backup_state, auth_aaguid, auth_sign_count, auth_clone_warning, auth_attachment, attestation_client_data_json, attestation_data_hash, attestation_authenticator_data, attestation_public_key_algorithm, attestation_object, name, created_at, last_used_at FROM user_credentials WHERE id = $1"
+
+	var credential models.UserCredential
+	err := pgxscan.Get(uc.ctx, uc.db, &credential, query, ID)
+	if err != nil {
+		uc.logger.Err(err).Str("func", funcName).Msg("failed to get user credential")
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// MarkUsed records a successful login with the credential and the authenticator's new sign count
+func (uc *userCredentialsDAL) MarkUsed(tx pgx.Tx, ID []byte, signCount uint32, usedAt time.Time) error {
+	const funcName = "MarkUsed"
+	const query = "UPDATE user_credentials SET auth_sign_count=$1, last_used_at=$2 WHERE id=$3"
 
 	if tx == nil {
-		_, err := uc.db.Exec(uc.ctx, query, signCount, ID)
+		_, err := uc.db.Exec(uc.ctx, query, signCount, usedAt, ID)
 		if err != nil {
-			uc.logger.Err(err).Str("func", funcName).Msg("failed to update user credential sign count")
+			uc.logger.Err(err).Str("func", funcName).Msg("failed to mark user credential used")
 		}
 		return err
 	}
-	_, err := tx.Exec(uc.ctx, query, signCount, ID)
+	_, err := tx.Exec(uc.ctx, query, signCount, usedAt, ID)
 	if err != nil {
-		uc.logger.Err(err).Str("func", funcName).Msg("failed to update user credential sign count")
+		uc.logger.Err(err).Str("func", funcName).Msg("failed to mark user credential used")
 	}
 	return err
 }
+
+// Rename reports false when the user has no credential with the given ID
+func (uc *userCredentialsDAL) Rename(userID uuid.UUID, ID []byte, name string) (bool, error) {
+	const funcName = "Rename"
+	const query = "UPDATE user_credentials SET name=$1 WHERE id=$2 AND user_id=$3"
+
+	tag, err := uc.db.Exec(uc.ctx, query, name, ID, userID)
+	if err != nil {
+		uc.logger.Err(err).Str("func", funcName).Msg("failed to rename user credential")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteUnlessLast deletes the credential only while the user keeps another one. The other
+// credentials are locked, so two concurrent deletes cannot leave the user without a passkey.
+func (uc *userCredentialsDAL) DeleteUnlessLast(userID uuid.UUID, ID []byte) (bool, error) {
+	const funcName = "DeleteUnlessLast"
+	const query = "DELETE FROM user_credentials WHERE id=$1 AND user_id=$2 AND EXISTS (SELECT 1 FROM user_credentials WHERE user_id=$2 AND id<>$1 FOR UPDATE)"
+
+	tag, err := uc.db.Exec(uc.ctx, query, ID, userID)
+	if err != nil {
+		uc.logger.Err(err).Str("func", funcName).Msg("failed to delete user credential")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
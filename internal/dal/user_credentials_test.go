@@ -3,6 +3,7 @@ package dal
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/asatraitis/mangrove/internal/utils"
@@ -126,7 +127,24 @@ func (suite *UserCredentialsDALTestSuite) TestGetByUserID_BadID() {
 	suite.Len(creds, 0)
 }
 
-func (suite *UserCredentialsDALTestSuite) TestUpdateSignCount_OK() {
+func (suite *UserCredentialsDALTestSuite) TestGetByID_OK() {
+	userCredential := getUserCredential(suite.userUUID)
+	err := suite.userCredentialsDAL.Create(nil, userCredential)
+	suite.NoError(err)
+
+	cred, err := suite.userCredentialsDAL.GetByID(userCredential.ID)
+	suite.NoError(err)
+	suite.Equal(userCredential.ID, cred.ID)
+	suite.Equal(suite.userUUID, cred.UserID)
+	suite.Equal("Test Passkey", cred.Name)
+}
+
+func (suite *UserCredentialsDALTestSuite) TestGetByID_NotFound() {
+	_, err := suite.userCredentialsDAL.GetByID([]byte("unknown-credential"))
+	suite.Error(err)
+}
+
+func (suite *UserCredentialsDALTestSuite) TestMarkUsed_OK() {
 	userCredential := getUserCredential(suite.userUUID)
 	err := suite.userCredentialsDAL.Create(nil, userCredential)
 	suite.NoError(err)
@@ -135,12 +153,65 @@ func (suite *UserCredentialsDALTestSuite) TestUpdateSignCount_OK() {
 	suite.NoError(err)
 	suite.Len(creds, 1)
 	suite.Equal(uint32(1), creds[0].AuthSignCount)
+	suite.Nil(creds[0].LastUsedAt)
 
-	err = suite.userCredentialsDAL.UpdateSignCount(nil, userCredential.ID, 2)
+	usedAt := time.Now().UTC().Truncate(time.Millisecond)
+	err = suite.userCredentialsDAL.MarkUsed(nil, userCredential.ID, 2, usedAt)
 	suite.NoError(err)
 
 	creds, err = suite.userCredentialsDAL.GetByUserID(suite.userUUID)
 	suite.NoError(err)
 	suite.Len(creds, 1)
 	suite.Equal(uint32(2), creds[0].AuthSignCount)
+	suite.Require().NotNil(creds[0].LastUsedAt)
+	suite.True(usedAt.Equal(*creds[0].LastUsedAt))
+}
+
+func (suite *UserCredentialsDALTestSuite) TestRename_OK() {
+	userCredential := getUserCredential(suite.userUUID)
+	err := suite.userCredentialsDAL.Create(nil, userCredential)
+	suite.NoError(err)
+
+	ok, err := suite.userCredentialsDAL.Rename(suite.userUUID, userCredential.ID, "Backup key")
+	suite.NoError(err)
+	suite.True(ok)
+
+	creds, err := suite.userCredentialsDAL.GetByUserID(suite.userUUID)
+	suite.NoError(err)
+	suite.Len(creds, 1)
+	suite.Equal("Backup key", creds[0].Name)
+}
+
+func (suite *UserCredentialsDALTestSuite) TestRename_OtherUser() {
+	userCredential := getUserCredential(suite.userUUID)
+	err := suite.userCredentialsDAL.Create(nil, userCredential)
+	suite.NoError(err)
+
+	ok, err := suite.userCredentialsDAL.Rename(uuid.New(), userCredential.ID, "Backup key")
+	suite.NoError(err)
+	suite.False(ok)
+}
+
+func (suite *UserCredentialsDALTestSuite) TestDeleteUnlessLast_OK() {
+	first := getUserCredential(suite.userUUID)
+	err := suite.userCredentialsDAL.Create(nil, first)
+	suite.NoError(err)
+	second := getUserCredential(suite.userUUID)
+	second.ID = []byte(uuid.New().String())
+	err = suite.userCredentialsDAL.Create(nil, second)
+	suite.NoError(err)
+
+	ok, err := suite.userCredentialsDAL.DeleteUnlessLast(suite.userUUID, first.ID)
+	suite.NoError(err)
+	suite.True(ok)
+
+	// second is the last remaining credential
+	ok, err = suite.userCredentialsDAL.DeleteUnlessLast(suite.userUUID, second.ID)
+	suite.NoError(err)
+	suite.False(ok)
+
+	creds, err := suite.userCredentialsDAL.GetByUserID(suite.userUUID)
+	suite.NoError(err)
+	suite.Len(creds, 1)
+	suite.Equal(second.ID, creds[0].ID)
 }
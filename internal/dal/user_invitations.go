@@ -0,0 +1,139 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:generate mockgen -destination=./mocks/mock_user_invitations.go -package=mocks github.com/asatraitis/mangrove/internal/dal UserInvitationsDAL
+type UserInvitationsDAL interface {
+	Create(pgx.Tx, *models.UserInvitation) error
+	GetAll() ([]*models.UserInvitation, error)
+	GetByCodeHash(string) (*models.UserInvitation, error)
+	MarkUsed(tx pgx.Tx, ID uuid.UUID, userID uuid.UUID, usedAt time.Time) (bool, error)
+	Delete(uuid.UUID) (bool, error)
+	// DeleteExpired deletes up to limit invitations, used or not, that expired before the given time
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type userInvitationsDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewUserInvitationsDAL(ctx context.Context, baseDAL *BaseDAL) UserInvitationsDAL {
+	uiDAL := &userInvitationsDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	uiDAL.logger = baseDAL.logger.With().Str("subcomponent", "UserInvitationsDAL").Logger()
+	return uiDAL
+}
+
+func (ui *userInvitationsDAL) Create(tx pgx.Tx, invitation *models.UserInvitation) error {
+	const funcName = "Create"
+	const query = "INSERT INTO user_invitations (id, code_hash, email, role, created_by, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7);"
+
+	if invitation == nil {
+		ui.logger.Error().Str("func", funcName).Msg("nil invitation")
+		return errors.New("failed to create invitation; nil invitation")
+	}
+	args := []interface{}{
+		invitation.ID,
+		invitation.CodeHash,
+		invitation.Email,
+		invitation.Role,
+		invitation.CreatedBy,
+		invitation.CreatedAt,
+		invitation.ExpiresAt,
+	}
+
+	var err error
+	if tx == nil {
+		_, err = ui.db.Exec(ui.ctx, query, args...)
+	} else {
+		_, err = tx.Exec(ui.ctx, query, args...)
+	}
+	if err != nil {
+		ui.logger.Err(err).Str("func", funcName).Msg("failed to insert invitation")
+	}
+	return err
+}
+
+// GetAll returns every invitation, including used and expired ones, newest first
+func (ui *userInvitationsDAL) GetAll() ([]*models.UserInvitation, error) {
+	const funcName = "GetAll"
+	const query = "SELECT id, code_hash, email, role, created_by, created_at, expires_at, used_at, used_by FROM user_invitations ORDER BY created_at DESC"
+
+	var invitations []*models.UserInvitation
+	err := pgxscan.Select(ui.ctx, ui.db, &invitations, query)
+	if err != nil {
+		ui.logger.Err(err).Str("func", funcName).Msg("failed to get invitations")
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (ui *userInvitationsDAL) GetByCodeHash(codeHash string) (*models.UserInvitation, error) {
+	const funcName = "GetByCodeHash"
+	const query = "SELECT id, code_hash, email, role, created_by, created_at, expires_at, used_at, used_by FROM user_invitations WHERE code_hash = $1"
+
+	invitation := &models.UserInvitation{}
+	err := pgxscan.Get(ui.ctx, ui.db, invitation, query, codeHash)
+	if err != nil {
+		ui.logger.Err(err).Str("func", funcName).Msg("failed to get invitation")
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// MarkUsed consumes the invitation for the registered user; it returns false when the invitation
+// was already used or expired, so concurrent registrations cannot both succeed
+func (ui *userInvitationsDAL) MarkUsed(tx pgx.Tx, ID uuid.UUID, userID uuid.UUID, usedAt time.Time) (bool, error) {
+	const funcName = "MarkUsed"
+	const query = "UPDATE user_invitations SET used_at = $1, used_by = $2 WHERE id = $3 AND used_at IS NULL AND expires_at > $1"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ui.db.Exec(ui.ctx, query, usedAt, userID, ID)
+	} else {
+		tag, err = tx.Exec(ui.ctx, query, usedAt, userID, ID)
+	}
+	if err != nil {
+		ui.logger.Err(err).Str("func", funcName).Msg("failed to mark invitation used")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Delete revokes an invitation that has not been used yet
+func (ui *userInvitationsDAL) Delete(ID uuid.UUID) (bool, error) {
+	const funcName = "Delete"
+	const query = "DELETE FROM user_invitations WHERE id = $1 AND used_at IS NULL"
+
+	tag, err := ui.db.Exec(ui.ctx, query, ID)
+	if err != nil {
+		ui.logger.Err(err).Str("func", funcName).Msg("failed to delete invitation")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (ui *userInvitationsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM user_invitations WHERE id IN (SELECT id FROM user_invitations WHERE expires_at <= $1 LIMIT $2)"
+
+	tag, err := ui.db.Exec(ui.ctx, query, before, limit)
+	if err != nil {
+		ui.logger.Err(err).Str("func", funcName).Msg("failed to delete expired invitations")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
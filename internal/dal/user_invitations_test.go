@@ -0,0 +1,155 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserInvitationsDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+
+	adminID uuid.UUID
+}
+
+func TestUserInvitationsDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(UserInvitationsDALTestSuite))
+}
+
+func (suite *UserInvitationsDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+
+func (suite *UserInvitationsDALTestSuite) SetupTest() {
+	suite.adminID = suite.newUser()
+}
+func (suite *UserInvitationsDALTestSuite) TearDownTest() {}
+
+func (suite *UserInvitationsDALTestSuite) newUser() uuid.UUID {
+	userID := uuid.New()
+	err := suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          userID,
+		Username:    "test" + userID.String(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	})
+	suite.NoError(err)
+	return userID
+}
+
+func (suite *UserInvitationsDALTestSuite) newInvitation(expiresAt time.Time) *models.UserInvitation {
+	email := "invitee@email.com"
+	invitation := &models.UserInvitation{
+		ID:        uuid.New(),
+		CodeHash:  uuid.NewString(),
+		Email:     &email,
+		Role:      models.USER_ROLE_USER,
+		CreatedBy: suite.adminID,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	err := suite.dal.UserInvitations(suite.ctx).Create(nil, invitation)
+	suite.NoError(err)
+	return invitation
+}
+
+func (suite *UserInvitationsDALTestSuite) TestCreate_FAIL() {
+	err := suite.dal.UserInvitations(suite.ctx).Create(nil, nil)
+	suite.Error(err)
+}
+
+func (suite *UserInvitationsDALTestSuite) TestGetByCodeHash_OK() {
+	invitation := suite.newInvitation(time.Now().Add(time.Hour))
+
+	found, err := suite.dal.UserInvitations(suite.ctx).GetByCodeHash(invitation.CodeHash)
+	suite.NoError(err)
+	suite.Equal(invitation.ID, found.ID)
+	suite.Equal(invitation.Email, found.Email)
+	suite.Equal(suite.adminID, found.CreatedBy)
+	suite.Nil(found.UsedAt)
+
+	_, err = suite.dal.UserInvitations(suite.ctx).GetByCodeHash("unknown")
+	suite.Error(err)
+}
+
+func (suite *UserInvitationsDALTestSuite) TestMarkUsed_OK() {
+	invitation := suite.newInvitation(time.Now().Add(time.Hour))
+	userID := suite.newUser()
+
+	used, err := suite.dal.UserInvitations(suite.ctx).MarkUsed(nil, invitation.ID, userID, time.Now())
+	suite.NoError(err)
+	suite.True(used)
+
+	// single use
+	used, err = suite.dal.UserInvitations(suite.ctx).MarkUsed(nil, invitation.ID, userID, time.Now())
+	suite.NoError(err)
+	suite.False(used)
+
+	found, err := suite.dal.UserInvitations(suite.ctx).GetByCodeHash(invitation.CodeHash)
+	suite.NoError(err)
+	suite.NotNil(found.UsedAt)
+	suite.Equal(&userID, found.UsedBy)
+
+	// used invitations are kept for the audit trail
+	deleted, err := suite.dal.UserInvitations(suite.ctx).Delete(invitation.ID)
+	suite.NoError(err)
+	suite.False(deleted)
+}
+
+func (suite *UserInvitationsDALTestSuite) TestMarkUsed_FAIL_Expired() {
+	invitation := suite.newInvitation(time.Now().Add(-time.Minute))
+
+	used, err := suite.dal.UserInvitations(suite.ctx).MarkUsed(nil, invitation.ID, suite.newUser(), time.Now())
+	suite.NoError(err)
+	suite.False(used)
+}
+
+func (suite *UserInvitationsDALTestSuite) TestDelete_OK() {
+	invitation := suite.newInvitation(time.Now().Add(time.Hour))
+
+	deleted, err := suite.dal.UserInvitations(suite.ctx).Delete(invitation.ID)
+	suite.NoError(err)
+	suite.True(deleted)
+
+	invitations, err := suite.dal.UserInvitations(suite.ctx).GetAll()
+	suite.NoError(err)
+	for _, inv := range invitations {
+		suite.NotEqual(invitation.ID, inv.ID)
+	}
+}
+
+func (suite *UserInvitationsDALTestSuite) TestDeleteExpired_OK() {
+	stale := suite.newInvitation(time.Now().Add(-48 * time.Hour))
+	recent := suite.newInvitation(time.Now().Add(-time.Minute))
+
+	deleted, err := suite.dal.UserInvitations(suite.ctx).DeleteExpired(time.Now().Add(-24*time.Hour), 1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(1))
+
+	_, err = suite.dal.UserInvitations(suite.ctx).GetByCodeHash(stale.CodeHash)
+	suite.Error(err)
+	// recently expired invitations are kept so admins can still see them
+	_, err = suite.dal.UserInvitations(suite.ctx).GetByCodeHash(recent.CodeHash)
+	suite.NoError(err)
+}
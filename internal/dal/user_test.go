@@ -2,6 +2,7 @@ package dal
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/asatraitis/mangrove/internal/dal/models"
@@ -119,3 +120,114 @@ func (suite *UserDALTestSuite) TestGetByUsernameWithCredentials_OK() {
 	suite.NoError(err)
 	suite.NotNil(createdUser.Credentials)
 }
+
+func (suite *UserDALTestSuite) TestExistsByUsername_OK() {
+	userUUID := uuid.New()
+	err := suite.dal.User(suite.ctx).Create(nil, &models.User{
+		ID:          userUUID,
+		Username:    "test" + userUUID.String(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	})
+	suite.NoError(err)
+
+	exists, err := suite.dal.User(suite.ctx).ExistsByUsername("test" + userUUID.String())
+	suite.NoError(err)
+	suite.True(exists)
+
+	exists, err = suite.dal.User(suite.ctx).ExistsByUsername("missing" + userUUID.String())
+	suite.NoError(err)
+	suite.False(exists)
+}
+
+func (suite *UserDALTestSuite) TestList_OK() {
+	userUUID := uuid.New()
+	email := "list" + userUUID.String() + "@email.com"
+	for i, status := range []models.UserStatus{models.USER_STATUS_ACTIVE, models.USER_STATUS_SUSPENDED} {
+		err := suite.dal.User(suite.ctx).Create(nil, &models.User{
+			ID:          uuid.New(),
+			Username:    "list" + userUUID.String() + "_" + string(rune('a'+i)),
+			DisplayName: "Test User",
+			Email:       &email,
+			Status:      status,
+			Role:        models.USER_ROLE_ADMIN,
+		})
+		suite.NoError(err)
+	}
+
+	users, total, err := suite.dal.User(suite.ctx).List(UserFilter{Search: strings.ToUpper(userUUID.String()), Limit: 10})
+	suite.NoError(err)
+	suite.Equal(2, total)
+	suite.Len(users, 2)
+	suite.Equal("list"+userUUID.String()+"_a", users[0].Username)
+
+	users, total, err = suite.dal.User(suite.ctx).List(UserFilter{Search: userUUID.String(), Status: models.USER_STATUS_SUSPENDED, Role: models.USER_ROLE_ADMIN, Limit: 10})
+	suite.NoError(err)
+	suite.Equal(1, total)
+	suite.Equal(models.USER_STATUS_SUSPENDED, users[0].Status)
+
+	users, total, err = suite.dal.User(suite.ctx).List(UserFilter{Search: userUUID.String(), Limit: 1, Offset: 1})
+	suite.NoError(err)
+	suite.Equal(2, total)
+	suite.Len(users, 1)
+	suite.Equal("list"+userUUID.String()+"_b", users[0].Username)
+
+	// LIKE wildcards in the search match literally
+	_, total, err = suite.dal.User(suite.ctx).List(UserFilter{Search: "list%" + userUUID.String(), Limit: 10})
+	suite.NoError(err)
+	suite.Zero(total)
+}
+
+func (suite *UserDALTestSuite) TestUpdate_OK() {
+	user := &models.User{
+		ID:          uuid.New(),
+		Username:    "update" + uuid.NewString(),
+		DisplayName: "Test User",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.USER_ROLE_USER,
+	}
+	err := suite.dal.User(suite.ctx).Create(nil, user)
+	suite.NoError(err)
+
+	email := "updated@email.com"
+	user.DisplayName = "Updated User"
+	user.Email = &email
+	user.Status = models.USER_STATUS_SUSPENDED
+	user.Role = models.USER_ROLE_ADMIN
+	err = suite.dal.User(suite.ctx).Update(nil, user)
+	suite.NoError(err)
+
+	updated, err := suite.dal.User(suite.ctx).GetByID(user.ID)
+	suite.NoError(err)
+	suite.Equal(user, updated)
+
+	err = suite.dal.User(suite.ctx).Update(nil, &models.User{ID: uuid.New()})
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *UserDALTestSuite) TestCountActiveSuperadmins_OK() {
+	tx, err := suite.DB.BeginTx(suite.ctx, pgx.TxOptions{})
+	suite.Require().NoError(err)
+	before, err := suite.dal.User(suite.ctx).CountActiveSuperadmins(tx)
+	suite.NoError(err)
+	suite.NoError(tx.Rollback(suite.ctx))
+
+	for _, status := range []models.UserStatus{models.USER_STATUS_ACTIVE, models.USER_STATUS_SUSPENDED} {
+		err = suite.dal.User(suite.ctx).Create(nil, &models.User{
+			ID:          uuid.New(),
+			Username:    "superadmin" + uuid.NewString(),
+			DisplayName: "Test Superadmin",
+			Status:      status,
+			Role:        models.USER_ROLE_SUPERUSER,
+		})
+		suite.NoError(err)
+	}
+
+	tx, err = suite.DB.BeginTx(suite.ctx, pgx.TxOptions{})
+	suite.Require().NoError(err)
+	defer tx.Rollback(suite.ctx)
+	count, err := suite.dal.User(suite.ctx).CountActiveSuperadmins(tx)
+	suite.NoError(err)
+	suite.Equal(before+1, count)
+}
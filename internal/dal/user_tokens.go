@@ -3,17 +3,35 @@ package dal
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 //go:generate mockgen -destination=./mocks/mock_user_tokens.go -package=mocks github.com/asatraitis/mangrove/internal/dal UserTokensDAL
 type UserTokensDAL interface {
 	Create(pgx.Tx, *models.UserToken) error
 	GetByID(uuid.UUID) (*models.UserToken, error)
-	GetByIdWithUser(uuid.UUID) (*models.UserToken, error)
+	// GetByHashWithUser looks a token up by the digest of the opaque token presented by its holder
+	GetByHashWithUser([]byte) (*models.UserToken, error)
+	DeleteByClientID(pgx.Tx, uuid.UUID) (int64, error)
+	// Delete deletes a single token; pgx.ErrNoRows when there is no such token
+	Delete(tx pgx.Tx, ID uuid.UUID) error
+	// GetSessionsByUserID lists the user's unexpired browser sessions, newest first
+	GetSessionsByUserID(uuid.UUID) ([]*models.UserToken, error)
+	// Touch records that the session was used and moves its expiry
+	Touch(ID uuid.UUID, seenAt time.Time, expires time.Time) error
+	// DeleteSession deletes a browser session of the user; pgx.ErrNoRows when the user has no such session
+	DeleteSession(tx pgx.Tx, userID uuid.UUID, ID uuid.UUID) error
+	DeleteSessionsByUserID(pgx.Tx, uuid.UUID) (int64, error)
+	// DeleteExpired deletes up to limit tokens that expired before the given time
+	DeleteExpired(before time.Time, limit int) (int64, error)
+	// DeleteOfDisabledUsers deletes up to limit tokens of inactive or suspended users, which cannot be used anymore
+	DeleteOfDisabledUsers(limit int) (int64, error)
 }
 type userTokensDAL struct {
 	ctx context.Context
@@ -31,16 +49,26 @@ func NewUserTokensDAL(ctx context.Context, baseDAL *BaseDAL) UserTokensDAL {
 
 func (ut *userTokensDAL) Create(tx pgx.Tx, token *models.UserToken) error {
 	const funcName string = "Create"
-	const query = "INSERT INTO user_tokens VALUES ($1, $2, $3);"
+	const query = "INSERT INTO user_tokens (id, token_hash, user_id, expires, client_id, scope, created_at, ip, user_agent) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);"
 
 	if token == nil {
 		ut.logger.Error().Str("func", funcName).Msg("nil user token")
 		return errors.New("failed to create user token; nil token")
 	}
+	if len(token.TokenHash) == 0 {
+		ut.logger.Error().Str("func", funcName).Msg("user token without hash")
+		return errors.New("failed to create user token; no token hash")
+	}
 	args := []interface{}{
 		token.ID,
+		token.TokenHash,
 		token.UserID,
 		token.Expires,
+		token.ClientID,
+		token.Scope,
+		token.CreatedAt,
+		token.IP,
+		token.UserAgent,
 	}
 
 	if tx == nil {
@@ -69,10 +97,10 @@ func (ut *userTokensDAL) Create(tx pgx.Tx, token *models.UserToken) error {
 func (ut *userTokensDAL) GetByID(ID uuid.UUID) (*models.UserToken, error) {
 	const funcName = "GetByID"
 
-	row := ut.db.QueryRow(ut.ctx, "SELECT id, user_id, expires FROM user_tokens WHERE id = $1", ID)
+	row := ut.db.QueryRow(ut.ctx, "SELECT id, user_id, expires, client_id, scope FROM user_tokens WHERE id = $1", ID)
 	token := &models.UserToken{}
 
-	err := row.Scan(&token.ID, &token.UserID, &token.Expires)
+	err := row.Scan(&token.ID, &token.UserID, &token.Expires, &token.ClientID, &token.Scope)
 	if err != nil {
 		ut.logger.Err(err).Str("func", funcName).Msg("failed to get a token")
 		return nil, err
@@ -81,10 +109,10 @@ func (ut *userTokensDAL) GetByID(ID uuid.UUID) (*models.UserToken, error) {
 	return token, err
 }
 
-func (ut *userTokensDAL) GetByIdWithUser(ID uuid.UUID) (*models.UserToken, error) {
-	const funcName = "GetByIdWithUser"
+func (ut *userTokensDAL) GetByHashWithUser(tokenHash []byte) (*models.UserToken, error) {
+	const funcName = "GetByHashWithUser"
 
-	row := ut.db.QueryRow(ut.ctx, "SELECT ut.id, ut.user_id, ut.expires, u.id, u.username, u.display_name, u.status, u.role  FROM user_tokens ut JOIN users u ON ut.user_id = u.id WHERE ut.id = $1", ID)
+	row := ut.db.QueryRow(ut.ctx, "SELECT ut.id, ut.user_id, ut.expires, ut.client_id, ut.scope, ut.created_at, ut.last_seen_at, u.id, u.username, u.display_name, u.status, u.role FROM user_tokens ut JOIN users u ON ut.user_id = u.id WHERE ut.token_hash = $1", tokenHash)
 
 	user := &models.User{}
 	token := &models.UserToken{}
@@ -93,6 +121,10 @@ func (ut *userTokensDAL) GetByIdWithUser(ID uuid.UUID) (*models.UserToken, error
 		&token.ID,
 		&token.UserID,
 		&token.Expires,
+		&token.ClientID,
+		&token.Scope,
+		&token.CreatedAt,
+		&token.LastSeenAt,
 		&user.ID,
 		&user.Username,
 		&user.DisplayName,
@@ -107,3 +139,133 @@ func (ut *userTokensDAL) GetByIdWithUser(ID uuid.UUID) (*models.UserToken, error
 
 	return token, err
 }
+
+// DeleteByClientID revokes every token issued to the client
+func (ut *userTokensDAL) DeleteByClientID(tx pgx.Tx, clientID uuid.UUID) (int64, error) {
+	const funcName = "DeleteByClientID"
+	const query = "DELETE FROM user_tokens WHERE client_id = $1"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ut.db.Exec(ut.ctx, query, clientID)
+	} else {
+		tag, err = tx.Exec(ut.ctx, query, clientID)
+	}
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to delete client tokens")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (ut *userTokensDAL) Delete(tx pgx.Tx, ID uuid.UUID) error {
+	const funcName = "Delete"
+	const query = "DELETE FROM user_tokens WHERE id = $1"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ut.db.Exec(ut.ctx, query, ID)
+	} else {
+		tag, err = tx.Exec(ut.ctx, query, ID)
+	}
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to delete user token")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (ut *userTokensDAL) GetSessionsByUserID(userID uuid.UUID) ([]*models.UserToken, error) {
+	const funcName = "GetSessionsByUserID"
+	const query = "SELECT id, user_id, expires, client_id, scope, created_at, last_seen_at, ip, user_agent FROM user_tokens WHERE user_id = $1 AND client_id IS NULL AND expires > now() ORDER BY created_at DESC"
+
+	var tokens []*models.UserToken
+	err := pgxscan.Select(ut.ctx, ut.db, &tokens, query, userID)
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to get user sessions")
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (ut *userTokensDAL) Touch(ID uuid.UUID, seenAt time.Time, expires time.Time) error {
+	const funcName = "Touch"
+
+	_, err := ut.db.Exec(ut.ctx, "UPDATE user_tokens SET last_seen_at = $2, expires = $3 WHERE id = $1", ID, seenAt, expires)
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to touch token")
+	}
+	return err
+}
+
+func (ut *userTokensDAL) DeleteSession(tx pgx.Tx, userID uuid.UUID, ID uuid.UUID) error {
+	const funcName = "DeleteSession"
+	const query = "DELETE FROM user_tokens WHERE id = $1 AND user_id = $2 AND client_id IS NULL"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ut.db.Exec(ut.ctx, query, ID, userID)
+	} else {
+		tag, err = tx.Exec(ut.ctx, query, ID, userID)
+	}
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to delete user session")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteSessionsByUserID signs the user out everywhere; tokens issued to OAuth clients are kept
+func (ut *userTokensDAL) DeleteSessionsByUserID(tx pgx.Tx, userID uuid.UUID) (int64, error) {
+	const funcName = "DeleteSessionsByUserID"
+	const query = "DELETE FROM user_tokens WHERE user_id = $1 AND client_id IS NULL"
+
+	var tag pgconn.CommandTag
+	var err error
+	if tx == nil {
+		tag, err = ut.db.Exec(ut.ctx, query, userID)
+	} else {
+		tag, err = tx.Exec(ut.ctx, query, userID)
+	}
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to delete user sessions")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (ut *userTokensDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM user_tokens WHERE id IN (SELECT id FROM user_tokens WHERE expires <= $1 LIMIT $2)"
+
+	tag, err := ut.db.Exec(ut.ctx, query, before, limit)
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to delete expired user tokens")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (ut *userTokensDAL) DeleteOfDisabledUsers(limit int) (int64, error) {
+	const funcName = "DeleteOfDisabledUsers"
+	const query = `DELETE FROM user_tokens WHERE id IN (
+		SELECT ut.id FROM user_tokens ut JOIN users u ON u.id = ut.user_id WHERE u.status = ANY($1) LIMIT $2
+	)`
+
+	statuses := []models.UserStatus{models.USER_STATUS_INACTIVE, models.USER_STATUS_SUSPENDED}
+	tag, err := ut.db.Exec(ut.ctx, query, statuses, limit)
+	if err != nil {
+		ut.logger.Err(err).Str("func", funcName).Msg("failed to delete tokens of disabled users")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/asatraitis/mangrove/internal/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/suite"
@@ -68,9 +69,10 @@ func (suite *UserTokensDALTestSuite) TearDownTest() {}
 
 func (suite *UserTokensDALTestSuite) TestCreateGetUserToken_OK() {
 	testToken := &models.UserToken{
-		ID:      uuid.New(),
-		UserID:  suite.testUserID,
-		Expires: time.Now().Add(time.Hour * 24),
+		ID:        uuid.New(),
+		TokenHash: utils.HashOpaqueToken(uuid.NewString()),
+		UserID:    suite.testUserID,
+		Expires:   time.Now().Add(time.Hour * 24),
 	}
 
 	err := suite.userTokensDAL.Create(nil, testToken)
@@ -89,16 +91,17 @@ func (suite *UserTokensDALTestSuite) TestCreateGetUserToken_OK() {
 	suite.Equal(testToken.Expires.Format(time.DateTime), createdToken.Expires.Format(time.DateTime))
 }
 
-func (suite *UserTokensDALTestSuite) TestGetByIdWithUser_OK() {
+func (suite *UserTokensDALTestSuite) TestGetByHashWithUser_OK() {
 	testToken := &models.UserToken{
-		ID:      uuid.New(),
-		UserID:  suite.testUserID,
-		Expires: time.Now().Add(time.Hour * 24),
+		ID:        uuid.New(),
+		TokenHash: utils.HashOpaqueToken("test-token-" + uuid.NewString()),
+		UserID:    suite.testUserID,
+		Expires:   time.Now().Add(time.Hour * 24),
 	}
 	err := suite.userTokensDAL.Create(nil, testToken)
 	suite.NoError(err)
 
-	createdToken, err := suite.userTokensDAL.GetByIdWithUser(testToken.ID)
+	createdToken, err := suite.userTokensDAL.GetByHashWithUser(testToken.TokenHash)
 	suite.NoError(err)
 	suite.NotNil(createdToken)
 	suite.NotNil(createdToken.User)
@@ -109,4 +112,111 @@ func (suite *UserTokensDALTestSuite) TestGetByIdWithUser_OK() {
 	suite.Equal("Test User Credentials", createdToken.User.DisplayName)
 	suite.Equal(models.UserRole("user"), createdToken.User.Role)
 	suite.Equal(models.UserStatus("active"), createdToken.User.Status)
+
+	_, err = suite.userTokensDAL.GetByHashWithUser(utils.HashOpaqueToken(testToken.ID.String()))
+	suite.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (suite *UserTokensDALTestSuite) TestDelete_OK() {
+	token := &models.UserToken{ID: uuid.New(), TokenHash: utils.HashOpaqueToken(uuid.NewString()), UserID: suite.testUserID, Expires: time.Now().Add(time.Hour)}
+	suite.NoError(suite.userTokensDAL.Create(nil, token))
+
+	suite.NoError(suite.userTokensDAL.Delete(nil, token.ID))
+	_, err := suite.userTokensDAL.GetByID(token.ID)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+	suite.ErrorIs(suite.userTokensDAL.Delete(nil, token.ID), pgx.ErrNoRows)
+}
+
+func (suite *UserTokensDALTestSuite) TestCreate_FAIL_NoHash() {
+	err := suite.userTokensDAL.Create(nil, &models.UserToken{
+		ID:      uuid.New(),
+		UserID:  suite.testUserID,
+		Expires: time.Now().Add(time.Hour),
+	})
+	suite.ErrorContains(err, "no token hash")
+}
+
+func (suite *UserTokensDALTestSuite) TestSessions_OK() {
+	now := time.Now()
+	session := &models.UserToken{
+		ID:        uuid.New(),
+		TokenHash: utils.HashOpaqueToken(uuid.NewString()),
+		UserID:    suite.testUserID,
+		Expires:   now.Add(time.Hour),
+		CreatedAt: now,
+		IP:        "127.0.0.1",
+		UserAgent: "test-agent",
+	}
+	expired := &models.UserToken{ID: uuid.New(), TokenHash: utils.HashOpaqueToken(uuid.NewString()), UserID: suite.testUserID, Expires: now.Add(-time.Hour), CreatedAt: now}
+	suite.NoError(suite.userTokensDAL.Create(nil, session))
+	suite.NoError(suite.userTokensDAL.Create(nil, expired))
+
+	suite.NoError(suite.userTokensDAL.Touch(session.ID, now, now.Add(time.Hour*2)))
+
+	sessions, err := suite.userTokensDAL.GetSessionsByUserID(suite.testUserID)
+	suite.NoError(err)
+	suite.Len(sessions, 1)
+	suite.Equal(session.ID, sessions[0].ID)
+	suite.Equal("127.0.0.1", sessions[0].IP)
+	suite.Equal("test-agent", sessions[0].UserAgent)
+	suite.NotNil(sessions[0].LastSeenAt)
+
+	withUser, err := suite.userTokensDAL.GetByHashWithUser(session.TokenHash)
+	suite.NoError(err)
+	suite.NotNil(withUser.LastSeenAt)
+	suite.Equal(now.Format(time.DateTime), withUser.CreatedAt.Format(time.DateTime))
+	suite.Equal(now.Add(time.Hour*2).Format(time.DateTime), withUser.Expires.Format(time.DateTime))
+
+	err = suite.userTokensDAL.DeleteSession(nil, uuid.New(), session.ID)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+	suite.NoError(suite.userTokensDAL.DeleteSession(nil, suite.testUserID, session.ID))
+
+	deleted, err := suite.userTokensDAL.DeleteSessionsByUserID(nil, suite.testUserID)
+	suite.NoError(err)
+	suite.Equal(int64(1), deleted)
+}
+
+func (suite *UserTokensDALTestSuite) TestDeleteExpired_OK() {
+	now := time.Now()
+	expired := &models.UserToken{ID: uuid.New(), TokenHash: utils.HashOpaqueToken(uuid.NewString()), UserID: suite.testUserID, Expires: now.Add(-time.Hour)}
+	valid := &models.UserToken{ID: uuid.New(), TokenHash: utils.HashOpaqueToken(uuid.NewString()), UserID: suite.testUserID, Expires: now.Add(time.Hour)}
+	suite.NoError(suite.userTokensDAL.Create(nil, expired))
+	suite.NoError(suite.userTokensDAL.Create(nil, valid))
+
+	for {
+		deleted, err := suite.userTokensDAL.DeleteExpired(now, 100)
+		suite.Require().NoError(err)
+		if deleted < 100 {
+			break
+		}
+	}
+
+	_, err := suite.userTokensDAL.GetByID(expired.ID)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+	_, err = suite.userTokensDAL.GetByID(valid.ID)
+	suite.NoError(err)
+}
+
+func (suite *UserTokensDALTestSuite) TestDeleteOfDisabledUsers_OK() {
+	suspendedID := uuid.New()
+	suite.NoError(suite.userDAL.Create(nil, &models.User{
+		ID:          suspendedID,
+		Username:    uuid.NewString(),
+		DisplayName: "Suspended User",
+		Status:      models.USER_STATUS_SUSPENDED,
+		Role:        models.USER_ROLE_USER,
+	}))
+	disabled := &models.UserToken{ID: uuid.New(), TokenHash: utils.HashOpaqueToken(uuid.NewString()), UserID: suspendedID, Expires: time.Now().Add(time.Hour)}
+	active := &models.UserToken{ID: uuid.New(), TokenHash: utils.HashOpaqueToken(uuid.NewString()), UserID: suite.testUserID, Expires: time.Now().Add(time.Hour)}
+	suite.NoError(suite.userTokensDAL.Create(nil, disabled))
+	suite.NoError(suite.userTokensDAL.Create(nil, active))
+
+	deleted, err := suite.userTokensDAL.DeleteOfDisabledUsers(1000)
+	suite.NoError(err)
+	suite.GreaterOrEqual(deleted, int64(1))
+
+	_, err = suite.userTokensDAL.GetByID(disabled.ID)
+	suite.ErrorIs(err, pgx.ErrNoRows)
+	_, err = suite.userTokensDAL.GetByID(active.ID)
+	suite.NoError(err)
 }
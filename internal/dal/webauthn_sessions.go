@@ -0,0 +1,93 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+//go:generate mockgen -destination=./mocks/mock_webauthn_sessions.go -package=mocks github.com/asatraitis/mangrove/internal/dal WebauthnSessionsDAL
+type WebauthnSessionsDAL interface {
+	Set(*models.WebauthnSession) error
+	Get(string) (*models.WebauthnSession, error)
+	GetAndDelete(string) (*models.WebauthnSession, error)
+	// DeleteExpired deletes up to limit sessions that expired before the given time
+	DeleteExpired(before time.Time, limit int) (int64, error)
+}
+type webauthnSessionsDAL struct {
+	ctx context.Context
+	*BaseDAL
+}
+
+func NewWebauthnSessionsDAL(ctx context.Context, baseDAL *BaseDAL) WebauthnSessionsDAL {
+	wsDAL := &webauthnSessionsDAL{
+		ctx:     ctx,
+		BaseDAL: baseDAL,
+	}
+	wsDAL.logger = baseDAL.logger.With().Str("subcomponent", "WebauthnSessionsDAL").Logger()
+	return wsDAL
+}
+
+// Set stores the session, replacing a session stored under the same key
+func (ws *webauthnSessionsDAL) Set(session *models.WebauthnSession) error {
+	const funcName = "Set"
+	const query = "INSERT INTO webauthn_sessions (key, data, expires_at) VALUES ($1, $2, $3) ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at"
+
+	if session == nil {
+		ws.logger.Error().Str("func", funcName).Msg("nil webauthn session")
+		return errors.New("failed to set webauthn session; nil session")
+	}
+
+	_, err := ws.db.Exec(ws.ctx, query, session.Key, session.Data, session.ExpiresAt)
+	if err != nil {
+		ws.logger.Err(err).Str("func", funcName).Msg("failed to set webauthn session")
+	}
+	return err
+}
+
+// Get returns pgx.ErrNoRows when there is no unexpired session for the key
+func (ws *webauthnSessionsDAL) Get(key string) (*models.WebauthnSession, error) {
+	const funcName = "Get"
+	const query = "SELECT key, data, expires_at FROM webauthn_sessions WHERE key = $1 AND expires_at > $2"
+
+	session := &models.WebauthnSession{}
+	err := pgxscan.Get(ws.ctx, ws.db, session, query, key, time.Now())
+	if err != nil {
+		ws.logger.Err(err).Str("func", funcName).Msg("failed to get webauthn session")
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetAndDelete removes the session and returns it unless it already expired; the row is gone either way,
+// so only one instance can ever redeem a session
+func (ws *webauthnSessionsDAL) GetAndDelete(key string) (*models.WebauthnSession, error) {
+	const funcName = "GetAndDelete"
+	const query = "DELETE FROM webauthn_sessions WHERE key = $1 RETURNING key, data, expires_at"
+
+	session := &models.WebauthnSession{}
+	err := pgxscan.Get(ws.ctx, ws.db, session, query, key)
+	if err != nil {
+		ws.logger.Err(err).Str("func", funcName).Msg("failed to delete webauthn session")
+		return nil, err
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, errors.New("webauthn session expired")
+	}
+	return session, nil
+}
+
+func (ws *webauthnSessionsDAL) DeleteExpired(before time.Time, limit int) (int64, error) {
+	const funcName = "DeleteExpired"
+	const query = "DELETE FROM webauthn_sessions WHERE key IN (SELECT key FROM webauthn_sessions WHERE expires_at <= $1 LIMIT $2)"
+
+	tag, err := ws.db.Exec(ws.ctx, query, before, limit)
+	if err != nil {
+		ws.logger.Err(err).Str("func", funcName).Msg("failed to delete expired webauthn sessions")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,108 @@
+package dal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebauthnSessionsDALTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	DB  *pgxpool.Pool
+	dal DAL
+}
+
+func TestWebauthnSessionsDALTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(WebauthnSessionsDALTestSuite))
+}
+
+func (suite *WebauthnSessionsDALTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	dbpool, err := utils.InitDbPool(suite.ctx)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+	suite.DB = dbpool
+	suite.dal = NewDAL(zerolog.Nop(), suite.DB)
+}
+func (suite *WebauthnSessionsDALTestSuite) SetupTest()    {}
+func (suite *WebauthnSessionsDALTestSuite) TearDownTest() {}
+
+func (suite *WebauthnSessionsDALTestSuite) newSession(expiresAt time.Time) *models.WebauthnSession {
+	return &models.WebauthnSession{
+		Key:       uuid.NewString(),
+		Data:      []byte(`{"challenge":"test"}`),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func (suite *WebauthnSessionsDALTestSuite) TestSetGet_OK() {
+	session := suite.newSession(time.Now().Add(time.Minute))
+	err := suite.dal.WebauthnSessions(suite.ctx).Set(session)
+	suite.NoError(err)
+
+	// setting the same key replaces the session
+	session.Data = []byte(`{"challenge":"replaced"}`)
+	err = suite.dal.WebauthnSessions(suite.ctx).Set(session)
+	suite.NoError(err)
+
+	stored, err := suite.dal.WebauthnSessions(suite.ctx).Get(session.Key)
+	suite.NoError(err)
+	suite.JSONEq(`{"challenge":"replaced"}`, string(stored.Data))
+
+	// Get does not consume the session
+	_, err = suite.dal.WebauthnSessions(suite.ctx).Get(session.Key)
+	suite.NoError(err)
+}
+
+func (suite *WebauthnSessionsDALTestSuite) TestGetAndDelete_OK() {
+	session := suite.newSession(time.Now().Add(time.Minute))
+	err := suite.dal.WebauthnSessions(suite.ctx).Set(session)
+	suite.NoError(err)
+
+	stored, err := suite.dal.WebauthnSessions(suite.ctx).GetAndDelete(session.Key)
+	suite.NoError(err)
+	suite.Equal(session.Key, stored.Key)
+
+	// a session can only be redeemed once
+	_, err = suite.dal.WebauthnSessions(suite.ctx).GetAndDelete(session.Key)
+	suite.Error(err)
+}
+
+func (suite *WebauthnSessionsDALTestSuite) TestGet_FailExpired() {
+	session := suite.newSession(time.Now().Add(-time.Second))
+	err := suite.dal.WebauthnSessions(suite.ctx).Set(session)
+	suite.NoError(err)
+
+	_, err = suite.dal.WebauthnSessions(suite.ctx).Get(session.Key)
+	suite.Error(err)
+	_, err = suite.dal.WebauthnSessions(suite.ctx).GetAndDelete(session.Key)
+	suite.ErrorContains(err, "expired")
+}
+
+func (suite *WebauthnSessionsDALTestSuite) TestDeleteExpired_OK() {
+	expired := suite.newSession(time.Now().Add(-time.Second))
+	valid := suite.newSession(time.Now().Add(time.Minute))
+	suite.NoError(suite.dal.WebauthnSessions(suite.ctx).Set(expired))
+	suite.NoError(suite.dal.WebauthnSessions(suite.ctx).Set(valid))
+
+	// batches are capped at the limit
+	deleted, err := suite.dal.WebauthnSessions(suite.ctx).DeleteExpired(time.Now(), 1)
+	suite.NoError(err)
+	suite.Equal(int64(1), deleted)
+
+	_, err = suite.dal.WebauthnSessions(suite.ctx).Get(valid.Key)
+	suite.NoError(err)
+}
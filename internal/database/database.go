@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+const (
+	minRetryBackoff = 250 * time.Millisecond
+	maxRetryBackoff = 5 * time.Second
+)
+
+// ConnString is the connection string for vars: the configured DSN, or one built from the discrete
+// fields, with the SSL settings added
+func ConnString(vars *configs.EnvVariables) string {
+	dsn := vars.MangrovePostgresDSN
+	if dsn == "" {
+		u := &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(vars.MangrovePostgresUser, vars.MangrovePostgresPassword),
+			Host:   net.JoinHostPort(vars.MangrovePostgresAddress, vars.MangrovePostgresPort),
+			Path:   "/" + vars.MangrovePostgresDBName,
+		}
+		dsn = u.String()
+	}
+
+	return withParams(dsn, [][2]string{
+		{"sslmode", vars.MangrovePostgresSSLMode},
+		{"sslrootcert", vars.MangrovePostgresSSLRootCert},
+		{"sslcert", vars.MangrovePostgresSSLCert},
+		{"sslkey", vars.MangrovePostgresSSLKey},
+	})
+}
+
+// PoolConfig is the app pool configuration; unlike single connections, pooled connections are subject
+// to the statement timeout
+func PoolConfig(vars *configs.EnvVariables) (*pgxpool.Config, error) {
+	conf, err := pgxpool.ParseConfig(ConnString(vars))
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres connection settings: %w", err)
+	}
+	if vars.MangrovePostgresPoolMaxConns > 0 {
+		conf.MaxConns = int32(vars.MangrovePostgresPoolMaxConns)
+	}
+	if vars.MangrovePostgresPoolMinConns > 0 {
+		conf.MinConns = int32(vars.MangrovePostgresPoolMinConns)
+	}
+	if vars.MangrovePostgresPoolMaxConnLifetime > 0 {
+		conf.MaxConnLifetime = vars.MangrovePostgresPoolMaxConnLifetime
+	}
+	if vars.MangrovePostgresPoolMaxConnIdleTime > 0 {
+		conf.MaxConnIdleTime = vars.MangrovePostgresPoolMaxConnIdleTime
+	}
+	if vars.MangrovePostgresStatementTimeout > 0 {
+		conf.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(vars.MangrovePostgresStatementTimeout.Milliseconds(), 10)
+	}
+	return conf, nil
+}
+
+// NewPool connects the app pool, retrying until postgres is reachable or the connect timeout passes
+func NewPool(ctx context.Context, vars *configs.EnvVariables, logger zerolog.Logger) (*pgxpool.Pool, error) {
+	conf, err := PoolConfig(vars)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = retry(ctx, logger, vars.MangrovePostgresConnectTimeout, func() error {
+		return pool.Ping(ctx)
+	})
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// Connect opens a single connection, e.g. for migrations, retrying like NewPool
+func Connect(ctx context.Context, vars *configs.EnvVariables, logger zerolog.Logger) (*pgx.Conn, error) {
+	conf, err := pgx.ParseConfig(ConnString(vars))
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres connection settings: %w", err)
+	}
+
+	var conn *pgx.Conn
+	err = retry(ctx, logger, vars.MangrovePostgresConnectTimeout, func() error {
+		conn, err = pgx.ConnectConfig(ctx, conf)
+		return err
+	})
+	return conn, err
+}
+
+// retry calls connect with exponential backoff until it succeeds, ctx is done or window has passed
+func retry(ctx context.Context, logger zerolog.Logger, window time.Duration, connect func() error) error {
+	deadline := time.Now().Add(window)
+	backoff := minRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := connect()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("could not connect to postgres after %d attempts: %w", attempt, err)
+		}
+		logger.Warn().Err(err).Int("attempt", attempt).Msgf("postgres is not reachable; retrying in %s", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// withParams adds the non-empty params to a URL or key=value connection string
+func withParams(dsn string, params [][2]string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			// leave it for pgx to report
+			return dsn
+		}
+		q := u.Query()
+		for _, p := range params {
+			if p[1] != "" {
+				q.Set(p[0], p[1])
+			}
+		}
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	for _, p := range params {
+		if p[1] != "" {
+			value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p[1])
+			dsn += fmt.Sprintf(" %s='%s'", p[0], value)
+		}
+	}
+	return strings.TrimSpace(dsn)
+}
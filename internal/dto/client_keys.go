@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+type ClientKey struct {
+	ID        string            `json:"id"`
+	ClientID  string            `json:"clientId"`
+	KID       string            `json:"kid"`
+	Algorithm UserClientKeyAlgo `json:"algorithm"`
+	NotBefore time.Time         `json:"notBefore"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Revoked   bool              `json:"revoked"`
+	RevokedAt *time.Time        `json:"revokedAt,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+type ClientKeysResponse []ClientKey
+
+type CreateClientKeyRequest struct {
+	// KID defaults to the RFC 7638 thumbprint of the key
+	KID       string            `json:"kid,omitempty"`
+	Algorithm UserClientKeyAlgo `json:"algorithm"`
+	// PublicKey is a PKIX DER or PEM encoded key; EdDSA keys may also be the raw 32 bytes
+	PublicKey []byte `json:"publicKey"`
+	// NotBefore defaults to now; set it in the future to pre-register the next key before a rotation
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type CreateClientKeyResponse ClientKey
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type UserClientStatus string
 
 const (
@@ -9,12 +11,22 @@ const (
 )
 
 type UserClient struct {
-	ID          string           `json:"id"`
-	UserID      string           `json:"userId"`
-	Name        string           `json:"name"`
-	Description string           `json:"description,omitempty"`
-	RedirectURI string           `json:"redirectURI"`
-	Status      UserClientStatus `json:"status"`
+	ID                     string           `json:"id"`
+	UserID                 string           `json:"userId"`
+	Name                   string           `json:"name"`
+	Description            string           `json:"description,omitempty"`
+	RedirectURIs           []string         `json:"redirectURIs"`
+	PostLogoutRedirectURIs []string         `json:"postLogoutRedirectURIs"`
+	WebOrigins             []string         `json:"webOrigins"`
+	JWKSURI                string           `json:"jwksURI,omitempty"`
+	Status                 UserClientStatus `json:"status"`
+	// StatusReason is set while the client is paused or suspended
+	StatusReason    string     `json:"statusReason,omitempty"`
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty"`
+	// token lifetimes in seconds
+	AccessTokenLifetime     int `json:"accessTokenLifetime"`
+	RefreshTokenLifetime    int `json:"refreshTokenLifetime"`
+	RefreshTokenMaxLifetime int `json:"refreshTokenMaxLifetime"`
 }
 
 type UserClientsResponse []UserClient
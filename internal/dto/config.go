@@ -0,0 +1,6 @@
+package dto
+
+// SetConfigRequest is the body of PUT /v1/config/{key}
+type SetConfigRequest struct {
+	Value string `json:"value"`
+}
@@ -4,16 +4,26 @@ type UserClientKeyAlgo string
 
 const (
 	CLIENT_KEY_ALGO_EDDSA UserClientKeyAlgo = "EdDSA"
+	CLIENT_KEY_ALGO_ES256 UserClientKeyAlgo = "ES256"
 )
 
 // TODO: create common fields struct and embed insetad of repeating?
 type CreateClientRequest struct {
-	Name        string            `json:"name"`
-	Description string            `json:"description,omitempty"`
-	RedirectURI string            `json:"redirectURI"`
-	Status      UserClientStatus  `json:"status"`
-	PublicKey   []byte            `json:"publicKey"`
-	KeyAlgo     UserClientKeyAlgo `json:"keyAlgo"`
+	Name                   string           `json:"name"`
+	Description            string           `json:"description,omitempty"`
+	RedirectURIs           []string         `json:"redirectURIs"`
+	PostLogoutRedirectURIs []string         `json:"postLogoutRedirectURIs,omitempty"`
+	WebOrigins             []string         `json:"webOrigins,omitempty"`
+	JWKSURI                string           `json:"jwksURI,omitempty"`
+	Status                 UserClientStatus `json:"status"`
+	// PublicKey and KeyAlgo register the client's first key; optional when JWKSURI is set
+	PublicKey []byte            `json:"publicKey,omitempty"`
+	KeyAlgo   UserClientKeyAlgo `json:"keyAlgo,omitempty"`
+	KeyID     string            `json:"kid,omitempty"`
+	// token lifetimes in seconds; omitted ones get the defaults and a refresh token lifetime of 0 disables refresh tokens
+	AccessTokenLifetime     *int `json:"accessTokenLifetime,omitempty"`
+	RefreshTokenLifetime    *int `json:"refreshTokenLifetime,omitempty"`
+	RefreshTokenMaxLifetime *int `json:"refreshTokenMaxLifetime,omitempty"`
 }
 
 type CreateClientResponse UserClient
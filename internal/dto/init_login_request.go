@@ -1,5 +1,6 @@
 package dto
 
 type InitLoginRequest struct {
+	// Username may be empty to log in with a discoverable passkey picked in the browser
 	Username string `json:"username"`
 }
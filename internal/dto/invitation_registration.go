@@ -0,0 +1,22 @@
+package dto
+
+import "github.com/go-webauthn/webauthn/protocol"
+
+type InitInvitationRegistrationRequest struct {
+	InvitationCode string  `json:"invitationCode"`
+	Username       string  `json:"username"`
+	DisplayName    string  `json:"displayName"`
+	Email          *string `json:"email,omitempty"`
+}
+
+type InitInvitationRegistrationResponse struct {
+	PublicKey protocol.PublicKeyCredentialCreationOptions `json:"publicKey"`
+}
+
+// FinishInvitationRegistrationRequest repeats the profile submitted to init; it is validated again
+// together with the invitation before the user is created
+type FinishInvitationRegistrationRequest struct {
+	InitInvitationRegistrationRequest
+	Credential protocol.CredentialCreationResponse `json:"credential"`
+	UserID     string                              `json:"userId"`
+}
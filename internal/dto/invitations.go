@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type Invitation struct {
+	ID        string     `json:"id"`
+	Email     *string    `json:"email,omitempty"`
+	Role      UserRole   `json:"role"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	UsedBy    *string    `json:"usedBy,omitempty"`
+}
+
+type InvitationsResponse []Invitation
+
+type CreateInvitationRequest struct {
+	// Email pins the invitation to one address; the invitee picks any address when it is empty
+	Email string `json:"email,omitempty"`
+	// Role defaults to user; superadmin cannot be invited
+	Role UserRole `json:"role,omitempty"`
+	// ExpiresAt defaults to 72 hours from now
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// CreateInvitationResponse carries the invitation code; it is only ever returned here
+type CreateInvitationResponse struct {
+	Invitation
+	Code string `json:"code"`
+}
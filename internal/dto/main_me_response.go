@@ -19,7 +19,9 @@ const (
 
 type MeResponse struct {
 	ID          string     `json:"id"`
+	Username    string     `json:"username"`
 	DisplayName string     `json:"displayName"`
+	Email       *string    `json:"email,omitempty"`
 	Role        UserRole   `json:"role"`
 	Status      UserStatus `json:"status"`
 }
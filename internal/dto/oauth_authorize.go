@@ -0,0 +1,27 @@
+package dto
+
+type AuthorizeRequest struct {
+	ResponseType        string `json:"responseType"`
+	ClientID            string `json:"clientId"`
+	RedirectURI         string `json:"redirectURI"`
+	Scope               string `json:"scope,omitempty"`
+	State               string `json:"state,omitempty"`
+	CodeChallenge       string `json:"codeChallenge"`
+	CodeChallengeMethod string `json:"codeChallengeMethod"`
+	Nonce               string `json:"nonce,omitempty"`
+}
+
+type InitAuthorizeRequest struct {
+	Authorize AuthorizeRequest `json:"authorize"`
+	// Username may be empty to log in with a discoverable passkey picked in the browser
+	Username string `json:"username"`
+}
+
+type FinishAuthorizeRequest struct {
+	Authorize AuthorizeRequest   `json:"authorize"`
+	Login     FinishLoginRequest `json:"login"`
+}
+
+type FinishAuthorizeResponse struct {
+	RedirectURI string `json:"redirectURI"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+// IntrospectRequest is an RFC 7662 introspection request; the caller authenticates like it does at the token endpoint
+type IntrospectRequest struct {
+	Token string `json:"token"`
+	// TokenTypeHint is access_token or refresh_token and only decides which kind of token is looked up first
+	TokenTypeHint       string `json:"token_type_hint,omitempty"`
+	ClientID            string `json:"client_id,omitempty"`
+	ClientAssertionType string `json:"client_assertion_type,omitempty"`
+	ClientAssertion     string `json:"client_assertion,omitempty"`
+}
+
+// IntrospectResponse of an unknown, expired or revoked token only carries active=false
+type IntrospectResponse struct {
+	Active   bool   `json:"active"`
+	Sub      string `json:"sub,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Exp      int64  `json:"exp,omitempty"`
+}
+
+// RevokeRequest is an RFC 7009 revocation request
+type RevokeRequest IntrospectRequest
@@ -0,0 +1,31 @@
+package dto
+
+// OAuth token endpoint payloads follow RFC 6749 naming instead of the camelCase used by the UI API
+
+type TokenRequest struct {
+	GrantType           string `json:"grant_type"`
+	Code                string `json:"code"`
+	RedirectURI         string `json:"redirect_uri"`
+	ClientID            string `json:"client_id"`
+	CodeVerifier        string `json:"code_verifier"`
+	ClientAssertionType string `json:"client_assertion_type,omitempty"`
+	ClientAssertion     string `json:"client_assertion,omitempty"`
+	// RefreshToken and Scope are used by the refresh_token grant; Scope may only narrow the original grant
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+	// RefreshToken replaces the one used for the request; every refresh token can only be used once
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+type Passkey struct {
+	// ID is the base64url (unpadded) credential ID
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Transport  []string   `json:"transport"`
+	Attachment string     `json:"attachment,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type PasskeysResponse []Passkey
+
+type InitPasskeyEnrollmentResponse struct {
+	PublicKey protocol.PublicKeyCredentialCreationOptions `json:"publicKey"`
+}
+
+type FinishPasskeyEnrollmentRequest struct {
+	// Name defaults to "Passkey"
+	Name       string                              `json:"name,omitempty"`
+	Credential protocol.CredentialCreationResponse `json:"credential"`
+}
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name"`
+}
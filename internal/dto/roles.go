@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type Permission string
+
+const (
+	PERMISSION_CLIENTS_READ  Permission = "clients:read"
+	PERMISSION_CLIENTS_WRITE Permission = "clients:write"
+	PERMISSION_USERS_MANAGE  Permission = "users:manage"
+	PERMISSION_CONFIG_WRITE  Permission = "config:write"
+)
+
+type Role struct {
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	// BuiltIn roles (user, admin and superadmin) cannot be changed or deleted
+	BuiltIn   bool       `json:"builtIn"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+type RolesResponse []Role
+
+type CreateRoleRequest struct {
+	// Name is 2-64 lowercase letters, digits, dashes or underscores
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateRoleRequest is a partial update; omitted fields are left unchanged
+type UpdateRoleRequest struct {
+	Description *string `json:"description,omitempty"`
+	// Permissions replaces the permissions of the role as a whole
+	Permissions *[]Permission `json:"permissions,omitempty"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// Session is a browser session of the signed in user
+type Session struct {
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+	Expires    time.Time  `json:"expires"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"userAgent"`
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
+
+type SessionsResponse []Session
+
+type RevokeSessionsResponse struct {
+	// Revoked is the number of sessions that were signed out
+	Revoked int64 `json:"revoked"`
+}
@@ -1,5 +1,38 @@
 // Code generated by tygo. DO NOT EDIT.
 
+//////////
+// source: client_keys.go
+
+export interface ClientKey {
+  id: string;
+  clientId: string;
+  kid: string;
+  algorithm: UserClientKeyAlgo;
+  notBefore: string /* RFC3339 */;
+  expiresAt: string /* RFC3339 */;
+  revoked: boolean;
+  revokedAt?: string /* RFC3339 */;
+  createdAt: string /* RFC3339 */;
+}
+export type ClientKeysResponse = ClientKey[];
+export interface CreateClientKeyRequest {
+  /**
+   * KID defaults to the RFC 7638 thumbprint of the key
+   */
+  kid?: string;
+  algorithm: UserClientKeyAlgo;
+  /**
+   * PublicKey is a PKIX DER or PEM encoded key; EdDSA keys may also be the raw 32 bytes
+   */
+  publicKey: string;
+  /**
+   * NotBefore defaults to now; set it in the future to pre-register the next key before a rotation
+   */
+  notBefore?: string /* RFC3339 */;
+  expiresAt?: string /* RFC3339 */;
+}
+export type CreateClientKeyResponse = ClientKey;
+
 //////////
 // source: clients_response.go
 
@@ -12,26 +45,64 @@ export interface UserClient {
   userId: string;
   name: string;
   description?: string;
-  redirectURI: string;
+  redirectURIs: string[];
+  postLogoutRedirectURIs: string[];
+  webOrigins: string[];
+  jwksURI?: string;
   status: UserClientStatus;
+  /**
+   * StatusReason is set while the client is paused or suspended
+   */
+  statusReason?: string;
+  statusChangedAt?: string /* RFC3339 */;
+  /**
+   * token lifetimes in seconds
+   */
+  accessTokenLifetime: number /* int */;
+  refreshTokenLifetime: number /* int */;
+  refreshTokenMaxLifetime: number /* int */;
 }
 export type UserClientsResponse = UserClient[];
 
+//////////
+// source: config.go
+
+/**
+ * SetConfigRequest is the body of PUT /v1/config/{key}
+ */
+export interface SetConfigRequest {
+  value: string;
+}
+
 //////////
 // source: create_client.go
 
 export type UserClientKeyAlgo = string;
 export const CLIENT_KEY_ALGO_EDDSA: UserClientKeyAlgo = "EdDSA";
+export const CLIENT_KEY_ALGO_ES256: UserClientKeyAlgo = "ES256";
 /**
  * TODO: create common fields struct and embed insetad of repeating?
  */
 export interface CreateClientRequest {
   name: string;
   description?: string;
-  redirectURI: string;
+  redirectURIs: string[];
+  postLogoutRedirectURIs?: string[];
+  webOrigins?: string[];
+  jwksURI?: string;
   status: UserClientStatus;
-  publicKey: string;
-  keyAlgo: UserClientKeyAlgo;
+  /**
+   * PublicKey and KeyAlgo register the client's first key; optional when JWKSURI is set
+   */
+  publicKey?: string;
+  keyAlgo?: UserClientKeyAlgo;
+  kid?: string;
+  /**
+   * token lifetimes in seconds; omitted ones get the defaults and a refresh token lifetime of 0 disables refresh tokens
+   */
+  accessTokenLifetime?: number /* int */;
+  refreshTokenLifetime?: number /* int */;
+  refreshTokenMaxLifetime?: number /* int */;
 }
 export type CreateClientResponse = UserClient;
 
@@ -55,6 +126,9 @@ export interface FinishRegistrationRequest {
 // source: init_login_request.go
 
 export interface InitLoginRequest {
+  /**
+   * Username may be empty to log in with a discoverable passkey picked in the browser
+   */
   username: string;
 }
 
@@ -80,6 +154,78 @@ export interface InitRegistrationResponse {
   publicKey: any /* protocol.PublicKeyCredentialCreationOptions */;
 }
 
+//////////
+// source: invitation_registration.go
+
+export interface InitInvitationRegistrationRequest {
+  invitationCode: string;
+  username: string;
+  displayName: string;
+  email?: string;
+}
+export interface InitInvitationRegistrationResponse {
+  publicKey: any /* protocol.PublicKeyCredentialCreationOptions */;
+}
+/**
+ * FinishInvitationRegistrationRequest repeats the profile submitted to init; it is validated again
+ * together with the invitation before the user is created
+ */
+export interface FinishInvitationRegistrationRequest extends InitInvitationRegistrationRequest {
+  credential: any /* protocol.CredentialCreationResponse */;
+  userId: string;
+}
+
+//////////
+// source: invitations.go
+
+export interface Invitation {
+  id: string;
+  email?: string;
+  role: UserRole;
+  createdBy: string;
+  createdAt: string /* RFC3339 */;
+  expiresAt: string /* RFC3339 */;
+  usedAt?: string /* RFC3339 */;
+  usedBy?: string;
+}
+export type InvitationsResponse = Invitation[];
+export interface CreateInvitationRequest {
+  /**
+   * Email pins the invitation to one address; the invitee picks any address when it is empty
+   */
+  email?: string;
+  /**
+   * Role defaults to user; superadmin cannot be invited
+   */
+  role?: UserRole;
+  /**
+   * ExpiresAt defaults to 72 hours from now
+   */
+  expiresAt?: string /* RFC3339 */;
+}
+/**
+ * CreateInvitationResponse carries the invitation code; it is only ever returned here
+ */
+export interface CreateInvitationResponse extends Invitation {
+  code: string;
+}
+
+//////////
+// source: jwks.go
+
+export interface JWK {
+  kty: string;
+  crv?: string;
+  x?: string;
+  y?: string;
+  kid: string;
+  use?: string;
+  alg?: string;
+}
+export interface JWKSResponse {
+  keys: JWK[];
+}
+
 //////////
 // source: main_me_response.go
 
@@ -94,11 +240,168 @@ export const USER_STATUS_PENDING: UserStatus = "pending";
 export const USER_STATUS_SUSPENDED: UserStatus = "suspended";
 export interface MeResponse {
   id: string;
+  username: string;
   displayName: string;
+  email?: string;
   role: UserRole;
   status: UserStatus;
 }
 
+//////////
+// source: oauth_authorize.go
+
+export interface AuthorizeRequest {
+  responseType: string;
+  clientId: string;
+  redirectURI: string;
+  scope?: string;
+  state?: string;
+  codeChallenge: string;
+  codeChallengeMethod: string;
+  nonce?: string;
+}
+export interface InitAuthorizeRequest {
+  authorize: AuthorizeRequest;
+  /**
+   * Username may be empty to log in with a discoverable passkey picked in the browser
+   */
+  username: string;
+}
+export interface FinishAuthorizeRequest {
+  authorize: AuthorizeRequest;
+  login: FinishLoginRequest;
+}
+export interface FinishAuthorizeResponse {
+  redirectURI: string;
+}
+
+//////////
+// source: oauth_introspect.go
+
+/**
+ * IntrospectRequest is an RFC 7662 introspection request; the caller authenticates like it does at the token endpoint
+ */
+export interface IntrospectRequest {
+  token: string;
+  /**
+   * TokenTypeHint is access_token or refresh_token and only decides which kind of token is looked up first
+   */
+  token_type_hint?: string;
+  client_id?: string;
+  client_assertion_type?: string;
+  client_assertion?: string;
+}
+/**
+ * IntrospectResponse of an unknown, expired or revoked token only carries active=false
+ */
+export interface IntrospectResponse {
+  active: boolean;
+  sub?: string;
+  client_id?: string;
+  scope?: string;
+  exp?: number /* int64 */;
+}
+/**
+ * RevokeRequest is an RFC 7009 revocation request
+ */
+export type RevokeRequest = IntrospectRequest;
+
+//////////
+// source: oauth_token.go
+
+export interface TokenRequest {
+  grant_type: string;
+  code: string;
+  redirect_uri: string;
+  client_id: string;
+  code_verifier: string;
+  client_assertion_type?: string;
+  client_assertion?: string;
+  /**
+   * RefreshToken and Scope are used by the refresh_token grant; Scope may only narrow the original grant
+   */
+  refresh_token?: string;
+  scope?: string;
+}
+export interface TokenResponse {
+  access_token: string;
+  token_type: string;
+  expires_in: number /* int */;
+  scope?: string;
+  id_token?: string;
+  /**
+   * RefreshToken replaces the one used for the request; every refresh token can only be used once
+   */
+  refresh_token?: string;
+}
+export interface OAuthErrorResponse {
+  error: string;
+  error_description?: string;
+}
+
+//////////
+// source: oidc.go
+
+export interface DiscoveryResponse {
+  issuer: string;
+  authorization_endpoint: string;
+  token_endpoint: string;
+  introspection_endpoint: string;
+  revocation_endpoint: string;
+  userinfo_endpoint: string;
+  jwks_uri: string;
+  scopes_supported: string[];
+  response_types_supported: string[];
+  grant_types_supported: string[];
+  subject_types_supported: string[];
+  id_token_signing_alg_values_supported: string[];
+  token_endpoint_auth_methods_supported: string[];
+  token_endpoint_auth_signing_alg_values_supported: string[];
+  /**
+   * introspection and revocation are only open to clients authenticating with their registered key
+   */
+  introspection_endpoint_auth_methods_supported: string[];
+  revocation_endpoint_auth_methods_supported: string[];
+  code_challenge_methods_supported: string[];
+  claims_supported: string[];
+  acr_values_supported: string[];
+}
+export interface UserInfoResponse {
+  sub: string;
+  preferred_username?: string;
+  name?: string;
+  email?: string;
+}
+
+//////////
+// source: passkeys.go
+
+export interface Passkey {
+  /**
+   * ID is the base64url (unpadded) credential ID
+   */
+  id: string;
+  name: string;
+  transport: string[];
+  attachment?: string;
+  createdAt: string /* RFC3339 */;
+  lastUsedAt?: string /* RFC3339 */;
+}
+export type PasskeysResponse = Passkey[];
+export interface InitPasskeyEnrollmentResponse {
+  publicKey: any /* protocol.PublicKeyCredentialCreationOptions */;
+}
+export interface FinishPasskeyEnrollmentRequest {
+  /**
+   * Name defaults to "Passkey"
+   */
+  name?: string;
+  credential: any /* protocol.CredentialCreationResponse */;
+}
+export interface RenamePasskeyRequest {
+  name: string;
+}
+
 //////////
 // source: response.go
 
@@ -110,3 +413,143 @@ export interface Response<T extends any> {
   response?: T;
   error?: ResponseError;
 }
+
+//////////
+// source: roles.go
+
+export type Permission = string;
+export const PERMISSION_CLIENTS_READ: Permission = "clients:read";
+export const PERMISSION_CLIENTS_WRITE: Permission = "clients:write";
+export const PERMISSION_USERS_MANAGE: Permission = "users:manage";
+export const PERMISSION_CONFIG_WRITE: Permission = "config:write";
+export interface Role {
+  name: UserRole;
+  description: string;
+  permissions: Permission[];
+  /**
+   * BuiltIn roles (user, admin and superadmin) cannot be changed or deleted
+   */
+  builtIn: boolean;
+  createdAt?: string /* RFC3339 */;
+}
+export type RolesResponse = Role[];
+export interface CreateRoleRequest {
+  /**
+   * Name is 2-64 lowercase letters, digits, dashes or underscores
+   */
+  name: UserRole;
+  description?: string;
+  permissions: Permission[];
+}
+/**
+ * UpdateRoleRequest is a partial update; omitted fields are left unchanged
+ */
+export interface UpdateRoleRequest {
+  description?: string;
+  /**
+   * Permissions replaces the permissions of the role as a whole
+   */
+  permissions?: Permission[];
+}
+
+//////////
+// source: sessions.go
+
+/**
+ * Session is a browser session of the signed in user
+ */
+export interface Session {
+  id: string;
+  createdAt: string /* RFC3339 */;
+  lastSeenAt?: string /* RFC3339 */;
+  expires: string /* RFC3339 */;
+  ip: string;
+  userAgent: string;
+  /**
+   * Current marks the session the request was made with
+   */
+  current: boolean;
+}
+export type SessionsResponse = Session[];
+export interface RevokeSessionsResponse {
+  /**
+   * Revoked is the number of sessions that were signed out
+   */
+  revoked: number /* int64 */;
+}
+
+//////////
+// source: update_client.go
+
+/**
+ * UpdateClientRequest is a partial update; omitted fields are left unchanged
+ */
+export interface UpdateClientRequest {
+  name?: string;
+  description?: string;
+  /**
+   * list fields replace the stored list as a whole
+   */
+  redirectURIs?: string[];
+  postLogoutRedirectURIs?: string[];
+  webOrigins?: string[];
+  jwksURI?: string;
+  status?: UserClientStatus;
+  /**
+   * StatusReason is required when pausing or suspending the client
+   */
+  statusReason?: string;
+  /**
+   * token lifetimes in seconds; changes apply to tokens issued afterwards
+   */
+  accessTokenLifetime?: number /* int */;
+  refreshTokenLifetime?: number /* int */;
+  refreshTokenMaxLifetime?: number /* int */;
+}
+export type UpdateClientResponse = UserClient;
+
+//////////
+// source: users.go
+
+/**
+ * User is a user as seen by admins
+ */
+export type User = MeResponse;
+/**
+ * UsersRequest is read from the query string of GET /v1/users; zero values match everything
+ */
+export interface UsersRequest {
+  /**
+   * Search matches a part of the username or email
+   */
+  search?: string;
+  status?: UserStatus;
+  role?: UserRole;
+  /**
+   * Limit defaults to 50 and is capped at 200
+   */
+  limit?: number /* int */;
+  offset?: number /* int */;
+}
+export interface UsersResponse {
+  users: User[];
+  /**
+   * Total is the number of users matching the filters across all pages
+   */
+  total: number /* int */;
+  limit: number /* int */;
+  offset: number /* int */;
+}
+/**
+ * UpdateUserRequest is a partial update; omitted fields are left unchanged
+ */
+export interface UpdateUserRequest {
+  displayName?: string;
+  /**
+   * Email is removed when set to an empty string
+   */
+  email?: string;
+  role?: UserRole;
+  status?: UserStatus;
+}
+export type UpdateUserResponse = User;
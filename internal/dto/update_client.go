@@ -0,0 +1,21 @@
+package dto
+
+// UpdateClientRequest is a partial update; omitted fields are left unchanged
+type UpdateClientRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// list fields replace the stored list as a whole
+	RedirectURIs           *[]string         `json:"redirectURIs,omitempty"`
+	PostLogoutRedirectURIs *[]string         `json:"postLogoutRedirectURIs,omitempty"`
+	WebOrigins             *[]string         `json:"webOrigins,omitempty"`
+	JWKSURI                *string           `json:"jwksURI,omitempty"`
+	Status                 *UserClientStatus `json:"status,omitempty"`
+	// StatusReason is required when pausing or suspending the client
+	StatusReason string `json:"statusReason,omitempty"`
+	// token lifetimes in seconds; changes apply to tokens issued afterwards
+	AccessTokenLifetime     *int `json:"accessTokenLifetime,omitempty"`
+	RefreshTokenLifetime    *int `json:"refreshTokenLifetime,omitempty"`
+	RefreshTokenMaxLifetime *int `json:"refreshTokenMaxLifetime,omitempty"`
+}
+
+type UpdateClientResponse UserClient
@@ -0,0 +1,34 @@
+package dto
+
+// User is a user as seen by admins
+type User MeResponse
+
+// UsersRequest is read from the query string of GET /v1/users; zero values match everything
+type UsersRequest struct {
+	// Search matches a part of the username or email
+	Search string     `json:"search,omitempty"`
+	Status UserStatus `json:"status,omitempty"`
+	Role   UserRole   `json:"role,omitempty"`
+	// Limit defaults to 50 and is capped at 200
+	Limit  int `json:"limit,omitempty"`
+	Offset int `json:"offset,omitempty"`
+}
+
+type UsersResponse struct {
+	Users []User `json:"users"`
+	// Total is the number of users matching the filters across all pages
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// UpdateUserRequest is a partial update; omitted fields are left unchanged
+type UpdateUserRequest struct {
+	DisplayName *string `json:"displayName,omitempty"`
+	// Email is removed when set to an empty string
+	Email  *string     `json:"email,omitempty"`
+	Role   *UserRole   `json:"role,omitempty"`
+	Status *UserStatus `json:"status,omitempty"`
+}
+
+type UpdateUserResponse User
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/asatraitis/mangrove/configs"
 	"github.com/asatraitis/mangrove/internal/bll"
 	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
@@ -13,6 +16,8 @@ import (
 type Handler interface {
 	Init(*http.ServeMux) InitHandler
 	Main(*http.ServeMux) MainHandler
+	OAuth(*http.ServeMux) OAuthHandler
+	OIDC(*http.ServeMux) OIDCHandler
 }
 type BaseHandler struct {
 	logger     zerolog.Logger
@@ -45,3 +50,77 @@ func (h *handler) Init(mux *http.ServeMux) InitHandler {
 func (h *handler) Main(mux *http.ServeMux) MainHandler {
 	return NewMainHandler(h.BaseHandler, mux)
 }
+func (h *handler) OAuth(mux *http.ServeMux) OAuthHandler {
+	return NewOAuthHandler(h.BaseHandler, mux)
+}
+func (h *handler) OIDC(mux *http.ServeMux) OIDCHandler {
+	return NewOIDCHandler(h.BaseHandler, mux)
+}
+
+func (h *BaseHandler) setCsrfCookies(w http.ResponseWriter, r *http.Request, authToken string) {
+	h.logger.Info().Msg("setting CSRF Cookies")
+	if r == nil || w == nil {
+		h.logger.Error().Msg("missing request or response; failed to set csrf cookies")
+		return
+	}
+
+	// validating IP might be harder to use with a proxy - omitting for now
+	// randomUUID + authToken + userAgent + acceptHeader + acceptLanguageHeader
+	csrfToken := uuid.NewString()
+	ip := getReqIP(r)
+	data := csrfToken + authToken + r.UserAgent() + r.Header.Get("Accept") + r.Header.Get("Accept-Language")
+	h.logger.Info().Str("userAgent", r.UserAgent()).Str("RemoteAdds", ip).Msg("data to sign")
+	hasher := utils.NewStandardCrypto([]byte(h.vars.MangroveSalt))
+	signature := hasher.GenerateTokenHMAC(data)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "csrf_token",
+		Value:    csrfToken,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+		Secure:   h.vars.MangroveEnv == configs.PROD,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "csrf_sig",
+		Value:    signature,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+		Secure:   h.vars.MangroveEnv == configs.PROD,
+		HttpOnly: true,
+	})
+}
+
+// setAuthCookie sets the session cookie; it is never readable from JS and only sent over TLS in prod
+func (h *BaseHandler) setAuthCookie(w http.ResponseWriter, authToken string, expires time.Time) {
+	http.SetCookie(w, newAuthCookie(h.vars, authToken, expires))
+}
+
+// newAuthCookie builds the session cookie; it expires together with the session on the server
+func newAuthCookie(vars *configs.EnvVariables, authToken string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     "auth_token",
+		Value:    authToken,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   max(int(time.Until(expires).Seconds()), 1),
+		SameSite: http.SameSiteStrictMode,
+		Secure:   vars.MangroveEnv == configs.PROD,
+		HttpOnly: true,
+	}
+}
+
+// clearSessionCookies expires the auth and csrf cookies set at login
+func (h *BaseHandler) clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{"auth_token", "csrf_token", "csrf_sig"} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			SameSite: http.SameSiteStrictMode,
+			Secure:   h.vars.MangroveEnv == configs.PROD,
+			HttpOnly: name != "csrf_token",
+		})
+	}
+}
@@ -6,9 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/asatraitis/mangrove/configs"
 	"github.com/asatraitis/mangrove/internal/dto"
-	"github.com/asatraitis/mangrove/internal/utils"
 	"github.com/google/uuid"
 )
 
@@ -135,7 +133,7 @@ func (ih *initHandler) finishRegistration(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	token, err := ih.bll.User(ctx).CreateToken(userUUID)
+	token, err := ih.bll.User(ctx).CreateToken(userUUID, getReqIP(r), r.UserAgent())
 	if err != nil {
 		ih.logger.Error().Msg("failed to create user token")
 		sendErrResponse[any](w, &dto.ResponseError{
@@ -145,44 +143,7 @@ func (ih *initHandler) finishRegistration(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    token.ID.String(),
-		Path:     "/",
-		SameSite: http.SameSiteStrictMode,
-		// Secure: true, // TODO: this needs to be set TRUE for prod
-	})
+	ih.setAuthCookie(w, token.Token, token.Expires)
 
 	w.WriteHeader(http.StatusOK)
 }
-
-// TODO: Consolidate with main handler (dupe)
-func (ih *initHandler) setCsrfCookies(w http.ResponseWriter, r *http.Request, authToken string) {
-	if r == nil || w == nil {
-		return
-	}
-
-	// validating IP might be harder to use with a proxy - omitting for now
-	// randomUUID + authToken + userAgent + acceptHeader + acceptLanguageHeader
-	csrfToken := uuid.NewString()
-	data := csrfToken + authToken + r.UserAgent() + r.Header.Get("Accept") + r.Header.Get("Accept-Language")
-	hasher := utils.NewStandardCrypto([]byte(ih.vars.MangroveSalt))
-	signature := hasher.GenerateTokenHMAC(data)
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "csrf_token",
-		Value:    csrfToken,
-		Path:     "/",
-		SameSite: http.SameSiteStrictMode,
-		Secure:   ih.vars.MangroveEnv == configs.PROD,
-	})
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "csrf_sig",
-		Value:    signature,
-		Path:     "/",
-		SameSite: http.SameSiteStrictMode,
-		Secure:   ih.vars.MangroveEnv == configs.PROD,
-		HttpOnly: true,
-	})
-}
@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"os"
 	"path"
-	"strings"
+	"path/filepath"
+	"strconv"
 
-	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/asatraitis/mangrove/internal/dto"
 	"github.com/asatraitis/mangrove/internal/typeconv"
 	"github.com/asatraitis/mangrove/internal/utils"
@@ -44,14 +46,15 @@ func (h *mainHandler) register() {
 			h.middleware.CsrfValidationMiddleware,
 		},
 	))
-	h.mux.Handle("GET /", http.FileServer(http.Dir("./dist/main")))
+	// client side routes such as /login, where /oauth2/authorize sends the browser, resolve to index.html
+	h.mux.Handle("GET /", h.clientRouting())
 	h.mux.HandleFunc("GET /v1/clients", HandleWithMiddleware(
 		h.clients,
 		[]MiddlewareFunc{
 			h.middleware.CsrfValidationMiddleware,
 			h.middleware.AuthValidationMiddleware,
 			h.middleware.UserStatusValidation,
-			h.middleware.UserRoleSuperadmin,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_READ),
 		},
 	))
 
@@ -61,7 +64,238 @@ func (h *mainHandler) register() {
 			h.middleware.CsrfValidationMiddleware,
 			h.middleware.AuthValidationMiddleware,
 			h.middleware.UserStatusValidation,
-			h.middleware.UserRoleSuperadmin,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_WRITE),
+		},
+	))
+	h.mux.HandleFunc("GET /v1/clients/{id}", HandleWithMiddleware(
+		h.getClient,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_READ),
+		},
+	))
+	h.mux.HandleFunc("PATCH /v1/clients/{id}", HandleWithMiddleware(
+		h.updateClient,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_WRITE),
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/clients/{id}", HandleWithMiddleware(
+		h.deleteClient,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_WRITE),
+		},
+	))
+	h.mux.HandleFunc("GET /v1/clients/{id}/keys", HandleWithMiddleware(
+		h.clientKeys,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_READ),
+		},
+	))
+	h.mux.HandleFunc("POST /v1/clients/{id}/keys", HandleWithMiddleware(
+		h.addClientKey,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_WRITE),
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/clients/{id}/keys/{keyId}", HandleWithMiddleware(
+		h.revokeClientKey,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CLIENTS_WRITE),
+		},
+	))
+	h.mux.HandleFunc("GET /v1/invitations", HandleWithMiddleware(
+		h.invitations,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("POST /v1/invitations", HandleWithMiddleware(
+		h.createInvitation,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/invitations/{id}", HandleWithMiddleware(
+		h.revokeInvitation,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("GET /v1/users", HandleWithMiddleware(
+		h.users,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("GET /v1/users/{id}", HandleWithMiddleware(
+		h.getUser,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("PATCH /v1/users/{id}", HandleWithMiddleware(
+		h.updateUser,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("GET /v1/roles", HandleWithMiddleware(
+		h.roles,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("POST /v1/roles", HandleWithMiddleware(
+		h.createRole,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("PATCH /v1/roles/{name}", HandleWithMiddleware(
+		h.updateRole,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/roles/{name}", HandleWithMiddleware(
+		h.deleteRole,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("PUT /v1/config/{key}", HandleWithMiddleware(
+		h.setConfig,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_CONFIG_WRITE),
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/users/{id}/sessions", HandleWithMiddleware(
+		h.revokeUserSessions,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+			h.middleware.RequirePermission(models.PERMISSION_USERS_MANAGE),
+		},
+	))
+	h.mux.HandleFunc("POST /v1/logout", HandleWithMiddleware(
+		h.logout,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+		},
+	))
+	h.mux.HandleFunc("GET /v1/sessions", HandleWithMiddleware(
+		h.sessions,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/sessions/{id}", HandleWithMiddleware(
+		h.revokeSession,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("GET /v1/me/passkeys", HandleWithMiddleware(
+		h.passkeys,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("POST /v1/me/passkeys", HandleWithMiddleware(
+		h.initPasskeyEnrollment,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("POST /v1/me/passkeys/finish", HandleWithMiddleware(
+		h.finishPasskeyEnrollment,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("PATCH /v1/me/passkeys/{id}", HandleWithMiddleware(
+		h.renamePasskey,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("DELETE /v1/me/passkeys/{id}", HandleWithMiddleware(
+		h.deletePasskey,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+			h.middleware.AuthValidationMiddleware,
+			h.middleware.UserStatusValidation,
+		},
+	))
+	h.mux.HandleFunc("POST /v1/register/invitation", h.initInvitationRegistration)
+	h.mux.HandleFunc("POST /v1/register/invitation/finish", HandleWithMiddleware(h.finishInvitationRegistration,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
 		},
 	))
 
@@ -72,13 +306,11 @@ func (h *mainHandler) clientRouting() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// If the requested file exists then return if; otherwise return index.html (fileserver default page)
 		if r.URL.Path != "/" {
-			fullPath := fsPath + strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+			fullPath := filepath.Join(fsPath, filepath.FromSlash(path.Clean(r.URL.Path)))
 			_, err := os.Stat(fullPath)
 			if err != nil {
-				if !os.IsNotExist(err) {
-					panic(err)
-				}
-				// Requested file does not exist so we return the default (resolves to index.html)
+				// Requested file does not exist (or is not reachable, e.g. a path below a file) so we return
+				// the default (resolves to index.html)
 				r.URL.Path = "/"
 			}
 		}
@@ -192,7 +424,7 @@ func (h *mainHandler) finishLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.bll.User(ctx).CreateToken(userID)
+	token, err := h.bll.User(ctx).CreateToken(userID, getReqIP(r), r.UserAgent())
 	if err != nil {
 		h.logger.Error().Msg("failed to create user token")
 		sendErrResponse[any](w, &dto.ResponseError{
@@ -202,15 +434,9 @@ func (h *mainHandler) finishLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.setCsrfCookies(w, r, token.ID.String())
+	h.setCsrfCookies(w, r, token.Token)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    token.ID.String(),
-		Path:     "/",
-		SameSite: http.SameSiteStrictMode,
-		// Secure: true, // TODO: this needs to be set TRUE for prod
-	})
+	h.setAuthCookie(w, token.Token, token.Expires)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -266,37 +492,753 @@ func (h *mainHandler) createClient(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(dto.Response[dto.CreateClientResponse]{Response: res})
 }
 
-// TODO: Consolidate with init handler (dupe)
-func (h *mainHandler) setCsrfCookies(w http.ResponseWriter, r *http.Request, authToken string) {
-	h.logger.Info().Msg("setting CSRF Cookies")
-	if r == nil || w == nil {
-		h.logger.Error().Msg("missing request or response; failed to set csrf cookies")
+func (h *mainHandler) getClient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid client id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
 		return
 	}
 
-	// validating IP might be harder to use with a proxy - omitting for now
-	// randomUUID + authToken + userAgent + acceptHeader + acceptLanguageHeader
-	csrfToken := uuid.NewString()
-	ip := getReqIP(r)
-	data := csrfToken + authToken + r.UserAgent() + r.Header.Get("Accept") + r.Header.Get("Accept-Language")
-	h.logger.Info().Str("userAgent", r.UserAgent()).Str("RemoteAdds", ip).Msg("data to sign")
-	hasher := utils.NewStandardCrypto([]byte(h.vars.MangroveSalt))
-	signature := hasher.GenerateTokenHMAC(data)
+	res, err := h.bll.Client(ctx).GetClient(clientID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get client",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusNotFound)
+		return
+	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "csrf_token",
-		Value:    csrfToken,
-		Path:     "/",
-		SameSite: http.SameSiteStrictMode,
-		Secure:   h.vars.MangroveEnv == configs.PROD,
-	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "csrf_sig",
-		Value:    signature,
-		Path:     "/",
-		SameSite: http.SameSiteStrictMode,
-		Secure:   h.vars.MangroveEnv == configs.PROD,
-		HttpOnly: true,
-	})
+	json.NewEncoder(w).Encode(dto.Response[dto.UserClient]{Response: res})
+}
+
+func (h *mainHandler) updateClient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid client id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateClientRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.Client(ctx).Update(clientID, req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to update client",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.UpdateClientResponse]{Response: res})
+}
+
+func (h *mainHandler) deleteClient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid client id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.Client(ctx).Delete(clientID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to delete client",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *mainHandler) clientKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid client id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	keys, err := h.bll.Client(ctx).GetKeys(clientID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get client keys",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.ClientKeysResponse]{Response: &keys})
+}
+
+func (h *mainHandler) addClientKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid client id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.CreateClientKeyRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.Client(ctx).AddKey(clientID, req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to add client key",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.CreateClientKeyResponse]{Response: res})
+}
+
+func (h *mainHandler) revokeClientKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	clientID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid client id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+	keyID, err := uuid.Parse(r.PathValue("keyId"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid key id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.Client(ctx).RevokeKey(clientID, keyID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to revoke client key",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *mainHandler) invitations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	invitations, err := h.bll.Invitation(ctx).GetAll()
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get invitations",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.InvitationsResponse]{Response: &invitations})
+}
+
+func (h *mainHandler) createInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.CreateInvitationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.Invitation(ctx).Create(req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to create invitation",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.CreateInvitationResponse]{Response: res})
+}
+
+func (h *mainHandler) revokeInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	invitationID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid invitation id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.Invitation(ctx).Revoke(invitationID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to revoke invitation",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// users lists users; the query string takes search, status, role, limit and offset
+func (h *mainHandler) users(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	req := dto.UsersRequest{
+		Search: query.Get("search"),
+		Status: dto.UserStatus(query.Get("status")),
+		Role:   dto.UserRole(query.Get("role")),
+	}
+	for _, param := range []string{"limit", "offset"} {
+		if query.Get(param) == "" {
+			continue
+		}
+		value, err := strconv.Atoi(query.Get(param))
+		if err != nil {
+			sendErrResponse[any](w, &dto.ResponseError{
+				Message: "invalid " + param,
+				Code:    "ERROR_CODE_TBD",
+			}, http.StatusBadRequest)
+			return
+		}
+		if param == "limit" {
+			req.Limit = value
+		} else {
+			req.Offset = value
+		}
+	}
+
+	res, err := h.bll.User(ctx).GetUsers(req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.UsersResponse]{Response: res})
+}
+
+func (h *mainHandler) getUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid user id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.User(ctx).GetUser(userID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get user",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.User]{Response: res})
+}
+
+func (h *mainHandler) updateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid user id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateUserRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.User(ctx).UpdateUser(userID, req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.UpdateUserResponse]{Response: res})
+}
+
+func (h *mainHandler) initInvitationRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.InitInvitationRegistrationRequest
+	var res dto.InitInvitationRegistrationResponse
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	creds, err := h.bll.Invitation(ctx).InitRegistration(req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+	res.PublicKey = creds.Response
+
+	h.setCsrfCookies(w, r, "")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.InitInvitationRegistrationResponse]{Response: &res})
+}
+
+// finishInvitationRegistration signs the new user in right away unless the policy leaves them pending
+func (h *mainHandler) finishInvitationRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.FinishInvitationRegistrationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.bll.Invitation(ctx).FinishRegistration(req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := typeconv.ConvertUserToMeResponse(user)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to typeconv user")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to typeconv",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	if user.Status == models.USER_STATUS_ACTIVE {
+		token, err := h.bll.User(ctx).CreateToken(user.ID, getReqIP(r), r.UserAgent())
+		if err != nil {
+			h.logger.Error().Msg("failed to create user token")
+			sendErrResponse[any](w, &dto.ResponseError{
+				Message: err.Error(),
+				Code:    "ERROR_CODE_TBD",
+			}, http.StatusBadRequest)
+			return
+		}
+
+		h.setCsrfCookies(w, r, token.Token)
+		h.setAuthCookie(w, token.Token, token.Expires)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.MeResponse]{Response: res})
+}
+
+func (h *mainHandler) passkeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	passkeys, err := h.bll.Passkey(ctx).GetAll()
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get passkeys",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.PasskeysResponse]{Response: &passkeys})
+}
+
+func (h *mainHandler) initPasskeyEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var res dto.InitPasskeyEnrollmentResponse
+	creds, err := h.bll.Passkey(ctx).BeginEnrollment()
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to begin passkey enrollment",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusInternalServerError)
+		return
+	}
+	res.PublicKey = creds.Response
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.InitPasskeyEnrollmentResponse]{Response: &res})
+}
+
+func (h *mainHandler) finishPasskeyEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.FinishPasskeyEnrollmentRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	passkey, err := h.bll.Passkey(ctx).FinishEnrollment(req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.Passkey]{Response: passkey})
+}
+
+func (h *mainHandler) renamePasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.RenamePasskeyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.Passkey(ctx).Rename(r.PathValue("id"), req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *mainHandler) deletePasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.bll.Passkey(ctx).Delete(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+func (h *mainHandler) roles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	roles, err := h.bll.Role(ctx).GetAll()
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get roles",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.RolesResponse]{Response: &roles})
+}
+func (h *mainHandler) createRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.CreateRoleRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.Role(ctx).Create(req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.Role]{Response: res})
+}
+func (h *mainHandler) updateRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.UpdateRoleRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.Role(ctx).Update(r.PathValue("name"), req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.Role]{Response: res})
+}
+func (h *mainHandler) deleteRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.bll.Role(ctx).Delete(r.PathValue("name"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+func (h *mainHandler) setConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.SetConfigRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		h.logger.Err(err).Msg("failed to decode payload")
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.Config(ctx).SetAdminConfig(dal.ConfigKey(r.PathValue("key")), req.Value)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+func (h *mainHandler) logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.bll.Session(ctx).Logout()
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	h.clearSessionCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+func (h *mainHandler) sessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sessions, err := h.bll.Session(ctx).GetAll()
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to get sessions",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.SessionsResponse]{Response: &sessions})
+}
+func (h *mainHandler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid session id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.Session(ctx).Revoke(sessionID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+func (h *mainHandler) revokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid user id",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.Session(ctx).RevokeAllForUser(userID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: err.Error(),
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.RevokeSessionsResponse]{Response: res})
 }
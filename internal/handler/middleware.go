@@ -11,7 +11,6 @@ import (
 	"github.com/asatraitis/mangrove/internal/dto"
 	"github.com/asatraitis/mangrove/internal/handler/types"
 	"github.com/asatraitis/mangrove/internal/utils"
-	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
@@ -20,8 +19,7 @@ type Middleware interface {
 	CsrfValidationMiddleware(HandlerFuncType) HandlerFuncType
 	AuthValidationMiddleware(HandlerFuncType) HandlerFuncType
 	UserStatusValidation(HandlerFuncType) HandlerFuncType
-	UserRoleSuperadmin(HandlerFuncType) HandlerFuncType
-	UserRoleUser(next HandlerFuncType) HandlerFuncType
+	RequirePermission(models.Permission) MiddlewareFunc
 }
 type middleware struct {
 	vars   *configs.EnvVariables
@@ -62,26 +60,21 @@ func (m *middleware) AuthValidationMiddleware(next HandlerFuncType) HandlerFuncT
 			}, http.StatusBadRequest)
 			return
 		}
-		tokenID, err := uuid.Parse(authToken.Value)
+		session, renewed, err := m.bll.User(r.Context()).ValidateToken(authToken.Value)
 		if err != nil {
 			sendErrResponse[any](w, &dto.ResponseError{
-				Message: "failed to validate token: bad token",
+				Message: "failed to validate token",
 				Code:    "ERROR_CODE_TBD",
 			}, http.StatusBadRequest)
 			return
 		}
 
-		user, err := m.bll.User(r.Context()).ValidateTokenAndGetUser(tokenID)
-		if err != nil {
-			sendErrResponse[any](w, &dto.ResponseError{
-				Message: "failed to validate token",
-				Code:    "ERROR_CODE_TBD",
-			}, http.StatusBadRequest)
-			return
+		if renewed {
+			http.SetCookie(w, newAuthCookie(m.vars, authToken.Value, session.Expires))
 		}
 
-		// TODO: keep in context?
-		ctx := context.WithValue(r.Context(), types.REQ_CTX_KEY_USER_TOKEN, authToken.Value)
+		user := session.User
+		ctx := context.WithValue(r.Context(), types.REQ_CTX_KEY_SESSION_ID, session.ID.String())
 		ctx = context.WithValue(ctx, types.REQ_CTX_KEY_USER_ID, user.ID.String())
 		ctx = context.WithValue(ctx, types.REQ_CTX_KEY_USER_ROLE, user.Role)
 		ctx = context.WithValue(ctx, types.REQ_CTX_KEY_USER_STATUS, user.Status)
@@ -167,34 +160,32 @@ func (m *middleware) UserStatusValidation(next HandlerFuncType) HandlerFuncType
 		next(w, r)
 	}
 }
-func (m *middleware) UserRoleSuperadmin(next HandlerFuncType) HandlerFuncType {
-	return m.userRoleValidation(next, models.USER_ROLE_SUPERUSER)
-}
-func (m *middleware) UserRoleUser(next HandlerFuncType) HandlerFuncType {
-	return m.userRoleValidation(next, models.USER_ROLE_USER)
-}
 
-func (m *middleware) userRoleValidation(next HandlerFuncType, role models.UserRole) HandlerFuncType {
-	return func(w http.ResponseWriter, r *http.Request) {
-		m.logger.Info().Msg("Validating user status")
-		userRole, err := utils.GetUserRoleFromCtx(r.Context())
-		if err != nil {
-			m.logger.Err(err).Str("role", string(userRole)).Msg("failed to get user status from context")
-			sendErrResponse[any](w, &dto.ResponseError{
-				Message: "failed to validate user status",
-				Code:    "ERROR_CODE_TBD",
-			}, http.StatusBadRequest)
-			return
-		}
-		if userRole != role {
-			m.logger.Err(err).Str("role", string(userRole)).Str("allowedRole", string(role)).Msg("user role was not the role validate against")
-			sendErrResponse[any](w, &dto.ResponseError{
-				Message: "not allowed",
-				Code:    "ERROR_CODE_TBD",
-			}, http.StatusForbidden)
-			return
+// RequirePermission only lets users through whose role grants the permission
+func (m *middleware) RequirePermission(permission models.Permission) MiddlewareFunc {
+	return func(next HandlerFuncType) HandlerFuncType {
+		return func(w http.ResponseWriter, r *http.Request) {
+			m.logger.Info().Str("permission", string(permission)).Msg("Validating user permission")
+			userRole, err := utils.GetUserRoleFromCtx(r.Context())
+			if err != nil {
+				m.logger.Err(err).Str("role", string(userRole)).Msg("failed to get user role from context")
+				sendErrResponse[any](w, &dto.ResponseError{
+					Message: "failed to validate user role",
+					Code:    "ERROR_CODE_TBD",
+				}, http.StatusBadRequest)
+				return
+			}
+			permissions, err := m.bll.Role(r.Context()).Permissions(userRole)
+			if err != nil || !slices.Contains(permissions, permission) {
+				m.logger.Err(err).Str("role", string(userRole)).Str("permission", string(permission)).Msg("user role does not grant the permission")
+				sendErrResponse[any](w, &dto.ResponseError{
+					Message: "not allowed",
+					Code:    "ERROR_CODE_TBD",
+				}, http.StatusForbidden)
+				return
+			}
+
+			next(w, r)
 		}
-
-		next(w, r)
 	}
 }
@@ -68,3 +68,31 @@ func (mr *MockHandlerMockRecorder) Main(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Main", reflect.TypeOf((*MockHandler)(nil).Main), arg0)
 }
+
+// OAuth mocks base method.
+func (m *MockHandler) OAuth(arg0 *http.ServeMux) handler.OAuthHandler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuth", arg0)
+	ret0, _ := ret[0].(handler.OAuthHandler)
+	return ret0
+}
+
+// OAuth indicates an expected call of OAuth.
+func (mr *MockHandlerMockRecorder) OAuth(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuth", reflect.TypeOf((*MockHandler)(nil).OAuth), arg0)
+}
+
+// OIDC mocks base method.
+func (m *MockHandler) OIDC(arg0 *http.ServeMux) handler.OIDCHandler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDC", arg0)
+	ret0, _ := ret[0].(handler.OIDCHandler)
+	return ret0
+}
+
+// OIDC indicates an expected call of OIDC.
+func (mr *MockHandlerMockRecorder) OIDC(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDC", reflect.TypeOf((*MockHandler)(nil).OIDC), arg0)
+}
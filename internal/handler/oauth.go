@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/asatraitis/mangrove/internal/bll"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/google/uuid"
+)
+
+type OAuthHandler interface{}
+type oauthHandler struct {
+	*BaseHandler
+
+	mux *http.ServeMux
+}
+
+func NewOAuthHandler(baseHandler *BaseHandler, mux *http.ServeMux) OAuthHandler {
+	h := &oauthHandler{
+		BaseHandler: baseHandler,
+		mux:         mux,
+	}
+	h.logger = h.logger.With().Str("subcomponent", "OAuthHandler").Logger()
+	h.register()
+	return h
+}
+
+func (h *oauthHandler) register() {
+	h.mux.HandleFunc("GET /oauth2/authorize", h.authorize)
+	h.mux.HandleFunc("POST /oauth2/authorize", h.initAuthorize)
+	h.mux.HandleFunc("POST /oauth2/authorize/finish", HandleWithMiddleware(h.finishAuthorize,
+		[]MiddlewareFunc{
+			h.middleware.CsrfValidationMiddleware,
+		},
+	))
+	h.mux.HandleFunc("POST /oauth2/token", h.token)
+	h.mux.HandleFunc("POST /oauth2/introspect", h.introspect)
+	h.mux.HandleFunc("POST /oauth2/revoke", h.revoke)
+}
+
+// authorize validates the authorization request and hands the browser over to the UI login page,
+// which then drives the passkey login through initAuthorize/finishAuthorize
+func (h *oauthHandler) authorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := authorizeRequestFromQuery(r.URL.Query())
+	_, err := h.bll.OAuth(ctx).ValidateAuthorizeRequest(req)
+	if err != nil {
+		h.sendAuthorizeError(w, r, err, req.State)
+		return
+	}
+
+	http.Redirect(w, r, "/login?"+r.URL.RawQuery, http.StatusFound)
+}
+
+func (h *oauthHandler) initAuthorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req dto.InitAuthorizeRequest
+	var res dto.InitLoginResponse
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.bll.OAuth(ctx).ValidateAuthorizeRequest(&req.Authorize)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid authorization request",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	creds, sessionKey, err := h.bll.User(ctx).InitLogin(req.Username)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to create login credentials",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+	res.PublicKey = creds
+	res.SessionKey = sessionKey
+
+	h.setCsrfCookies(w, r, "")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.InitLoginResponse]{Response: &res})
+}
+
+func (h *oauthHandler) finishAuthorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req dto.FinishAuthorizeRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid request body",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.bll.OAuth(ctx).ValidateAuthorizeRequest(&req.Authorize)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "invalid authorization request",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	me, err := h.bll.User(ctx).FinishLogin(&req.Login)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to login",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := uuid.Parse(me.ID)
+	if err != nil {
+		sendErrResponse[any](w, &dto.ResponseError{
+			Message: "failed to parse uuid",
+			Code:    "ERROR_CODE_TBD",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.OAuth(ctx).CreateAuthorizationCode(&req.Authorize, userID, req.Login.Credential.RawID)
+	if err != nil {
+		var oerr *bll.OAuthError
+		if errors.As(err, &oerr) && oerr.RedirectURI != "" {
+			// the client is trusted; let the UI send the user back with the error
+			redirectURI, rerr := authorizeErrorRedirectURI(oerr, req.Authorize.State)
+			if rerr == nil {
+				res = &dto.FinishAuthorizeResponse{RedirectURI: redirectURI}
+			}
+		}
+		if res == nil {
+			sendErrResponse[any](w, &dto.ResponseError{
+				Message: "failed to authorize",
+				Code:    "ERROR_CODE_TBD",
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(dto.Response[dto.FinishAuthorizeResponse]{Response: res})
+}
+
+func (h *oauthHandler) token(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		h.logger.Err(err).Msg("failed to parse token request form")
+		sendOAuthErrResponse(w, &dto.OAuthErrorResponse{
+			Error:            string(bll.OAUTH_ERR_INVALID_REQUEST),
+			ErrorDescription: "invalid request body",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.OAuth(ctx).Token(&dto.TokenRequest{
+		GrantType:           r.PostForm.Get("grant_type"),
+		Code:                r.PostForm.Get("code"),
+		RedirectURI:         r.PostForm.Get("redirect_uri"),
+		ClientID:            r.PostForm.Get("client_id"),
+		CodeVerifier:        r.PostForm.Get("code_verifier"),
+		ClientAssertionType: r.PostForm.Get("client_assertion_type"),
+		ClientAssertion:     r.PostForm.Get("client_assertion"),
+		RefreshToken:        r.PostForm.Get("refresh_token"),
+		Scope:               r.PostForm.Get("scope"),
+	})
+	if err != nil {
+		sendOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *oauthHandler) introspect(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		h.logger.Err(err).Msg("failed to parse introspection request form")
+		sendOAuthErrResponse(w, &dto.OAuthErrorResponse{
+			Error:            string(bll.OAUTH_ERR_INVALID_REQUEST),
+			ErrorDescription: "invalid request body",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.bll.OAuth(ctx).Introspect(&dto.IntrospectRequest{
+		Token:               r.PostForm.Get("token"),
+		TokenTypeHint:       r.PostForm.Get("token_type_hint"),
+		ClientID:            r.PostForm.Get("client_id"),
+		ClientAssertionType: r.PostForm.Get("client_assertion_type"),
+		ClientAssertion:     r.PostForm.Get("client_assertion"),
+	})
+	if err != nil {
+		sendOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *oauthHandler) revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		h.logger.Err(err).Msg("failed to parse revocation request form")
+		sendOAuthErrResponse(w, &dto.OAuthErrorResponse{
+			Error:            string(bll.OAUTH_ERR_INVALID_REQUEST),
+			ErrorDescription: "invalid request body",
+		}, http.StatusBadRequest)
+		return
+	}
+
+	err = h.bll.OAuth(ctx).Revoke(&dto.RevokeRequest{
+		Token:               r.PostForm.Get("token"),
+		TokenTypeHint:       r.PostForm.Get("token_type_hint"),
+		ClientID:            r.PostForm.Get("client_id"),
+		ClientAssertionType: r.PostForm.Get("client_assertion_type"),
+		ClientAssertion:     r.PostForm.Get("client_assertion"),
+	})
+	if err != nil {
+		sendOAuthError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *oauthHandler) sendAuthorizeError(w http.ResponseWriter, r *http.Request, err error, state string) {
+	var oerr *bll.OAuthError
+	if !errors.As(err, &oerr) {
+		oerr = &bll.OAuthError{Code: bll.OAUTH_ERR_SERVER_ERROR, Description: "failed to authorize"}
+	}
+	if oerr.RedirectURI != "" {
+		redirectURI, rerr := authorizeErrorRedirectURI(oerr, state)
+		if rerr == nil {
+			http.Redirect(w, r, redirectURI, http.StatusFound)
+			return
+		}
+		h.logger.Err(rerr).Msg("failed to build error redirect uri")
+	}
+	sendOAuthErrResponse(w, &dto.OAuthErrorResponse{
+		Error:            string(oerr.Code),
+		ErrorDescription: oerr.Description,
+	}, http.StatusBadRequest)
+}
+
+func authorizeRequestFromQuery(q url.Values) *dto.AuthorizeRequest {
+	return &dto.AuthorizeRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+		Nonce:               q.Get("nonce"),
+	}
+}
+
+func authorizeErrorRedirectURI(oerr *bll.OAuthError, state string) (string, error) {
+	u, err := url.Parse(oerr.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("error", string(oerr.Code))
+	if oerr.Description != "" {
+		q.Set("error_description", oerr.Description)
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
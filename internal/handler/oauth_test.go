@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/bll"
+	"github.com/asatraitis/mangrove/internal/bll/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/service/config"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type OAuthHandlerTestSuite struct {
+	suite.Suite
+	Ctrl *gomock.Controller
+
+	bll      *mocks.MockBLL
+	oauthBll *mocks.MockOAuthBLL
+	userBll  *mocks.MockUserBLL
+	mux      *http.ServeMux
+
+	oauthHandler *oauthHandler
+	authorize    dto.AuthorizeRequest
+}
+
+func TestOAuthHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(OAuthHandlerTestSuite))
+}
+
+func (suite *OAuthHandlerTestSuite) SetupTest() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.bll = mocks.NewMockBLL(suite.Ctrl)
+	suite.oauthBll = mocks.NewMockOAuthBLL(suite.Ctrl)
+	suite.userBll = mocks.NewMockUserBLL(suite.Ctrl)
+	suite.mux = http.NewServeMux()
+
+	logger := zerolog.Nop()
+	suite.oauthHandler = NewOAuthHandler(&BaseHandler{
+		logger:     logger,
+		vars:       &configs.EnvVariables{},
+		appConfig:  config.NewConfig(context.Background(), logger),
+		bll:        suite.bll,
+		middleware: NewMiddleware(&configs.EnvVariables{}, suite.bll, logger),
+	}, suite.mux).(*oauthHandler)
+
+	suite.authorize = dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            uuid.NewString(),
+		RedirectURI:         "https://client.example.com/callback",
+		Scope:               "openid",
+		State:               "test-state",
+		CodeChallenge:       "test-challenge",
+		CodeChallengeMethod: "S256",
+	}
+}
+func (suite *OAuthHandlerTestSuite) TearDownTest() {
+	suite.Ctrl.Finish()
+}
+
+func (suite *OAuthHandlerTestSuite) TestAuthorize_OK_RedirectsToLogin() {
+	suite.bll.EXPECT().OAuth(gomock.Any()).Times(1).Return(suite.oauthBll)
+	suite.oauthBll.EXPECT().ValidateAuthorizeRequest(&suite.authorize).Times(1).Return(&models.Client{}, nil)
+
+	query := "response_type=code&client_id=" + suite.authorize.ClientID +
+		"&redirect_uri=https%3A%2F%2Fclient.example.com%2Fcallback&scope=openid&state=test-state&code_challenge=test-challenge&code_challenge_method=S256"
+	w := httptest.NewRecorder()
+	suite.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+query, http.NoBody))
+
+	// the login page reads the authorization request back from the query
+	suite.Equal(http.StatusFound, w.Code)
+	suite.Equal("/login?"+query, w.Header().Get("Location"))
+}
+
+func (suite *OAuthHandlerTestSuite) TestFinishAuthorize_OK_RedirectsToClient() {
+	userID := uuid.New()
+	login := dto.FinishLoginRequest{
+		Credential: protocol.CredentialAssertionResponse{PublicKeyCredential: protocol.PublicKeyCredential{RawID: []byte("test-cred")}},
+		SessionKey: "test-session-key",
+	}
+	suite.bll.EXPECT().OAuth(gomock.Any()).Times(2).Return(suite.oauthBll)
+	suite.oauthBll.EXPECT().ValidateAuthorizeRequest(&suite.authorize).Times(1).Return(&models.Client{}, nil)
+	suite.bll.EXPECT().User(gomock.Any()).Times(1).Return(suite.userBll)
+	suite.userBll.EXPECT().FinishLogin(gomock.Any()).Times(1).Return(&dto.MeResponse{ID: userID.String()}, nil)
+	suite.oauthBll.EXPECT().CreateAuthorizationCode(&suite.authorize, userID, protocol.URLEncodedBase64("test-cred")).Times(1).Return(&dto.FinishAuthorizeResponse{
+		RedirectURI: "https://client.example.com/callback?code=test-code&state=test-state",
+	}, nil)
+
+	w := httptest.NewRecorder()
+	suite.oauthHandler.finishAuthorize(w, suite.finishAuthorizeRequest(login))
+
+	suite.Equal(http.StatusOK, w.Code)
+	var res dto.Response[dto.FinishAuthorizeResponse]
+	suite.NoError(json.NewDecoder(w.Body).Decode(&res))
+	suite.Equal("https://client.example.com/callback?code=test-code&state=test-state", res.Response.RedirectURI)
+}
+
+func (suite *OAuthHandlerTestSuite) TestFinishAuthorize_OK_ErrorRedirectsToClient() {
+	userID := uuid.New()
+	suite.bll.EXPECT().OAuth(gomock.Any()).Times(2).Return(suite.oauthBll)
+	suite.oauthBll.EXPECT().ValidateAuthorizeRequest(&suite.authorize).Times(1).Return(&models.Client{}, nil)
+	suite.bll.EXPECT().User(gomock.Any()).Times(1).Return(suite.userBll)
+	suite.userBll.EXPECT().FinishLogin(gomock.Any()).Times(1).Return(&dto.MeResponse{ID: userID.String()}, nil)
+	suite.oauthBll.EXPECT().CreateAuthorizationCode(&suite.authorize, userID, gomock.Any()).Times(1).Return(nil, &bll.OAuthError{
+		Code:        bll.OAUTH_ERR_ACCESS_DENIED,
+		Description: "user is not active",
+		RedirectURI: suite.authorize.RedirectURI,
+	})
+
+	w := httptest.NewRecorder()
+	suite.oauthHandler.finishAuthorize(w, suite.finishAuthorizeRequest(dto.FinishLoginRequest{}))
+
+	// the client gets the error on its redirect_uri instead of the user being stuck on the login page
+	suite.Equal(http.StatusOK, w.Code)
+	var res dto.Response[dto.FinishAuthorizeResponse]
+	suite.NoError(json.NewDecoder(w.Body).Decode(&res))
+	suite.Equal("https://client.example.com/callback?error=access_denied&error_description=user+is+not+active&state=test-state", res.Response.RedirectURI)
+}
+
+func (suite *OAuthHandlerTestSuite) TestFinishAuthorize_FAIL_InvalidRequest() {
+	suite.bll.EXPECT().OAuth(gomock.Any()).Times(1).Return(suite.oauthBll)
+	suite.oauthBll.EXPECT().ValidateAuthorizeRequest(&suite.authorize).Times(1).Return(nil, &bll.OAuthError{Code: bll.OAUTH_ERR_INVALID_REQUEST})
+
+	w := httptest.NewRecorder()
+	suite.oauthHandler.finishAuthorize(w, suite.finishAuthorizeRequest(dto.FinishLoginRequest{}))
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *OAuthHandlerTestSuite) finishAuthorizeRequest(login dto.FinishLoginRequest) *http.Request {
+	body, err := json.Marshal(dto.FinishAuthorizeRequest{Authorize: suite.authorize, Login: login})
+	suite.NoError(err)
+	return httptest.NewRequest(http.MethodPost, "/oauth2/authorize/finish", bytes.NewReader(body))
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type OIDCHandler interface{}
+type oidcHandler struct {
+	*BaseHandler
+
+	mux *http.ServeMux
+}
+
+func NewOIDCHandler(baseHandler *BaseHandler, mux *http.ServeMux) OIDCHandler {
+	h := &oidcHandler{
+		BaseHandler: baseHandler,
+		mux:         mux,
+	}
+	h.logger = h.logger.With().Str("subcomponent", "OIDCHandler").Logger()
+	h.register()
+	return h
+}
+
+func (h *oidcHandler) register() {
+	h.mux.HandleFunc("GET /.well-known/openid-configuration", h.discovery)
+	h.mux.HandleFunc("GET /.well-known/jwks.json", h.jwks)
+	h.mux.HandleFunc("GET /userinfo", h.userInfo)
+	h.mux.HandleFunc("POST /userinfo", h.userInfo)
+}
+
+func (h *oidcHandler) discovery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(h.bll.OIDC(ctx).Discovery())
+}
+
+func (h *oidcHandler) jwks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Set("Content-Type", "application/json")
+	// verifiers refetch on an unknown kid, so a short cache only delays picking up retirements
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(h.bll.OIDC(ctx).JWKS())
+}
+
+func (h *oidcHandler) userInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || accessToken == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	res, err := h.bll.OIDC(ctx).UserInfo(accessToken)
+	if err != nil {
+		// RFC 6750 - no details beyond the error code
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(res)
+}
@@ -3,7 +3,7 @@ package types
 type ReqCtxKey string
 
 const (
-	REQ_CTX_KEY_USER_TOKEN  ReqCtxKey = "userToken"
+	REQ_CTX_KEY_SESSION_ID  ReqCtxKey = "sessionID"
 	REQ_CTX_KEY_USER_ID     ReqCtxKey = "userID"
 	REQ_CTX_KEY_USER_ROLE   ReqCtxKey = "userRole"
 	REQ_CTX_KEY_USER_STATUS ReqCtxKey = "userStatus"
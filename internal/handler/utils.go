@@ -2,8 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/asatraitis/mangrove/internal/bll"
 	"github.com/asatraitis/mangrove/internal/dto"
 )
 
@@ -19,6 +21,35 @@ func sendErrResponse[T any](w http.ResponseWriter, err *dto.ResponseError, statu
 	)
 }
 
+// sendOAuthErrResponse writes an RFC 6749 error body; OAuth endpoints do not use the dto.Response envelope
+func sendOAuthErrResponse(w http.ResponseWriter, err *dto.OAuthErrorResponse, status int) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	return json.NewEncoder(w).Encode(err)
+}
+
+func sendOAuthError(w http.ResponseWriter, err error) error {
+	var oerr *bll.OAuthError
+	if !errors.As(err, &oerr) {
+		oerr = &bll.OAuthError{Code: bll.OAUTH_ERR_SERVER_ERROR, Description: "unexpected error"}
+	}
+
+	status := http.StatusBadRequest
+	switch oerr.Code {
+	case bll.OAUTH_ERR_INVALID_CLIENT:
+		status = http.StatusUnauthorized
+	case bll.OAUTH_ERR_SERVER_ERROR:
+		status = http.StatusInternalServerError
+	}
+
+	return sendOAuthErrResponse(w, &dto.OAuthErrorResponse{
+		Error:            string(oerr.Code),
+		ErrorDescription: oerr.Description,
+	}, status)
+}
+
 func getReqIP(r *http.Request) string {
 	IPAddress := r.Header.Get("X-Real-Ip")
 	if IPAddress == "" {
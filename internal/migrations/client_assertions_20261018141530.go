@@ -0,0 +1,40 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type client_assertions_20261018141530 struct {
+	version int
+}
+
+func Newclient_assertions_20261018141530() Migration {
+	return &client_assertions_20261018141530{
+		version: 20261018141530,
+	}
+}
+
+func (m *client_assertions_20261018141530) Version() int {
+	return m.version
+}
+
+func (m *client_assertions_20261018141530) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS client_assertions (
+			client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+			jti TEXT NOT NULL,
+			expires timestamp NOT NULL,
+			PRIMARY KEY (client_id, jti)
+		);
+	`)
+	return err
+}
+func (m *client_assertions_20261018141530) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP TABLE IF EXISTS client_assertions;
+	`)
+	return err
+}
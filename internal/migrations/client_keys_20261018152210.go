@@ -0,0 +1,80 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type client_keys_20261018152210 struct {
+	version int
+}
+
+func Newclient_keys_20261018152210() Migration {
+	return &client_keys_20261018152210{
+		version: 20261018152210,
+	}
+}
+
+func (m *client_keys_20261018152210) Version() int {
+	return m.version
+}
+
+func (m *client_keys_20261018152210) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS client_keys (
+			id uuid PRIMARY KEY,
+			client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+			kid TEXT NOT NULL,
+			algorithm TEXT NOT NULL,
+			public_key bytea NOT NULL,
+			not_before timestamp NOT NULL,
+			expires_at timestamp NOT NULL,
+			revoked BOOLEAN NOT NULL DEFAULT FALSE,
+			revoked_at timestamp,
+			created_at timestamp NOT NULL,
+			UNIQUE (client_id, kid)
+		);
+		CREATE INDEX IF NOT EXISTS client_keys_client_id_idx ON client_keys (client_id);
+
+		INSERT INTO client_keys (id, client_id, kid, algorithm, public_key, not_before, expires_at, created_at)
+		SELECT gen_random_uuid(), id, encode(sha256(public_key), 'hex'), key_algo, public_key, now(), key_expires_at, now()
+		FROM clients;
+
+		ALTER TABLE clients
+			DROP COLUMN public_key,
+			DROP COLUMN key_algo,
+			DROP COLUMN key_expires_at,
+			ADD COLUMN jwks_uri TEXT NOT NULL DEFAULT '';
+	`)
+	return err
+}
+func (m *client_keys_20261018152210) Down(tx pgx.Tx) error {
+	// only the newest key of each client survives the downgrade
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE clients
+			DROP COLUMN jwks_uri,
+			ADD COLUMN public_key bytea,
+			ADD COLUMN key_algo TEXT,
+			ADD COLUMN key_expires_at timestamp;
+
+		UPDATE clients c SET public_key = k.public_key, key_algo = k.algorithm, key_expires_at = k.expires_at
+		FROM (
+			SELECT DISTINCT ON (client_id) client_id, public_key, algorithm, expires_at
+			FROM client_keys
+			ORDER BY client_id, revoked, created_at DESC
+		) k
+		WHERE c.id = k.client_id;
+
+		UPDATE clients SET public_key = ''::bytea, key_algo = 'EdDSA', key_expires_at = now() WHERE public_key IS NULL;
+
+		ALTER TABLE clients
+			ALTER COLUMN public_key SET NOT NULL,
+			ALTER COLUMN key_algo SET NOT NULL,
+			ALTER COLUMN key_expires_at SET NOT NULL;
+
+		DROP TABLE IF EXISTS client_keys;
+	`)
+	return err
+}
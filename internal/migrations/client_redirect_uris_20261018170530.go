@@ -0,0 +1,43 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type client_redirect_uris_20261018170530 struct {
+	version int
+}
+
+func Newclient_redirect_uris_20261018170530() Migration {
+	return &client_redirect_uris_20261018170530{
+		version: 20261018170530,
+	}
+}
+
+func (m *client_redirect_uris_20261018170530) Version() int {
+	return m.version
+}
+
+func (m *client_redirect_uris_20261018170530) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS redirect_uris text[] NOT NULL DEFAULT '{}';
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS post_logout_redirect_uris text[] NOT NULL DEFAULT '{}';
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS web_origins text[] NOT NULL DEFAULT '{}';
+		UPDATE clients SET redirect_uris = ARRAY[redirect_uri] WHERE redirect_uri <> '';
+		ALTER TABLE clients DROP COLUMN IF EXISTS redirect_uri;
+	`)
+	return err
+}
+func (m *client_redirect_uris_20261018170530) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS redirect_uri TEXT NOT NULL DEFAULT '';
+		UPDATE clients SET redirect_uri = COALESCE(redirect_uris[1], '');
+		ALTER TABLE clients DROP COLUMN IF EXISTS web_origins;
+		ALTER TABLE clients DROP COLUMN IF EXISTS post_logout_redirect_uris;
+		ALTER TABLE clients DROP COLUMN IF EXISTS redirect_uris;
+	`)
+	return err
+}
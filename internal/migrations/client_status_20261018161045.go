@@ -0,0 +1,39 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type client_status_20261018161045 struct {
+	version int
+}
+
+func Newclient_status_20261018161045() Migration {
+	return &client_status_20261018161045{
+		version: 20261018161045,
+	}
+}
+
+func (m *client_status_20261018161045) Version() int {
+	return m.version
+}
+
+func (m *client_status_20261018161045) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS status_changed_at timestamp;
+		CREATE INDEX IF NOT EXISTS user_tokens_client_id_idx ON user_tokens (client_id);
+	`)
+	return err
+}
+func (m *client_status_20261018161045) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP INDEX IF EXISTS user_tokens_client_id_idx;
+		ALTER TABLE clients DROP COLUMN IF EXISTS status_changed_at;
+		ALTER TABLE clients DROP COLUMN IF EXISTS status_reason;
+	`)
+	return err
+}
@@ -85,7 +85,7 @@ func (m *initial_20241203101104) Up(tx pgx.Tx) error {
 func (m *initial_20241203101104) Down(tx pgx.Tx) error {
 	_, err := tx.Exec(context.Background(), `
         -- SQL migration revert
-		DROP TABLE IF EXISTS clients
+		DROP TABLE IF EXISTS clients;
 		DROP TABLE IF EXISTS user_tokens;
 		DROP TABLE IF EXISTS user_credentials;
 		DROP TABLE IF EXISTS users;
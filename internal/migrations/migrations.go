@@ -5,6 +5,22 @@ package migrations
 func (m *Migrator) setMigrations() {
 	m.migrations = []Migration{
 		Newinitial_20241203101104(),
+		Newoauth_20261018104512(),
+		Newoidc_20261018113020(),
+		Newsigning_keys_20261018130245(),
+		Newclient_assertions_20261018141530(),
+		Newclient_keys_20261018152210(),
+		Newclient_status_20261018161045(),
+		Newclient_redirect_uris_20261018170530(),
+		Newuser_invitations_20261018174215(),
+		Newuser_credential_details_20261018182040(),
+		Newwebauthn_sessions_20261018190215(),
+		Newroles_20261018203012(),
+		Newuser_sessions_20261018211540(),
+		Newopaque_user_tokens_20261018220015(),
+		Newsession_policy_20261018223540(),
+		Newreaper_runs_20261018230510(),
+		Newrefresh_tokens_20261018234015(),
 		// Add new migrations above this line
 	}
 }
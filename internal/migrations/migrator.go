@@ -2,28 +2,78 @@ package migrations
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/database"
+	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/rs/zerolog"
 )
 
 const migrationsTable = "schema_history"
 
+// migrationsLockKey identifies the advisory lock held while migrating
+const migrationsLockKey int64 = 0x6d616e67726f7665
+
 type Migration interface {
 	Up(pgx.Tx) error
 	Down(pgx.Tx) error
 	Version() int
 }
+
+type Direction string
+
+const (
+	UP   Direction = "up"
+	DOWN Direction = "down"
+)
+
+// Step is a migration to apply or revert; SQL holds the statements it will execute
+type Step struct {
+	Version   int
+	Name      string
+	Direction Direction
+	SQL       []string
+
+	migration Migration
+}
+
+// MigrationStatus describes a registered or applied migration
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Duration  *time.Duration
+	// Modified is set when the migration changed after it was applied
+	Modified bool
+	// Unknown is set for applied versions that are not registered in this build
+	Unknown bool
+}
+
+// appliedMigration is a schema_history row; rows recorded before the history kept details only have a version
+type appliedMigration struct {
+	Version    int
+	AppliedAt  *time.Time
+	DurationMs *int64
+	Checksum   *string
+}
+
 type Migrator struct {
 	logger zerolog.Logger
 
 	db *pgx.Conn
 
-	currentVersion int
-	migrations     []Migration
+	migrations []Migration
 }
 
 func NewMigrator(vars *configs.EnvVariables, logger zerolog.Logger) (*Migrator, error) {
@@ -31,7 +81,7 @@ func NewMigrator(vars *configs.EnvVariables, logger zerolog.Logger) (*Migrator,
 	ctx := context.Background()
 
 	// Get connection to the database
-	db, err := getConnection(ctx, vars)
+	db, err := database.Connect(ctx, vars, l)
 	if err != nil {
 		l.Error().Err(err).Msg("could not connect to the database")
 		return nil, fmt.Errorf("could not connect to the database: %w", err)
@@ -47,49 +97,91 @@ func NewMigrator(vars *configs.EnvVariables, logger zerolog.Logger) (*Migrator,
 		return nil, fmt.Errorf("could not initialize migration table: %w", err)
 	}
 
-	// Get current version
-	currentVersion, err := m.getCurrentVersion()
-	if err != nil {
-		l.Error().Err(err).Msg("could not get current version")
-		return nil, fmt.Errorf("could not get current version: %w", err)
-	}
-	m.currentVersion = currentVersion
-
 	// Set migrations
 	m.setMigrations()
+	m.sortMigrations()
 
 	return m, nil
 }
 
+func (m *Migrator) Close() error {
+	return m.db.Close(context.Background())
+}
+
+// Run applies every pending migration while holding the migration lock, so replicas starting at
+// the same time apply each migration once
 func (m *Migrator) Run() error {
-	defer m.db.Close(context.Background())
-	if len(m.migrations) == 0 {
-		m.logger.Info().Msg("no migrations to apply")
-		return nil
-	}
-	m.logger.Info().Msg("running migrations")
-	// find index of the current version
-	var currentVersionIdx int
-	for i, migration := range m.migrations {
-		if migration.Version() == m.currentVersion {
-			currentVersionIdx = i
-			break
+	return m.withLock(func() error {
+		steps, err := m.PlanUp(0)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			m.logger.Info().Msg("Migrations up to date")
+			return nil
 		}
+		m.logger.Info().Msg("running migrations")
+		return m.apply(steps)
+	})
+}
+
+// PlanUp lists the registered migrations that are not applied, up to and including target; 0 means all
+func (m *Migrator) PlanUp(target int) ([]Step, error) {
+	applied, err := m.getApplied()
+	if err != nil {
+		return nil, err
+	}
+	return planUp(m.migrations, applied, target)
+}
+
+// PlanDown lists the last n applied migrations, newest first
+func (m *Migrator) PlanDown(n int) ([]Step, error) {
+	applied, err := m.getApplied()
+	if err != nil {
+		return nil, err
 	}
+	return planDown(m.migrations, applied, n)
+}
 
-	if m.currentVersion != 0 && currentVersionIdx == len(m.migrations)-1 {
-		m.logger.Info().Msg("Migrations up to date")
-		return nil
+// PlanTo lists the migrations to apply or revert to end up at version; 0 reverts everything
+func (m *Migrator) PlanTo(version int) ([]Step, error) {
+	applied, err := m.getApplied()
+	if err != nil {
+		return nil, err
 	}
+	return planTo(m.migrations, applied, version)
+}
 
-	var startIdx int
-	if currentVersionIdx != 0 {
-		startIdx += 1
+// Status lists the registered migrations and the applied ones that are not registered, oldest first
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.getApplied()
+	if err != nil {
+		return nil, err
 	}
+	return status(m.migrations, applied)
+}
+
+// Apply runs the steps in order while holding the migration lock, stopping at the first failure.
+// It fails without running anything if the schema history changed since the steps were planned.
+func (m *Migrator) Apply(steps []Step) error {
+	return m.withLock(func() error {
+		applied, err := m.getApplied()
+		if err != nil {
+			return err
+		}
+		if err := checkPlan(steps, applied); err != nil {
+			return err
+		}
+		return m.apply(steps)
+	})
+}
+
+// apply runs each step and updates the schema history in the same transaction
+func (m *Migrator) apply(steps []Step) error {
+	for _, step := range steps {
+		m.logger.Info().Msgf("%s migration %d", step.verb(), step.Version)
+		started := time.Now()
 
-	// iterate over migrations from versionIdx
-	for _, migration := range m.migrations[startIdx:] {
-		m.logger.Info().Msgf("applying migration %d", migration.Version())
 		// start transaction
 		tx, err := m.db.Begin(context.Background())
 		if err != nil {
@@ -97,47 +189,73 @@ func (m *Migrator) Run() error {
 			return fmt.Errorf("could not start transaction: %w", err)
 		}
 
-		// apply migration
-		if err := migration.Up(tx); err != nil {
-			m.logger.Error().Err(err).Msg("could not apply migration")
+		if step.Direction == UP {
+			err = step.migration.Up(tx)
+		} else {
+			err = step.migration.Down(tx)
+		}
+		if err != nil {
+			m.logger.Error().Err(err).Msgf("could not %s migration %d", step.Direction, step.Version)
+			_ = tx.Rollback(context.Background())
+			return fmt.Errorf("could not %s migration %d: %w", step.Direction, step.Version, err)
+		}
+
+		if step.Direction == UP {
+			err = recordApplied(tx, step.Version, time.Since(started), checksum(step.SQL))
+		} else {
+			err = deleteApplied(tx, step.Version)
+		}
+		if err != nil {
+			m.logger.Error().Err(err).Msg("could not update schema history")
 			_ = tx.Rollback(context.Background())
-			return fmt.Errorf("could not apply migration: %w", err)
+			return fmt.Errorf("could not update schema history: %w", err)
 		}
 
 		// commit transaction
 		if err := tx.Commit(context.Background()); err != nil {
 			return fmt.Errorf("could not commit transaction: %w", err)
 		}
-
-		// update current version
-		err = m.setCurrentVersion(migration.Version())
-		if err != nil {
-			m.logger.Error().Err(err).Msg("could not update current version")
-			return fmt.Errorf("could not update current version: %w", err)
-		}
-		m.currentVersion = migration.Version()
-		m.logger.Info().Msgf("migration %d applied", migration.Version())
+		m.logger.Info().Msgf("migration %d %s", step.Version, step.pastVerb())
 	}
-
 	return nil
 }
 
-// TODO: Consolidate w/ initDbPool() in main.go
-func getConnection(ctx context.Context, vars *configs.EnvVariables) (*pgx.Conn, error) {
-	connectionString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", vars.MangrovePostgresUser, vars.MangrovePostgresPassword, vars.MangrovePostgresAddress, vars.MangrovePostgresPort, vars.MangrovePostgresDBName)
-	return pgx.Connect(ctx, connectionString)
+// withLock runs fn while holding a session advisory lock, waiting for other migrators to finish first
+func (m *Migrator) withLock(fn func() error) error {
+	var locked bool
+	if err := m.db.QueryRow(context.Background(), "SELECT pg_try_advisory_lock($1)", migrationsLockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("could not take migration lock: %w", err)
+	}
+	if !locked {
+		m.logger.Info().Msg("another instance is migrating; waiting for the migration lock")
+		if _, err := m.db.Exec(context.Background(), "SELECT pg_advisory_lock($1)", migrationsLockKey); err != nil {
+			return fmt.Errorf("could not take migration lock: %w", err)
+		}
+	}
+	defer func() {
+		if _, err := m.db.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockKey); err != nil {
+			m.logger.Error().Err(err).Msg("could not release migration lock")
+		}
+	}()
+	return fn()
 }
 
 func (m *Migrator) initMigrationTable() error {
-	_, err := m.db.Exec(context.Background(), fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY)", migrationsTable))
+	_, err := m.db.Exec(context.Background(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (version BIGINT NOT NULL PRIMARY KEY);
+		ALTER TABLE %[1]s
+			ADD COLUMN IF NOT EXISTS applied_at timestamp,
+			ADD COLUMN IF NOT EXISTS duration_ms BIGINT,
+			ADD COLUMN IF NOT EXISTS checksum TEXT;
+	`, migrationsTable))
 	return err
 }
 
-// get current version by getting largest integer from the migrations table
-func (m *Migrator) getCurrentVersion() (int, error) {
-	var version int
-	err := m.db.QueryRow(context.Background(), fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", migrationsTable)).Scan(&version)
-	return version, err
+// getApplied lists the schema history, oldest first
+func (m *Migrator) getApplied() ([]appliedMigration, error) {
+	var applied []appliedMigration
+	err := pgxscan.Select(context.Background(), m.db, &applied, fmt.Sprintf("SELECT version, applied_at, duration_ms, checksum FROM %s ORDER BY version", migrationsTable))
+	return applied, err
 }
 
 // sort migrations by version
@@ -148,7 +266,251 @@ func (m *Migrator) sortMigrations() {
 }
 
 // inserts version into the migrations table
-func (m *Migrator) setCurrentVersion(version int) error {
-	_, err := m.db.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (version) VALUES ($1)", migrationsTable), version)
+func recordApplied(tx pgx.Tx, version int, duration time.Duration, checksum string) error {
+	_, err := tx.Exec(context.Background(), fmt.Sprintf("INSERT INTO %s (version, applied_at, duration_ms, checksum) VALUES ($1, $2, $3, $4)", migrationsTable), version, time.Now(), duration.Milliseconds(), checksum)
 	return err
 }
+
+func deleteApplied(tx pgx.Tx, version int) error {
+	_, err := tx.Exec(context.Background(), fmt.Sprintf("DELETE FROM %s WHERE version = $1", migrationsTable), version)
+	return err
+}
+
+// planUp lists the registered migrations missing from the schema history, including ones older than
+// the latest applied version
+func planUp(migrations []Migration, applied []appliedMigration, target int) ([]Step, error) {
+	if err := checkUnknown(migrations, applied); err != nil {
+		return nil, err
+	}
+	if target != 0 && findMigration(migrations, target) == nil {
+		return nil, fmt.Errorf("unknown migration version %d", target)
+	}
+	isApplied := appliedVersions(applied)
+
+	var steps []Step
+	for _, migration := range migrations {
+		if isApplied[migration.Version()] || (target != 0 && migration.Version() > target) {
+			continue
+		}
+		step, err := newStep(migration, UP)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func planDown(migrations []Migration, applied []appliedMigration, n int) ([]Step, error) {
+	if err := checkUnknown(migrations, applied); err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("number of migrations to revert has to be positive, got %d", n)
+	}
+	if n > len(applied) {
+		return nil, fmt.Errorf("cannot revert %d migrations; only %d applied", n, len(applied))
+	}
+
+	var steps []Step
+	for i := len(applied) - 1; i >= len(applied)-n; i-- {
+		step, err := newStep(findMigration(migrations, applied[i].Version), DOWN)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// planTo reverts the applied migrations newer than version, then applies the pending ones up to it
+func planTo(migrations []Migration, applied []appliedMigration, version int) ([]Step, error) {
+	if version != 0 && findMigration(migrations, version) == nil {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+	newer := 0
+	for _, a := range applied {
+		if a.Version > version {
+			newer++
+		}
+	}
+
+	var steps []Step
+	if newer > 0 {
+		down, err := planDown(migrations, applied, newer)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, down...)
+	}
+	if version == 0 {
+		return steps, nil
+	}
+	up, err := planUp(migrations, applied, version)
+	if err != nil {
+		return nil, err
+	}
+	return append(steps, up...), nil
+}
+
+// checkUnknown fails on applied versions that are not registered; they come from a newer build or a
+// removed migration, and planning around them could corrupt the schema
+func checkUnknown(migrations []Migration, applied []appliedMigration) error {
+	var unknown []string
+	for _, a := range applied {
+		if findMigration(migrations, a.Version) == nil {
+			unknown = append(unknown, strconv.Itoa(a.Version))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("schema history has unknown migration versions %s; is this build older than the database?", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// checkPlan fails if the steps no longer match the schema history, e.g. another instance migrated in
+// the meantime
+func checkPlan(steps []Step, applied []appliedMigration) error {
+	isApplied := appliedVersions(applied)
+	for _, step := range steps {
+		if step.Direction == UP && isApplied[step.Version] {
+			return fmt.Errorf("migration %d was applied since the migrations were planned", step.Version)
+		}
+		if step.Direction == DOWN && !isApplied[step.Version] {
+			return fmt.Errorf("migration %d was reverted since the migrations were planned", step.Version)
+		}
+		isApplied[step.Version] = step.Direction == UP
+	}
+	return nil
+}
+
+func appliedVersions(applied []appliedMigration) map[int]bool {
+	versions := map[int]bool{}
+	for _, a := range applied {
+		versions[a.Version] = true
+	}
+	return versions
+}
+
+func status(migrations []Migration, applied []appliedMigration) ([]MigrationStatus, error) {
+	byVersion := map[int]appliedMigration{}
+	for _, a := range applied {
+		byVersion[a.Version] = a
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		s := MigrationStatus{Version: migration.Version(), Name: migrationName(migration)}
+		if a, ok := byVersion[migration.Version()]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			if a.DurationMs != nil {
+				d := time.Duration(*a.DurationMs) * time.Millisecond
+				s.Duration = &d
+			}
+			if a.Checksum != nil {
+				statements, err := record(migration.Up)
+				if err != nil {
+					return nil, err
+				}
+				s.Modified = checksum(statements) != *a.Checksum
+			}
+			delete(byVersion, migration.Version())
+		}
+		statuses = append(statuses, s)
+	}
+	for version, a := range byVersion {
+		statuses = append(statuses, MigrationStatus{Version: version, Applied: true, AppliedAt: a.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+func newStep(migration Migration, direction Direction) (Step, error) {
+	run := migration.Up
+	if direction == DOWN {
+		run = migration.Down
+	}
+	statements, err := record(run)
+	if err != nil {
+		return Step{}, fmt.Errorf("could not read migration %d: %w", migration.Version(), err)
+	}
+	return Step{
+		Version:   migration.Version(),
+		Name:      migrationName(migration),
+		Direction: direction,
+		SQL:       statements,
+		migration: migration,
+	}, nil
+}
+
+func (s Step) verb() string {
+	if s.Direction == UP {
+		return "applying"
+	}
+	return "reverting"
+}
+func (s Step) pastVerb() string {
+	if s.Direction == UP {
+		return "applied"
+	}
+	return "reverted"
+}
+
+func findMigration(migrations []Migration, version int) Migration {
+	for _, migration := range migrations {
+		if migration.Version() == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// migrationName is the name the migration was generated with, e.g. initial for initial_20241203101104
+func migrationName(migration Migration) string {
+	t := reflect.TypeOf(migration)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), "_"+strconv.Itoa(migration.Version()))
+}
+
+// recordingTx captures the statements of a migration without executing them; migrations only use Exec
+type recordingTx struct {
+	pgx.Tx
+	statements []string
+}
+
+func (r *recordingTx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	statement := strings.TrimSpace(sql)
+	if len(args) > 0 {
+		statement += fmt.Sprintf(" -- args: %v", args)
+	}
+	r.statements = append(r.statements, statement)
+	return pgconn.CommandTag{}, nil
+}
+
+func record(run func(pgx.Tx) error) (statements []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("migration uses more than Exec: %v", r)
+		}
+	}()
+	tx := &recordingTx{}
+	if err := run(tx); err != nil {
+		return nil, err
+	}
+	return tx.statements, nil
+}
+
+// checksum identifies the statements of a migration; whitespace changes do not count as modifications
+func checksum(statements []string) string {
+	h := sha256.New()
+	for _, statement := range statements {
+		h.Write([]byte(strings.Join(strings.Fields(statement), " ")))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,289 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeMigration struct {
+	version int
+	sql     string
+}
+
+func (f *fakeMigration) Version() int {
+	return f.version
+}
+func (f *fakeMigration) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), f.sql)
+	return err
+}
+func (f *fakeMigration) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), "-- revert "+f.sql)
+	return err
+}
+
+type queryingMigration struct {
+	fakeMigration
+}
+
+func (q *queryingMigration) Up(tx pgx.Tx) error {
+	return tx.QueryRow(context.Background(), "SELECT 1").Scan()
+}
+
+type MigratorTestSuite struct {
+	suite.Suite
+
+	migrations []Migration
+}
+
+func TestMigratorTestSuite(t *testing.T) {
+	suite.Run(t, new(MigratorTestSuite))
+}
+
+func (suite *MigratorTestSuite) SetupTest() {
+	suite.migrations = []Migration{
+		&fakeMigration{version: 1, sql: "CREATE TABLE a ()"},
+		&fakeMigration{version: 2, sql: "CREATE TABLE b ()"},
+		&fakeMigration{version: 3, sql: "CREATE TABLE c ()"},
+	}
+}
+
+func applied(versions ...int) []appliedMigration {
+	var rows []appliedMigration
+	for _, version := range versions {
+		rows = append(rows, appliedMigration{Version: version})
+	}
+	return rows
+}
+
+func versions(steps []Step) []int {
+	var v []int
+	for _, step := range steps {
+		v = append(v, step.Version)
+	}
+	return v
+}
+
+func (suite *MigratorTestSuite) TestRegisteredMigrationsCanBeRecorded() {
+	m := &Migrator{}
+	m.setMigrations()
+	for _, migration := range m.migrations {
+		up, err := record(migration.Up)
+		suite.NoError(err, migration.Version())
+		suite.NotEmpty(up, migration.Version())
+		_, err = record(migration.Down)
+		suite.NoError(err, migration.Version())
+	}
+}
+
+func (suite *MigratorTestSuite) TestPlanUp_OK() {
+	steps, err := planUp(suite.migrations, applied(1), 0)
+	suite.NoError(err)
+	suite.Equal([]int{2, 3}, versions(steps))
+	suite.Equal(UP, steps[0].Direction)
+	suite.Equal([]string{"CREATE TABLE b ()"}, steps[0].SQL)
+	suite.Equal("fakeMigration", steps[0].Name)
+
+	steps, err = planUp(suite.migrations, applied(), 2)
+	suite.NoError(err)
+	suite.Equal([]int{1, 2}, versions(steps))
+
+	steps, err = planUp(suite.migrations, applied(1, 2, 3), 0)
+	suite.NoError(err)
+	suite.Empty(steps)
+}
+
+func (suite *MigratorTestSuite) TestPlanUp_OK_Gap() {
+	// a migration merged with an older version than the latest applied one
+	steps, err := planUp(suite.migrations, applied(1, 3), 0)
+	suite.NoError(err)
+	suite.Equal([]int{2}, versions(steps))
+}
+
+func (suite *MigratorTestSuite) TestPlanUp_FailUnknownApplied() {
+	_, err := planUp(suite.migrations, applied(1, 2, 3, 4, 5), 0)
+	suite.ErrorContains(err, "unknown migration versions 4, 5")
+}
+
+func (suite *MigratorTestSuite) TestPlanUp_FailUnknownTarget() {
+	_, err := planUp(suite.migrations, applied(), 4)
+	suite.ErrorContains(err, "unknown migration version 4")
+}
+
+func (suite *MigratorTestSuite) TestPlanDown_OK() {
+	steps, err := planDown(suite.migrations, applied(1, 2, 3), 2)
+	suite.NoError(err)
+	suite.Equal([]int{3, 2}, versions(steps))
+	suite.Equal(DOWN, steps[0].Direction)
+	suite.Equal([]string{"-- revert CREATE TABLE c ()"}, steps[0].SQL)
+}
+
+func (suite *MigratorTestSuite) TestPlanDown_Fail() {
+	_, err := planDown(suite.migrations, applied(1), 0)
+	suite.ErrorContains(err, "has to be positive")
+
+	_, err = planDown(suite.migrations, applied(1), 2)
+	suite.ErrorContains(err, "only 1 applied")
+
+	_, err = planDown(suite.migrations, applied(1, 9), 1)
+	suite.ErrorContains(err, "unknown migration versions 9")
+}
+
+func (suite *MigratorTestSuite) TestPlanTo_OK() {
+	steps, err := planTo(suite.migrations, applied(1), 3)
+	suite.NoError(err)
+	suite.Equal([]int{2, 3}, versions(steps))
+	suite.Equal(UP, steps[0].Direction)
+
+	steps, err = planTo(suite.migrations, applied(1, 2, 3), 1)
+	suite.NoError(err)
+	suite.Equal([]int{3, 2}, versions(steps))
+	suite.Equal(DOWN, steps[0].Direction)
+
+	steps, err = planTo(suite.migrations, applied(1, 2), 0)
+	suite.NoError(err)
+	suite.Equal([]int{2, 1}, versions(steps))
+
+	steps, err = planTo(suite.migrations, applied(1, 2), 2)
+	suite.NoError(err)
+	suite.Empty(steps)
+
+	steps, err = planTo(suite.migrations, applied(1, 3), 2)
+	suite.NoError(err)
+	suite.Equal([]int{3, 2}, versions(steps))
+	suite.Equal(DOWN, steps[0].Direction)
+	suite.Equal(UP, steps[1].Direction)
+}
+
+func (suite *MigratorTestSuite) TestCheckPlan() {
+	steps, err := planTo(suite.migrations, applied(1, 3), 2)
+	suite.Require().NoError(err)
+	suite.NoError(checkPlan(steps, applied(1, 3)))
+	suite.ErrorContains(checkPlan(steps, applied(1, 2, 3)), "migration 2 was applied")
+	suite.ErrorContains(checkPlan(steps, applied(1)), "migration 3 was reverted")
+}
+
+func (suite *MigratorTestSuite) TestStatus_OK() {
+	statements, err := record(suite.migrations[0].Up)
+	suite.Require().NoError(err)
+	current := checksum(statements)
+	stale := "stale"
+	durationMs := int64(1500)
+	rows := []appliedMigration{
+		{Version: 1, Checksum: &current, DurationMs: &durationMs},
+		{Version: 2, Checksum: &stale},
+		{Version: 7},
+	}
+
+	statuses, err := status(suite.migrations, rows)
+	suite.NoError(err)
+	suite.Len(statuses, 4)
+
+	suite.True(statuses[0].Applied)
+	suite.False(statuses[0].Modified)
+	suite.Equal("1.5s", statuses[0].Duration.String())
+	suite.True(statuses[1].Modified)
+	suite.False(statuses[2].Applied)
+	suite.Equal(7, statuses[3].Version)
+	suite.True(statuses[3].Unknown)
+}
+
+func (suite *MigratorTestSuite) TestRecord_Fail() {
+	_, err := record(func(pgx.Tx) error { return errors.New("bad migration") })
+	suite.ErrorContains(err, "bad migration")
+
+	_, err = record((&queryingMigration{}).Up)
+	suite.ErrorContains(err, "migration uses more than Exec")
+}
+
+func (suite *MigratorTestSuite) TestChecksum() {
+	suite.Equal(checksum([]string{"CREATE TABLE a ()"}), checksum([]string{"CREATE TABLE a\n\t\t()"}))
+	suite.NotEqual(checksum([]string{"CREATE TABLE a ()"}), checksum([]string{"CREATE TABLE b ()"}))
+	suite.NotEqual(checksum([]string{"a", "b"}), checksum([]string{"a b"}))
+}
+
+func (suite *MigratorTestSuite) TestMigrationName() {
+	suite.Equal("initial", migrationName(Newinitial_20241203101104()))
+	suite.Equal("user_credential_details", migrationName(Newuser_credential_details_20261018182040()))
+}
+
+// MigratorDBTestSuite runs the registered migrations against Postgres in a throwaway schema, so the
+// other integration suites sharing the database are not affected
+type MigratorDBTestSuite struct {
+	suite.Suite
+
+	ctx      context.Context
+	db       *pgx.Conn
+	schema   string
+	migrator *Migrator
+}
+
+func TestMigratorDBTestSuiteIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test suite")
+	}
+	suite.Run(t, new(MigratorDBTestSuite))
+}
+
+func (suite *MigratorDBTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	vars := configs.NewConf(zerolog.Nop()).GetEnvironmentVars()
+	db, err := database.Connect(suite.ctx, vars, zerolog.Nop())
+	suite.Require().NoError(err)
+	suite.db = db
+
+	suite.schema = "migrator_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	_, err = suite.db.Exec(suite.ctx, "CREATE SCHEMA "+suite.schema)
+	suite.Require().NoError(err)
+	_, err = suite.db.Exec(suite.ctx, "SET search_path TO "+suite.schema)
+	suite.Require().NoError(err)
+
+	suite.migrator = &Migrator{db: suite.db, logger: zerolog.Nop()}
+	suite.Require().NoError(suite.migrator.initMigrationTable())
+	suite.migrator.setMigrations()
+	suite.migrator.sortMigrations()
+}
+
+func (suite *MigratorDBTestSuite) TearDownTest() {
+	_, err := suite.db.Exec(suite.ctx, "DROP SCHEMA IF EXISTS "+suite.schema+" CASCADE")
+	suite.NoError(err)
+	suite.NoError(suite.db.Close(suite.ctx))
+}
+
+func (suite *MigratorDBTestSuite) TestUpAndDownToZero() {
+	suite.Require().NoError(suite.migrator.Run())
+	statuses, err := suite.migrator.Status()
+	suite.Require().NoError(err)
+	for _, s := range statuses {
+		suite.True(s.Applied, s.Version)
+	}
+
+	steps, err := suite.migrator.PlanTo(0)
+	suite.Require().NoError(err)
+	suite.Len(steps, len(suite.migrator.migrations))
+	suite.Require().NoError(suite.migrator.Apply(steps))
+
+	statuses, err = suite.migrator.Status()
+	suite.Require().NoError(err)
+	for _, s := range statuses {
+		suite.False(s.Applied, s.Version)
+	}
+	// only the schema history is left
+	rows, err := suite.db.Query(suite.ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = $1", suite.schema)
+	suite.Require().NoError(err)
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	suite.Require().NoError(err)
+	suite.Equal([]string{migrationsTable}, tables)
+
+	// and everything can be applied again
+	suite.NoError(suite.migrator.Run())
+}
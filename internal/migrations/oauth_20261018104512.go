@@ -0,0 +1,48 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type oauth_20261018104512 struct {
+	version int
+}
+
+func Newoauth_20261018104512() Migration {
+	return &oauth_20261018104512{
+		version: 20261018104512,
+	}
+}
+
+func (m *oauth_20261018104512) Version() int {
+	return m.version
+}
+
+func (m *oauth_20261018104512) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS authorization_codes (
+			code TEXT PRIMARY KEY,
+			client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			redirect_uri TEXT NOT NULL,
+			scope TEXT NOT NULL DEFAULT '',
+			code_challenge TEXT NOT NULL,
+			code_challenge_method TEXT NOT NULL,
+			expires timestamp NOT NULL
+		);
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS client_id uuid REFERENCES clients(id) ON DELETE CASCADE;
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS scope TEXT NOT NULL DEFAULT '';
+	`)
+	return err
+}
+func (m *oauth_20261018104512) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS scope;
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS client_id;
+		DROP TABLE IF EXISTS authorization_codes;
+	`)
+	return err
+}
@@ -0,0 +1,41 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type oidc_20261018113020 struct {
+	version int
+}
+
+func Newoidc_20261018113020() Migration {
+	return &oidc_20261018113020{
+		version: 20261018113020,
+	}
+}
+
+func (m *oidc_20261018113020) Version() int {
+	return m.version
+}
+
+func (m *oidc_20261018113020) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS nonce TEXT NOT NULL DEFAULT '';
+		ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS auth_time timestamp NOT NULL DEFAULT now();
+		ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS amr text[] NOT NULL DEFAULT '{}';
+		ALTER TABLE authorization_codes ADD COLUMN IF NOT EXISTS acr TEXT NOT NULL DEFAULT '';
+	`)
+	return err
+}
+func (m *oidc_20261018113020) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE authorization_codes DROP COLUMN IF EXISTS acr;
+		ALTER TABLE authorization_codes DROP COLUMN IF EXISTS amr;
+		ALTER TABLE authorization_codes DROP COLUMN IF EXISTS auth_time;
+		ALTER TABLE authorization_codes DROP COLUMN IF EXISTS nonce;
+	`)
+	return err
+}
@@ -0,0 +1,42 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type opaque_user_tokens_20261018220015 struct {
+	version int
+}
+
+func Newopaque_user_tokens_20261018220015() Migration {
+	return &opaque_user_tokens_20261018220015{
+		version: 20261018220015,
+	}
+}
+
+func (m *opaque_user_tokens_20261018220015) Version() int {
+	return m.version
+}
+
+// Up signs everyone out: the existing tokens are their raw IDs and have no digest to look them up by
+func (m *opaque_user_tokens_20261018220015) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DELETE FROM user_tokens;
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS token_hash bytea NOT NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS user_tokens_token_hash_idx ON user_tokens (token_hash);
+	`)
+	return err
+}
+
+// Down signs everyone out as well, so no token handed out as a secret becomes usable by its ID
+func (m *opaque_user_tokens_20261018220015) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DELETE FROM user_tokens;
+		DROP INDEX IF EXISTS user_tokens_token_hash_idx;
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS token_hash;
+	`)
+	return err
+}
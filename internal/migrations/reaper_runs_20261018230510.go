@@ -0,0 +1,51 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type reaper_runs_20261018230510 struct {
+	version int
+}
+
+func Newreaper_runs_20261018230510() Migration {
+	return &reaper_runs_20261018230510{
+		version: 20261018230510,
+	}
+}
+
+func (m *reaper_runs_20261018230510) Version() int {
+	return m.version
+}
+
+func (m *reaper_runs_20261018230510) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS reaper_runs (
+			id UUID PRIMARY KEY,
+			started_at timestamp NOT NULL,
+			finished_at timestamp NOT NULL,
+			removed jsonb NOT NULL DEFAULT '{}',
+			error TEXT
+		);
+		CREATE INDEX IF NOT EXISTS reaper_runs_started_at_idx ON reaper_runs (started_at);
+		-- the reaper looks expired rows up in batches
+		CREATE INDEX IF NOT EXISTS user_tokens_expires_idx ON user_tokens (expires);
+		CREATE INDEX IF NOT EXISTS authorization_codes_expires_idx ON authorization_codes (expires);
+		CREATE INDEX IF NOT EXISTS client_assertions_expires_idx ON client_assertions (expires);
+		CREATE INDEX IF NOT EXISTS user_invitations_expires_at_idx ON user_invitations (expires_at);
+	`)
+	return err
+}
+func (m *reaper_runs_20261018230510) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP INDEX IF EXISTS user_invitations_expires_at_idx;
+		DROP INDEX IF EXISTS client_assertions_expires_idx;
+		DROP INDEX IF EXISTS authorization_codes_expires_idx;
+		DROP INDEX IF EXISTS user_tokens_expires_idx;
+		DROP TABLE IF EXISTS reaper_runs;
+	`)
+	return err
+}
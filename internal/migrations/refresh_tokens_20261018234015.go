@@ -0,0 +1,58 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type refresh_tokens_20261018234015 struct {
+	version int
+}
+
+func Newrefresh_tokens_20261018234015() Migration {
+	return &refresh_tokens_20261018234015{
+		version: 20261018234015,
+	}
+}
+
+func (m *refresh_tokens_20261018234015) Version() int {
+	return m.version
+}
+
+func (m *refresh_tokens_20261018234015) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		-- lifetimes in seconds
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS access_token_lifetime integer NOT NULL DEFAULT 3600;
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS refresh_token_lifetime integer NOT NULL DEFAULT 2592000;
+		ALTER TABLE clients ADD COLUMN IF NOT EXISTS refresh_token_max_lifetime integer NOT NULL DEFAULT 7776000;
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id uuid PRIMARY KEY,
+			token_hash bytea NOT NULL,
+			family_id uuid NOT NULL,
+			client_id uuid NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			scope TEXT NOT NULL DEFAULT '',
+			created_at timestamp NOT NULL,
+			expires timestamp NOT NULL,
+			family_expires timestamp NOT NULL,
+			used_at timestamp,
+			revoked_at timestamp
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS refresh_tokens_token_hash_idx ON refresh_tokens (token_hash);
+		CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+		CREATE INDEX IF NOT EXISTS refresh_tokens_client_id_idx ON refresh_tokens (client_id);
+		CREATE INDEX IF NOT EXISTS refresh_tokens_family_expires_idx ON refresh_tokens (family_expires);
+	`)
+	return err
+}
+func (m *refresh_tokens_20261018234015) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP TABLE IF EXISTS refresh_tokens;
+		ALTER TABLE clients DROP COLUMN IF EXISTS refresh_token_max_lifetime;
+		ALTER TABLE clients DROP COLUMN IF EXISTS refresh_token_lifetime;
+		ALTER TABLE clients DROP COLUMN IF EXISTS access_token_lifetime;
+	`)
+	return err
+}
@@ -0,0 +1,42 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type roles_20261018203012 struct {
+	version int
+}
+
+func Newroles_20261018203012() Migration {
+	return &roles_20261018203012{
+		version: 20261018203012,
+	}
+}
+
+func (m *roles_20261018203012) Version() int {
+	return m.version
+}
+
+func (m *roles_20261018203012) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS roles (
+			name TEXT PRIMARY KEY,
+			description TEXT NOT NULL DEFAULT '',
+			permissions TEXT[] NOT NULL DEFAULT '{}',
+			created_at timestamp NOT NULL DEFAULT now()
+		);
+		CREATE INDEX IF NOT EXISTS users_role_idx ON users (role);
+	`)
+	return err
+}
+func (m *roles_20261018203012) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP INDEX IF EXISTS users_role_idx;
+		DROP TABLE IF EXISTS roles;
+	`)
+	return err
+}
@@ -0,0 +1,38 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type session_policy_20261018223540 struct {
+	version int
+}
+
+func Newsession_policy_20261018223540() Migration {
+	return &session_policy_20261018223540{
+		version: 20261018223540,
+	}
+}
+
+func (m *session_policy_20261018223540) Version() int {
+	return m.version
+}
+
+func (m *session_policy_20261018223540) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		INSERT INTO config (key, label, value, type, description) VALUES
+			('sessionIdleTimeout', 'Session idle timeout', '24h', 'duration', 'How long a browser session stays valid without being used; every use extends it, up to the session max lifetime'),
+			('sessionMaxLifetime', 'Session max lifetime', '168h', 'duration', 'How long after login a browser session ends regardless of use')
+		ON CONFLICT DO NOTHING;
+	`)
+	return err
+}
+func (m *session_policy_20261018223540) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DELETE FROM config WHERE key IN ('sessionIdleTimeout', 'sessionMaxLifetime');
+	`)
+	return err
+}
@@ -0,0 +1,46 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type signing_keys_20261018130245 struct {
+	version int
+}
+
+func Newsigning_keys_20261018130245() Migration {
+	return &signing_keys_20261018130245{
+		version: 20261018130245,
+	}
+}
+
+func (m *signing_keys_20261018130245) Version() int {
+	return m.version
+}
+
+func (m *signing_keys_20261018130245) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS signing_keys (
+			id TEXT PRIMARY KEY,
+			algorithm TEXT NOT NULL,
+			public_key bytea NOT NULL,
+			private_key bytea NOT NULL,
+			status TEXT NOT NULL,
+			created_at timestamp NOT NULL DEFAULT now(),
+			retired_at timestamp,
+			expires_at timestamp
+		);
+		-- only one key signs at a time; concurrent rotations from other replicas fail on this index
+		CREATE UNIQUE INDEX IF NOT EXISTS signing_keys_single_active_idx ON signing_keys (status) WHERE status = 'active';
+	`)
+	return err
+}
+func (m *signing_keys_20261018130245) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP TABLE IF EXISTS signing_keys;
+	`)
+	return err
+}
@@ -0,0 +1,46 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type user_credential_details_20261018182040 struct {
+	version int
+}
+
+func Newuser_credential_details_20261018182040() Migration {
+	return &user_credential_details_20261018182040{
+		version: 20261018182040,
+	}
+}
+
+func (m *user_credential_details_20261018182040) Version() int {
+	return m.version
+}
+
+func (m *user_credential_details_20261018182040) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE user_credentials
+			ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT 'Passkey',
+			ADD COLUMN IF NOT EXISTS created_at timestamp NOT NULL DEFAULT now(),
+			ADD COLUMN IF NOT EXISTS last_used_at timestamp;
+		ALTER TABLE user_credentials
+			ALTER COLUMN name DROP DEFAULT,
+			ALTER COLUMN created_at DROP DEFAULT,
+			ADD CONSTRAINT user_credentials_pkey PRIMARY KEY (id);
+	`)
+	return err
+}
+func (m *user_credential_details_20261018182040) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE user_credentials
+			DROP CONSTRAINT IF EXISTS user_credentials_pkey,
+			DROP COLUMN IF EXISTS last_used_at,
+			DROP COLUMN IF EXISTS created_at,
+			DROP COLUMN IF EXISTS name;
+	`)
+	return err
+}
@@ -0,0 +1,49 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type user_invitations_20261018174215 struct {
+	version int
+}
+
+func Newuser_invitations_20261018174215() Migration {
+	return &user_invitations_20261018174215{
+		version: 20261018174215,
+	}
+}
+
+func (m *user_invitations_20261018174215) Version() int {
+	return m.version
+}
+
+func (m *user_invitations_20261018174215) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS user_invitations (
+			id UUID PRIMARY KEY,
+			code_hash TEXT NOT NULL UNIQUE,
+			email TEXT,
+			role TEXT NOT NULL,
+			created_by uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at timestamp NOT NULL,
+			expires_at timestamp NOT NULL,
+			used_at timestamp,
+			used_by uuid REFERENCES users(id) ON DELETE SET NULL
+		);
+		INSERT INTO config (key, label, value, type, description) VALUES
+			('invitedUserStatus', 'Invited user status', 'pending', 'string', 'Status of users registering with an invitation: pending users have to be activated by an admin, active users can sign in right away')
+		ON CONFLICT DO NOTHING;
+	`)
+	return err
+}
+func (m *user_invitations_20261018174215) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DELETE FROM config WHERE key = 'invitedUserStatus';
+		DROP TABLE IF EXISTS user_invitations;
+	`)
+	return err
+}
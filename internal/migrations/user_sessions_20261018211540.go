@@ -0,0 +1,43 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type user_sessions_20261018211540 struct {
+	version int
+}
+
+func Newuser_sessions_20261018211540() Migration {
+	return &user_sessions_20261018211540{
+		version: 20261018211540,
+	}
+}
+
+func (m *user_sessions_20261018211540) Version() int {
+	return m.version
+}
+
+func (m *user_sessions_20261018211540) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS created_at timestamp NOT NULL DEFAULT now();
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS last_seen_at timestamp;
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '';
+		ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON user_tokens (user_id);
+	`)
+	return err
+}
+func (m *user_sessions_20261018211540) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP INDEX IF EXISTS user_tokens_user_id_idx;
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS user_agent;
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS ip;
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS last_seen_at;
+		ALTER TABLE user_tokens DROP COLUMN IF EXISTS created_at;
+	`)
+	return err
+}
@@ -0,0 +1,40 @@
+// Migration generated by tools/migration_gen.js
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type webauthn_sessions_20261018190215 struct {
+	version int
+}
+
+func Newwebauthn_sessions_20261018190215() Migration {
+	return &webauthn_sessions_20261018190215{
+		version: 20261018190215,
+	}
+}
+
+func (m *webauthn_sessions_20261018190215) Version() int {
+	return m.version
+}
+
+func (m *webauthn_sessions_20261018190215) Up(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS webauthn_sessions (
+			key TEXT PRIMARY KEY,
+			data jsonb NOT NULL,
+			expires_at timestamp NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS webauthn_sessions_expires_at_idx ON webauthn_sessions (expires_at);
+	`)
+	return err
+}
+func (m *webauthn_sessions_20261018190215) Down(tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `
+		DROP TABLE IF EXISTS webauthn_sessions;
+	`)
+	return err
+}
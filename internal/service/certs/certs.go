@@ -0,0 +1,112 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// reloadInterval is how often the certificate files are checked for changes
+const reloadInterval = 30 * time.Second
+
+// Reloader serves a TLS certificate that is reloaded when its files change on disk,
+// so renewed certificates are picked up without a restart
+type Reloader interface {
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	Reload() error
+	Start(context.Context)
+}
+type reloader struct {
+	logger   zerolog.Logger
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+// NewReloader loads the certificate; it fails when the initial certificate cannot be loaded
+func NewReloader(logger zerolog.Logger, certFile, keyFile string) (Reloader, error) {
+	r := &reloader{
+		logger:   logger.With().Str("component", "CertReloader").Logger(),
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := r.Reload(); err != nil {
+		r.logger.Err(err).Msg("failed to load certificate")
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads the certificate when either file changed since the last load; on failure the
+// current certificate keeps being served
+func (r *reloader) Reload() error {
+	modified, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	unchanged := r.cert != nil && modified.Equal(r.modified)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modified = modified
+	r.logger.Info().Str("certFile", r.certFile).Msg("certificate loaded")
+	return nil
+}
+
+// Start checks the certificate files for changes until ctx is done
+func (r *reloader) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(reloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Reload(); err != nil {
+					r.logger.Err(err).Msg("failed to reload certificate; keeping the current one")
+				}
+			}
+		}
+	}()
+}
+
+// lastModified is the latest modification time of the certificate and key files
+func (r *reloader) lastModified() (time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
@@ -0,0 +1,98 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type CertsTestSuite struct {
+	suite.Suite
+
+	certFile string
+	keyFile  string
+}
+
+func TestCertsTestSuite(t *testing.T) {
+	suite.Run(t, new(CertsTestSuite))
+}
+
+func (suite *CertsTestSuite) SetupTest() {
+	dir := suite.T().TempDir()
+	suite.certFile = filepath.Join(dir, "cert.pem")
+	suite.keyFile = filepath.Join(dir, "key.pem")
+}
+
+// writeCert writes a new self signed certificate and moves the files' mtime to modified
+func (suite *CertsTestSuite) writeCert(commonName string, modified time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	suite.Require().NoError(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(os.WriteFile(suite.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	suite.Require().NoError(os.WriteFile(suite.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	suite.Require().NoError(os.Chtimes(suite.certFile, modified, modified))
+	suite.Require().NoError(os.Chtimes(suite.keyFile, modified, modified))
+}
+
+func (suite *CertsTestSuite) commonName(r Reloader) string {
+	cert, err := r.GetCertificate(nil)
+	suite.Require().NoError(err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	suite.Require().NoError(err)
+	return leaf.Subject.CommonName
+}
+
+func (suite *CertsTestSuite) TestNewReloader_OK() {
+	suite.writeCert("first", time.Now())
+
+	r, err := NewReloader(zerolog.Nop(), suite.certFile, suite.keyFile)
+	suite.NoError(err)
+	suite.Equal("first", suite.commonName(r))
+}
+
+func (suite *CertsTestSuite) TestNewReloader_FailMissingFiles() {
+	_, err := NewReloader(zerolog.Nop(), suite.certFile, suite.keyFile)
+	suite.Error(err)
+}
+
+func (suite *CertsTestSuite) TestReload_OK() {
+	suite.writeCert("first", time.Now().Add(-time.Minute))
+	r, err := NewReloader(zerolog.Nop(), suite.certFile, suite.keyFile)
+	suite.Require().NoError(err)
+
+	suite.writeCert("renewed", time.Now())
+	suite.NoError(r.Reload())
+	suite.Equal("renewed", suite.commonName(r))
+}
+
+func (suite *CertsTestSuite) TestReload_FailKeepsCurrent() {
+	suite.writeCert("first", time.Now().Add(-time.Minute))
+	r, err := NewReloader(zerolog.Nop(), suite.certFile, suite.keyFile)
+	suite.Require().NoError(err)
+
+	// a renewal caught halfway through writing
+	suite.Require().NoError(os.WriteFile(suite.certFile, []byte("not a certificate"), 0600))
+	suite.Error(r.Reload())
+	suite.Equal("first", suite.commonName(r))
+}
@@ -0,0 +1,183 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// cacheTTL is how long a fetched key set is used before it is fetched again
+	cacheTTL = 10 * time.Minute
+	// minRefreshInterval throttles fetches per uri, failed ones included, so requests naming a client
+	// cannot make the server hammer that client's jwks_uri, e.g. with assertions carrying an unknown kid
+	minRefreshInterval = 30 * time.Second
+	fetchTimeout       = 5 * time.Second
+	maxBodySize        = 1 << 20
+)
+
+//go:generate mockgen -destination=./mocks/mock_fetcher.go -package=mocks github.com/asatraitis/mangrove/internal/service/jwks Fetcher
+type Fetcher interface {
+	// Get returns the key set published at uri, served from cache while it is fresh
+	Get(ctx context.Context, uri string) (dto.JWKSResponse, error)
+	// Refresh refetches the key set, e.g. after a client rotated to a kid that is not cached yet.
+	// Fetches are throttled per uri; within the throttle window the result of the last fetch is returned.
+	Refresh(ctx context.Context, uri string) (dto.JWKSResponse, error)
+}
+type fetcher struct {
+	logger zerolog.Logger
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*entry
+	// group collapses concurrent fetches of the same uri into one request
+	group singleflight.Group
+}
+
+// entry is the result of the last fetch of a uri; err is set when it failed
+type entry struct {
+	keys      dto.JWKSResponse
+	err       error
+	fetchedAt time.Time
+}
+
+// NewFetcher uses client for all fetches; without one, fetches may only reach public addresses, since the
+// jwks_uri is chosen by whoever registers a client and must not be usable to probe the server's network
+func NewFetcher(logger zerolog.Logger, client *http.Client) Fetcher {
+	if client == nil {
+		client = newHTTPClient(&net.Dialer{Timeout: fetchTimeout, Control: rejectInternalAddress})
+	}
+	return &fetcher{
+		logger: logger.With().Str("component", "JWKSFetcher").Logger(),
+		client: client,
+		cache:  make(map[string]*entry),
+	}
+}
+
+// newHTTPClient dials through dialer only; the environment's proxy is not used since it would dial the
+// jwks_uri host on the fetcher's behalf, past the dialer's checks. Redirects are not followed.
+func newHTTPClient(dialer *net.Dialer) *http.Client {
+	return &http.Client{
+		Timeout: fetchTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: fetchTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errors.New("jwks redirects are not followed")
+		},
+	}
+}
+
+// rejectInternalAddress is a dialer Control: it runs after DNS resolution, on the address actually dialed,
+// so a public hostname resolving to an internal address is refused too
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := addrPort.Addr().Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("jwks address %s is not allowed", ip)
+	}
+	return nil
+}
+
+func (f *fetcher) Get(ctx context.Context, uri string) (dto.JWKSResponse, error) {
+	f.mu.Lock()
+	cached, ok := f.cache[uri]
+	f.mu.Unlock()
+	if ok && cached.err == nil && time.Since(cached.fetchedAt) < cacheTTL {
+		return cached.keys, nil
+	}
+	return f.throttledFetch(ctx, uri)
+}
+
+func (f *fetcher) Refresh(ctx context.Context, uri string) (dto.JWKSResponse, error) {
+	return f.throttledFetch(ctx, uri)
+}
+
+// throttledFetch fetches the key set unless the uri was fetched within minRefreshInterval, in which case the
+// result of that fetch is returned again. The throttle is checked inside the flight so callers that just
+// missed a concurrent fetch reuse its result too.
+func (f *fetcher) throttledFetch(ctx context.Context, uri string) (dto.JWKSResponse, error) {
+	keys, err, _ := f.group.Do(uri, func() (interface{}, error) {
+		f.mu.Lock()
+		cached, ok := f.cache[uri]
+		f.mu.Unlock()
+		if ok && time.Since(cached.fetchedAt) < minRefreshInterval {
+			return cached.keys, cached.err
+		}
+
+		// the fetch is shared, so it must not fail because the caller that started it went away
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		keys, err := f.fetch(fetchCtx, uri)
+
+		f.mu.Lock()
+		f.cache[uri] = &entry{keys: keys, err: err, fetchedAt: time.Now()}
+		f.mu.Unlock()
+		return keys, err
+	})
+	if err != nil {
+		return dto.JWKSResponse{}, err
+	}
+	return keys.(dto.JWKSResponse), nil
+}
+
+func (f *fetcher) fetch(ctx context.Context, uri string) (dto.JWKSResponse, error) {
+	const funcName = "fetch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		f.logger.Err(err).Str("func", funcName).Str("uri", uri).Msg("failed to create request")
+		return dto.JWKSResponse{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		f.logger.Err(err).Str("func", funcName).Str("uri", uri).Msg("failed to fetch jwks")
+		return dto.JWKSResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected jwks response status %d", res.StatusCode)
+		f.logger.Err(err).Str("func", funcName).Str("uri", uri).Msg("failed to fetch jwks")
+		return dto.JWKSResponse{}, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize+1))
+	if err != nil {
+		f.logger.Err(err).Str("func", funcName).Str("uri", uri).Msg("failed to read jwks")
+		return dto.JWKSResponse{}, err
+	}
+	if len(body) > maxBodySize {
+		err := errors.New("jwks response too large")
+		f.logger.Err(err).Str("func", funcName).Str("uri", uri).Msg("failed to read jwks")
+		return dto.JWKSResponse{}, err
+	}
+
+	var keys dto.JWKSResponse
+	if err := json.Unmarshal(body, &keys); err != nil {
+		f.logger.Err(err).Str("func", funcName).Str("uri", uri).Msg("failed to decode jwks")
+		return dto.JWKSResponse{}, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,175 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type FetcherTestSuite struct {
+	suite.Suite
+
+	ctx    context.Context
+	server *httptest.Server
+	hits   atomic.Int32
+	kid    atomic.Value
+}
+
+func TestFetcherTestSuite(t *testing.T) {
+	suite.Run(t, new(FetcherTestSuite))
+}
+
+func (suite *FetcherTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.hits.Store(0)
+	suite.kid.Store("kid-1")
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.hits.Add(1)
+		switch r.URL.Path {
+		case "/jwks.json":
+			json.NewEncoder(w).Encode(dto.JWKSResponse{Keys: []dto.JWK{{Kty: "OKP", Kid: suite.kid.Load().(string)}}})
+		case "/slow.json":
+			time.Sleep(50 * time.Millisecond)
+			json.NewEncoder(w).Encode(dto.JWKSResponse{Keys: []dto.JWK{{Kty: "OKP", Kid: suite.kid.Load().(string)}}})
+		case "/redirect.json":
+			http.Redirect(w, r, "/jwks.json", http.StatusFound)
+		case "/large.json":
+			w.Write([]byte(`{"keys":["` + strings.Repeat("a", maxBodySize) + `"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+func (suite *FetcherTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// fetcher returns a fetcher that may reach the loopback test server
+func (suite *FetcherTestSuite) fetcher() Fetcher {
+	return NewFetcher(zerolog.Nop(), newHTTPClient(&net.Dialer{}))
+}
+
+func (suite *FetcherTestSuite) TestGet_OK_Cached() {
+	f := suite.fetcher()
+
+	keys, err := f.Get(suite.ctx, suite.server.URL+"/jwks.json")
+	suite.NoError(err)
+	suite.Len(keys.Keys, 1)
+	suite.Equal("kid-1", keys.Keys[0].Kid)
+
+	_, err = f.Get(suite.ctx, suite.server.URL+"/jwks.json")
+	suite.NoError(err)
+	suite.Equal(int32(1), suite.hits.Load())
+}
+
+func (suite *FetcherTestSuite) TestRefresh_OK_Throttled() {
+	f := suite.fetcher().(*fetcher)
+	uri := suite.server.URL + "/jwks.json"
+
+	_, err := f.Get(suite.ctx, uri)
+	suite.NoError(err)
+
+	// the client rotated, but the cached set was fetched too recently
+	suite.kid.Store("kid-2")
+	keys, err := f.Refresh(suite.ctx, uri)
+	suite.NoError(err)
+	suite.Equal("kid-1", keys.Keys[0].Kid)
+	suite.Equal(int32(1), suite.hits.Load())
+
+	f.cache[uri].fetchedAt = time.Now().Add(-minRefreshInterval)
+	keys, err = f.Refresh(suite.ctx, uri)
+	suite.NoError(err)
+	suite.Equal("kid-2", keys.Keys[0].Kid)
+	suite.Equal(int32(2), suite.hits.Load())
+}
+
+func (suite *FetcherTestSuite) TestGet_OK_ConcurrentFetchesCollapsed() {
+	f := suite.fetcher()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := f.Get(suite.ctx, suite.server.URL+"/slow.json")
+			suite.NoError(err)
+			suite.Len(keys.Keys, 1)
+		}()
+	}
+	wg.Wait()
+	suite.Equal(int32(1), suite.hits.Load())
+}
+
+func (suite *FetcherTestSuite) TestGet_FAIL_Throttled() {
+	f := suite.fetcher().(*fetcher)
+	uri := suite.server.URL + "/missing.json"
+
+	// a failing jwks_uri is only hit once per interval, however the fetch is triggered
+	_, err := f.Get(suite.ctx, uri)
+	suite.ErrorContains(err, "404")
+	_, err = f.Get(suite.ctx, uri)
+	suite.ErrorContains(err, "404")
+	_, err = f.Refresh(suite.ctx, uri)
+	suite.ErrorContains(err, "404")
+	suite.Equal(int32(1), suite.hits.Load())
+
+	f.cache[uri].fetchedAt = time.Now().Add(-minRefreshInterval)
+	_, err = f.Get(suite.ctx, uri)
+	suite.ErrorContains(err, "404")
+	suite.Equal(int32(2), suite.hits.Load())
+}
+
+func (suite *FetcherTestSuite) TestGet_FAIL() {
+	f := suite.fetcher()
+
+	_, err := f.Get(suite.ctx, suite.server.URL+"/missing.json")
+	suite.ErrorContains(err, "404")
+
+	_, err = f.Get(suite.ctx, suite.server.URL+"/large.json")
+	suite.ErrorContains(err, "too large")
+}
+
+func (suite *FetcherTestSuite) TestGet_FAIL_Redirect() {
+	f := suite.fetcher()
+
+	_, err := f.Get(suite.ctx, suite.server.URL+"/redirect.json")
+	suite.ErrorContains(err, "redirects are not followed")
+	suite.Equal(int32(1), suite.hits.Load())
+}
+
+func (suite *FetcherTestSuite) TestGet_FAIL_InternalAddress() {
+	f := NewFetcher(zerolog.Nop(), nil)
+
+	_, err := f.Get(suite.ctx, suite.server.URL+"/jwks.json")
+	suite.ErrorContains(err, "is not allowed")
+	suite.Equal(int32(0), suite.hits.Load())
+
+	for _, uri := range []string{"http://10.0.0.1/jwks.json", "http://[::1]:8080/jwks.json", "http://169.254.169.254/jwks.json", "http://0.0.0.0/jwks.json"} {
+		_, err := f.Get(suite.ctx, uri)
+		suite.ErrorContains(err, "is not allowed", uri)
+	}
+}
+
+func TestRejectInternalAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:443", "[::1]:443", "10.1.2.3:443", "172.16.0.1:443", "192.168.1.1:443", "169.254.169.254:80", "[fe80::1]:443", "0.0.0.0:443", "[::]:443", "[::ffff:127.0.0.1]:443", "[fd00::1]:443"} {
+		if err := rejectInternalAddress("tcp", address, nil); err == nil {
+			t.Errorf("expected %s to be rejected", address)
+		}
+	}
+	for _, address := range []string{"93.184.216.34:443", "[2606:4700::1111]:443"} {
+		if err := rejectInternalAddress("tcp", address, nil); err != nil {
+			t.Errorf("expected %s to be allowed: %v", address, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// recordTimeout bounds recording a run that was cut short by shutdown
+const recordTimeout = 5 * time.Second
+
+type Config struct {
+	// Interval is how often Start runs the reaper
+	Interval time.Duration
+	// BatchSize caps the rows a single delete removes, so no statement holds locks for long
+	BatchSize int
+	// Retention is how long expired invitations, expired or revoked client keys and run records are kept
+	// before they are purged
+	Retention time.Duration
+}
+
+// Reaper purges expired and orphaned rows so the tables do not grow forever. Runs on several instances
+// at once are safe; they only split the work.
+type Reaper interface {
+	// Run purges everything that is due once and records what was removed; a failing purge does not
+	// stop the others, their errors are joined
+	Run(context.Context) (*models.ReaperRun, error)
+	// Start runs the reaper right away and then every interval until ctx is done
+	Start(context.Context)
+}
+type reaper struct {
+	logger zerolog.Logger
+	dal    dal.DAL
+	conf   Config
+}
+
+// purge deletes one kind of data; delete removes up to limit rows that were due before the cutoff
+type purge struct {
+	name string
+	// retained data is only purged once it has been due for the retention period
+	retained bool
+	delete   func(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+func NewReaper(logger zerolog.Logger, dal dal.DAL, conf Config) (Reaper, error) {
+	logger = logger.With().Str("component", "Reaper").Logger()
+	if conf.BatchSize <= 0 || conf.Retention <= 0 {
+		err := errors.New("reaper batch size and retention must be positive")
+		logger.Err(err).Msg("failed to create reaper")
+		return nil, err
+	}
+	return &reaper{
+		logger: logger,
+		dal:    dal,
+		conf:   conf,
+	}, nil
+}
+
+func (r *reaper) purges() []purge {
+	return []purge{
+		{name: "user_tokens", delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.UserTokens(ctx).DeleteExpired(before, limit)
+		}},
+		// sessions and access tokens of inactive or suspended users are rejected on every use
+		{name: "disabled_user_tokens", delete: func(ctx context.Context, _ time.Time, limit int) (int64, error) {
+			return r.dal.UserTokens(ctx).DeleteOfDisabledUsers(limit)
+		}},
+		// used refresh tokens are kept until their family expires so replaying them is still detected
+		{name: "refresh_tokens", delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.RefreshTokens(ctx).DeleteExpired(before, limit)
+		}},
+		{name: "authorization_codes", delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.AuthorizationCodes(ctx).DeleteExpired(before, limit)
+		}},
+		{name: "client_assertions", delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.ClientAssertions(ctx).DeleteExpired(before, limit)
+		}},
+		{name: "webauthn_sessions", delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.WebauthnSessions(ctx).DeleteExpired(before, limit)
+		}},
+		{name: "user_invitations", retained: true, delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.UserInvitations(ctx).DeleteExpired(before, limit)
+		}},
+		{name: "client_keys", retained: true, delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.ClientKeys(ctx).DeleteExpired(before, limit)
+		}},
+		{name: "reaper_runs", retained: true, delete: func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			return r.dal.ReaperRuns(ctx).DeleteExpired(before, limit)
+		}},
+	}
+}
+
+func (r *reaper) Run(ctx context.Context) (*models.ReaperRun, error) {
+	const funcName = "Run"
+
+	run := &models.ReaperRun{
+		ID:        uuid.New(),
+		StartedAt: time.Now(),
+		Removed:   map[string]int64{},
+	}
+	var errs error
+	var total int64
+	for _, p := range r.purges() {
+		if ctx.Err() != nil {
+			errs = errors.Join(errs, ctx.Err())
+			break
+		}
+		before := run.StartedAt
+		if p.retained {
+			before = before.Add(-r.conf.Retention)
+		}
+		removed, err := r.purgeAll(ctx, p, before)
+		run.Removed[p.name] = removed
+		total += removed
+		if err != nil {
+			r.logger.Err(err).Str("func", funcName).Str("data", p.name).Int64("removed", removed).Msg("failed to purge")
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", p.name, err))
+		}
+	}
+	run.FinishedAt = time.Now()
+	if errs != nil {
+		message := errs.Error()
+		run.Error = &message
+	}
+
+	// a run interrupted by shutdown is still recorded
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	if err := r.dal.ReaperRuns(recordCtx).Create(run); err != nil {
+		r.logger.Err(err).Str("func", funcName).Msg("failed to record reaper run")
+		errs = errors.Join(errs, errors.New("failed to record reaper run"))
+	}
+
+	r.logger.Info().
+		Str("func", funcName).
+		Int64("total", total).
+		Interface("removed", run.Removed).
+		Dur("duration", run.FinishedAt.Sub(run.StartedAt)).
+		Bool("failed", errs != nil).
+		Msg("purged expired data")
+	return run, errs
+}
+
+// purgeAll deletes in batches until a batch comes back short
+func (r *reaper) purgeAll(ctx context.Context, p purge, before time.Time) (int64, error) {
+	var total int64
+	for {
+		removed, err := p.delete(ctx, before, r.conf.BatchSize)
+		total += removed
+		if err != nil {
+			return total, err
+		}
+		if removed < int64(r.conf.BatchSize) {
+			return total, nil
+		}
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+	}
+}
+
+func (r *reaper) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.conf.Interval)
+		defer ticker.Stop()
+		for {
+			// failures are logged and recorded; the next run retries
+			r.Run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,145 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type ReaperTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal                *mocks.MockDAL
+	userTokensDal      *mocks.MockUserTokensDAL
+	refreshTokensDal   *mocks.MockRefreshTokensDAL
+	authCodesDal       *mocks.MockAuthorizationCodesDAL
+	clientAssertionDal *mocks.MockClientAssertionsDAL
+	webauthnDal        *mocks.MockWebauthnSessionsDAL
+	invitationsDal     *mocks.MockUserInvitationsDAL
+	clientKeysDal      *mocks.MockClientKeysDAL
+	runsDal            *mocks.MockReaperRunsDAL
+	reaper             Reaper
+}
+
+func TestReaperTestSuite(t *testing.T) {
+	suite.Run(t, new(ReaperTestSuite))
+}
+
+func (suite *ReaperTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.userTokensDal = mocks.NewMockUserTokensDAL(suite.Ctrl)
+	suite.refreshTokensDal = mocks.NewMockRefreshTokensDAL(suite.Ctrl)
+	suite.authCodesDal = mocks.NewMockAuthorizationCodesDAL(suite.Ctrl)
+	suite.clientAssertionDal = mocks.NewMockClientAssertionsDAL(suite.Ctrl)
+	suite.webauthnDal = mocks.NewMockWebauthnSessionsDAL(suite.Ctrl)
+	suite.invitationsDal = mocks.NewMockUserInvitationsDAL(suite.Ctrl)
+	suite.clientKeysDal = mocks.NewMockClientKeysDAL(suite.Ctrl)
+	suite.runsDal = mocks.NewMockReaperRunsDAL(suite.Ctrl)
+
+	suite.dal.EXPECT().UserTokens(gomock.Any()).AnyTimes().Return(suite.userTokensDal)
+	suite.dal.EXPECT().RefreshTokens(gomock.Any()).AnyTimes().Return(suite.refreshTokensDal)
+	suite.dal.EXPECT().AuthorizationCodes(gomock.Any()).AnyTimes().Return(suite.authCodesDal)
+	suite.dal.EXPECT().ClientAssertions(gomock.Any()).AnyTimes().Return(suite.clientAssertionDal)
+	suite.dal.EXPECT().WebauthnSessions(gomock.Any()).AnyTimes().Return(suite.webauthnDal)
+	suite.dal.EXPECT().UserInvitations(gomock.Any()).AnyTimes().Return(suite.invitationsDal)
+	suite.dal.EXPECT().ClientKeys(gomock.Any()).AnyTimes().Return(suite.clientKeysDal)
+	suite.dal.EXPECT().ReaperRuns(gomock.Any()).AnyTimes().Return(suite.runsDal)
+
+	var err error
+	suite.reaper, err = NewReaper(zerolog.Nop(), suite.dal, Config{Interval: time.Hour, BatchSize: 2, Retention: 24 * time.Hour})
+	suite.Require().NoError(err)
+}
+
+// expectNothingDue expects a single short batch for every purge not set up by the test
+func (suite *ReaperTestSuite) expectNothingDue() {
+	suite.userTokensDal.EXPECT().DeleteOfDisabledUsers(2).AnyTimes().Return(int64(0), nil)
+	suite.refreshTokensDal.EXPECT().DeleteExpired(gomock.Any(), 2).AnyTimes().Return(int64(0), nil)
+	suite.authCodesDal.EXPECT().DeleteExpired(gomock.Any(), 2).AnyTimes().Return(int64(0), nil)
+	suite.clientAssertionDal.EXPECT().DeleteExpired(gomock.Any(), 2).AnyTimes().Return(int64(0), nil)
+	suite.webauthnDal.EXPECT().DeleteExpired(gomock.Any(), 2).AnyTimes().Return(int64(0), nil)
+	suite.clientKeysDal.EXPECT().DeleteExpired(gomock.Any(), 2).AnyTimes().Return(int64(0), nil)
+	suite.runsDal.EXPECT().DeleteExpired(gomock.Any(), 2).AnyTimes().Return(int64(0), nil)
+}
+
+func (suite *ReaperTestSuite) TestRun_OK() {
+	// batches continue until one comes back short
+	gomock.InOrder(
+		suite.userTokensDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(2).Return(int64(2), nil),
+		suite.userTokensDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(1).Return(int64(1), nil),
+	)
+	var invitationsBefore time.Time
+	suite.invitationsDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(1).DoAndReturn(func(before time.Time, _ int) (int64, error) {
+		invitationsBefore = before
+		return 1, nil
+	})
+	suite.expectNothingDue()
+	suite.runsDal.EXPECT().Create(gomock.Any()).Times(1).DoAndReturn(func(run *models.ReaperRun) error {
+		suite.Equal(int64(5), run.Removed["user_tokens"])
+		suite.Equal(int64(1), run.Removed["user_invitations"])
+		suite.Equal(int64(0), run.Removed["authorization_codes"])
+		suite.Nil(run.Error)
+		return nil
+	})
+
+	run, err := suite.reaper.Run(suite.ctx)
+	suite.NoError(err)
+	suite.Len(run.Removed, 9)
+	suite.False(run.FinishedAt.Before(run.StartedAt))
+	// retained data is only purged after the retention period
+	suite.Equal(run.StartedAt.Add(-24*time.Hour), invitationsBefore)
+}
+
+func (suite *ReaperTestSuite) TestRun_FAIL_ContinuesAfterError() {
+	suite.userTokensDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(1).Return(int64(0), errors.New("test"))
+	suite.invitationsDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(1).Return(int64(1), nil)
+	suite.expectNothingDue()
+	suite.runsDal.EXPECT().Create(gomock.Any()).Times(1).DoAndReturn(func(run *models.ReaperRun) error {
+		suite.Equal(int64(1), run.Removed["user_invitations"])
+		suite.Require().NotNil(run.Error)
+		suite.Contains(*run.Error, "user_tokens: test")
+		return nil
+	})
+
+	_, err := suite.reaper.Run(suite.ctx)
+	suite.ErrorContains(err, "user_tokens: test")
+}
+
+func (suite *ReaperTestSuite) TestRun_FAIL_Record() {
+	suite.userTokensDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(1).Return(int64(0), nil)
+	suite.invitationsDal.EXPECT().DeleteExpired(gomock.Any(), 2).Times(1).Return(int64(0), nil)
+	suite.expectNothingDue()
+	suite.runsDal.EXPECT().Create(gomock.Any()).Times(1).Return(errors.New("test"))
+
+	_, err := suite.reaper.Run(suite.ctx)
+	suite.ErrorContains(err, "failed to record reaper run")
+}
+
+func (suite *ReaperTestSuite) TestRun_Cancelled() {
+	ctx, cancel := context.WithCancel(suite.ctx)
+	cancel()
+	// nothing is purged, but the interrupted run is still recorded
+	suite.runsDal.EXPECT().Create(gomock.Any()).Times(1).Return(nil)
+
+	run, err := suite.reaper.Run(ctx)
+	suite.ErrorIs(err, context.Canceled)
+	suite.Empty(run.Removed)
+}
+
+func (suite *ReaperTestSuite) TestNewReaper_FAIL() {
+	_, err := NewReaper(zerolog.Nop(), suite.dal, Config{BatchSize: 0, Retention: time.Hour})
+	suite.Error(err)
+	_, err = NewReaper(zerolog.Nop(), suite.dal, Config{BatchSize: 10})
+	suite.Error(err)
+}
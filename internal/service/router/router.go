@@ -54,4 +54,6 @@ func (ro *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (ro *router) register() {
 	ro.handler.Init(ro.initMux)
 	ro.handler.Main(ro.mainMux)
+	ro.handler.OAuth(ro.mainMux)
+	ro.handler.OIDC(ro.mainMux)
 }
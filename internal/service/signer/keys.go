@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is the decoded, ready to use form of models.SigningKey
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+	jwk        dto.JWK
+	status     models.SigningKeyStatus
+	createdAt  time.Time
+	expiresAt  *time.Time
+}
+
+func signingMethod(alg models.SigningKeyAlgorithm) (jwt.SigningMethod, error) {
+	switch alg {
+	case models.SIGNING_KEY_ALGORITHM_EDDSA:
+		return jwt.SigningMethodEdDSA, nil
+	case models.SIGNING_KEY_ALGORITHM_ES256:
+		return jwt.SigningMethodES256, nil
+	}
+	return nil, errors.New("unsupported signing key algorithm")
+}
+
+// generateKey returns a new key pair as PKIX public / PKCS #8 private DER along with its kid
+func generateKey(alg models.SigningKeyAlgorithm) (kid string, publicDER []byte, privateDER []byte, err error) {
+	var publicKey crypto.PublicKey
+	var privateKey crypto.PrivateKey
+	switch alg {
+	case models.SIGNING_KEY_ALGORITHM_EDDSA:
+		publicKey, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	case models.SIGNING_KEY_ALGORITHM_ES256:
+		var ecKey *ecdsa.PrivateKey
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err == nil {
+			publicKey, privateKey = &ecKey.PublicKey, ecKey
+		}
+	default:
+		err = errors.New("unsupported signing key algorithm")
+	}
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	publicDER, err = x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	privateDER, err = x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	jwk, err := utils.PublicKeyToJWK("", string(alg), publicKey)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return utils.JWKThumbprint(jwk), publicDER, privateDER, nil
+}
+
+func decodeKey(key *models.SigningKey, privateDER []byte) (*signingKey, error) {
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(privateDER)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := utils.PublicKeyToJWK(key.ID, string(key.Algorithm), publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{
+		kid:        key.ID,
+		method:     method,
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		jwk:        jwk,
+		status:     key.Status,
+		createdAt:  key.CreatedAt,
+		expiresAt:  key.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,304 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+)
+
+const (
+	keyEncryptionPurpose = "mangrove-signing-key-encryption"
+	// refreshInterval is how often keys rotated by other replicas are picked up and rotation is checked
+	refreshInterval = 5 * time.Minute
+	// minRefreshInterval throttles reloads triggered by tokens with an unknown kid
+	minRefreshInterval = 30 * time.Second
+)
+
+type Config struct {
+	// Algorithm is used for newly generated keys; existing keys keep their own algorithm
+	Algorithm      models.SigningKeyAlgorithm
+	RotationPeriod time.Duration
+	GracePeriod    time.Duration
+	// EncryptionSecret is the configured secret the private key encryption key is derived from
+	EncryptionSecret []byte
+}
+
+//go:generate mockgen -destination=./mocks/mock_signer.go -package=mocks github.com/asatraitis/mangrove/internal/service/signer Signer
+type Signer interface {
+	Sign(jwt.Claims) (string, error)
+	Verify(string, jwt.Claims) error
+	JWKS() dto.JWKSResponse
+	Algorithms() []string
+	Refresh() error
+	Start(context.Context)
+}
+type signer struct {
+	ctx    context.Context
+	logger zerolog.Logger
+	dal    dal.DAL
+	conf   Config
+	kek    []byte
+
+	mu          sync.RWMutex
+	active      *signingKey
+	keys        []*signingKey
+	lastRefresh time.Time
+}
+
+// NewSigner loads the signing keys from the database, generating the first key if there is none
+func NewSigner(ctx context.Context, logger zerolog.Logger, dal dal.DAL, conf Config) (Signer, error) {
+	logger = logger.With().Str("component", "Signer").Logger()
+	if len(conf.EncryptionSecret) == 0 {
+		err := errors.New("missing signing key encryption secret")
+		logger.Err(err).Msg("failed to create signer")
+		return nil, err
+	}
+	if conf.Algorithm == "" {
+		conf.Algorithm = models.SIGNING_KEY_ALGORITHM_EDDSA
+	}
+	if _, err := signingMethod(conf.Algorithm); err != nil {
+		logger.Err(err).Str("algorithm", string(conf.Algorithm)).Msg("failed to create signer")
+		return nil, err
+	}
+	if conf.RotationPeriod <= 0 || conf.GracePeriod <= 0 {
+		err := errors.New("signing key rotation and grace period must be positive")
+		logger.Err(err).Msg("failed to create signer")
+		return nil, err
+	}
+
+	s := &signer{
+		ctx:    ctx,
+		logger: logger,
+		dal:    dal,
+		conf:   conf,
+		kek:    utils.DeriveKey(conf.EncryptionSecret, keyEncryptionPurpose),
+	}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start keeps the key set current until ctx is done
+func (s *signer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Refresh()
+			}
+		}
+	}()
+}
+
+// Refresh reloads the keys, rotates the active key when it is due and drops keys past their grace period
+func (s *signer) Refresh() error {
+	const funcName = "Refresh"
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	due := s.active == nil || !time.Now().Before(s.active.createdAt.Add(s.conf.RotationPeriod))
+	s.mu.RUnlock()
+	if due {
+		if err := s.rotate(); err != nil {
+			// another replica may have rotated first; whatever is in the DB now wins
+			s.logger.Err(err).Str("func", funcName).Msg("failed to rotate signing key")
+		}
+		if err := s.load(); err != nil {
+			return err
+		}
+		s.mu.RLock()
+		hasActive := s.active != nil
+		s.mu.RUnlock()
+		if !hasActive {
+			return errors.New("no active signing key")
+		}
+	}
+
+	deleted, err := s.dal.SigningKeys(s.ctx).DeleteExpired()
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to delete expired signing keys")
+	} else if deleted > 0 {
+		s.logger.Info().Str("func", funcName).Int64("deleted", deleted).Msg("deleted expired signing keys")
+	}
+	return nil
+}
+
+func (s *signer) load() error {
+	const funcName = "load"
+
+	dbKeys, err := s.dal.SigningKeys(s.ctx).GetVerifiable()
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to load signing keys")
+		return err
+	}
+
+	var active *signingKey
+	keys := make([]*signingKey, 0, len(dbKeys))
+	for _, dbKey := range dbKeys {
+		privateDER, err := utils.Decrypt(s.kek, dbKey.PrivateKey, []byte(dbKey.ID))
+		if err != nil {
+			// most likely MANGROVE_SALT changed; the key is unusable but others may still be fine
+			s.logger.Err(err).Str("func", funcName).Str("kid", dbKey.ID).Msg("failed to decrypt signing key")
+			continue
+		}
+		key, err := decodeKey(dbKey, privateDER)
+		if err != nil {
+			s.logger.Err(err).Str("func", funcName).Str("kid", dbKey.ID).Msg("failed to decode signing key")
+			continue
+		}
+		if key.status == models.SIGNING_KEY_STATUS_ACTIVE {
+			active = key
+		}
+		keys = append(keys, key)
+	}
+
+	s.mu.Lock()
+	s.active = active
+	s.keys = keys
+	s.lastRefresh = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *signer) rotate() error {
+	const funcName = "rotate"
+
+	kid, publicDER, privateDER, err := generateKey(s.conf.Algorithm)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to generate signing key")
+		return err
+	}
+	encrypted, err := utils.Encrypt(s.kek, privateDER, []byte(kid))
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to encrypt signing key")
+		return err
+	}
+
+	tx, err := s.dal.BeginTx(s.ctx)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to start DB transaction")
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(s.ctx)
+		}
+	}()
+
+	err = s.dal.SigningKeys(s.ctx).RetireActive(tx, time.Now().Add(s.conf.GracePeriod))
+	if err != nil {
+		return err
+	}
+	err = s.dal.SigningKeys(s.ctx).Create(tx, &models.SigningKey{
+		ID:         kid,
+		Algorithm:  s.conf.Algorithm,
+		PublicKey:  publicDER,
+		PrivateKey: encrypted,
+		Status:     models.SIGNING_KEY_STATUS_ACTIVE,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(s.ctx)
+	if err != nil {
+		s.logger.Err(err).Str("func", funcName).Msg("failed to commit signing key rotation")
+		return err
+	}
+
+	s.logger.Info().Str("func", funcName).Str("kid", kid).Str("algorithm", string(s.conf.Algorithm)).Msg("rotated signing key")
+	return nil
+}
+
+func (s *signer) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	key := s.active
+	s.mu.RUnlock()
+	if key == nil {
+		err := errors.New("no active signing key")
+		s.logger.Err(err).Str("func", "Sign").Msg("failed to sign token")
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	signed, err := token.SignedString(key.privateKey)
+	if err != nil {
+		s.logger.Err(err).Str("func", "Sign").Msg("failed to sign token")
+		return "", err
+	}
+	return signed, nil
+}
+
+func (s *signer) Verify(token string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key := s.key(kid)
+		if key == nil {
+			return nil, errors.New("unknown kid")
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("algorithm does not match key")
+		}
+		return key.publicKey, nil
+	}, jwt.WithValidMethods(s.Algorithms()))
+	if err != nil {
+		s.logger.Err(err).Str("func", "Verify").Msg("failed to verify token")
+		return err
+	}
+	return nil
+}
+
+// key finds a verifiable key by kid, reloading once in a while in case another replica rotated
+func (s *signer) key(kid string) *signingKey {
+	find := func() (*signingKey, time.Time) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		for _, key := range s.keys {
+			if key.kid == kid && (key.expiresAt == nil || time.Now().Before(*key.expiresAt)) {
+				return key, s.lastRefresh
+			}
+		}
+		return nil, s.lastRefresh
+	}
+
+	key, lastRefresh := find()
+	if key == nil && time.Since(lastRefresh) > minRefreshInterval {
+		if err := s.load(); err == nil {
+			key, _ = find()
+		}
+	}
+	return key
+}
+
+func (s *signer) JWKS() dto.JWKSResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := dto.JWKSResponse{Keys: make([]dto.JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		res.Keys = append(res.Keys, key.jwk)
+	}
+	return res
+}
+
+func (s *signer) Algorithms() []string {
+	return []string{string(models.SIGNING_KEY_ALGORITHM_EDDSA), string(models.SIGNING_KEY_ALGORITHM_ES256)}
+}
@@ -0,0 +1,203 @@
+package signer
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeTx only supports ending the transaction; DAL calls are mocked
+type fakeTx struct {
+	pgx.Tx
+}
+
+func (tx *fakeTx) Commit(context.Context) error   { return nil }
+func (tx *fakeTx) Rollback(context.Context) error { return nil }
+
+type SignerTestSuite struct {
+	suite.Suite
+
+	Ctrl *gomock.Controller
+	ctx  context.Context
+
+	dal    *mocks.MockDAL
+	keyDal *mocks.MockSigningKeysDAL
+	conf   Config
+
+	// stored mimics the signing_keys table
+	stored []*models.SigningKey
+}
+
+func TestSignerTestSuite(t *testing.T) {
+	suite.Run(t, new(SignerTestSuite))
+}
+
+func (suite *SignerTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.keyDal = mocks.NewMockSigningKeysDAL(suite.Ctrl)
+	suite.conf = Config{
+		Algorithm:        models.SIGNING_KEY_ALGORITHM_EDDSA,
+		RotationPeriod:   24 * time.Hour,
+		GracePeriod:      time.Hour,
+		EncryptionSecret: []byte("testsalt"),
+	}
+	suite.stored = nil
+
+	suite.dal.EXPECT().SigningKeys(gomock.Any()).AnyTimes().Return(suite.keyDal)
+	suite.keyDal.EXPECT().GetVerifiable().AnyTimes().DoAndReturn(func() ([]*models.SigningKey, error) {
+		var keys []*models.SigningKey
+		for i := len(suite.stored) - 1; i >= 0; i-- {
+			key := suite.stored[i]
+			if key.Status == models.SIGNING_KEY_STATUS_ACTIVE || key.ExpiresAt.After(time.Now()) {
+				keys = append(keys, key)
+			}
+		}
+		return keys, nil
+	})
+	suite.keyDal.EXPECT().DeleteExpired().AnyTimes().Return(int64(0), nil)
+}
+
+func (suite *SignerTestSuite) expectRotation() {
+	suite.dal.EXPECT().BeginTx(gomock.Any()).Times(1).Return(&fakeTx{}, nil)
+	suite.keyDal.EXPECT().RetireActive(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, expiresAt time.Time) error {
+		for _, key := range suite.stored {
+			if key.Status == models.SIGNING_KEY_STATUS_ACTIVE {
+				key.Status = models.SIGNING_KEY_STATUS_RETIRED
+				key.ExpiresAt = &expiresAt
+			}
+		}
+		return nil
+	})
+	suite.keyDal.EXPECT().Create(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ pgx.Tx, key *models.SigningKey) error {
+		suite.stored = append(suite.stored, key)
+		return nil
+	})
+}
+
+func (suite *SignerTestSuite) signAndVerify(s Signer) {
+	token, err := s.Sign(jwt.RegisteredClaims{
+		Subject:   "test-subject",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	suite.NoError(err)
+
+	var claims jwt.RegisteredClaims
+	suite.NoError(s.Verify(token, &claims))
+	suite.Equal("test-subject", claims.Subject)
+}
+
+func (suite *SignerTestSuite) TestNewSigner_OK_GeneratesFirstKey() {
+	suite.expectRotation()
+
+	s, err := NewSigner(suite.ctx, zerolog.Nop(), suite.dal, suite.conf)
+	suite.NoError(err)
+	suite.Len(suite.stored, 1)
+
+	// private keys are never stored in the clear
+	_, err = x509.ParsePKCS8PrivateKey(suite.stored[0].PrivateKey)
+	suite.Error(err)
+
+	jwks := s.JWKS()
+	suite.Len(jwks.Keys, 1)
+	suite.Equal(suite.stored[0].ID, jwks.Keys[0].Kid)
+	suite.Equal("OKP", jwks.Keys[0].Kty)
+	suite.Equal("EdDSA", jwks.Keys[0].Alg)
+
+	suite.signAndVerify(s)
+}
+
+func (suite *SignerTestSuite) TestNewSigner_OK_ES256() {
+	suite.conf.Algorithm = models.SIGNING_KEY_ALGORITHM_ES256
+	suite.expectRotation()
+
+	s, err := NewSigner(suite.ctx, zerolog.Nop(), suite.dal, suite.conf)
+	suite.NoError(err)
+
+	jwks := s.JWKS()
+	suite.Len(jwks.Keys, 1)
+	suite.Equal("EC", jwks.Keys[0].Kty)
+	suite.Equal("P-256", jwks.Keys[0].Crv)
+	suite.NotEmpty(jwks.Keys[0].Y)
+
+	suite.signAndVerify(s)
+}
+
+func (suite *SignerTestSuite) TestNewSigner_FAIL_Config() {
+	conf := suite.conf
+	conf.EncryptionSecret = nil
+	_, err := NewSigner(suite.ctx, zerolog.Nop(), suite.dal, conf)
+	suite.Error(err)
+
+	conf = suite.conf
+	conf.Algorithm = "RS256"
+	_, err = NewSigner(suite.ctx, zerolog.Nop(), suite.dal, conf)
+	suite.Error(err)
+
+	conf = suite.conf
+	conf.GracePeriod = 0
+	_, err = NewSigner(suite.ctx, zerolog.Nop(), suite.dal, conf)
+	suite.Error(err)
+}
+
+func (suite *SignerTestSuite) TestRefresh_OK_RotatesWithGracePeriod() {
+	suite.expectRotation()
+	s, err := NewSigner(suite.ctx, zerolog.Nop(), suite.dal, suite.conf)
+	suite.NoError(err)
+
+	oldToken, err := s.Sign(jwt.RegisteredClaims{Subject: "old"})
+	suite.NoError(err)
+
+	// not due yet
+	suite.NoError(s.Refresh())
+	suite.Len(suite.stored, 1)
+
+	suite.stored[0].CreatedAt = time.Now().Add(-25 * time.Hour)
+	suite.expectRotation()
+	suite.NoError(s.Refresh())
+	suite.Len(suite.stored, 2)
+	suite.Len(s.JWKS().Keys, 2)
+
+	// the retired key still verifies during the grace period
+	var claims jwt.RegisteredClaims
+	suite.NoError(s.Verify(oldToken, &claims))
+	suite.Equal("old", claims.Subject)
+
+	newToken, err := s.Sign(jwt.RegisteredClaims{Subject: "new"})
+	suite.NoError(err)
+	suite.NoError(s.Verify(newToken, &claims))
+
+	// and stops once the grace period is over
+	expired := time.Now().Add(-time.Minute)
+	suite.stored[0].ExpiresAt = &expired
+	suite.NoError(s.Refresh())
+	suite.Len(s.JWKS().Keys, 1)
+	suite.Error(s.Verify(oldToken, &jwt.RegisteredClaims{}))
+}
+
+func (suite *SignerTestSuite) TestVerify_FAIL_OtherSecret() {
+	suite.expectRotation()
+	s, err := NewSigner(suite.ctx, zerolog.Nop(), suite.dal, suite.conf)
+	suite.NoError(err)
+
+	token, err := s.Sign(jwt.RegisteredClaims{Subject: "test-subject"})
+	suite.NoError(err)
+
+	// a key encrypted under another secret cannot be decrypted, so a new one is generated
+	conf := suite.conf
+	conf.EncryptionSecret = []byte("othersalt")
+	suite.expectRotation()
+	other, err := NewSigner(suite.ctx, zerolog.Nop(), suite.dal, conf)
+	suite.NoError(err)
+	suite.Error(other.Verify(token, &jwt.RegisteredClaims{}))
+}
@@ -0,0 +1,151 @@
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/utils"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/rs/zerolog"
+)
+
+type SessionStoreType string
+
+const (
+	SESSION_STORE_MEMORY   SessionStoreType = "memory"
+	SESSION_STORE_POSTGRES SessionStoreType = "postgres"
+)
+
+const (
+	// sessionCleanupInterval is how often expired sessions are dropped from the postgres store
+	sessionCleanupInterval = time.Minute
+	// sessionCleanupBatchSize caps the sessions dropped per interval; the reaper catches up on any backlog
+	sessionCleanupBatchSize = 1000
+)
+
+var ErrSessionNotFound = errors.New("webauthn session not found")
+
+// SessionStore keeps the state of unfinished ceremonies between the begin and finish requests.
+// The memory store only works with a single instance; replicas have to share the postgres store.
+type SessionStore interface {
+	Set(string, *webauthn.SessionData) error
+	// Get peeks at a session without consuming it
+	Get(string) (*webauthn.SessionData, error)
+	// GetAndDelete consumes a session, so it can only be redeemed once
+	GetAndDelete(string) (*webauthn.SessionData, error)
+}
+
+type memorySessionStore struct {
+	cache utils.Cache[string, *webauthn.SessionData]
+}
+
+// NewMemorySessionStore keeps sessions in process; expired sessions are cleaned up until ctx is done
+func NewMemorySessionStore(ctx context.Context, ttl time.Duration) SessionStore {
+	return &memorySessionStore{
+		cache: utils.NewCache[string, *webauthn.SessionData](ctx, utils.CacheConfig{
+			TTL:     ttl,
+			MaxSize: sessionCacheMaxSize,
+		}),
+	}
+}
+
+func (m *memorySessionStore) Set(key string, session *webauthn.SessionData) error {
+	m.cache.SetValue(key, session)
+	return nil
+}
+
+func (m *memorySessionStore) Get(key string) (*webauthn.SessionData, error) {
+	session := m.cache.GetValue(key)
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (m *memorySessionStore) GetAndDelete(key string) (*webauthn.SessionData, error) {
+	session, ok := m.cache.GetAndDelete(key)
+	if !ok || session == nil {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+type postgresSessionStore struct {
+	ctx    context.Context
+	logger zerolog.Logger
+	dal    dal.DAL
+	ttl    time.Duration
+}
+
+// NewPostgresSessionStore shares sessions between instances through the webauthn_sessions table;
+// expired sessions are cleaned up until ctx is done
+func NewPostgresSessionStore(ctx context.Context, logger zerolog.Logger, dal dal.DAL, ttl time.Duration) SessionStore {
+	s := &postgresSessionStore{
+		ctx:    ctx,
+		logger: logger.With().Str("component", "WebAuthNSessionStore").Logger(),
+		dal:    dal,
+		ttl:    ttl,
+	}
+	go s.janitor()
+	return s
+}
+
+func (p *postgresSessionStore) Set(key string, session *webauthn.SessionData) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return p.dal.WebauthnSessions(p.ctx).Set(&models.WebauthnSession{
+		Key:       key,
+		Data:      data,
+		ExpiresAt: time.Now().Add(p.ttl),
+	})
+}
+
+func (p *postgresSessionStore) Get(key string) (*webauthn.SessionData, error) {
+	session, err := p.dal.WebauthnSessions(p.ctx).Get(key)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	return decodeSession(session)
+}
+
+func (p *postgresSessionStore) GetAndDelete(key string) (*webauthn.SessionData, error) {
+	session, err := p.dal.WebauthnSessions(p.ctx).GetAndDelete(key)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	return decodeSession(session)
+}
+
+func (p *postgresSessionStore) janitor() {
+	const funcName = "janitor"
+
+	ticker := time.NewTicker(sessionCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := p.dal.WebauthnSessions(p.ctx).DeleteExpired(time.Now(), sessionCleanupBatchSize)
+			if err != nil {
+				p.logger.Err(err).Str("func", funcName).Msg("failed to delete expired webauthn sessions")
+			} else if deleted > 0 {
+				p.logger.Debug().Str("func", funcName).Int64("deleted", deleted).Msg("deleted expired webauthn sessions")
+			}
+		}
+	}
+}
+
+func decodeSession(session *models.WebauthnSession) (*webauthn.SessionData, error) {
+	data := &webauthn.SessionData{}
+	if err := json.Unmarshal(session.Data, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,133 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/mocks"
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type SessionStoreTestSuite struct {
+	suite.Suite
+
+	Ctrl   *gomock.Controller
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	dal         *mocks.MockDAL
+	sessionsDal *mocks.MockWebauthnSessionsDAL
+}
+
+func TestSessionStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionStoreTestSuite))
+}
+
+func (suite *SessionStoreTestSuite) SetupTest() {
+	suite.Ctrl = gomock.NewController(suite.T())
+	suite.ctx, suite.cancel = context.WithCancel(context.Background())
+	suite.dal = mocks.NewMockDAL(suite.Ctrl)
+	suite.sessionsDal = mocks.NewMockWebauthnSessionsDAL(suite.Ctrl)
+	suite.dal.EXPECT().WebauthnSessions(gomock.Any()).Return(suite.sessionsDal).AnyTimes()
+}
+func (suite *SessionStoreTestSuite) TearDownTest() {
+	suite.cancel()
+}
+
+func (suite *SessionStoreTestSuite) TestMemoryStore_OK() {
+	store := NewMemorySessionStore(suite.ctx, time.Minute)
+	err := store.Set("key", &webauthn.SessionData{Challenge: "challenge"})
+	suite.NoError(err)
+
+	session, err := store.Get("key")
+	suite.NoError(err)
+	suite.Equal("challenge", session.Challenge)
+
+	session, err = store.GetAndDelete("key")
+	suite.NoError(err)
+	suite.Equal("challenge", session.Challenge)
+
+	_, err = store.GetAndDelete("key")
+	suite.ErrorIs(err, ErrSessionNotFound)
+	_, err = store.Get("key")
+	suite.ErrorIs(err, ErrSessionNotFound)
+}
+
+func (suite *SessionStoreTestSuite) TestPostgresStore_OK() {
+	store := NewPostgresSessionStore(suite.ctx, zerolog.Nop(), suite.dal, time.Minute)
+
+	var stored *models.WebauthnSession
+	suite.sessionsDal.EXPECT().Set(gomock.Any()).DoAndReturn(func(session *models.WebauthnSession) error {
+		stored = session
+		return nil
+	}).Times(1)
+	err := store.Set("key", &webauthn.SessionData{Challenge: "challenge", UserID: []byte("user")})
+	suite.NoError(err)
+	suite.Equal("key", stored.Key)
+	suite.WithinDuration(time.Now().Add(time.Minute), stored.ExpiresAt, time.Second)
+
+	suite.sessionsDal.EXPECT().Get("key").Return(stored, nil).Times(1)
+	session, err := store.Get("key")
+	suite.NoError(err)
+	suite.Equal("challenge", session.Challenge)
+
+	suite.sessionsDal.EXPECT().GetAndDelete("key").Return(stored, nil).Times(1)
+	session, err = store.GetAndDelete("key")
+	suite.NoError(err)
+	suite.Equal("challenge", session.Challenge)
+	suite.Equal([]byte("user"), session.UserID)
+}
+
+func (suite *SessionStoreTestSuite) TestPostgresStore_FailNotFound() {
+	store := NewPostgresSessionStore(suite.ctx, zerolog.Nop(), suite.dal, time.Minute)
+
+	suite.sessionsDal.EXPECT().Get("key").Return(nil, pgx.ErrNoRows).Times(1)
+	_, err := store.Get("key")
+	suite.ErrorIs(err, ErrSessionNotFound)
+
+	suite.sessionsDal.EXPECT().GetAndDelete("key").Return(nil, errors.New("webauthn session expired")).Times(1)
+	_, err = store.GetAndDelete("key")
+	suite.ErrorIs(err, ErrSessionNotFound)
+}
+
+// a ceremony begun on one instance can be finished on another sharing the store
+func (suite *SessionStoreTestSuite) TestPostgresStore_SharedBetweenInstances() {
+	rows := map[string]*models.WebauthnSession{}
+	suite.sessionsDal.EXPECT().Set(gomock.Any()).DoAndReturn(func(session *models.WebauthnSession) error {
+		rows[session.Key] = session
+		return nil
+	}).AnyTimes()
+	suite.sessionsDal.EXPECT().GetAndDelete(gomock.Any()).DoAndReturn(func(key string) (*models.WebauthnSession, error) {
+		session, ok := rows[key]
+		if !ok {
+			return nil, pgx.ErrNoRows
+		}
+		delete(rows, key)
+		return session, nil
+	}).AnyTimes()
+
+	conf := &webauthn.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}
+	first, err := NewWebAuthN(suite.ctx, conf, NewPostgresSessionStore(suite.ctx, zerolog.Nop(), suite.dal, SessionTTL(conf)), zerolog.Nop())
+	suite.NoError(err)
+	second, err := NewWebAuthN(suite.ctx, conf, NewPostgresSessionStore(suite.ctx, zerolog.Nop(), suite.dal, SessionTTL(conf)), zerolog.Nop())
+	suite.NoError(err)
+
+	_, sessionKey, err := first.BeginDiscoverableLogin()
+	suite.NoError(err)
+	suite.Contains(rows, sessionKey)
+
+	session, err := second.(*webAuthN).store.GetAndDelete(sessionKey)
+	suite.NoError(err)
+	suite.NotEmpty(session.Challenge)
+}
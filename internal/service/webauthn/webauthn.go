@@ -1,13 +1,14 @@
 package webauthn
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
+	"time"
 
 	"github.com/asatraitis/mangrove/internal/dal/models"
 	"github.com/asatraitis/mangrove/internal/dto"
 	"github.com/asatraitis/mangrove/internal/typeconv"
-	"github.com/asatraitis/mangrove/internal/utils"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
@@ -34,21 +35,40 @@ func (wau *WebAuthNUser) WebAuthnCredentials() []webauthn.Credential {
 	return wau.Credentials
 }
 
+// UserResolver looks up the user of a discoverable login from the credential ID and the userHandle
+// returned by the authenticator
+type UserResolver func(credentialID []byte, userHandle []byte) (*models.User, error)
+
 type WebAuthN interface {
-	BeginRegistration() (*protocol.CredentialCreation, error)
+	BeginRegistration(*models.User) (*protocol.CredentialCreation, error)
 	FinishRegistration(string, *protocol.CredentialCreationResponse) (*webauthn.Credential, error)
 	BeginLogin(*models.User, []webauthn.Credential) (*protocol.CredentialAssertion, string, error)
 	FinishLogin(*dto.FinishLoginRequest, *models.User) (*webauthn.Credential, error)
+	BeginDiscoverableLogin() (*protocol.CredentialAssertion, string, error)
+	FinishDiscoverableLogin(*dto.FinishLoginRequest, UserResolver) (*webauthn.Credential, *models.User, error)
 	GetSession(key string) *webauthn.SessionData
 }
 type webAuthN struct {
 	logger zerolog.Logger
 	wa     *webauthn.WebAuthn
-	cache  utils.Cache[string, *webauthn.SessionData]
+	store  SessionStore
 }
 
-// TODO: Need to add cache expiration logic
-func NewWebAuthN(conf *webauthn.Config, logger zerolog.Logger) (WebAuthN, error) {
+const (
+	// sessionCacheTTL bounds how long an unfinished ceremony is kept; longer ceremony timeouts extend it
+	sessionCacheTTL = time.Minute * 5
+	// sessionCacheMaxSize caps the ceremonies in flight; the least recently used are dropped first
+	sessionCacheMaxSize = 10000
+)
+
+// SessionTTL is how long a ceremony session is kept for the given config
+func SessionTTL(conf *webauthn.Config) time.Duration {
+	return max(sessionCacheTTL, conf.Timeouts.Login.Timeout, conf.Timeouts.Registration.Timeout)
+}
+
+// NewWebAuthN creates the service; without a store, ceremony sessions are kept in memory, where they
+// expire and are cleaned up until ctx is done
+func NewWebAuthN(ctx context.Context, conf *webauthn.Config, store SessionStore, logger zerolog.Logger) (WebAuthN, error) {
 	logger = logger.With().Str("component", "WebAuthN").Logger()
 
 	wa, err := webauthn.New(conf)
@@ -57,31 +77,59 @@ func NewWebAuthN(conf *webauthn.Config, logger zerolog.Logger) (WebAuthN, error)
 		return nil, err
 	}
 
+	if store == nil {
+		store = NewMemorySessionStore(ctx, SessionTTL(conf))
+	}
+
 	return &webAuthN{
 		logger: logger,
 		wa:     wa,
-		cache:  utils.NewCache[string, *webauthn.SessionData](),
+		store:  store,
 	}, nil
 }
 
-func (w *webAuthN) BeginRegistration() (*protocol.CredentialCreation, error) {
-	id, err := uuid.NewV7()
-	if err != nil {
-		// TODO: add logging
-		return nil, err
+// BeginRegistration starts a passkey registration for the user; a nil user (or one without an ID)
+// gets a new ID, which FinishRegistration later receives back as the user handle. The user's existing
+// credentials are sent as excludeCredentials so an authenticator cannot be enrolled twice.
+func (w *webAuthN) BeginRegistration(user *models.User) (*protocol.CredentialCreation, error) {
+	const funcName = "BeginRegistration"
+
+	newUser := &WebAuthNUser{}
+	var exclusions []protocol.CredentialDescriptor
+	if user != nil {
+		newUser.ID = user.ID
+		newUser.Name = user.Username
+		newUser.DisplayName = user.DisplayName
+		for _, credential := range user.Credentials {
+			waCredential, err := typeconv.ConvertUserCredentialToWebauthnCredential(credential)
+			if err != nil {
+				w.logger.Err(err).Str("func", funcName).Msg("failed to typeconv model.credential to webauthn.credential")
+				return nil, err
+			}
+			exclusions = append(exclusions, waCredential.Descriptor())
+		}
 	}
-
-	newUser := &WebAuthNUser{
-		ID: id,
+	if newUser.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			// TODO: add logging
+			return nil, err
+		}
+		newUser.ID = id
 	}
 
-	opts, session, err := w.wa.BeginRegistration(newUser)
+	// discoverable (resident) credentials are what make usernameless login possible
+	opts, session, err := w.wa.BeginRegistration(newUser, webauthn.WithExclusions(exclusions), webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired))
 	if err != nil {
 		// TODO: add logging
 		return nil, err
 	}
 
-	w.cache.SetValue(newUser.ID.String(), session)
+	err = w.store.Set(newUser.ID.String(), session)
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to store registration session")
+		return nil, err
+	}
 
 	return opts, nil
 }
@@ -101,11 +149,10 @@ func (w *webAuthN) FinishRegistration(userID string, credResp *protocol.Credenti
 		return nil, err
 	}
 
-	userSession := w.cache.GetValue(string(bUserID))
-	if userSession == nil {
-		err = errors.New("failed to get user session")
-		w.logger.Err(err).Str("func", funcName).Msg("failed to get user session from cache")
-		return nil, err
+	userSession, err := w.store.GetAndDelete(string(bUserID))
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to get user session from store")
+		return nil, errors.New("failed to get user session")
 	}
 
 	parsedUserID, err := uuid.Parse(string(bUserID))
@@ -147,55 +194,44 @@ func (w *webAuthN) BeginLogin(user *models.User, creds []webauthn.Credential) (*
 	if err != nil {
 		return nil, "", err
 	}
-	w.cache.SetValue(cacheKey.String(), session)
+	if err = w.store.Set(cacheKey.String(), session); err != nil {
+		w.logger.Err(err).Str("func", "BeginLogin").Msg("failed to store login session")
+		return nil, "", err
+	}
 	return opts, cacheKey.String(), nil
 }
 
+// GetSession peeks at a login session without consuming it; FinishLogin and FinishDiscoverableLogin consume it
 func (w *webAuthN) GetSession(key string) *webauthn.SessionData {
-	return w.cache.GetValue(key)
+	session, err := w.store.Get(key)
+	if err != nil {
+		return nil
+	}
+	return session
 }
 
-// TODO: implement usernameless login
 func (w *webAuthN) FinishLogin(req *dto.FinishLoginRequest, user *models.User) (*webauthn.Credential, error) {
 	const funcName = "FinishLogin"
 
-	if req.SessionKey == "" {
-		err := errors.New("could not find session in cache")
+	userSession, err := w.getLoginSession(req)
+	if err != nil {
 		w.logger.Err(err).Str("func", funcName).Msg("failed to get user session")
 		return nil, err
 	}
 
-	userSession := w.cache.GetValue(req.SessionKey)
-	if userSession == nil {
-		err := errors.New("could not find session in cache")
-		w.logger.Err(err).Str("func", funcName).Msg("failed to get user session")
+	waUser, err := newWebAuthNUser(user)
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to typeconv model.credential to webauthn.credential")
 		return nil, err
 	}
 
-	var credentials []webauthn.Credential
-	for _, credential := range user.Credentials {
-		waCredential, err := typeconv.ConvertUserCredentialToWebauthnCredential(credential)
-		if err != nil {
-			w.logger.Err(err).Str("func", funcName).Msg("failed to typeconv model.credential to webauthn.credential")
-			return nil, err
-		}
-		credentials = append(credentials, *waCredential)
-	}
-
-	waUser := WebAuthNUser{
-		ID:          user.ID,
-		Name:        user.Username,
-		DisplayName: user.DisplayName,
-		Credentials: credentials,
-	}
-
 	parsedCred, err := req.Credential.Parse()
 	if err != nil {
 		w.logger.Err(err).Str("func", funcName).Msg("failed to parse credentials")
 		return nil, err
 	}
 
-	credential, err := w.wa.ValidateLogin(&waUser, *userSession, parsedCred)
+	credential, err := w.wa.ValidateLogin(waUser, *userSession, parsedCred)
 	if err != nil {
 		w.logger.Err(err).Str("func", funcName).Msg("failed to validate login")
 		return nil, err
@@ -209,3 +245,98 @@ func (w *webAuthN) FinishLogin(req *dto.FinishLoginRequest, user *models.User) (
 
 	return credential, nil
 }
+
+// BeginDiscoverableLogin starts a usernameless login; allowCredentials is left empty so the browser
+// offers every passkey it holds for the RP
+func (w *webAuthN) BeginDiscoverableLogin() (*protocol.CredentialAssertion, string, error) {
+	const funcName = "BeginDiscoverableLogin"
+
+	cacheKey, err := uuid.NewV7()
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to generate session key")
+		return nil, "", err
+	}
+	opts, session, err := w.wa.BeginDiscoverableLogin()
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to begin discoverable login")
+		return nil, "", err
+	}
+	if err = w.store.Set(cacheKey.String(), session); err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to store login session")
+		return nil, "", err
+	}
+	return opts, cacheKey.String(), nil
+}
+
+// FinishDiscoverableLogin validates a usernameless login. The user is not known up front; resolve
+// receives the credential ID and the userHandle from the assertion and returns the user owning both.
+func (w *webAuthN) FinishDiscoverableLogin(req *dto.FinishLoginRequest, resolve UserResolver) (*webauthn.Credential, *models.User, error) {
+	const funcName = "FinishDiscoverableLogin"
+
+	userSession, err := w.getLoginSession(req)
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to get user session")
+		return nil, nil, err
+	}
+
+	parsedCred, err := req.Credential.Parse()
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to parse credentials")
+		return nil, nil, err
+	}
+
+	var user *models.User
+	credential, err := w.wa.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		resolved, err := resolve(rawID, userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user = resolved
+		return newWebAuthNUser(resolved)
+	}, *userSession, parsedCred)
+	if err != nil {
+		w.logger.Err(err).Str("func", funcName).Msg("failed to validate discoverable login")
+		return nil, nil, err
+	}
+
+	if credential.Authenticator.CloneWarning {
+		err := errors.New("cloned key error")
+		w.logger.Err(err).Str("func", funcName).Msg("authenticator clone warning")
+		return nil, nil, err
+	}
+
+	return credential, user, nil
+}
+
+func (w *webAuthN) getLoginSession(req *dto.FinishLoginRequest) (*webauthn.SessionData, error) {
+	if req.SessionKey == "" {
+		return nil, errors.New("could not find session in cache")
+	}
+	// sessions are single use; a replayed or failed assertion has to start over
+	userSession, err := w.store.GetAndDelete(req.SessionKey)
+	if err != nil {
+		return nil, errors.New("could not find session in cache")
+	}
+	return userSession, nil
+}
+
+// newWebAuthNUser wraps the user and its stored credentials for the webauthn library
+func newWebAuthNUser(user *models.User) (*WebAuthNUser, error) {
+	if user == nil {
+		return nil, errors.New("missing user")
+	}
+	var credentials []webauthn.Credential
+	for _, credential := range user.Credentials {
+		waCredential, err := typeconv.ConvertUserCredentialToWebauthnCredential(credential)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, *waCredential)
+	}
+	return &WebAuthNUser{
+		ID:          user.ID,
+		Name:        user.Username,
+		DisplayName: user.DisplayName,
+		Credentials: credentials,
+	}, nil
+}
@@ -1,8 +1,11 @@
 package webauthn
 
 import (
+	"context"
 	"testing"
 
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/suite"
@@ -23,11 +26,63 @@ func (suite *WebAuthNTestSuite) SetupSuite() {
 }
 
 func (suite *WebAuthNTestSuite) TestNewWebAuthN() {
-	wa, err := NewWebAuthN(&webauthn.Config{
+	wa, err := NewWebAuthN(context.Background(), &webauthn.Config{
 		RPDisplayName: "Mangrove",
 		RPID:          "localhost",
 		RPOrigins:     []string{"http://localhost:3030"},
-	}, suite.logger)
+	}, nil, suite.logger)
 	suite.NoError(err)
 	suite.NotNil(wa)
 }
+
+func (suite *WebAuthNTestSuite) TestBeginRegistration_RequiresResidentKey() {
+	wa, err := NewWebAuthN(context.Background(), &webauthn.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, suite.logger)
+	suite.NoError(err)
+
+	creds, err := wa.BeginRegistration(nil)
+	suite.NoError(err)
+	suite.Equal(protocol.ResidentKeyRequirementRequired, creds.Response.AuthenticatorSelection.ResidentKey)
+	suite.True(*creds.Response.AuthenticatorSelection.RequireResidentKey)
+}
+
+func (suite *WebAuthNTestSuite) TestBeginDiscoverableLogin() {
+	wa, err := NewWebAuthN(context.Background(), &webauthn.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, suite.logger)
+	suite.NoError(err)
+
+	opts, sessionKey, err := wa.BeginDiscoverableLogin()
+	suite.NoError(err)
+	suite.Empty(opts.Response.AllowedCredentials)
+
+	// the session is not bound to a user; the user comes from the assertion's userHandle
+	session := wa.GetSession(sessionKey)
+	suite.NotNil(session)
+	suite.Empty(session.UserID)
+}
+
+func (suite *WebAuthNTestSuite) TestFinishLogin_SessionIsSingleUse() {
+	wa, err := NewWebAuthN(context.Background(), &webauthn.Config{
+		RPDisplayName: "Mangrove",
+		RPID:          "localhost",
+		RPOrigins:     []string{"http://localhost:3030"},
+	}, nil, suite.logger)
+	suite.NoError(err)
+
+	_, sessionKey, err := wa.BeginDiscoverableLogin()
+	suite.NoError(err)
+
+	// a failed assertion still burns the session
+	_, _, err = wa.FinishDiscoverableLogin(&dto.FinishLoginRequest{SessionKey: sessionKey}, nil)
+	suite.Error(err)
+	suite.Nil(wa.GetSession(sessionKey))
+
+	_, _, err = wa.FinishDiscoverableLogin(&dto.FinishLoginRequest{SessionKey: sessionKey}, nil)
+	suite.ErrorContains(err, "could not find session in cache")
+}
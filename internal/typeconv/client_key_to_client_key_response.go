@@ -0,0 +1,42 @@
+package typeconv
+
+import (
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+func ConvertClientKeyToCreateClientKeyResponse(key *models.ClientKey) (*dto.CreateClientKeyResponse, error) {
+	if key == nil {
+		return nil, errors.New("client key is nil")
+	}
+	res := dto.CreateClientKeyResponse(convertClientKey(key))
+	return &res, nil
+}
+
+func ConvertClientKeysToClientKeysResponse(keys []*models.ClientKey) (dto.ClientKeysResponse, error) {
+	if keys == nil {
+		return nil, errors.New("client keys is nil")
+	}
+
+	clientKeys := dto.ClientKeysResponse{}
+	for _, key := range keys {
+		clientKeys = append(clientKeys, convertClientKey(key))
+	}
+	return clientKeys, nil
+}
+
+func convertClientKey(key *models.ClientKey) dto.ClientKey {
+	return dto.ClientKey{
+		ID:        key.ID.String(),
+		ClientID:  key.ClientID.String(),
+		KID:       key.KID,
+		Algorithm: dto.UserClientKeyAlgo(key.Algorithm),
+		NotBefore: key.NotBefore,
+		ExpiresAt: key.ExpiresAt,
+		Revoked:   key.Revoked,
+		RevokedAt: key.RevokedAt,
+		CreatedAt: key.CreatedAt,
+	}
+}
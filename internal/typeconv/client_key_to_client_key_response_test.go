@@ -0,0 +1,74 @@
+package typeconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertClientKeysToClientKeysResponse_OK(t *testing.T) {
+	now := time.Now()
+	keys := []*models.ClientKey{
+		{
+			ID:        uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95510"),
+			ClientID:  uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+			KID:       "test-kid-0",
+			Algorithm: models.CLIENT_KEY_ALGO_EDDSA,
+			PublicKey: []byte("test-public-key-0"),
+			NotBefore: now,
+			ExpiresAt: now.Add(time.Hour),
+			CreatedAt: now,
+		},
+		{
+			ID:        uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95511"),
+			ClientID:  uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+			KID:       "test-kid-1",
+			Algorithm: models.CLIENT_KEY_ALGO_ES256,
+			PublicKey: []byte("test-public-key-1"),
+			NotBefore: now,
+			ExpiresAt: now.Add(time.Hour),
+			Revoked:   true,
+			RevokedAt: &now,
+			CreatedAt: now,
+		},
+	}
+
+	res, err := ConvertClientKeysToClientKeysResponse(keys)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95510", res[0].ID)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95508", res[0].ClientID)
+	assert.Equal(t, "test-kid-0", res[0].KID)
+	assert.Equal(t, dto.CLIENT_KEY_ALGO_EDDSA, res[0].Algorithm)
+	assert.Equal(t, now.Add(time.Hour), res[0].ExpiresAt)
+	assert.False(t, res[0].Revoked)
+	assert.Nil(t, res[0].RevokedAt)
+	assert.Equal(t, dto.CLIENT_KEY_ALGO_ES256, res[1].Algorithm)
+	assert.True(t, res[1].Revoked)
+	assert.Equal(t, &now, res[1].RevokedAt)
+}
+
+func TestConvertClientKeysToClientKeysResponse_FAIL_Nil(t *testing.T) {
+	_, err := ConvertClientKeysToClientKeysResponse(nil)
+	assert.ErrorContains(t, err, "client keys is nil")
+}
+
+func TestConvertClientKeyToCreateClientKeyResponse_OK(t *testing.T) {
+	key := &models.ClientKey{
+		ID:        uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95510"),
+		ClientID:  uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+		KID:       "test-kid",
+		Algorithm: models.CLIENT_KEY_ALGO_EDDSA,
+	}
+	res, err := ConvertClientKeyToCreateClientKeyResponse(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-kid", res.KID)
+
+	_, err = ConvertClientKeyToCreateClientKeyResponse(nil)
+	assert.Error(t, err)
+}
@@ -12,11 +12,17 @@ func ConvertClientToCreateClientResponse(client *models.Client) (*dto.CreateClie
 		return nil, errors.New("client is nil")
 	}
 	return &dto.CreateClientResponse{
-		ID:          client.ID.String(),
-		UserID:      client.UserID.String(),
-		Name:        client.Name,
-		Description: client.Description,
-		RedirectURI: client.RedirectURI,
-		Status:      dto.UserClientStatus(client.Status),
+		ID:                      client.ID.String(),
+		UserID:                  client.UserID.String(),
+		Name:                    client.Name,
+		Description:             client.Description,
+		RedirectURIs:            client.RedirectURIs,
+		PostLogoutRedirectURIs:  client.PostLogoutRedirectURIs,
+		WebOrigins:              client.WebOrigins,
+		JWKSURI:                 client.JWKSURI,
+		Status:                  dto.UserClientStatus(client.Status),
+		AccessTokenLifetime:     client.AccessTokenLifetime,
+		RefreshTokenLifetime:    client.RefreshTokenLifetime,
+		RefreshTokenMaxLifetime: client.RefreshTokenMaxLifetime,
 	}, nil
 }
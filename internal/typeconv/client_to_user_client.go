@@ -7,6 +7,28 @@ import (
 	"github.com/asatraitis/mangrove/internal/dto"
 )
 
+func ConvertClientToUserClient(client *models.Client) (*dto.UserClient, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	return &dto.UserClient{
+		ID:                      client.ID.String(),
+		UserID:                  client.UserID.String(),
+		Name:                    client.Name,
+		Description:             client.Description,
+		RedirectURIs:            client.RedirectURIs,
+		PostLogoutRedirectURIs:  client.PostLogoutRedirectURIs,
+		WebOrigins:              client.WebOrigins,
+		JWKSURI:                 client.JWKSURI,
+		Status:                  dto.UserClientStatus(client.Status),
+		StatusReason:            client.StatusReason,
+		StatusChangedAt:         client.StatusChangedAt,
+		AccessTokenLifetime:     client.AccessTokenLifetime,
+		RefreshTokenLifetime:    client.RefreshTokenLifetime,
+		RefreshTokenMaxLifetime: client.RefreshTokenMaxLifetime,
+	}, nil
+}
+
 func ConvertClientsToUserClientsResponse(clients []*models.Client) ([]dto.UserClient, error) {
 	if clients == nil {
 		return nil, errors.New("client is nil")
@@ -14,14 +36,11 @@ func ConvertClientsToUserClientsResponse(clients []*models.Client) ([]dto.UserCl
 
 	var userClients dto.UserClientsResponse
 	for _, client := range clients {
-		userClients = append(userClients, dto.UserClient{
-			ID:          client.ID.String(),
-			UserID:      client.UserID.String(),
-			Name:        client.Name,
-			Description: client.Description,
-			RedirectURI: client.RedirectURI,
-			Status:      dto.UserClientStatus(client.Status),
-		})
+		userClient, err := ConvertClientToUserClient(client)
+		if err != nil {
+			return nil, err
+		}
+		userClients = append(userClients, *userClient)
 	}
 
 	return userClients, nil
@@ -11,17 +11,13 @@ import (
 )
 
 func TestConvertClientsToUserClientsResponse_OK(t *testing.T) {
-	now := time.Now()
 	clients := []*models.Client{
 		{
 			ID:           uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
 			UserID:       uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
 			Name:         "test-client-name-0",
 			Description:  "test-client-description-0",
-			RedirectURI:  "http://localhost:3030",
-			PublicKey:    []byte("test-public-key-0"),
-			KeyExpiresAt: now,
-			KeyAlgo:      models.CLIENT_KEY_ALGO_EDDSA,
+			RedirectURIs: []string{"http://localhost:3030"},
 			Status:       models.CLIENT_STATUS_ACTIVE,
 		},
 		{
@@ -29,10 +25,8 @@ func TestConvertClientsToUserClientsResponse_OK(t *testing.T) {
 			UserID:       uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
 			Name:         "test-client-name-1",
 			Description:  "test-client-description-1",
-			RedirectURI:  "http://localhost:3031",
-			PublicKey:    []byte("test-public-key-1"),
-			KeyExpiresAt: now,
-			KeyAlgo:      models.CLIENT_KEY_ALGO_EDDSA,
+			RedirectURIs: []string{"http://localhost:3031"},
+			JWKSURI:      "https://localhost:3031/jwks.json",
 			Status:       models.CLIENT_STATUS_ACTIVE,
 		},
 	}
@@ -49,8 +43,10 @@ func TestConvertClientsToUserClientsResponse_OK(t *testing.T) {
 	assert.Equal(t, "test-client-name-1", userClients[1].Name)
 	assert.Equal(t, "test-client-description-0", userClients[0].Description)
 	assert.Equal(t, "test-client-description-1", userClients[1].Description)
-	assert.Equal(t, "http://localhost:3030", userClients[0].RedirectURI)
-	assert.Equal(t, "http://localhost:3031", userClients[1].RedirectURI)
+	assert.Equal(t, []string{"http://localhost:3030"}, userClients[0].RedirectURIs)
+	assert.Equal(t, []string{"http://localhost:3031"}, userClients[1].RedirectURIs)
+	assert.Empty(t, userClients[0].JWKSURI)
+	assert.Equal(t, "https://localhost:3031/jwks.json", userClients[1].JWKSURI)
 	assert.Equal(t, dto.UserClientStatus("active"), userClients[0].Status)
 	assert.Equal(t, dto.UserClientStatus("active"), userClients[1].Status)
 }
@@ -60,3 +56,36 @@ func TestConvertClientsToUserClientsResponse_FAIL_NilUsers(t *testing.T) {
 	assert.Error(t, err)
 	assert.ErrorContains(t, err, "client is nil")
 }
+
+func TestConvertClientToUserClient_OK(t *testing.T) {
+	changedAt := time.Now()
+	client := &models.Client{
+		ID:                      uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+		UserID:                  uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+		Name:                    "test-client-name",
+		RedirectURIs:            []string{"http://localhost:3030", "http://127.0.0.1/callback"},
+		PostLogoutRedirectURIs:  []string{"http://localhost:3030/logout"},
+		WebOrigins:              []string{"http://localhost:3030"},
+		Status:                  models.CLIENT_STATUS_SUSPENDED,
+		StatusReason:            "test-reason",
+		StatusChangedAt:         &changedAt,
+		AccessTokenLifetime:     900,
+		RefreshTokenLifetime:    86400,
+		RefreshTokenMaxLifetime: 604800,
+	}
+	userClient, err := ConvertClientToUserClient(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95508", userClient.ID)
+	assert.Equal(t, client.RedirectURIs, userClient.RedirectURIs)
+	assert.Equal(t, client.PostLogoutRedirectURIs, userClient.PostLogoutRedirectURIs)
+	assert.Equal(t, client.WebOrigins, userClient.WebOrigins)
+	assert.Equal(t, dto.UserClientStatus("suspended"), userClient.Status)
+	assert.Equal(t, "test-reason", userClient.StatusReason)
+	assert.Equal(t, &changedAt, userClient.StatusChangedAt)
+	assert.Equal(t, 900, userClient.AccessTokenLifetime)
+	assert.Equal(t, 86400, userClient.RefreshTokenLifetime)
+	assert.Equal(t, 604800, userClient.RefreshTokenMaxLifetime)
+
+	_, err = ConvertClientToUserClient(nil)
+	assert.Error(t, err)
+}
@@ -12,12 +12,23 @@ func ConvertCreateClientRequestToClient(createUserReq *dto.CreateClientRequest)
 		return nil, errors.New("createUserReq is nil")
 	}
 
-	return &models.Client{
-		Name:        createUserReq.Name,
-		Description: createUserReq.Description,
-		RedirectURI: createUserReq.RedirectURI,
-		Status:      models.ClientStatus(createUserReq.Status),
-		PublicKey:   createUserReq.PublicKey,
-		KeyAlgo:     models.ClientKeyAlgo(createUserReq.KeyAlgo),
-	}, nil
+	client := &models.Client{
+		Name:                   createUserReq.Name,
+		Description:            createUserReq.Description,
+		RedirectURIs:           createUserReq.RedirectURIs,
+		PostLogoutRedirectURIs: createUserReq.PostLogoutRedirectURIs,
+		WebOrigins:             createUserReq.WebOrigins,
+		JWKSURI:                createUserReq.JWKSURI,
+		Status:                 models.ClientStatus(createUserReq.Status),
+	}
+	if createUserReq.AccessTokenLifetime != nil {
+		client.AccessTokenLifetime = *createUserReq.AccessTokenLifetime
+	}
+	if createUserReq.RefreshTokenLifetime != nil {
+		client.RefreshTokenLifetime = *createUserReq.RefreshTokenLifetime
+	}
+	if createUserReq.RefreshTokenMaxLifetime != nil {
+		client.RefreshTokenMaxLifetime = *createUserReq.RefreshTokenMaxLifetime
+	}
+	return client, nil
 }
@@ -9,13 +9,14 @@ import (
 )
 
 func TestConvertCreateClientRequestToClient_OK(t *testing.T) {
+	accessTokenLifetime := 900
 	clientReq := dto.CreateClientRequest{
-		Name:        "test-name",
-		Description: "test-desc",
-		RedirectURI: "http://test.com",
-		Status:      dto.UserClientStatus("active"),
-		PublicKey:   []byte("pub_key"),
-		KeyAlgo:     dto.UserClientKeyAlgo("EdDSA"),
+		Name:                "test-name",
+		Description:         "test-desc",
+		RedirectURIs:        []string{"https://test.com/callback"},
+		Status:              dto.UserClientStatus("active"),
+		JWKSURI:             "https://test.com/jwks.json",
+		AccessTokenLifetime: &accessTokenLifetime,
 	}
 	client, err := ConvertCreateClientRequestToClient(&clientReq)
 	assert.NoError(t, err)
@@ -23,8 +24,10 @@ func TestConvertCreateClientRequestToClient_OK(t *testing.T) {
 
 	assert.Equal(t, clientReq.Name, client.Name)
 	assert.Equal(t, clientReq.Description, client.Description)
-	assert.Equal(t, clientReq.RedirectURI, client.RedirectURI)
+	assert.Equal(t, clientReq.RedirectURIs, client.RedirectURIs)
 	assert.Equal(t, models.ClientStatus(clientReq.Status), client.Status)
-	assert.Equal(t, clientReq.PublicKey, client.PublicKey)
-	assert.Equal(t, models.ClientKeyAlgo(clientReq.KeyAlgo), client.KeyAlgo)
+	assert.Equal(t, clientReq.JWKSURI, client.JWKSURI)
+	assert.Equal(t, 900, client.AccessTokenLifetime)
+	// omitted lifetimes are defaulted by the BLL
+	assert.Equal(t, 0, client.RefreshTokenLifetime)
 }
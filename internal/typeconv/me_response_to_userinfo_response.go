@@ -0,0 +1,28 @@
+package typeconv
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+// ConvertMeResponseToUserInfoResponse only releases the claims covered by the granted scopes
+func ConvertMeResponseToUserInfoResponse(me *dto.MeResponse, scopes []string) (*dto.UserInfoResponse, error) {
+	if me == nil {
+		return nil, errors.New("me response is nil")
+	}
+
+	userInfo := &dto.UserInfoResponse{
+		Sub: me.ID,
+	}
+	if slices.Contains(scopes, "profile") {
+		userInfo.PreferredUsername = me.Username
+		userInfo.Name = me.DisplayName
+	}
+	if slices.Contains(scopes, "email") {
+		userInfo.Email = me.Email
+	}
+
+	return userInfo, nil
+}
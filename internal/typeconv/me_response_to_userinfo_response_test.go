@@ -0,0 +1,39 @@
+package typeconv
+
+import (
+	"testing"
+
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMeResponseToUserInfoResponse_OK(t *testing.T) {
+	email := "test@email.com"
+	me := &dto.MeResponse{
+		ID:          "0bdd05ec-8008-4869-b6ec-6d812ce95507",
+		Username:    "test-user",
+		DisplayName: "test-display",
+		Email:       &email,
+		Role:        dto.USER_ROLE_USER,
+		Status:      dto.USER_STATUS_ACTIVE,
+	}
+
+	userInfo, err := ConvertMeResponseToUserInfoResponse(me, []string{"openid", "profile", "email"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95507", userInfo.Sub)
+	assert.Equal(t, "test-user", userInfo.PreferredUsername)
+	assert.Equal(t, "test-display", userInfo.Name)
+	assert.Equal(t, &email, userInfo.Email)
+
+	userInfo, err = ConvertMeResponseToUserInfoResponse(me, []string{"openid"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95507", userInfo.Sub)
+	assert.Empty(t, userInfo.PreferredUsername)
+	assert.Empty(t, userInfo.Name)
+	assert.Nil(t, userInfo.Email)
+}
+
+func TestConvertMeResponseToUserInfoResponse_FAIL_Nil(t *testing.T) {
+	_, err := ConvertMeResponseToUserInfoResponse(nil, []string{"openid"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,28 @@
+package typeconv
+
+import (
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+func ConvertRoleToRole(role *models.Role) (*dto.Role, error) {
+	if role == nil {
+		return nil, errors.New("role is nil")
+	}
+	permissions := []dto.Permission{}
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, dto.Permission(permission))
+	}
+	res := &dto.Role{
+		Name:        dto.UserRole(role.Name),
+		Description: role.Description,
+		Permissions: permissions,
+	}
+	if !role.CreatedAt.IsZero() {
+		createdAt := role.CreatedAt
+		res.CreatedAt = &createdAt
+	}
+	return res, nil
+}
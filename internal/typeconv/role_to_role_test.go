@@ -0,0 +1,37 @@
+package typeconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertRoleToRole_OK(t *testing.T) {
+	now := time.Now()
+	res, err := ConvertRoleToRole(&models.Role{
+		Name:        "auditor",
+		Description: "Reads clients",
+		Permissions: []models.Permission{models.PERMISSION_CLIENTS_READ},
+		CreatedAt:   now,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, dto.UserRole("auditor"), res.Name)
+	assert.Equal(t, "Reads clients", res.Description)
+	assert.Equal(t, []dto.Permission{dto.PERMISSION_CLIENTS_READ}, res.Permissions)
+	assert.Equal(t, &now, res.CreatedAt)
+	assert.False(t, res.BuiltIn)
+
+	// built-in roles have no creation time and an empty permission list rather than null
+	res, err = ConvertRoleToRole(&models.Role{Name: models.USER_ROLE_USER})
+	assert.NoError(t, err)
+	assert.Nil(t, res.CreatedAt)
+	assert.NotNil(t, res.Permissions)
+}
+
+func TestConvertRoleToRole_FAIL_Nil(t *testing.T) {
+	_, err := ConvertRoleToRole(nil)
+	assert.ErrorContains(t, err, "role is nil")
+}
@@ -0,0 +1,43 @@
+package typeconv
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+func ConvertUserCredentialToPasskey(credential *models.UserCredential) (*dto.Passkey, error) {
+	if credential == nil {
+		return nil, errors.New("credential is nil")
+	}
+	transport := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transport = append(transport, string(t))
+	}
+	return &dto.Passkey{
+		ID:         base64.RawURLEncoding.EncodeToString(credential.ID),
+		Name:       credential.Name,
+		Transport:  transport,
+		Attachment: string(credential.AuthAttachment),
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}, nil
+}
+
+func ConvertUserCredentialsToPasskeysResponse(credentials []*models.UserCredential) (dto.PasskeysResponse, error) {
+	if credentials == nil {
+		return nil, errors.New("credentials is nil")
+	}
+
+	res := dto.PasskeysResponse{}
+	for _, credential := range credentials {
+		passkey, err := ConvertUserCredentialToPasskey(credential)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *passkey)
+	}
+	return res, nil
+}
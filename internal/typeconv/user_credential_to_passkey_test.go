@@ -0,0 +1,53 @@
+package typeconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertUserCredentialsToPasskeysResponse(t *testing.T) {
+	now := time.Now()
+	userID := uuid.New()
+	credentials := []*models.UserCredential{
+		{
+			ID:             []byte{0xfb, 0xff, 0x01},
+			UserID:         userID,
+			Transport:      []protocol.AuthenticatorTransport{protocol.USB, protocol.NFC},
+			AuthAttachment: protocol.CrossPlatform,
+			Name:           "YubiKey",
+			CreatedAt:      now,
+			LastUsedAt:     &now,
+		},
+		{
+			ID:        []byte("second"),
+			UserID:    userID,
+			Transport: []protocol.AuthenticatorTransport{},
+			Name:      models.USER_CREDENTIAL_DEFAULT_NAME,
+			CreatedAt: now,
+		},
+	}
+
+	res, err := ConvertUserCredentialsToPasskeysResponse(credentials)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "-_8B", res[0].ID)
+	assert.Equal(t, "YubiKey", res[0].Name)
+	assert.Equal(t, []string{"usb", "nfc"}, res[0].Transport)
+	assert.Equal(t, "cross-platform", res[0].Attachment)
+	assert.Equal(t, now, res[0].CreatedAt)
+	assert.Equal(t, &now, res[0].LastUsedAt)
+	assert.Equal(t, "c2Vjb25k", res[1].ID)
+	assert.NotNil(t, res[1].Transport)
+	assert.Empty(t, res[1].Transport)
+	assert.Nil(t, res[1].LastUsedAt)
+
+	_, err = ConvertUserCredentialsToPasskeysResponse(nil)
+	assert.Error(t, err)
+	_, err = ConvertUserCredentialToPasskey(nil)
+	assert.Error(t, err)
+}
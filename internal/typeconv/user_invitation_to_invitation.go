@@ -0,0 +1,44 @@
+package typeconv
+
+import (
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+func ConvertUserInvitationToInvitation(invitation *models.UserInvitation) (*dto.Invitation, error) {
+	if invitation == nil {
+		return nil, errors.New("invitation is nil")
+	}
+	res := &dto.Invitation{
+		ID:        invitation.ID.String(),
+		Email:     invitation.Email,
+		Role:      dto.UserRole(invitation.Role),
+		CreatedBy: invitation.CreatedBy.String(),
+		CreatedAt: invitation.CreatedAt,
+		ExpiresAt: invitation.ExpiresAt,
+		UsedAt:    invitation.UsedAt,
+	}
+	if invitation.UsedBy != nil {
+		usedBy := invitation.UsedBy.String()
+		res.UsedBy = &usedBy
+	}
+	return res, nil
+}
+
+func ConvertUserInvitationsToInvitationsResponse(invitations []*models.UserInvitation) (dto.InvitationsResponse, error) {
+	if invitations == nil {
+		return nil, errors.New("invitations is nil")
+	}
+
+	res := dto.InvitationsResponse{}
+	for _, invitation := range invitations {
+		inv, err := ConvertUserInvitationToInvitation(invitation)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *inv)
+	}
+	return res, nil
+}
@@ -0,0 +1,52 @@
+package typeconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertUserInvitationsToInvitationsResponse(t *testing.T) {
+	now := time.Now()
+	email := "invitee@email.com"
+	usedBy := uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95509")
+	invitations := []*models.UserInvitation{
+		{
+			ID:        uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+			CodeHash:  "test-hash",
+			Email:     &email,
+			Role:      models.USER_ROLE_ADMIN,
+			CreatedBy: uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+			UsedAt:    &now,
+			UsedBy:    &usedBy,
+		},
+		{
+			ID:        uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95510"),
+			Role:      models.USER_ROLE_USER,
+			CreatedBy: uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		},
+	}
+
+	res, err := ConvertUserInvitationsToInvitationsResponse(invitations)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95508", res[0].ID)
+	assert.Equal(t, &email, res[0].Email)
+	assert.Equal(t, dto.UserRole("admin"), res[0].Role)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95507", res[0].CreatedBy)
+	assert.Equal(t, &now, res[0].UsedAt)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95509", *res[0].UsedBy)
+	assert.Nil(t, res[1].Email)
+	assert.Nil(t, res[1].UsedBy)
+
+	_, err = ConvertUserInvitationsToInvitationsResponse(nil)
+	assert.Error(t, err)
+}
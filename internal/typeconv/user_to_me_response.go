@@ -9,13 +9,10 @@ import (
 )
 
 func ConvertUserToMeResponse(user *models.User) (*dto.MeResponse, error) {
+	// besides the built-in roles, users can hold custom roles stored in the DB
 	meRole := dto.UserRole(user.Role)
-	if !slices.Contains([]dto.UserRole{
-		dto.USER_ROLE_ADMIN,
-		dto.USER_ROLE_SUPERUSER,
-		dto.USER_ROLE_USER,
-	}, meRole) {
-		return nil, errors.New("user role is not available in the list of roles. Role: " + string(meRole))
+	if meRole == "" {
+		return nil, errors.New("user has no role")
 	}
 
 	meStatus := dto.UserStatus(user.Status)
@@ -23,13 +20,16 @@ func ConvertUserToMeResponse(user *models.User) (*dto.MeResponse, error) {
 		dto.USER_STATUS_ACTIVE,
 		dto.USER_STATUS_INACTIVE,
 		dto.USER_STATUS_PENDING,
+		dto.USER_STATUS_SUSPENDED,
 	}, meStatus) {
 		return nil, errors.New("user status is not available in the list of statuses. Status: " + string(meStatus))
 	}
 
 	return &dto.MeResponse{
 		ID:          user.ID.String(),
+		Username:    user.Username,
 		DisplayName: user.DisplayName,
+		Email:       user.Email,
 		Role:        meRole,
 		Status:      dto.UserStatus(user.Status),
 	}, nil
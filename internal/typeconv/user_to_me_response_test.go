@@ -24,6 +24,7 @@ func TestConvertUserToMeResponse_OK(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, me)
 	assert.Equal(t, user.ID.String(), me.ID)
+	assert.Equal(t, "test-user", me.Username)
 	assert.Equal(t, "test-display", me.DisplayName)
 	assert.Equal(t, dto.UserStatus("active"), me.Status)
 	assert.Equal(t, dto.UserRole("user"), me.Role)
@@ -51,12 +52,27 @@ func TestConvertUserToMeResponse_FAIL_BadRole(t *testing.T) {
 		Username:    "test-user",
 		DisplayName: "test-display",
 		Status:      models.USER_STATUS_ACTIVE,
-		Role:        models.UserRole("random"),
+		Role:        models.UserRole(""),
 	}
 
 	me, err := ConvertUserToMeResponse(user)
 
 	assert.Error(t, err)
-	assert.ErrorContains(t, err, "user role is not available in the list of roles")
+	assert.ErrorContains(t, err, "user has no role")
 	assert.Nil(t, me)
 }
+
+func TestConvertUserToMeResponse_OK_CustomRole(t *testing.T) {
+	user := &models.User{
+		ID:          uuid.New(),
+		Username:    "test-user",
+		DisplayName: "test-display",
+		Status:      models.USER_STATUS_ACTIVE,
+		Role:        models.UserRole("auditor"),
+	}
+
+	me, err := ConvertUserToMeResponse(user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dto.UserRole("auditor"), me.Role)
+}
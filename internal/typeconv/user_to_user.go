@@ -0,0 +1,36 @@
+package typeconv
+
+import (
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+func ConvertUserToUser(user *models.User) (*dto.User, error) {
+	if user == nil {
+		return nil, errors.New("user is nil")
+	}
+	me, err := ConvertUserToMeResponse(user)
+	if err != nil {
+		return nil, err
+	}
+	res := dto.User(*me)
+	return &res, nil
+}
+
+func ConvertUsersToUsers(users []*models.User) ([]dto.User, error) {
+	if users == nil {
+		return nil, errors.New("users is nil")
+	}
+
+	res := []dto.User{}
+	for _, user := range users {
+		u, err := ConvertUserToUser(user)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *u)
+	}
+	return res, nil
+}
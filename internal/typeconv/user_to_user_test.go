@@ -0,0 +1,52 @@
+package typeconv
+
+import (
+	"testing"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertUsersToUsers_OK(t *testing.T) {
+	email := "test@email.com"
+	users := []*models.User{
+		{
+			ID:          uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95507"),
+			Username:    "test-user",
+			DisplayName: "test-display",
+			Email:       &email,
+			Status:      models.USER_STATUS_SUSPENDED,
+			Role:        models.USER_ROLE_ADMIN,
+		},
+		{
+			ID:          uuid.MustParse("0bdd05ec-8008-4869-b6ec-6d812ce95508"),
+			Username:    "test-user-2",
+			DisplayName: "test-display-2",
+			Status:      models.USER_STATUS_ACTIVE,
+			Role:        models.USER_ROLE_USER,
+		},
+	}
+
+	res, err := ConvertUsersToUsers(users)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "0bdd05ec-8008-4869-b6ec-6d812ce95507", res[0].ID)
+	assert.Equal(t, &email, res[0].Email)
+	assert.Equal(t, dto.USER_STATUS_SUSPENDED, res[0].Status)
+	assert.Equal(t, dto.USER_ROLE_ADMIN, res[0].Role)
+	assert.Nil(t, res[1].Email)
+
+	res, err = ConvertUsersToUsers([]*models.User{})
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestConvertUsersToUsers_FAIL(t *testing.T) {
+	_, err := ConvertUsersToUsers(nil)
+	assert.ErrorContains(t, err, "users is nil")
+
+	_, err = ConvertUsersToUsers([]*models.User{{Status: models.UserStatus("random"), Role: models.USER_ROLE_USER}})
+	assert.ErrorContains(t, err, "user status is not available")
+}
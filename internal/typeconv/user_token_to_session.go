@@ -0,0 +1,25 @@
+package typeconv
+
+import (
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+func ConvertUserTokenToSession(token *models.UserToken) (*dto.Session, error) {
+	if token == nil {
+		return nil, errors.New("user token is nil")
+	}
+	if token.ClientID != nil {
+		return nil, errors.New("user token is not a browser session")
+	}
+	return &dto.Session{
+		ID:         token.ID.String(),
+		CreatedAt:  token.CreatedAt,
+		LastSeenAt: token.LastSeenAt,
+		Expires:    token.Expires,
+		IP:         token.IP,
+		UserAgent:  token.UserAgent,
+	}, nil
+}
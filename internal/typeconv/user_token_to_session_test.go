@@ -0,0 +1,41 @@
+package typeconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertUserTokenToSession_OK(t *testing.T) {
+	now := time.Now()
+	token := &models.UserToken{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Expires:    now.Add(time.Hour),
+		CreatedAt:  now,
+		LastSeenAt: &now,
+		IP:         "127.0.0.1",
+		UserAgent:  "test-agent",
+	}
+	res, err := ConvertUserTokenToSession(token)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID.String(), res.ID)
+	assert.Equal(t, now, res.CreatedAt)
+	assert.Equal(t, &now, res.LastSeenAt)
+	assert.Equal(t, token.Expires, res.Expires)
+	assert.Equal(t, "127.0.0.1", res.IP)
+	assert.Equal(t, "test-agent", res.UserAgent)
+	assert.False(t, res.Current)
+}
+
+func TestConvertUserTokenToSession_FAIL(t *testing.T) {
+	_, err := ConvertUserTokenToSession(nil)
+	assert.ErrorContains(t, err, "user token is nil")
+
+	clientID := uuid.New()
+	_, err = ConvertUserTokenToSession(&models.UserToken{ClientID: &clientID})
+	assert.ErrorContains(t, err, "not a browser session")
+}
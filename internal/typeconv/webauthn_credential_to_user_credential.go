@@ -0,0 +1,42 @@
+package typeconv
+
+import (
+	"errors"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+func ConvertWebauthnCredentialToUserCredential(cred *webauthn.Credential, userID uuid.UUID) (*models.UserCredential, error) {
+	if cred == nil {
+		return nil, errors.New("webauthn credential is nil")
+	}
+	ucred := &models.UserCredential{
+		ID:                            cred.ID,
+		UserID:                        userID,
+		PublicKey:                     cred.PublicKey,
+		AttestationType:               cred.AttestationType,
+		Transport:                     cred.Transport,
+		FlagUserPresent:               cred.Flags.UserPresent,
+		FlagVerified:                  cred.Flags.UserVerified,
+		FlagBackupEligible:            cred.Flags.BackupEligible,
+		FlagBackupState:               cred.Flags.BackupState,
+		AuthAaguid:                    cred.Authenticator.AAGUID,
+		AuthSignCount:                 cred.Authenticator.SignCount,
+		AuthCloneWarning:              cred.Authenticator.CloneWarning,
+		AuthAttachment:                cred.Authenticator.Attachment,
+		AttestationClientDataJson:     cred.Attestation.ClientDataJSON,
+		AttestationDataHash:           cred.Attestation.ClientDataHash,
+		AttestationAuthenticatorData:  cred.Attestation.AuthenticatorData,
+		AttestationPublicKeyAlgorithm: cred.Attestation.PublicKeyAlgorithm,
+		AttestationObject:             cred.Attestation.Object,
+		Name:                          models.USER_CREDENTIAL_DEFAULT_NAME,
+	}
+	// transport is a NOT NULL array
+	if len(cred.Transport) == 0 {
+		ucred.Transport = []protocol.AuthenticatorTransport{}
+	}
+	return ucred, nil
+}
@@ -0,0 +1,49 @@
+package typeconv
+
+import (
+	"testing"
+
+	"github.com/asatraitis/mangrove/internal/dal/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertWebauthnCredentialToUserCredential(t *testing.T) {
+	userID := uuid.New()
+	waCred := &webauthn.Credential{
+		ID:              []byte("test-credential-id"),
+		PublicKey:       []byte("test-public-key"),
+		AttestationType: "none",
+	}
+	waCred.Flags.UserPresent = true
+	waCred.Flags.UserVerified = true
+	waCred.Authenticator.AAGUID = []byte("test-aaguid")
+	waCred.Authenticator.SignCount = 3
+	waCred.Authenticator.Attachment = protocol.Platform
+	waCred.Attestation.PublicKeyAlgorithm = -7
+
+	userCred, err := ConvertWebauthnCredentialToUserCredential(waCred, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, userCred.UserID)
+	assert.Equal(t, waCred.ID, userCred.ID)
+	assert.Equal(t, waCred.PublicKey, userCred.PublicKey)
+	assert.True(t, userCred.FlagUserPresent)
+	assert.True(t, userCred.FlagVerified)
+	assert.Equal(t, uint32(3), userCred.AuthSignCount)
+	assert.Equal(t, protocol.Platform, userCred.AuthAttachment)
+	assert.Equal(t, int64(-7), userCred.AttestationPublicKeyAlgorithm)
+	assert.NotNil(t, userCred.Transport)
+	assert.Empty(t, userCred.Transport)
+	assert.Equal(t, models.USER_CREDENTIAL_DEFAULT_NAME, userCred.Name)
+
+	// converting back yields the same credential
+	roundTrip, err := ConvertUserCredentialToWebauthnCredential(userCred)
+	assert.NoError(t, err)
+	assert.Equal(t, waCred.ID, roundTrip.ID)
+	assert.Equal(t, waCred.Authenticator, roundTrip.Authenticator)
+
+	_, err = ConvertWebauthnCredentialToUserCredential(nil, userID)
+	assert.Error(t, err)
+}
@@ -1,30 +1,164 @@
 package utils
 
-import "sync"
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
 
-type CacheMap[K comparable, V any] map[K]V
+type CacheMap[K comparable, V any] map[K]*list.Element
 type Cache[K comparable, V any] interface {
 	GetValue(K) V
 	SetValue(K, V)
+	Delete(K)
+	// GetAndDelete returns the value and removes it, so a key can only be redeemed once
+	GetAndDelete(K) (V, bool)
+	Len() int
 }
+
+type CacheConfig struct {
+	// TTL is how long a value lives after it was set; 0 keeps values until they are evicted or deleted
+	TTL time.Duration
+	// MaxSize evicts the least recently used value once reached; 0 means unbounded
+	MaxSize int
+	// CleanupInterval is how often the janitor drops expired values; defaults to TTL
+	CleanupInterval time.Duration
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
 type cache[K comparable, V any] struct {
-	mu   sync.RWMutex
+	mu   sync.Mutex
+	conf CacheConfig
 	data CacheMap[K, V]
+	// lru holds the entries, most recently used first
+	lru *list.List
+	now func() time.Time
 }
 
-func NewCache[K comparable, V any]() Cache[K, V] {
-	return &cache[K, V]{
+// NewCache creates a cache; with a TTL set, a janitor removes expired values until ctx is done
+func NewCache[K comparable, V any](ctx context.Context, conf CacheConfig) Cache[K, V] {
+	c := &cache[K, V]{
+		conf: conf,
 		data: make(CacheMap[K, V]),
+		lru:  list.New(),
+		now:  time.Now,
+	}
+	if conf.TTL > 0 {
+		interval := conf.CleanupInterval
+		if interval <= 0 {
+			interval = conf.TTL
+		}
+		go c.janitor(ctx, interval)
 	}
+	return c
 }
 
 func (c *cache[K, V]) GetValue(key K) V {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.data[key]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.data[key]
+	if !ok || c.expired(elem) {
+		var zero V
+		return zero
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry[K, V]).value
 }
+
 func (c *cache[K, V]) SetValue(key K, value V) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data[key] = value
+
+	var expires time.Time
+	if c.conf.TTL > 0 {
+		expires = c.now().Add(c.conf.TTL)
+	}
+
+	if elem, ok := c.data[key]; ok {
+		entry := elem.Value.(*cacheEntry[K, V])
+		entry.value = value
+		entry.expires = expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.data[key] = c.lru.PushFront(&cacheEntry[K, V]{key: key, value: value, expires: expires})
+	for c.conf.MaxSize > 0 && c.lru.Len() > c.conf.MaxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.data[key]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *cache[K, V]) GetAndDelete(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.data[key]
+	if !ok {
+		return zero, false
+	}
+	c.remove(elem)
+	if c.expired(elem) {
+		return zero, false
+	}
+	return elem.Value.(*cacheEntry[K, V]).value, true
+}
+
+func (c *cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// deleteExpired drops every expired value
+func (c *cache[K, V]) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if c.expired(elem) {
+			c.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+func (c *cache[K, V]) janitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+func (c *cache[K, V]) expired(elem *list.Element) bool {
+	expires := elem.Value.(*cacheEntry[K, V]).expires
+	return !expires.IsZero() && !c.now().Before(expires)
+}
+
+func (c *cache[K, V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.data, elem.Value.(*cacheEntry[K, V]).key)
 }
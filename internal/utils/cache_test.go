@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -14,10 +16,103 @@ func TestCacheTestSuite(t *testing.T) {
 	suite.Run(t, new(CacheTestSuite))
 }
 
+// newTestCache returns a cache whose clock only moves when the returned func is called
+func newTestCache(ctx context.Context, conf CacheConfig) (*cache[string, string], func(time.Duration)) {
+	c := NewCache[string, string](ctx, conf).(*cache[string, string])
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
 func (suite *CacheTestSuite) TestNewCache() {
-	cache := NewCache[string, string]()
+	cache := NewCache[string, string](context.Background(), CacheConfig{})
 	cache.SetValue("testKey", "testValue")
 	testValue := cache.GetValue("testKey")
 
 	suite.Equal("testValue", testValue)
 }
+
+func (suite *CacheTestSuite) TestDelete() {
+	cache := NewCache[string, string](context.Background(), CacheConfig{})
+	cache.SetValue("testKey", "testValue")
+	cache.Delete("testKey")
+	cache.Delete("missingKey")
+
+	suite.Equal("", cache.GetValue("testKey"))
+	suite.Equal(0, cache.Len())
+}
+
+func (suite *CacheTestSuite) TestGetAndDelete() {
+	cache := NewCache[string, string](context.Background(), CacheConfig{})
+	cache.SetValue("testKey", "testValue")
+
+	value, ok := cache.GetAndDelete("testKey")
+	suite.True(ok)
+	suite.Equal("testValue", value)
+
+	// a key can only be redeemed once
+	value, ok = cache.GetAndDelete("testKey")
+	suite.False(ok)
+	suite.Equal("", value)
+}
+
+func (suite *CacheTestSuite) TestTTL() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache, advance := newTestCache(ctx, CacheConfig{TTL: time.Minute})
+
+	cache.SetValue("testKey", "testValue")
+	advance(time.Second * 59)
+	suite.Equal("testValue", cache.GetValue("testKey"))
+
+	advance(time.Second)
+	suite.Equal("", cache.GetValue("testKey"))
+	_, ok := cache.GetAndDelete("testKey")
+	suite.False(ok)
+
+	// setting a key again restarts its TTL
+	cache.SetValue("testKey", "testValue")
+	advance(time.Second * 30)
+	cache.SetValue("testKey", "newValue")
+	advance(time.Second * 45)
+	suite.Equal("newValue", cache.GetValue("testKey"))
+}
+
+func (suite *CacheTestSuite) TestDeleteExpired() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache, advance := newTestCache(ctx, CacheConfig{TTL: time.Minute})
+
+	cache.SetValue("old", "value")
+	advance(time.Second * 30)
+	cache.SetValue("new", "value")
+	advance(time.Second * 30)
+
+	cache.deleteExpired()
+	suite.Equal(1, cache.Len())
+	suite.Equal("value", cache.GetValue("new"))
+}
+
+func (suite *CacheTestSuite) TestMaxSize() {
+	cache := NewCache[string, string](context.Background(), CacheConfig{MaxSize: 2})
+
+	cache.SetValue("a", "1")
+	cache.SetValue("b", "2")
+	// reading a makes b the least recently used
+	suite.Equal("1", cache.GetValue("a"))
+	cache.SetValue("c", "3")
+
+	suite.Equal(2, cache.Len())
+	suite.Equal("1", cache.GetValue("a"))
+	suite.Equal("", cache.GetValue("b"))
+	suite.Equal("3", cache.GetValue("c"))
+}
+
+func (suite *CacheTestSuite) TestJanitor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := NewCache[string, string](ctx, CacheConfig{TTL: time.Millisecond * 10, CleanupInterval: time.Millisecond * 5})
+
+	cache.SetValue("testKey", "testValue")
+	suite.Eventually(func() bool { return cache.Len() == 0 }, time.Second, time.Millisecond*5)
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
@@ -66,6 +68,34 @@ func EncodeToString(max int) string {
 	return string(b)
 }
 
+// GenerateRandomString returns n random bytes encoded as an unpadded base64url string
+func GenerateRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// opaqueTokenLen is the number of random bytes in session and access tokens (256 bits)
+const opaqueTokenLen = 32
+
+// NewOpaqueToken returns a random token for its holder and the digest to store in its place
+func NewOpaqueToken() (string, []byte, error) {
+	token, err := GenerateRandomString(opaqueTokenLen)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the digest opaque tokens are stored and looked up by. The tokens are random
+// 256-bit values, so a plain SHA-256 cannot be reversed and a leaked digest is no usable token.
+func HashOpaqueToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
 func (c *crypto) Generate(value []byte) []byte {
 	return argon2.IDKey(value, c.salt, c.time, c.memory, c.threads, c.keyLen)
 }
@@ -110,3 +140,45 @@ func SignToken(token string, secret []byte) string {
 	mac.Write([]byte(token))
 	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
 }
+
+// DeriveKey derives a 256-bit key for the given purpose from a configured secret,
+// so a single secret never gets used directly for more than one thing
+func DeriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Encrypt seals plaintext with AES-256-GCM; the random nonce is prepended to the ciphertext.
+// additionalData is authenticated but not encrypted and must match on Decrypt.
+func Encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func Decrypt(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -71,3 +71,47 @@ func (suite *CryptoTestSuite) TestHmac() {
 	err = c.VerifyToken("test", "whatever")
 	suite.Error(err)
 }
+
+func (suite *CryptoTestSuite) TestGenerateRandomString() {
+	s1, err := GenerateRandomString(32)
+	suite.NoError(err)
+	suite.Len(s1, 43)
+
+	s2, err := GenerateRandomString(32)
+	suite.NoError(err)
+	suite.NotEqual(s1, s2)
+}
+
+func (suite *CryptoTestSuite) TestNewOpaqueToken() {
+	token, hash, err := NewOpaqueToken()
+	suite.NoError(err)
+	suite.Len(token, 43)
+	suite.Len(hash, 32)
+	suite.Equal(hash, HashOpaqueToken(token))
+	suite.NotEqual(hash, HashOpaqueToken(token+"x"))
+
+	other, _, err := NewOpaqueToken()
+	suite.NoError(err)
+	suite.NotEqual(token, other)
+}
+
+func (suite *CryptoTestSuite) TestEncryptDecrypt() {
+	key := DeriveKey(suite.salt, "test-purpose")
+	suite.Len(key, 32)
+	suite.NotEqual(key, DeriveKey(suite.salt, "other-purpose"))
+
+	ciphertext, err := Encrypt(key, []byte("test-plaintext"), []byte("test-ad"))
+	suite.NoError(err)
+	suite.NotContains(string(ciphertext), "test-plaintext")
+
+	plaintext, err := Decrypt(key, ciphertext, []byte("test-ad"))
+	suite.NoError(err)
+	suite.Equal([]byte("test-plaintext"), plaintext)
+
+	_, err = Decrypt(key, ciphertext, []byte("other-ad"))
+	suite.Error(err)
+	_, err = Decrypt(DeriveKey(suite.salt, "other-purpose"), ciphertext, []byte("test-ad"))
+	suite.Error(err)
+	_, err = Decrypt(key, []byte("short"), nil)
+	suite.Error(err)
+}
@@ -40,3 +40,16 @@ func GetUserRoleFromCtx(ctx context.Context) (models.UserRole, error) {
 	}
 	return s, nil
 }
+
+func GetSessionIdFromCtx(ctx context.Context) (uuid.UUID, error) {
+	value := ctx.Value(types.REQ_CTX_KEY_SESSION_ID)
+	s, ok := value.(string)
+	if !ok {
+		return uuid.Nil, errors.New("failed type assertion - SessionID")
+	}
+	sessionID, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, errors.New("failed to parse session uuid")
+	}
+	return sessionID, nil
+}
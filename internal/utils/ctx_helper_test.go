@@ -78,3 +78,22 @@ func TestGetUserRoleFromCtx_FAIL_BadType(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, models.UserRole(""), role)
 }
+
+func TestGetSessionIdFromCtx_OK(t *testing.T) {
+	sessionID := uuid.New()
+	ctx := context.WithValue(context.Background(), types.REQ_CTX_KEY_SESSION_ID, sessionID.String())
+
+	id, err := GetSessionIdFromCtx(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sessionID, id)
+}
+
+func TestGetSessionIdFromCtx_FAIL(t *testing.T) {
+	_, err := GetSessionIdFromCtx(context.Background())
+	assert.Error(t, err)
+
+	ctx := context.WithValue(context.Background(), types.REQ_CTX_KEY_SESSION_ID, "123-abc")
+	_, err = GetSessionIdFromCtx(ctx)
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/asatraitis/mangrove/internal/dto"
+)
+
+const (
+	JWK_ALG_EDDSA = "EdDSA"
+	JWK_ALG_ES256 = "ES256"
+)
+
+// PublicKeyToJWK encodes an Ed25519 (EdDSA) or P-256 (ES256) public key as a signing JWK
+func PublicKeyToJWK(kid, alg string, publicKey gocrypto.PublicKey) (dto.JWK, error) {
+	switch pub := publicKey.(type) {
+	case ed25519.PublicKey:
+		if alg != JWK_ALG_EDDSA {
+			break
+		}
+		return dto.JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+		}, nil
+	case *ecdsa.PublicKey:
+		if alg != JWK_ALG_ES256 || pub.Curve != elliptic.P256() {
+			break
+		}
+		return dto.JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+		}, nil
+	}
+	return dto.JWK{}, errors.New("public key does not match algorithm")
+}
+
+// JWKToPublicKey decodes an Ed25519 or P-256 JWK; the returned algorithm is derived from the key type
+func JWKToPublicKey(jwk dto.JWK) (gocrypto.PublicKey, string, error) {
+	switch {
+	case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, "", errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), JWK_ALG_EDDSA, nil
+	case jwk.Kty == "EC" && jwk.Crv == "P-256":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, "", err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, "", err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, "", errors.New("point is not on P-256")
+		}
+		return pub, JWK_ALG_ES256, nil
+	}
+	return nil, "", errors.New("unsupported JWK key type")
+}
+
+// JWKThumbprint returns the RFC 7638 thumbprint of the public JWK
+func JWKThumbprint(jwk dto.JWK) string {
+	// required members only, in lexicographic order with no whitespace
+	var b []byte
+	if jwk.Kty == "EC" {
+		b, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y})
+	} else {
+		b, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X})
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/asatraitis/mangrove/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKRoundTrip_OK(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	jwk, err := PublicKeyToJWK("test-kid", JWK_ALG_EDDSA, edPub)
+	assert.NoError(t, err)
+	assert.Equal(t, "OKP", jwk.Kty)
+	key, alg, err := JWKToPublicKey(jwk)
+	assert.NoError(t, err)
+	assert.Equal(t, JWK_ALG_EDDSA, alg)
+	assert.True(t, edPub.Equal(key))
+
+	jwk, err = PublicKeyToJWK("test-kid", JWK_ALG_ES256, &ecKey.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "EC", jwk.Kty)
+	key, alg, err = JWKToPublicKey(jwk)
+	assert.NoError(t, err)
+	assert.Equal(t, JWK_ALG_ES256, alg)
+	assert.True(t, ecKey.PublicKey.Equal(key))
+}
+
+func TestPublicKeyToJWK_FAIL_AlgMismatch(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	_, err = PublicKeyToJWK("test-kid", JWK_ALG_ES256, edPub)
+	assert.Error(t, err)
+}
+
+func TestJWKToPublicKey_FAIL(t *testing.T) {
+	_, _, err := JWKToPublicKey(dto.JWK{Kty: "RSA"})
+	assert.Error(t, err)
+	_, _, err = JWKToPublicKey(dto.JWK{Kty: "OKP", Crv: "Ed25519", X: "dG9vLXNob3J0"})
+	assert.Error(t, err)
+	_, _, err = JWKToPublicKey(dto.JWK{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"})
+	assert.Error(t, err)
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 8037 appendix A.3
+	kid := JWKThumbprint(dto.JWK{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"})
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", kid)
+}
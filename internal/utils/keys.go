@@ -0,0 +1,54 @@
+package utils
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// ParsePublicKey parses a PKIX DER or PEM "PUBLIC KEY" encoded key for the given JWS algorithm.
+// EdDSA keys may also be given as the raw 32 bytes.
+func ParsePublicKey(alg string, b []byte) (gocrypto.PublicKey, error) {
+	switch alg {
+	case JWK_ALG_EDDSA:
+		return ParseEd25519PublicKey(b)
+	case JWK_ALG_ES256:
+		key, err := parsePKIXPublicKey(b)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return nil, errors.New("not a P-256 public key")
+		}
+		return ecKey, nil
+	}
+	return nil, errors.New("unsupported key algorithm")
+}
+
+// ParseEd25519PublicKey accepts a raw 32 byte key, a PKIX DER encoded key or a PEM "PUBLIC KEY" block
+func ParseEd25519PublicKey(b []byte) (ed25519.PublicKey, error) {
+	if len(b) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(b), nil
+	}
+	key, err := parsePKIXPublicKey(b)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("not an Ed25519 public key")
+	}
+	return edKey, nil
+}
+
+func parsePKIXPublicKey(b []byte) (gocrypto.PublicKey, error) {
+	if block, _ := pem.Decode(b); block != nil {
+		b = block.Bytes
+	}
+	return x509.ParsePKIXPublicKey(b)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEd25519PublicKey_OK(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+
+	for _, b := range [][]byte{
+		pub,
+		der,
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+	} {
+		key, err := ParseEd25519PublicKey(b)
+		assert.NoError(t, err)
+		assert.True(t, pub.Equal(key))
+	}
+}
+
+func TestParseEd25519PublicKey_FAIL(t *testing.T) {
+	_, err := ParseEd25519PublicKey([]byte("test-public-key"))
+	assert.Error(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	assert.NoError(t, err)
+	_, err = ParseEd25519PublicKey(der)
+	assert.Error(t, err)
+}
+
+func TestParsePublicKey_OK_ES256(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	assert.NoError(t, err)
+
+	key, err := ParsePublicKey(JWK_ALG_ES256, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NoError(t, err)
+	assert.True(t, ecKey.PublicKey.Equal(key))
+
+	_, err = ParsePublicKey(JWK_ALG_EDDSA, der)
+	assert.Error(t, err)
+	_, err = ParsePublicKey("RS256", der)
+	assert.Error(t, err)
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"regexp"
+)
+
+// RFC 7636 section 4.1: 43-128 characters from the unreserved set
+var pkceVerifierRegexp = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+func GeneratePKCEChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func VerifyPKCEChallengeS256(verifier, challenge string) error {
+	if !pkceVerifierRegexp.MatchString(verifier) {
+		return errors.New("invalid code verifier")
+	}
+	expected := GeneratePKCEChallengeS256(verifier)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) != 1 {
+		return errors.New("code verifier does not match challenge")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePKCEChallengeS256(t *testing.T) {
+	// RFC 7636 appendix B example
+	challenge := GeneratePKCEChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", challenge)
+}
+
+func TestVerifyPKCEChallengeS256_OK(t *testing.T) {
+	err := VerifyPKCEChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+	assert.NoError(t, err)
+}
+
+func TestVerifyPKCEChallengeS256_FAIL_Mismatch(t *testing.T) {
+	err := VerifyPKCEChallengeS256(strings.Repeat("a", 43), "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "does not match")
+}
+
+func TestVerifyPKCEChallengeS256_FAIL_BadVerifier(t *testing.T) {
+	err := VerifyPKCEChallengeS256("short", GeneratePKCEChallengeS256("short"))
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "invalid code verifier")
+
+	err = VerifyPKCEChallengeS256(strings.Repeat("a", 42)+"!", GeneratePKCEChallengeS256(strings.Repeat("a", 42)+"!"))
+	assert.Error(t, err)
+}
@@ -2,11 +2,11 @@ package utils
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/asatraitis/mangrove/configs"
+	"github.com/asatraitis/mangrove/internal/database"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 )
@@ -30,17 +30,7 @@ func (w *MockResponseWriter) WriteHeader(code int) {
 	w.Code = code
 }
 
-// TODO: consolidate w/ getConnection() in migrator.go and initDbPool in main
 func InitDbPool(ctx context.Context) (*pgxpool.Pool, error) {
-	conf := configs.NewConf(zerolog.Logger{})
-	vars := conf.GetEnvironmentVars()
-
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
-		vars.MangrovePostgresUser,
-		vars.MangrovePostgresPassword,
-		vars.MangrovePostgresAddress,
-		vars.MangrovePostgresPort,
-		vars.MangrovePostgresDBName,
-	)
-	return pgxpool.New(ctx, connStr)
+	vars := configs.NewConf(zerolog.Nop()).GetEnvironmentVars()
+	return database.NewPool(ctx, vars, zerolog.Nop())
 }
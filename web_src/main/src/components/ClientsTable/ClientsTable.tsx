@@ -15,7 +15,7 @@ return (
                             <Table.Th>ID</Table.Th>
                             <Table.Th>Name</Table.Th>
                             <Table.Th>Description</Table.Th>
-                            <Table.Th>Redirect URI</Table.Th>
+                            <Table.Th>Redirect URIs</Table.Th>
                             <Table.Th>Status</Table.Th>
                         </Table.Tr>
                     </Table.Thead>
@@ -26,7 +26,7 @@ return (
                                     <Table.Td><Text truncate="end">{c.id}</Text></Table.Td>
                                     <Table.Td>{c.name}</Table.Td>
                                     <Table.Td>{c.description}</Table.Td>
-                                    <Table.Td>{c.redirectURI}</Table.Td>
+                                    <Table.Td>{c.redirectURIs.join(", ")}</Table.Td>
                                     <Table.Td>{c.status}</Table.Td>
                                 </Table.Tr>
                             ))
@@ -12,7 +12,7 @@ export default function CreateClient() {
         name: "",
         description: "",
         status: CLIENT_STATUS_ACTIVE,
-        redirectURI: "",
+        redirectURIs: [],
         keyAlgo: CLIENT_KEY_ALGO_EDDSA,
         publicKey: ""
     })
@@ -24,7 +24,8 @@ export default function CreateClient() {
             console.error("api service is missing in services context!")
             return
         }
-        const {response, error} = await services.api.createClient(form)
+        const redirectURIs = form.redirectURIs.map(uri => uri.trim()).filter(uri => uri !== "")
+        const {response, error} = await services.api.createClient({...form, redirectURIs})
         if (error) {
             // TODO: handle error
             console.log(error)
@@ -42,7 +43,7 @@ export default function CreateClient() {
                     <TextInput value={form.name} onChange={(e) => {setForm(data => ({...data, name: e.target.value}))}} label="Name" required />
                     <Textarea value={form.description} onChange={(e) => {setForm(data => ({...data, description: e.target.value}))}} mt="md" label="Description (optional)" />
                     <NativeSelect value={form.status} onChange={(e) => {setForm(data => ({...data, status: e.target.value}))}} mt="md" label="Status" required data={[CLIENT_STATUS_ACTIVE]} />
-                    <TextInput value={form.redirectURI} onChange={(e) => {setForm(data => ({...data, redirectURI: e.target.value}))}} mt="md" label="Redirect URI" required />
+                    <Textarea value={form.redirectURIs.join("\n")} onChange={(e) => {setForm(data => ({...data, redirectURIs: e.target.value.split("\n")}))}} mt="md" label="Redirect URIs (one per line)" autosize required />
                     <NativeSelect value={form.keyAlgo} onChange={(e) => {setForm(data => ({...data, keyAlgo: e.target.value}))}} mt="md" label="Key Algorithm" required data={[CLIENT_KEY_ALGO_EDDSA]} />
                     <Textarea value={form.publicKey} onChange={(e) => {setForm(data => ({...data, publicKey: e.target.value}))}} mt="md" label="Public Key" resize="vertical" required />
                     <Flex justify="flex-end">
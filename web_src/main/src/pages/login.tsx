@@ -5,6 +5,7 @@ import { FormEvent, useState } from 'react';
 import { useRouter, getRouteApi } from '@tanstack/react-router';
 
 import { startAuth } from '@services/auth/auth';
+import { AuthorizeRequest } from '@dto/types';
 
 import { useServices } from '../contexts/services';
 import { useAuthCtx } from '../contexts/auth';
@@ -29,6 +30,19 @@ export default function Login() {
             console.error("api service missing in services context")
             return
         }
+        if (search.client_id && search.response_type) {
+            await handleAuthorize({
+                responseType: search.response_type,
+                clientId: search.client_id,
+                redirectURI: search.redirect_uri ?? "",
+                scope: search.scope,
+                state: search.state,
+                codeChallenge: search.code_challenge ?? "",
+                codeChallengeMethod: search.code_challenge_method ?? "",
+                nonce: search.nonce,
+            })
+            return
+        }
         // hit the api and get options to be used with authenticator
         const {response, error: initLoginErr} = await services.api.initLogin(username)
         if (initLoginErr) {
@@ -63,13 +77,52 @@ export default function Login() {
         setLoading(false)
         router.history.push(search.redirect)
     }
+
+    // handleAuthorize logs in for a client and sends the browser back to its redirect_uri with the code
+    const handleAuthorize = async (authorize: AuthorizeRequest) => {
+        if (!services?.api) {
+            console.error("api service missing in services context")
+            return
+        }
+        const {response, error: initAuthorizeErr} = await services.api.initAuthorize({authorize, username})
+        if (initAuthorizeErr) {
+            // TODO: handle error
+            console.error("initAuthorize API error", initAuthorizeErr)
+            return
+        }
+        if (!response) {
+            // TODO: handle error
+            console.error("initAuthorize API returned null response")
+            return
+        }
+
+        const [credential, startAuthErr] = await startAuth(response.publicKey)
+        if (startAuthErr) {
+            // TODO: handler err
+            console.error("authenticator error", startAuthErr)
+            return
+        }
+        const {response: finishAuthorizeRes, error: finishAuthorizeErr} = await services.api.finishAuthorize({authorize, login: {credential, sessionKey: response.sessionKey}})
+        if (finishAuthorizeErr) {
+            // TODO: handle error
+            console.error("finishAuthorize API error", finishAuthorizeErr)
+            return
+        }
+        if (!finishAuthorizeRes) {
+            // TODO: handle error
+            console.error("finishAuthorize API returned null response")
+            return
+        }
+        // the redirect_uri belongs to the client, outside of this app's router
+        window.location.assign(finishAuthorizeRes.redirectURI)
+    }
     
     return (
     <div className={classes.container}>
         <Card withBorder p="xl" radius="lg" style={{display: "flex", alignItems: "center"}}>
                 <TbCircleKeyFilled size={150} />
                 <form onSubmit={handleAuth} style={{display: "flex", flexDirection: "column"}}>
-                    <TextInput value={username} onChange={(e) => {setUsername(e.target.value)}} label="Username" mt={30} />
+                    <TextInput value={username} onChange={(e) => {setUsername(e.target.value)}} label="Username" description="Leave empty to pick a passkey" mt={30} />
                     <Button type='submit' style={{flexGrow: "1"}} variant='gradient' mt={5}>
                         { loading ? <Loader color="white" type="dots" /> : "Authenticate"}        
                     </Button>
@@ -1,16 +1,31 @@
 import { createFileRoute, redirect } from '@tanstack/react-router'
 import { USER_STATUS_ACTIVE, USER_STATUS_INACTIVE, USER_STATUS_PENDING, USER_STATUS_SUSPENDED } from '@dto/types'
 
+// the authorization request parameters are set when GET /oauth2/authorize handed the browser over to
+// log in for a client; they keep their OAuth names so the query is passed through as is
+const authorizeParams = ["response_type", "client_id", "redirect_uri", "scope", "state", "code_challenge", "code_challenge_method", "nonce"] as const
+
 type LoginSearch = {
   redirect: string
-}
+} & Partial<Record<typeof authorizeParams[number], string>>
+
 export const Route = createFileRoute('/login')({
   validateSearch: (search: Record<string, unknown>): LoginSearch => {
-    return {
+    const validated: LoginSearch = {
       redirect: (search.redirect as string) || "/"
     }
+    for (const param of authorizeParams) {
+      if (search[param] !== undefined) {
+        validated[param] = `${search[param]}`
+      }
+    }
+    return validated
   },
   beforeLoad: async ({context: {setUser, api}, search}) => {
+    if (search.client_id && search.response_type) {
+      // a client asked for a login; the code is only issued after a passkey login, session or not
+      return
+    }
     const {response, error} = await api.me()
     if (error) {
       // handle error
@@ -7,6 +7,29 @@ import {
     UserClientsResponse,
     CreateClientResponse,
     CreateClientRequest,
+    UserClient,
+    UpdateClientRequest,
+    UpdateClientResponse,
+    InvitationsResponse,
+    CreateInvitationRequest,
+    CreateInvitationResponse,
+    PasskeysResponse,
+    Passkey,
+    InitPasskeyEnrollmentResponse,
+    User,
+    UsersRequest,
+    UsersResponse,
+    UpdateUserRequest,
+    UpdateUserResponse,
+    Role,
+    RolesResponse,
+    CreateRoleRequest,
+    UpdateRoleRequest,
+    SessionsResponse,
+    RevokeSessionsResponse,
+    InitAuthorizeRequest,
+    FinishAuthorizeRequest,
+    FinishAuthorizeResponse,
 } from "@dto/types"
 import { RegistrationResponseJSON } from "@simplewebauthn/browser"
 
@@ -16,13 +39,39 @@ export interface IApiClient {
     finishRegistration(userId: string, credential: RegistrationResponseJSON): Promise<Response<unknown>>
     initLogin(username: string): Promise<Response<InitLoginResponse>>
     finishLogin(finishLogin: FinishLoginRequest): Promise<Response<MeResponse>>
+    initAuthorize(initAuthorize: InitAuthorizeRequest): Promise<Response<InitLoginResponse>>
+    finishAuthorize(finishAuthorize: FinishAuthorizeRequest): Promise<Response<FinishAuthorizeResponse>>
     userClients(): Promise<Response<UserClientsResponse>>
     createClient(client: CreateClientRequest): Promise<Response<CreateClientResponse>>
+    getClient(id: string): Promise<Response<UserClient>>
+    updateClient(id: string, update: UpdateClientRequest): Promise<Response<UpdateClientResponse>>
+    deleteClient(id: string): Promise<Response<unknown>>
+    invitations(): Promise<Response<InvitationsResponse>>
+    createInvitation(invitation: CreateInvitationRequest): Promise<Response<CreateInvitationResponse>>
+    revokeInvitation(id: string): Promise<Response<unknown>>
+    passkeys(): Promise<Response<PasskeysResponse>>
+    initPasskeyEnrollment(): Promise<Response<InitPasskeyEnrollmentResponse>>
+    finishPasskeyEnrollment(name: string, credential: RegistrationResponseJSON): Promise<Response<Passkey>>
+    renamePasskey(id: string, name: string): Promise<Response<unknown>>
+    deletePasskey(id: string): Promise<Response<unknown>>
+    users(query?: UsersRequest): Promise<Response<UsersResponse>>
+    getUser(id: string): Promise<Response<User>>
+    updateUser(id: string, update: UpdateUserRequest): Promise<Response<UpdateUserResponse>>
+    roles(): Promise<Response<RolesResponse>>
+    createRole(role: CreateRoleRequest): Promise<Response<Role>>
+    updateRole(name: string, update: UpdateRoleRequest): Promise<Response<Role>>
+    deleteRole(name: string): Promise<Response<unknown>>
+    setConfig(key: string, value: string): Promise<Response<unknown>>
+    logout(): Promise<Response<unknown>>
+    sessions(): Promise<Response<SessionsResponse>>
+    revokeSession(id: string): Promise<Response<unknown>>
+    revokeUserSessions(userId: string): Promise<Response<RevokeSessionsResponse>>
 }
 
 export default class ApiClient implements IApiClient {
     private url: string
     private apiEndpoint = "/v1"
+    private oauthEndpoint = "/oauth2"
 
     static async call<T>(url: string, config?: RequestInit): Promise<Response<T>> {
         const csrfToken = ApiClient.getCookie("csrf_token")
@@ -73,10 +122,86 @@ export default class ApiClient implements IApiClient {
     async finishLogin(finishLogin: FinishLoginRequest) {
         return ApiClient.call<MeResponse>(`${this.url}${this.apiEndpoint}/login/finish`, {method: "POST", body: JSON.stringify(finishLogin)})
     }
+    async initAuthorize(initAuthorize: InitAuthorizeRequest) {
+        return ApiClient.call<InitLoginResponse>(`${this.url}${this.oauthEndpoint}/authorize`, {method: "POST", body: JSON.stringify(initAuthorize)})
+    }
+    async finishAuthorize(finishAuthorize: FinishAuthorizeRequest) {
+        return ApiClient.call<FinishAuthorizeResponse>(`${this.url}${this.oauthEndpoint}/authorize/finish`, {method: "POST", body: JSON.stringify(finishAuthorize)})
+    }
     async userClients() {
         return ApiClient.call<UserClientsResponse>(`${this.url}${this.apiEndpoint}/clients`)
     }
     async createClient(client: CreateClientRequest) {
         return ApiClient.call<CreateClientResponse>(`${this.url}${this.apiEndpoint}/clients`, {method: "POST", body: JSON.stringify(client)})
     }
+    async getClient(id: string) {
+        return ApiClient.call<UserClient>(`${this.url}${this.apiEndpoint}/clients/${id}`)
+    }
+    async updateClient(id: string, update: UpdateClientRequest) {
+        return ApiClient.call<UpdateClientResponse>(`${this.url}${this.apiEndpoint}/clients/${id}`, {method: "PATCH", body: JSON.stringify(update)})
+    }
+    async deleteClient(id: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/clients/${id}`, {method: "DELETE"})
+    }
+    async invitations() {
+        return ApiClient.call<InvitationsResponse>(`${this.url}${this.apiEndpoint}/invitations`)
+    }
+    async createInvitation(invitation: CreateInvitationRequest) {
+        return ApiClient.call<CreateInvitationResponse>(`${this.url}${this.apiEndpoint}/invitations`, {method: "POST", body: JSON.stringify(invitation)})
+    }
+    async revokeInvitation(id: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/invitations/${id}`, {method: "DELETE"})
+    }
+    async passkeys() {
+        return ApiClient.call<PasskeysResponse>(`${this.url}${this.apiEndpoint}/me/passkeys`)
+    }
+    async initPasskeyEnrollment() {
+        return ApiClient.call<InitPasskeyEnrollmentResponse>(`${this.url}${this.apiEndpoint}/me/passkeys`, {method: "POST"})
+    }
+    async finishPasskeyEnrollment(name: string, credential: RegistrationResponseJSON) {
+        return ApiClient.call<Passkey>(`${this.url}${this.apiEndpoint}/me/passkeys/finish`, {method: "POST", body: JSON.stringify({name, credential})})
+    }
+    async renamePasskey(id: string, name: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/me/passkeys/${id}`, {method: "PATCH", body: JSON.stringify({name})})
+    }
+    async deletePasskey(id: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/me/passkeys/${id}`, {method: "DELETE"})
+    }
+    async users(query: UsersRequest = {}) {
+        const params = new URLSearchParams(Object.entries(query).filter(([, v]) => v !== undefined && v !== "").map(([k, v]) => [k, `${v}`]))
+        return ApiClient.call<UsersResponse>(`${this.url}${this.apiEndpoint}/users?${params}`)
+    }
+    async getUser(id: string) {
+        return ApiClient.call<User>(`${this.url}${this.apiEndpoint}/users/${id}`)
+    }
+    async updateUser(id: string, update: UpdateUserRequest) {
+        return ApiClient.call<UpdateUserResponse>(`${this.url}${this.apiEndpoint}/users/${id}`, {method: "PATCH", body: JSON.stringify(update)})
+    }
+    async roles() {
+        return ApiClient.call<RolesResponse>(`${this.url}${this.apiEndpoint}/roles`)
+    }
+    async createRole(role: CreateRoleRequest) {
+        return ApiClient.call<Role>(`${this.url}${this.apiEndpoint}/roles`, {method: "POST", body: JSON.stringify(role)})
+    }
+    async updateRole(name: string, update: UpdateRoleRequest) {
+        return ApiClient.call<Role>(`${this.url}${this.apiEndpoint}/roles/${name}`, {method: "PATCH", body: JSON.stringify(update)})
+    }
+    async deleteRole(name: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/roles/${name}`, {method: "DELETE"})
+    }
+    async setConfig(key: string, value: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/config/${key}`, {method: "PUT", body: JSON.stringify({value})})
+    }
+    async logout() {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/logout`, {method: "POST"})
+    }
+    async sessions() {
+        return ApiClient.call<SessionsResponse>(`${this.url}${this.apiEndpoint}/sessions`)
+    }
+    async revokeSession(id: string) {
+        return ApiClient.call<unknown>(`${this.url}${this.apiEndpoint}/sessions/${id}`, {method: "DELETE"})
+    }
+    async revokeUserSessions(userId: string) {
+        return ApiClient.call<RevokeSessionsResponse>(`${this.url}${this.apiEndpoint}/users/${userId}/sessions`, {method: "DELETE"})
+    }
 }